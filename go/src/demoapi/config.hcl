@@ -4,12 +4,55 @@
 api_slug    = "demoapi"
 api_addr    = ":8080"
 metric_addr = ":9090"
+grpc_addr   = ":9091"
 
 graceful_shutdown_timeout_sec = 5
 write_timeout_sec             = 15
 read_timeout_sec              = 15
 idle_timeout_sec              = 15
+readiness_timeout_sec         = 2
+
+// requests per minute of each client to each route class. the burst defaults
+// to a minute of requests. 0 or unset is unlimited
+rate_limit_reads_per_min  = 600
+rate_limit_writes_per_min = 120
+rate_limit_bulk_per_min   = 30
+
+// how long the responses to writes with an Idempotency-Key are replayed
+idempotency_key_ttl_sec = 86400
+
+// the most records that a page of a list can have. page tokens are signed
+// with the cursor secret, so they can't be forged. without one, a random key
+// is used and tokens don't work across restarts or replicas
+pagination_max_limit = 50
+//pagination_cursor_secret = "change me"
+
+// request bodies longer than this are rejected with a 413
+max_request_body_bytes = 1048576
+
+// the api is served under /v1. the unversioned paths it used to have, like
+// /users, keep working with Deprecation headers until this date (or time)
+// and are a 410 after it. unset keeps serving them
+//unversioned_paths_sunset = "2027-04-01"
 
 loglevel = "debug"
+log_format = "text"
 developer_mode = true
 insecure_requests_mode = true
+
+// spans are exported with trace_exporter = "otlp" (to trace_otlp_endpoint),
+// "stdout" or "file" (to trace_file)
+//trace_exporter = "otlp"
+//trace_otlp_endpoint = "localhost:4317"
+
+// the api and metric servers serve tls with a certificate and key, which are
+// reloaded when the files change. clients have to present a certificate
+// signed by *_tls_client_ca_file if it's set
+//api_tls_cert_file = "/etc/demoapi/tls/api.crt"
+//api_tls_key_file = "/etc/demoapi/tls/api.key"
+//api_tls_client_ca_file = "/etc/demoapi/tls/clients.crt"
+//metric_tls_cert_file = "/etc/demoapi/tls/metric.crt"
+//metric_tls_key_file = "/etc/demoapi/tls/metric.key"
+//metric_tls_client_ca_file = "/etc/demoapi/tls/prometheus.crt"
+//tls_min_version = "1.2"
+//tls_cipher_suites = "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"
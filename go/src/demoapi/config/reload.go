@@ -0,0 +1,193 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	monitor "demoapi/prometheus"
+)
+
+// how often the config file is checked for changes
+const watchInterval = 2 * time.Second
+
+// reload results, used as the label of the reload metric
+const (
+	reloadSucceeded = "success"
+	reloadRejected  = "rejected"
+	reloadFailed    = "failed"
+)
+
+// Change is a setting that differs between two Configs
+type Change struct {
+	Field      string
+	Old        string
+	New        string
+	Reloadable bool
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Field, c.Old, c.New)
+}
+
+// Diff lists every setting that differs between c and other
+func (c *Configs) Diff(other *Configs) []Change {
+	var changes []Change
+	prev, next := reflect.ValueOf(c).Elem(), reflect.ValueOf(other).Elem()
+	for i := 0; i < prev.NumField(); i++ {
+		field := prev.Type().Field(i)
+		oldValue := formatSetting(prev.Field(i).Interface())
+		newValue := formatSetting(next.Field(i).Interface())
+		if oldValue == newValue {
+			continue
+		}
+		if field.Tag.Get("secret") == "true" {
+			oldValue, newValue = redact(oldValue), redact(newValue)
+		}
+		changes = append(changes, Change{
+			Field:      field.Name,
+			Old:        oldValue,
+			New:        newValue,
+			Reloadable: field.Tag.Get("reload") == "true",
+		})
+	}
+	return changes
+}
+
+// formatSetting formats a setting so that it's safe to log
+func formatSetting(v interface{}) string {
+	if u, ok := v.(*url.URL); ok {
+		if u == nil {
+			return ""
+		}
+		return u.Redacted() // don't log username/password
+	}
+	return fmt.Sprintf("%q", fmt.Sprint(v))
+}
+
+// Store holds the current Configs. Reloads swap in the new Configs
+// atomically, so a reader always sees every setting from the same reload.
+type Store struct {
+	current atomic.Pointer[Configs]
+	parse   func() (*Configs, error)
+
+	mu        sync.Mutex // serializes reloads
+	listeners []func(*Configs)
+}
+
+// NewStore returns a Store holding c that reloads with Parse
+func NewStore(c *Configs) *Store {
+	s := &Store{parse: Parse}
+	s.current.Store(c)
+	return s
+}
+
+// Load returns the current Configs. they must not be modified
+func (s *Store) Load() *Configs {
+	return s.current.Load()
+}
+
+// OnReload adds a function that applies the reloadable settings. It's called
+// with the new Configs after every successful reload.
+func (s *Store) OnReload(fn func(*Configs)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// Reload parses the configuration again. If only reloadable settings changed
+// they are swapped in, otherwise the reload is rejected and the current
+// Configs are kept. Either way the changes are logged.
+func (s *Store) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next, err := s.parse()
+	if err != nil {
+		monitor.ConfigReloadCounter.WithLabelValues(reloadFailed).Inc()
+		logrus.Errorf("config reload failed: %s", err)
+		return err
+	}
+
+	changes := s.Load().Diff(next)
+	var rejected []string
+	for _, change := range changes {
+		if !change.Reloadable {
+			rejected = append(rejected, change.String())
+		}
+	}
+	if len(rejected) > 0 {
+		monitor.ConfigReloadCounter.WithLabelValues(reloadRejected).Inc()
+		logrus.Errorf("config reload rejected. these settings need a "+
+			"restart: %s", strings.Join(rejected, ", "))
+		return configErr.New("settings need a restart: %s",
+			strings.Join(rejected, ", "))
+	}
+
+	s.current.Store(next)
+	for _, fn := range s.listeners {
+		fn(next)
+	}
+
+	monitor.ConfigReloadCounter.WithLabelValues(reloadSucceeded).Inc()
+	if len(changes) == 0 {
+		logrus.Info("config reloaded without changes")
+	}
+	for _, change := range changes {
+		logrus.Infof("config reloaded %s", change)
+	}
+	return nil
+}
+
+// Watch reloads the configuration whenever the process receives SIGHUP or
+// the config file changes, until ctx is canceled. Failed reloads are logged
+// and the current Configs are kept.
+func (s *Store) Watch(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	last := configFileVersion()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-hup:
+			logrus.Info("reloading config after SIGHUP")
+		case <-ticker.C:
+			version := configFileVersion()
+			if version == last {
+				continue
+			}
+			last = version
+			logrus.Info("reloading config after the config file changed")
+		}
+		_ = s.Reload() // already logged
+	}
+}
+
+// configFileVersion identifies the current contents of the config file
+func configFileVersion() string {
+	path := configFilePath()
+	if path == "" {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+}
@@ -0,0 +1,397 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+)
+
+// Every setting has the same name in every source. A setting like api_addr
+// is set with `api_addr = ":8080"` in the config file, with the --api_addr
+// flag, or with the DEMOAPI_API_ADDR env var. Secret settings, like db_url,
+// can also be read from a file given by the --db_url_file flag or the
+// DEMOAPI_DB_URL_FILE env var, so they don't have to be in the environment.
+
+const (
+	envPrefix  = "DEMOAPI_"
+	fileSuffix = "_file"
+
+	sourceDefault = "default"
+	sourceUnset   = "unset"
+)
+
+var (
+	configFileFlag  = flag.String("config", "", "config file path")
+	printConfigFlag = flag.Bool("print-config", false,
+		"print the effective configuration, and where each setting came from, "+
+			"then exit")
+
+	// the flags of every setting, by hcl name. secret settings also have a
+	// flag for the path of a file containing them
+	settingFlags = map[string]*settingFlag{}
+)
+
+func init() {
+	for _, field := range (&rawConfigs{}).fields() {
+		f := &settingFlag{isBool: field.value.Kind() == reflect.Bool}
+		flag.Var(f, field.name, fmt.Sprintf("%s setting (env %s)", field.name,
+			envName(field.name)))
+		settingFlags[field.name] = f
+
+		if field.secret {
+			f := &settingFlag{}
+			flag.Var(f, field.name+fileSuffix, fmt.Sprintf(
+				"path of a file containing the %s setting (env %s)", field.name,
+				envName(field.name+fileSuffix)))
+			settingFlags[field.name+fileSuffix] = f
+		}
+	}
+}
+
+// PrintRequested returns whether the --print-config flag was given
+func PrintRequested() bool {
+	flag.Parse()
+	return *printConfigFlag
+}
+
+// settingFlag is a flag.Value that can be told apart from an unset flag
+type settingFlag struct {
+	value  string
+	isBool bool
+}
+
+func (f *settingFlag) String() string     { return f.value }
+func (f *settingFlag) Set(s string) error { f.value = s; return nil }
+func (f *settingFlag) IsBoolFlag() bool   { return f.isBool }
+
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(name)
+}
+
+type rawField struct {
+	name   string
+	secret bool
+	value  reflect.Value
+}
+
+// fields lists every setting of raw
+func (raw *rawConfigs) fields() []rawField {
+	v := reflect.ValueOf(raw).Elem()
+	var fields []rawField
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := field.Tag.Get("hcl")
+		if name == "" {
+			continue
+		}
+		fields = append(fields, rawField{
+			name:   name,
+			secret: field.Tag.Get("secret") == "true",
+			value:  v.Field(i),
+		})
+	}
+	return fields
+}
+
+func (raw *rawConfigs) field(name string) (rawField, bool) {
+	for _, field := range raw.fields() {
+		if field.name == name {
+			return field, true
+		}
+	}
+	return rawField{}, false
+}
+
+// set sets the setting called name from source. a setting can be given by
+// more than one source, but only if they agree on the value
+func (raw *rawConfigs) set(name, value, source string) error {
+	field, ok := raw.field(name)
+	if !ok {
+		return configErr.New("unknown setting %q in %s", name, source)
+	}
+
+	parsed := reflect.New(field.value.Type()).Elem()
+	switch field.value.Kind() {
+	case reflect.String:
+		parsed.SetString(value)
+	case reflect.Int:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return configErr.New("%s from %s must be a number", name, source)
+		}
+		parsed.SetInt(int64(i))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return configErr.New("%s from %s must be true or false", name,
+				source)
+		}
+		parsed.SetBool(b)
+	}
+
+	if prev, ok := raw.sources[name]; ok &&
+		!reflect.DeepEqual(field.value.Interface(), parsed.Interface()) {
+		if field.secret {
+			return configErr.New("%s from %s and %s don't match", name, prev,
+				source)
+		}
+		return configErr.New("%s %q from %s and %q from %s don't match", name,
+			fmt.Sprint(field.value.Interface()), prev, value, source)
+	}
+
+	field.value.Set(parsed)
+	if raw.sources == nil {
+		raw.sources = map[string]string{}
+	}
+	if prev, ok := raw.sources[name]; ok {
+		source = prev + ", " + source
+	}
+	raw.sources[name] = source
+	return nil
+}
+
+func (raw *rawConfigs) setDefault(name, value string) {
+	_ = raw.set(name, value, sourceDefault) // defaults are never invalid
+}
+
+// setConfigFile will set all of the values provided in the config file, which
+// is given by the --config flag or the DEMOAPI_CONFIG env var
+func (raw *rawConfigs) setConfigFile() error {
+	path := configFilePath()
+	if path == "" {
+		return nil
+	}
+
+	hclBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return configErr.Wrap(err)
+	}
+
+	settings := map[string]interface{}{}
+	if err := hcl.Unmarshal(hclBytes, &settings); err != nil {
+		return configErr.Wrap(err)
+	}
+
+	for name, value := range settings {
+		err := raw.set(name, fmt.Sprint(value), "file "+path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func configFilePath() string {
+	if *configFileFlag != "" {
+		return *configFileFlag
+	}
+	return os.Getenv(envName("config"))
+}
+
+// setConfigFlags will set all of the values provided as config flags
+func (raw *rawConfigs) setConfigFlags() (err error) {
+	flag.Visit(func(f *flag.Flag) {
+		setting, ok := settingFlags[f.Name]
+		if !ok || err != nil {
+			return
+		}
+
+		name, value := f.Name, setting.value
+		if strings.HasSuffix(name, fileSuffix) {
+			name = strings.TrimSuffix(name, fileSuffix)
+			value, err = readSecretFile(value)
+			if err != nil {
+				return
+			}
+		}
+		err = raw.set(name, value, "flag --"+f.Name)
+	})
+	return err
+}
+
+// setEnvVars will set all of the values provided as environment vars
+func (raw *rawConfigs) setEnvVars() error {
+	for _, field := range raw.fields() {
+		env := envName(field.name)
+		if value, ok := os.LookupEnv(env); ok {
+			if err := raw.set(field.name, value, "env "+env); err != nil {
+				return err
+			}
+		}
+
+		if !field.secret {
+			continue
+		}
+		env = envName(field.name + fileSuffix)
+		if path, ok := os.LookupEnv(env); ok {
+			value, err := readSecretFile(path)
+			if err != nil {
+				return err
+			}
+			if err := raw.set(field.name, value, "env "+env); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setDatabaseEnvVars builds db_url from the DATABASE_* env vars that are set
+// by docker-compose. The user, password and database name fall back to the
+// POSTGRES_* env vars that the postgres image reads, and have to match them
+// when both are set.
+func (raw *rawConfigs) setDatabaseEnvVars() error {
+	parts := map[string]string{}
+	for _, part := range []string{"DRIVER", "HOST", "PORT", "SSLMODE", "USER",
+		"PASSWORD", "NAME"} {
+		parts[part] = os.Getenv("DATABASE_" + part)
+	}
+	if path := os.Getenv("DATABASE_PASSWORD_FILE"); path != "" {
+		password, err := readSecretFile(path)
+		if err != nil {
+			return err
+		}
+		parts["PASSWORD"] = password
+	}
+
+	if parts["DRIVER"] == "" {
+		for part, value := range parts {
+			if value != "" {
+				return configErr.New("DATABASE_DRIVER is required when "+
+					"DATABASE_%s is set", part)
+			}
+		}
+		return nil
+	}
+
+	postgresParts := map[string]string{
+		"USER":     os.Getenv("POSTGRES_USER"),
+		"PASSWORD": os.Getenv("POSTGRES_PASSWORD"),
+		"NAME":     os.Getenv("POSTGRES_DB"),
+	}
+	if path := os.Getenv("POSTGRES_PASSWORD_FILE"); path != "" {
+		password, err := readSecretFile(path)
+		if err != nil {
+			return err
+		}
+		postgresParts["PASSWORD"] = password
+	}
+	for part, value := range postgresParts {
+		switch {
+		case value == "":
+		case parts[part] == "":
+			parts[part] = value
+		case parts[part] != value:
+			return configErr.New("DATABASE_%s doesn't match the postgres env "+
+				"var", part)
+		}
+	}
+
+	if parts["HOST"] == "" {
+		return configErr.New("DATABASE_HOST is required when DATABASE_DRIVER " +
+			"is set")
+	}
+
+	host := parts["HOST"]
+	if parts["PORT"] != "" {
+		host += ":" + parts["PORT"]
+	}
+	dbURL := &url.URL{
+		Scheme: parts["DRIVER"],
+		User:   url.UserPassword(parts["USER"], parts["PASSWORD"]),
+		Host:   host,
+		Path:   parts["NAME"],
+	}
+	if parts["SSLMODE"] != "" {
+		dbURL.RawQuery = "sslmode=" + parts["SSLMODE"]
+	}
+
+	return raw.set("db_url", dbURL.String(), "env DATABASE_*")
+}
+
+func readSecretFile(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", configErr.Wrap(err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// Setting is a raw setting as it's printed by Print
+type Setting struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// settings lists every setting of raw, with secrets redacted
+func (raw *rawConfigs) settings() []Setting {
+	var settings []Setting
+	for _, field := range raw.fields() {
+		value := field.value.Interface()
+		formatted := fmt.Sprint(value)
+		if s, ok := value.(string); ok {
+			if field.secret && s != "" {
+				s = redact(s)
+			}
+			formatted = strconv.Quote(s)
+		}
+
+		source, ok := raw.sources[field.name]
+		if !ok {
+			source = sourceUnset
+		}
+		settings = append(settings, Setting{
+			Name:   field.name,
+			Value:  formatted,
+			Source: source,
+		})
+	}
+	return settings
+}
+
+// redact hides the secret parts of a setting. urls keep everything but their
+// password
+func redact(secret string) string {
+	u, err := url.Parse(secret)
+	if err != nil || u.Scheme == "" {
+		return "<redacted>"
+	}
+	query := u.Query()
+	if query.Get("password") != "" {
+		query.Set("password", "xxxxx")
+		u.RawQuery = query.Encode()
+	}
+	return u.Redacted()
+}
+
+// Print parses the configuration like Parse and writes every setting in the
+// config file format, with secrets redacted and where each one came from. The
+// settings are printed even if they aren't valid, followed by why.
+func Print(w io.Writer) error {
+	raw, err := parse()
+	if err != nil {
+		return err
+	}
+
+	for _, s := range raw.settings() {
+		_, err := fmt.Fprintf(w, "%s = %s # %s\n", s.Name, s.Value, s.Source)
+		if err != nil {
+			return configErr.Wrap(err)
+		}
+	}
+
+	_, err = raw.validate()
+	if err != nil {
+		fmt.Fprintf(w, "# invalid: %s\n", err)
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSources(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.hcl")
+	assert.NoError(t, ioutil.WriteFile(configFile, []byte(`
+api_addr = ":8080"
+write_timeout_sec = 15
+developer_mode = true
+`), 0644))
+	passwordFile := filepath.Join(dir, "password")
+	assert.NoError(t, ioutil.WriteFile(passwordFile, []byte("dbpass\n"),
+		0600))
+
+	t.Setenv("DEMOAPI_CONFIG", configFile)
+	t.Setenv("DEMOAPI_API_ADDR", ":8080") // agrees with the file
+	t.Setenv("DEMOAPI_GRPC_ADDR", ":9091")
+	t.Setenv("DATABASE_DRIVER", "postgres")
+	t.Setenv("DATABASE_HOST", "db")
+	t.Setenv("DATABASE_PORT", "5432")
+	t.Setenv("DATABASE_PASSWORD_FILE", passwordFile)
+	t.Setenv("POSTGRES_USER", "dbuser")
+	t.Setenv("POSTGRES_DB", "demoapidb")
+
+	raw, err := parse()
+	assert.NoError(t, err)
+	assert.Equal(t, ":8080", raw.APIAddress)
+	assert.Equal(t, ":9091", raw.GRPCAddress)
+	assert.Equal(t, 15, raw.WriteTimeout)
+	assert.True(t, raw.DeveloperMode)
+	assert.Equal(t, "postgres://dbuser:dbpass@db:5432/demoapidb", raw.DBURL)
+	assert.Equal(t, "text", raw.LogFormat)
+
+	settings := map[string]Setting{}
+	for _, s := range raw.settings() {
+		settings[s.Name] = s
+	}
+	assert.Equal(t, "file "+configFile+", env DEMOAPI_API_ADDR",
+		settings["api_addr"].Source)
+	assert.Equal(t, `"postgres://dbuser:xxxxx@db:5432/demoapidb"`,
+		settings["db_url"].Value)
+	assert.Equal(t, sourceDefault, settings["log_format"].Source)
+	assert.Equal(t, sourceUnset, settings["trace_file"].Source)
+
+	// sources have to agree
+	t.Setenv("DEMOAPI_WRITE_TIMEOUT_SEC", "30")
+	_, err = parse()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "write_timeout_sec")
+}
@@ -2,181 +2,181 @@ package config
 
 import (
 	"flag"
-	"io/ioutil"
+	"fmt"
 	"net/url"
-	"os"
 	"time"
 
-	"github.com/hashicorp/hcl"
 	"github.com/sirupsen/logrus"
 	"github.com/zeebo/errs"
-)
 
-var (
-	configFileFlag = flag.String("config", "", "config file path")
-	dbURLFlag      = flag.String("db_url", "", "database url") // flag override
-	logLevelFlag   = flag.String("loglevel", "", "log level")  // flag override
-
-	// env var overrides
-	dbDriverEnv = os.Getenv("DATABASE_DRIVER")
-	dbHostEnv   = os.Getenv("DATABASE_HOST")
-	dbPortEnv   = os.Getenv("DATABASE_PORT")
-	dbSSLEnv    = os.Getenv("DATABASE_SSLMODE")
-	dbUserEnv   = os.Getenv("DATABASE_USER")
-	dbPassEnv   = os.Getenv("DATABASE_PASSWORD")
-	dbDBNameEnv = os.Getenv("DATABASE_NAME")
-
-	// handled explicitly because the postgres server expects these env vars
-	psqlUserEnv   = os.Getenv("POSTGRES_USER")
-	psqlPassEnv   = os.Getenv("POSTGRES_PASSWORD")
-	psqlDBNameEnv = os.Getenv("POSTGRES_DB")
-
-	configErr = errs.Class("configuration")
+	"demoapi/ratelimit"
+	"demoapi/tlsconfig"
 )
 
+var configErr = errs.Class("configuration")
+
+// Configs are the validated settings of the api. Settings tagged reload can
+// be changed while the api is running (see Store); changing any other setting
+// needs a restart.
 type Configs struct {
 	DBURL                   *url.URL
 	APISlug                 string
 	APIAddress              string
 	MetricAddress           string
+	GRPCAddress             string // optional. grpc is disabled if empty
+	APITLS                  tlsconfig.Settings
+	MetricTLS               tlsconfig.Settings
 	GracefulShutdownTimeout time.Duration
 	WriteTimeout            time.Duration
 	ReadTimeout             time.Duration
 	IdleTimeout             time.Duration
-	LogLevel                logrus.Level
-	DeveloperMode           bool
-	InsecureRequestsMode    bool
+	ReadinessTimeout        time.Duration
+	LogLevel                logrus.Level `reload:"true"`
+	LogFormat               string       `reload:"true"` // "text" or "json"
+	TraceExporter           string       // "", "otlp", "stdout" or "file"
+	TraceOTLPEndpoint       string       // host:port of the otlp collector
+	TraceFile               string       // path of the span file for "file"
+	DeveloperMode           bool         `reload:"true"`
+	InsecureRequestsMode    bool         `reload:"true"`
+
+	// requests are limited by client and route class (see server/ratelimit.go)
+	RateLimits ratelimit.Limits `reload:"true"`
+
+	// retries of writes with an Idempotency-Key get the first response
+	// until it expires (see server/idempotency.go)
+	IdempotencyKeyTTL time.Duration `reload:"true"`
+
+	// list routes have pages of at most PaginationMaxLimit records. their
+	// cursors are signed with PaginationCursorSecret (see
+	// server/pagination.go), or a random key if it's empty
+	PaginationMaxLimit     int    `reload:"true"`
+	PaginationCursorSecret string `secret:"true"`
+
+	// bodies of validated requests that are longer than this are a 413
+	// (see server/openapi_validate.go)
+	MaxRequestBodyBytes int `reload:"true"`
+
+	// unversioned api paths, like /users, are served as /v1 until they're
+	// sunset. zero keeps serving them (see server/version.go)
+	UnversionedPathsSunset time.Time `reload:"true"`
 }
 
 // Parse will set the configuration values pulled from the provided config
-// file, any config flags, and any environment variables. env vars have highest
-// priority, then config flags, then the config file. If any values overlap and
-// *DON'T* match, an error is thrown.
+// file, any config flags, and any environment variables (see sources.go for
+// how each setting is named). If a setting is given by more than one source
+// and the values *DON'T* match, an error is thrown. Parse can be called again
+// to reload the configuration.
 func Parse() (*Configs, error) {
+	raw, err := parse()
+	if err != nil {
+		return nil, err
+	}
+	return raw.validate()
+}
+
+func parse() (*rawConfigs, error) {
 	flag.Parse()
 
-	raw := rawConfigs{}
+	raw := &rawConfigs{}
 	err := raw.setConfigFile()
 	if err != nil {
 		return nil, err
 	}
 
-	err = raw.setNoChangeConfigFlags()
+	err = raw.setConfigFlags()
 	if err != nil {
 		return nil, err
 	}
 
-	err = raw.setNoChangeEnvVars()
+	err = raw.setEnvVars()
 	if err != nil {
 		return nil, err
 	}
 
-	return raw.validate()
+	err = raw.setDatabaseEnvVars()
+	if err != nil {
+		return nil, err
+	}
+
+	raw.setDefaults()
+	return raw, nil
 }
 
+// rawConfigs are the settings as they're given. every setting can be set in
+// the config file by its hcl name, by a flag and by an environment variable.
+// Secret settings can also be read from a file and are redacted when printed.
 type rawConfigs struct {
-	DBURL                   string `hcl:"db_url"`
+	DBURL                   string `hcl:"db_url" secret:"true"`
 	APISlug                 string `hcl:"api_slug"`
 	APIAddress              string `hcl:"api_addr"`
 	MetricAddress           string `hcl:"metric_addr"`
+	GRPCAddress             string `hcl:"grpc_addr"`
+	APITLSCertFile          string `hcl:"api_tls_cert_file"`
+	APITLSKeyFile           string `hcl:"api_tls_key_file"`
+	APITLSClientCAFile      string `hcl:"api_tls_client_ca_file"`
+	MetricTLSCertFile       string `hcl:"metric_tls_cert_file"`
+	MetricTLSKeyFile        string `hcl:"metric_tls_key_file"`
+	MetricTLSClientCAFile   string `hcl:"metric_tls_client_ca_file"`
+	TLSMinVersion           string `hcl:"tls_min_version"`
+	TLSCipherSuites         string `hcl:"tls_cipher_suites"`
 	GracefulShutdownTimeout int    `hcl:"graceful_shutdown_timeout_sec"`
 	WriteTimeout            int    `hcl:"write_timeout_sec"`
 	ReadTimeout             int    `hcl:"read_timeout_sec"`
 	IdleTimeout             int    `hcl:"idle_timeout_sec"`
+	ReadinessTimeout        int    `hcl:"readiness_timeout_sec"`
+	RateLimitReadsPerMin    int    `hcl:"rate_limit_reads_per_min"`
+	RateLimitReadsBurst     int    `hcl:"rate_limit_reads_burst"`
+	RateLimitWritesPerMin   int    `hcl:"rate_limit_writes_per_min"`
+	RateLimitWritesBurst    int    `hcl:"rate_limit_writes_burst"`
+	RateLimitBulkPerMin     int    `hcl:"rate_limit_bulk_per_min"`
+	RateLimitBulkBurst      int    `hcl:"rate_limit_bulk_burst"`
+	IdempotencyKeyTTL       int    `hcl:"idempotency_key_ttl_sec"`
+	UnversionedPathsSunset  string `hcl:"unversioned_paths_sunset"`
+	PaginationMaxLimit      int    `hcl:"pagination_max_limit"`
+	PaginationCursorSecret  string `hcl:"pagination_cursor_secret" secret:"true"`
+	MaxRequestBodyBytes     int    `hcl:"max_request_body_bytes"`
 	LogLevel                string `hcl:"loglevel"`
+	LogFormat               string `hcl:"log_format"`
+	TraceExporter           string `hcl:"trace_exporter"`
+	TraceOTLPEndpoint       string `hcl:"trace_otlp_endpoint"`
+	TraceFile               string `hcl:"trace_file"`
 	DeveloperMode           bool   `hcl:"developer_mode"`
 	InsecureRequestsMode    bool   `hcl:"insecure_requests_mode"`
-}
-
-// setConfigFile will set all of the values provided in the config file,
-// stomping over any existing values
-func (raw *rawConfigs) setConfigFile() error {
-	if configFileFlag == nil || *configFileFlag == "" {
-		return nil
-	}
-
-	hclBytes, err := ioutil.ReadFile(*configFileFlag)
-	if err != nil {
-		return configErr.Wrap(err)
-	}
 
-	if err := hcl.Unmarshal(hclBytes, raw); err != nil {
-		return configErr.Wrap(err)
-	}
-
-	return nil
+	sources map[string]string // where each setting came from, by hcl name
 }
 
-// setNoChangeConfigFlags will set all of the values provided as config flags
-// as long as they don't change existing non-empty values in Configs
-func (raw *rawConfigs) setNoChangeConfigFlags() error {
-	if dbURLFlag != nil && *dbURLFlag != "" {
-		if raw.DBURL == "" {
-			raw.DBURL = *dbURLFlag
-		} else if raw.DBURL != *dbURLFlag {
-			return configErr.New("db urls %q and %q don't match", raw.DBURL,
-				*dbURLFlag)
-		}
+// setDefaults fills in the optional settings that weren't given
+func (raw *rawConfigs) setDefaults() {
+	if raw.LogFormat == "" {
+		raw.setDefault("log_format", "text")
 	}
-
-	if logLevelFlag != nil && *logLevelFlag != "" {
-		if raw.LogLevel == "" {
-			raw.LogLevel = *logLevelFlag
-		} else if raw.LogLevel != *logLevelFlag {
-			return configErr.New("log level %q and %q don't match", raw.LogLevel,
-				*logLevelFlag)
-		}
+	if raw.TraceExporter == "otlp" && raw.TraceOTLPEndpoint == "" {
+		raw.setDefault("trace_otlp_endpoint", "localhost:4317")
 	}
 
-	return nil
-}
-
-// setNoChangeEnvVars will set all of the values provided as environment vars
-// as long as they don't change existing non-empty values in Configs
-func (raw *rawConfigs) setNoChangeEnvVars() error {
-
-	// use dbUserEnv and psqlUserEnv as sentinel values. if these env vars
-	// are set, assume the other ones exist.
-	if psqlUserEnv == "" || dbUserEnv == "" {
-		if dbDriverEnv != "" || dbHostEnv != "" || dbPortEnv != "" ||
-			dbSSLEnv != "" || dbUserEnv != "" || dbPassEnv != "" ||
-			dbDBNameEnv != "" || psqlUserEnv != "" || psqlPassEnv != "" ||
-			psqlDBNameEnv != "" {
-			return configErr.New("database connection env vars incompletely set")
+	// a client can use a minute of requests at once
+	for _, class := range []string{"reads", "writes", "bulk"} {
+		perMin, _ := raw.field("rate_limit_" + class + "_per_min")
+		burst, _ := raw.field("rate_limit_" + class + "_burst")
+		if perMin.value.Int() > 0 && burst.value.Int() == 0 {
+			raw.setDefault(burst.name, fmt.Sprint(perMin.value.Int()))
 		}
-
-		return nil
 	}
 
-	if dbDriverEnv == "postgres" {
-		if psqlUserEnv == "" || psqlPassEnv == "" || psqlDBNameEnv == "" {
-			return configErr.New("required postgres env vars missing")
-		}
-
-		if (dbUserEnv != "" || dbPassEnv != "" || dbDBNameEnv != "") &&
-			(dbUserEnv != psqlUserEnv || dbPassEnv != psqlPassEnv ||
-				dbDBNameEnv != psqlDBNameEnv) {
-			return configErr.New("non matching db and psql specific env vars set")
-		}
+	// a day is longer than clients keep retrying
+	if raw.IdempotencyKeyTTL == 0 {
+		raw.setDefault("idempotency_key_ttl_sec", "86400")
 	}
 
-	envVarDB := &url.URL{
-		Scheme:   dbDriverEnv,
-		User:     url.UserPassword(dbUserEnv, dbPassEnv),
-		Host:     dbHostEnv + ":" + dbPortEnv,
-		Path:     dbDBNameEnv,
-		RawQuery: "sslmode=" + dbSSLEnv,
+	if raw.PaginationMaxLimit == 0 {
+		raw.setDefault("pagination_max_limit", "50")
 	}
 
-	envVarDBString := envVarDB.String()
-	if raw.DBURL == "" {
-		raw.DBURL = envVarDBString
-	} else if raw.DBURL != envVarDBString {
-		return configErr.New("db urls %q and env %q don't match", raw.DBURL,
-			envVarDBString)
+	// a MiB fits any batch of users that a request should make
+	if raw.MaxRequestBodyBytes == 0 {
+		raw.setDefault("max_request_body_bytes", "1048576")
 	}
-
-	return nil
 }
 
 func (raw *rawConfigs) validate() (*Configs, error) {
@@ -217,23 +217,146 @@ func (raw *rawConfigs) validate() (*Configs, error) {
 	write := time.Second * time.Duration(raw.WriteTimeout)
 	read := time.Second * time.Duration(raw.ReadTimeout)
 	idle := time.Second * time.Duration(raw.IdleTimeout)
+	readiness := time.Second * time.Duration(raw.ReadinessTimeout) // optional
 
 	loglevel, err := logrus.ParseLevel(raw.LogLevel)
 	if err != nil {
 		return nil, err
 	}
 
+	switch raw.LogFormat {
+	case "text", "json":
+	default:
+		return nil, configErr.New("log_format must be \"text\" or \"json\"")
+	}
+
+	switch raw.TraceExporter {
+	case "", "stdout", "otlp":
+	case "file":
+		if raw.TraceFile == "" {
+			return nil, configErr.New("trace_file misconfigured")
+		}
+	default:
+		return nil, configErr.New("trace_exporter must be \"otlp\", " +
+			"\"stdout\" or \"file\"")
+	}
+
+	for _, limit := range []int{raw.RateLimitReadsPerMin,
+		raw.RateLimitReadsBurst, raw.RateLimitWritesPerMin,
+		raw.RateLimitWritesBurst, raw.RateLimitBulkPerMin,
+		raw.RateLimitBulkBurst} {
+		if limit < 0 {
+			return nil, configErr.New("rate limits can't be negative")
+		}
+	}
+	if raw.IdempotencyKeyTTL < 0 {
+		return nil, configErr.New("idempotency_key_ttl_sec misconfigured")
+	}
+	idempotencyKeyTTL := time.Second * time.Duration(raw.IdempotencyKeyTTL)
+	if raw.PaginationMaxLimit < 1 {
+		return nil, configErr.New("pagination_max_limit misconfigured")
+	}
+	if raw.MaxRequestBodyBytes < 1 {
+		return nil, configErr.New("max_request_body_bytes misconfigured")
+	}
+
+	var sunset time.Time
+	if raw.UnversionedPathsSunset != "" {
+		sunset, err = parseDate(raw.UnversionedPathsSunset)
+		if err != nil {
+			return nil, configErr.New("unversioned_paths_sunset "+
+				"misconfigured: %s", err)
+		}
+	}
+
+	minVersion, err := tlsconfig.ParseVersion(raw.TLSMinVersion)
+	if err != nil {
+		return nil, configErr.New("tls_min_version misconfigured: %s", err)
+	}
+	cipherSuites, err := tlsconfig.ParseCipherSuites(raw.TLSCipherSuites)
+	if err != nil {
+		return nil, configErr.New("tls_cipher_suites misconfigured: %s", err)
+	}
+
+	apiTLS, err := tlsSettings("api", raw.APITLSCertFile, raw.APITLSKeyFile,
+		raw.APITLSClientCAFile, minVersion, cipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	metricTLS, err := tlsSettings("metric", raw.MetricTLSCertFile,
+		raw.MetricTLSKeyFile, raw.MetricTLSClientCAFile, minVersion,
+		cipherSuites)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Configs{
 		DBURL:                   dbURL,
 		APISlug:                 raw.APISlug,
 		APIAddress:              raw.APIAddress,
 		MetricAddress:           raw.MetricAddress,
+		GRPCAddress:             raw.GRPCAddress,
+		APITLS:                  apiTLS,
+		MetricTLS:               metricTLS,
 		GracefulShutdownTimeout: grace,
 		WriteTimeout:            write,
 		ReadTimeout:             read,
 		IdleTimeout:             idle,
+		ReadinessTimeout:        readiness,
 		LogLevel:                loglevel,
+		LogFormat:               raw.LogFormat,
+		TraceExporter:           raw.TraceExporter,
+		TraceOTLPEndpoint:       raw.TraceOTLPEndpoint,
+		TraceFile:               raw.TraceFile,
 		DeveloperMode:           raw.DeveloperMode,
 		InsecureRequestsMode:    raw.InsecureRequestsMode,
+		RateLimits: ratelimit.Limits{
+			Reads: ratelimit.Limit{PerMinute: raw.RateLimitReadsPerMin,
+				Burst: raw.RateLimitReadsBurst},
+			Writes: ratelimit.Limit{PerMinute: raw.RateLimitWritesPerMin,
+				Burst: raw.RateLimitWritesBurst},
+			Bulk: ratelimit.Limit{PerMinute: raw.RateLimitBulkPerMin,
+				Burst: raw.RateLimitBulkBurst},
+		},
+		IdempotencyKeyTTL:      idempotencyKeyTTL,
+		PaginationMaxLimit:     raw.PaginationMaxLimit,
+		PaginationCursorSecret: raw.PaginationCursorSecret,
+		MaxRequestBodyBytes:    raw.MaxRequestBodyBytes,
+		UnversionedPathsSunset: sunset,
+	}, nil
+}
+
+// parseDate parses a date like "2027-01-31", which is midnight utc, or a
+// time like "2027-01-31T12:00:00Z"
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// tlsSettings checks the tls files of the listener called name. tls is
+// optional, but a certificate needs its key and client certificates can only
+// be verified over tls.
+func tlsSettings(name, certFile, keyFile, clientCAFile string,
+	minVersion uint16, cipherSuites []uint16) (tlsconfig.Settings, error) {
+
+	if (certFile == "") != (keyFile == "") {
+		return tlsconfig.Settings{}, configErr.New("%s_tls_cert_file and "+
+			"%s_tls_key_file have to be set together", name, name)
+	}
+	if clientCAFile != "" && certFile == "" {
+		return tlsconfig.Settings{}, configErr.New("%s_tls_client_ca_file "+
+			"needs %s_tls_cert_file", name, name)
+	}
+	if certFile == "" {
+		return tlsconfig.Settings{}, nil
+	}
+	return tlsconfig.Settings{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: clientCAFile,
+		MinVersion:   minVersion,
+		CipherSuites: cipherSuites,
 	}, nil
 }
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// This file contains the checks used to decide whether the database is ready
+
+var (
+	createTableRegexp = regexp.MustCompile(`^CREATE TABLE (\w+) \($`)
+	columnRegexp      = regexp.MustCompile(`^\s+(\w+) `)
+)
+
+// Ping checks that the database can be reached
+func (db *Database) Ping(ctx context.Context) error {
+	return dbErr.Wrap(db.DB.PingContext(ctx))
+}
+
+// CheckSchema checks that every table and column of the schema exists. The
+// database is migrated when the api starts (see migrate.go), so they only go
+// missing if the database was changed by something else.
+func (db *Database) CheckSchema(ctx context.Context) error {
+	for _, table := range schemaTables(db.DB.Schema()) {
+		stmt := "SELECT " + strings.Join(table.columns, ", ") + " FROM " +
+			table.name + " LIMIT 0"
+		rows, err := db.DB.QueryContext(ctx, stmt)
+		if err != nil {
+			return dbErr.New("table %s is out of date: %s", table.name, err)
+		}
+		if err := rows.Close(); err != nil {
+			return dbErr.Wrap(err)
+		}
+	}
+	return nil
+}
+
+type schemaTable struct {
+	name        string
+	columns     []string
+	definitions []string // of the columns, like "status TEXT NOT NULL"
+}
+
+// schemaTables lists the tables and columns created by a dbx schema
+func schemaTables(schema string) []*schemaTable {
+	var tables []*schemaTable
+	var table *schemaTable
+	for _, line := range strings.Split(schema, "\n") {
+		if m := createTableRegexp.FindStringSubmatch(line); m != nil {
+			table = &schemaTable{name: m[1]}
+			tables = append(tables, table)
+			continue
+		}
+		m := columnRegexp.FindStringSubmatch(line)
+		if table == nil || m == nil || m[1] == "PRIMARY" || m[1] == "UNIQUE" {
+			continue
+		}
+		table.columns = append(table.columns, m[1])
+		table.definitions = append(table.definitions,
+			strings.TrimSuffix(strings.TrimSpace(line), ","))
+	}
+	return tables
+}
+
+// findSchemaTable returns the table of a dbx schema with name, or nil
+func findSchemaTable(schema, name string) *schemaTable {
+	for _, table := range schemaTables(schema) {
+		if table.name == name {
+			return table
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSchema(test *testing.T) {
+	ctx, t := newDBTest(test)
+	defer t.cleanup()
+
+	tables := schemaTables(t.db.DB.Schema())
+	if assert.Len(t, tables, 10) {
+		assert.Equal(t, "users", tables[6].name)
+		assert.Equal(t, []string{"pk", "uuid", "created", "id", "first_name",
+			"last_name", "status"}, tables[6].columns)
+	}
+
+	assert.NoError(t, t.db.Ping(ctx))
+	assert.NoError(t, t.db.CheckSchema(ctx))
+
+	_, err := t.db.DB.Exec("DROP TABLE memberships")
+	assert.NoError(t, err)
+	assert.Error(t, t.db.CheckSchema(ctx))
+}
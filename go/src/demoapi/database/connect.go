@@ -32,19 +32,15 @@ type Config struct {
 // TODO(sam): this database package needs a lot of love. there should be a
 // database interface to make supporting multiple database drivers easier and
 // cleaner. all of the switches are gross.
-// TODO(sam): add support for migrations! Critical importance.
 func Connect(dbURL *url.URL, c *Config) (*Database, error) {
 	// WrapErr is a dbx specific error wrapping hook
 	WrapErr = StacktraceWrapAnyError
 
-	// Logger is a dbx specific logging hook. it's called by every dbx query
+	// Logger is a dbx specific logging hook. it's called by every dbx query,
+	// but without its context, so the statements are only logged at trace
+	// level. instrument logs every query with its request id.
 	Logger = func(format string, args ...interface{}) {
-		logrus.Debugf(format, args...)
-
-		go func() {
-			// it is perhaps an abuse of this logger to collect metrics with it
-			monitor.DatabaseQueryCounter.Inc()
-		}()
+		logrus.Tracef(format, args...)
 	}
 
 	// copy the dbURL and remove user/password so it's loggable
@@ -76,19 +72,18 @@ func Connect(dbURL *url.URL, c *Config) (*Database, error) {
 		if err != nil {
 			return nil, err
 		}
+		err = db.initializeVersion()
 	} else {
 		logrus.Debugf("%s already exists", loggableURL.String())
+		err = db.migrate()
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	db.configure(c)
 	logrus.Infof("connected to database")
 
-	// TODO(sam): add support for migrations!
-	// err = db.migrate()
-	// if err != nil {
-	//   return nil, err
-	// }
-
 	// TODO(sam): spin off a lightweight goroutine that will occasionally query
 	// the database for counts of various tables and Set monitor metrics
 
@@ -96,6 +91,10 @@ func Connect(dbURL *url.URL, c *Config) (*Database, error) {
 }
 
 func newDatabase(driver string, dbConn *DB) (*Database, error) {
+	// trace and measure every dbx query
+	dbConn.dbMethods = instrumentDB(driver, dbConn.dbMethods)
+	monitor.RegisterDBStats(dbConn.DB, driver)
+
 	db := &Database{DB: dbConn, driver: driver}
 	return db, nil
 }
@@ -2,8 +2,13 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 
-	"github.com/sirupsen/logrus"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+
+	"demoapi/logging"
 )
 
 // StacktraceWrapAnyError is used by the dbx WrapErr hook to provide stack
@@ -11,32 +16,127 @@ import (
 //
 // TODO(sam): more graceful/user-friendly errors should be returned when there
 // are unique-key constraint violations, no row found, etc
+//
+// the hook has no context, so the error is logged by instrument instead, with
+// the request id of the query
 func StacktraceWrapAnyError(err *Error) error {
 	if err == nil {
 		return nil
 	}
 
-	logrus.WithError(err).Warning("database connection error")
 	return dbErr.Wrap(err)
 }
 
-// WithTx provides a way to transactionally execute multiple db statements
+// IsUniqueViolation reports whether err is from a write that would have
+// given a unique column a value that another row already has
+func IsUniqueViolation(err error) bool {
+	var dbxErr *Error
+	if errors.As(err, &dbxErr) {
+		err = dbxErr.Err
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique ||
+			sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505" // unique_violation
+	}
+	return false
+}
+
+type txKey struct{}
+
+// openTx is a transaction and what to do once it's committed
+type openTx struct {
+	*Tx
+	afterCommit []func()
+}
+
+// WithTx provides a way to transactionally execute multiple db statements.
+// the transaction is carried by the context passed to fn, and a WithTx with
+// that context joins it instead of starting another, so that statements of
+// separate operations can be committed or rolled back together.
 func (db *DB) WithTx(ctx context.Context,
 	fn func(context.Context, *Tx) error) (err error) {
 
+	if open, ok := ctx.Value(txKey{}).(*openTx); ok {
+		return fn(ctx, open.Tx)
+	}
+
 	tx, err := db.Open(ctx)
 	if err != nil {
 		return err
 	}
+	open := &openTx{Tx: tx}
 	defer func() {
 		if err == nil {
 			err = tx.Commit()
 		} else {
 			txerr := tx.Rollback() // careful not to shadow "err"
 			if txerr != nil {
-				logrus.Warningf("transaction rollback error: %s", txerr)
+				logging.Entry(ctx).Warningf("transaction rollback error: %s",
+					txerr)
+			}
+		}
+		if err == nil {
+			for _, after := range open.afterCommit {
+				after()
 			}
 		}
 	}()
-	return fn(ctx, tx)
+	return fn(context.WithValue(ctx, txKey{}, open), tx)
+}
+
+// WithTx is DB.WithTx, instrumented as a whole transaction
+func (db *Database) WithTx(ctx context.Context,
+	fn func(context.Context, *Tx) error) (err error) {
+
+	if _, ok := ctx.Value(txKey{}).(*openTx); ok {
+		return db.DB.WithTx(ctx, fn) // joined, so it's already instrumented
+	}
+
+	ctx, done := instrumentTx(ctx, db.driver)
+	defer func() { done(err) }()
+	return db.DB.WithTx(ctx, fn)
+}
+
+// In returns the queries of the transaction that ctx carries, or the queries
+// of db outside of a transaction
+func (db *Database) In(ctx context.Context) Methods {
+	if open, ok := ctx.Value(txKey{}).(*openTx); ok {
+		return open.Tx
+	}
+	return db.DB
+}
+
+// querier runs hand-written sql
+type querier interface {
+	ExecContext(ctx context.Context, query string,
+		args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string,
+		args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string,
+		args ...interface{}) *sql.Row
+}
+
+// querier is In for hand-written sql
+func (db *Database) querier(ctx context.Context) querier {
+	if open, ok := ctx.Value(txKey{}).(*openTx); ok {
+		return open.Tx.Tx
+	}
+	return db.DB.DB
+}
+
+// AfterCommit calls fn once the transaction that ctx carries is committed,
+// and never if it's rolled back. outside of a transaction it's called right
+// away.
+func AfterCommit(ctx context.Context, fn func()) {
+	if open, ok := ctx.Value(txKey{}).(*openTx); ok {
+		open.afterCommit = append(open.afterCommit, fn)
+		return
+	}
+	fn()
 }
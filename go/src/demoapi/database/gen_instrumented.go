@@ -0,0 +1,93 @@
+//go:build ignore
+
+// This program generates instrumented.dbx.go, which wraps every method of the
+// dbx Methods interface so it can be traced (see instrument.go). It's run by
+// go generate after dbx, so the wrappers always match schema.dbx.go.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+func main() {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "schema.dbx.go", nil, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	methods := findInterface(file, "Methods")
+	if methods == nil {
+		log.Fatal("schema.dbx.go has no Methods interface")
+	}
+
+	out := &bytes.Buffer{}
+	fmt.Fprintln(out, "// Code generated by gen_instrumented.go. DO NOT EDIT.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "package database")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `import "context"`)
+
+	for _, method := range methods.Methods.List {
+		fn := method.Type.(*ast.FuncType)
+		name := method.Names[0].Name
+
+		var args []string
+		for _, param := range fn.Params.List {
+			for _, n := range param.Names {
+				args = append(args, n.Name)
+			}
+		}
+
+		fmt.Fprintf(out, "\nfunc (obj *instrumentedMethods) %s%s {\n", name,
+			strings.TrimPrefix(node(fset, fn), "func"))
+		fmt.Fprintf(out, "\tctx, done := instrument(ctx, obj.driver, %q)\n",
+			name)
+		fmt.Fprintln(out, "\tdefer func() { done(err) }()")
+		fmt.Fprintf(out, "\treturn obj.Methods.%s(%s)\n", name,
+			strings.Join(args, ", "))
+		fmt.Fprintln(out, "}")
+	}
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = ioutil.WriteFile("instrumented.dbx.go", src, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func findInterface(file *ast.File, name string) *ast.InterfaceType {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if it, ok := ts.Type.(*ast.InterfaceType); ok && ts.Name.Name == name {
+				return it
+			}
+		}
+	}
+	return nil
+}
+
+func node(fset *token.FileSet, n ast.Node) string {
+	buf := &bytes.Buffer{}
+	if err := printer.Fprint(buf, fset, n); err != nil {
+		log.Fatal(err)
+	}
+	return buf.String()
+}
@@ -1,3 +1,4 @@
 package database
 
 //go:generate dbx.v1 golang -d postgres -d sqlite3 -p database schema.dbx  .
+//go:generate go run gen_instrumented.go
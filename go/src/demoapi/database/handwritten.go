@@ -2,12 +2,13 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
-	"github.com/sirupsen/logrus"
-
-	monitor "demoapi/prometheus"
+	"demoapi/logging"
 )
 
 // This file contains any sql queries that are written by hand because they use
@@ -20,11 +21,14 @@ import (
 // aren't provided in userIDs, it will add any new membership relationships,
 // and the intersection set will be untouched.
 func (db *Database) SetGroupMembership(ctx context.Context, groupName string,
-	userIDs []string) (int, int, int, error) { // added, removed, unchanged
+	userIDs []string) (_, _, _ int, err error) { // added, removed, unchanged
+
+	ctx, done := instrument(ctx, db.driver, "SetGroupMembership")
+	defer func() { done(err) }()
 
 	added, removed := 0, 0
 
-	err := db.WithTx(ctx, func(ctx context.Context, tx *Tx) error {
+	err = db.WithTx(ctx, func(ctx context.Context, tx *Tx) error {
 		var err error
 
 		// delete all memberships for the groupname that aren't listed in userIDs
@@ -47,7 +51,7 @@ func (db *Database) SetGroupMembership(ctx context.Context, groupName string,
 		return nil
 	})
 	if err != nil {
-		logrus.Error(err)
+		logging.Entry(ctx).Error(err)
 		return 0, 0, 0, dbErr.Wrap(err)
 	}
 
@@ -58,7 +62,10 @@ func (db *Database) SetGroupMembership(ctx context.Context, groupName string,
 // membership rows that are not included in the provided slice of userIDs, for
 // a groupName. This is all done within the provided transaction.
 func (db *Database) DeleteMembershipNotListedForGroup(ctx context.Context,
-	tx *Tx, groupName string, userIDs []string) (int, error) {
+	tx *Tx, groupName string, userIDs []string) (_ int, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "DeleteMembershipNotListedForGroup")
+	defer func() { done(err) }()
 
 	values := make([]interface{}, 0, 1+len(userIDs))
 	values = append(values, groupName)
@@ -84,12 +91,10 @@ func (db *Database) DeleteMembershipNotListedForGroup(ctx context.Context,
 	stmt := db.Rebind(queryRaw) // cleans up sql as needed per driver (eg ?->$1)
 	Logger("stmt: <%s>, values: <%v>", stmt, values)
 
-	start := time.Now()
 	result, err := tx.Tx.ExecContext(ctx, stmt, values...)
 	if err != nil {
 		return 0, dbErr.Wrap(err)
 	}
-	monitor.DatabaseQueryLatencyHistogram.Observe(time.Now().Sub(start).Seconds())
 
 	removed, err := result.RowsAffected()
 	if err != nil {
@@ -102,7 +107,10 @@ func (db *Database) DeleteMembershipNotListedForGroup(ctx context.Context,
 // userIDs with the groupName that do not already exist in one query. This
 // is all done within the provided transaction.
 func (db *Database) InsertOrIgnoreMembershipToGroup(ctx context.Context,
-	tx *Tx, groupName string, userIDs []string) (int, error) {
+	tx *Tx, groupName string, userIDs []string) (_ int, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "InsertOrIgnoreMembershipToGroup")
+	defer func() { done(err) }()
 
 	if len(userIDs) == 0 {
 		// nothing to do
@@ -136,12 +144,10 @@ func (db *Database) InsertOrIgnoreMembershipToGroup(ctx context.Context,
 	stmt := db.Rebind(queryRaw) // cleans up sql as needed per driver (eg ?->$1)
 	Logger("stmt: <%s>, values: <%v>", stmt, values)
 
-	start := time.Now()
 	result, err := tx.Tx.ExecContext(ctx, stmt, values...)
 	if err != nil {
 		return 0, dbErr.Wrap(err)
 	}
-	monitor.DatabaseQueryLatencyHistogram.Observe(time.Now().Sub(start).Seconds())
 
 	added, err := result.RowsAffected()
 	if err != nil {
@@ -150,15 +156,81 @@ func (db *Database) InsertOrIgnoreMembershipToGroup(ctx context.Context,
 	return int(added), nil
 }
 
+// AddGroupMembership adds the userIDs to a group, keeping its other members.
+// users that are already members are untouched.
+func (db *Database) AddGroupMembership(ctx context.Context, groupName string,
+	userIDs []string) (added int, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "AddGroupMembership")
+	defer func() { done(err) }()
+
+	err = db.WithTx(ctx, func(ctx context.Context, tx *Tx) error {
+		added, err = db.InsertOrIgnoreMembershipToGroup(ctx, tx, groupName,
+			userIDs)
+		return err
+	})
+	if err != nil {
+		return 0, dbErr.Wrap(err)
+	}
+	return added, nil
+}
+
+// RemoveGroupMembership removes the userIDs from a group, keeping its other
+// members
+func (db *Database) RemoveGroupMembership(ctx context.Context,
+	groupName string, userIDs []string) (removed int, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "RemoveGroupMembership")
+	defer func() { done(err) }()
+
+	if len(userIDs) == 0 {
+		// nothing to do
+		return 0, nil
+	}
+
+	values := make([]interface{}, 0, 1+len(userIDs))
+	values = append(values, groupName)
+	for _, userID := range userIDs {
+		values = append(values, userID)
+	}
+
+	queryRaw := "DELETE FROM memberships WHERE memberships.pk IN (" +
+		"SELECT memberships.pk FROM memberships " +
+		"JOIN groups ON groups.pk = memberships.group_pk " +
+		"JOIN users ON users.pk = memberships.user_pk " +
+		"WHERE groups.name = ? " +
+		"AND users.id IN (?" + strings.Repeat(",?", len(userIDs)-1) + "))"
+
+	stmt := db.Rebind(queryRaw) // cleans up sql as needed per driver (eg ?->$1)
+	Logger("stmt: <%s>, values: <%v>", stmt, values)
+
+	err = db.WithTx(ctx, func(ctx context.Context, tx *Tx) error {
+		result, err := tx.Tx.ExecContext(ctx, stmt, values...)
+		if err != nil {
+			return err
+		}
+		deleted, err := result.RowsAffected()
+		removed = int(deleted)
+		return err
+	})
+	if err != nil {
+		return 0, dbErr.Wrap(err)
+	}
+	return removed, nil
+}
+
 // SetUserMembership will remove any membership relationships that exist but
 // aren't provided in groupNames, it will add any new membership relationships,
 // and the intersection set will be untouched.
 func (db *Database) SetUserMembership(ctx context.Context, userID string,
-	groupNames []string) (int, int, int, error) { // added, removed, unchanged
+	groupNames []string) (_, _, _ int, err error) { // added, removed, unchanged
+
+	ctx, done := instrument(ctx, db.driver, "SetUserMembership")
+	defer func() { done(err) }()
 
 	added, removed := 0, 0
 
-	err := db.WithTx(ctx, func(ctx context.Context, tx *Tx) error {
+	err = db.WithTx(ctx, func(ctx context.Context, tx *Tx) error {
 		var err error
 
 		// delete all memberships for the user that aren't listed in groupNames
@@ -181,7 +253,7 @@ func (db *Database) SetUserMembership(ctx context.Context, userID string,
 		return nil
 	})
 	if err != nil {
-		logrus.Error(err)
+		logging.Entry(ctx).Error(err)
 		return 0, 0, 0, dbErr.Wrap(err)
 	}
 
@@ -192,7 +264,10 @@ func (db *Database) SetUserMembership(ctx context.Context, userID string,
 // membership rows that are not included in the provided slice of groupNames,
 // for a userID. This is all done within the provided transaction.
 func (db *Database) DeleteMembershipNotListedForUser(ctx context.Context,
-	tx *Tx, userID string, groupNames []string) (int, error) {
+	tx *Tx, userID string, groupNames []string) (_ int, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "DeleteMembershipNotListedForUser")
+	defer func() { done(err) }()
 
 	values := make([]interface{}, 0, 1+len(groupNames))
 	values = append(values, userID)
@@ -218,12 +293,10 @@ func (db *Database) DeleteMembershipNotListedForUser(ctx context.Context,
 	stmt := db.Rebind(queryRaw) // cleans up sql as needed per driver (eg ?->$1)
 	Logger("stmt: <%s>, values: <%v>", stmt, values)
 
-	start := time.Now()
 	result, err := tx.Tx.ExecContext(ctx, stmt, values...)
 	if err != nil {
 		return 0, dbErr.Wrap(err)
 	}
-	monitor.DatabaseQueryLatencyHistogram.Observe(time.Now().Sub(start).Seconds())
 
 	removed, err := result.RowsAffected()
 	if err != nil {
@@ -236,7 +309,10 @@ func (db *Database) DeleteMembershipNotListedForUser(ctx context.Context,
 // groupNames with the userID that do not already exist in one query. This
 // is all done within the provided transaction.
 func (db *Database) InsertOrIgnoreMembershipToUser(ctx context.Context,
-	tx *Tx, userID string, groupNames []string) (int, error) {
+	tx *Tx, userID string, groupNames []string) (_ int, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "InsertOrIgnoreMembershipToUser")
+	defer func() { done(err) }()
 
 	if len(groupNames) == 0 {
 		// nothing to do
@@ -270,12 +346,10 @@ func (db *Database) InsertOrIgnoreMembershipToUser(ctx context.Context,
 	stmt := db.Rebind(queryRaw) // cleans up sql as needed per driver (eg ?->$1)
 	Logger("stmt: <%s>, values: <%v>", stmt, values)
 
-	start := time.Now()
 	result, err := tx.Tx.ExecContext(ctx, stmt, values...)
 	if err != nil {
 		return 0, dbErr.Wrap(err)
 	}
-	monitor.DatabaseQueryLatencyHistogram.Observe(time.Now().Sub(start).Seconds())
 
 	added, err := result.RowsAffected()
 	if err != nil {
@@ -283,3 +357,994 @@ func (db *Database) InsertOrIgnoreMembershipToUser(ctx context.Context,
 	}
 	return int(added), nil
 }
+
+// MembershipRecord is a membership joined with its user and group
+type MembershipRecord struct {
+	Created time.Time
+	User    *User
+	Group   *Group
+}
+
+// AllMembershipsByUserPks returns the memberships of all of the userPks in a
+// single query, ordered by group. It's used to batch load the groups of many
+// users at once.
+func (db *Database) AllMembershipsByUserPks(ctx context.Context,
+	userPks []int64) (_ []*MembershipRecord, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "AllMembershipsByUserPks")
+	defer func() { done(err) }()
+	return db.allMembershipsWhereIn(ctx, "memberships.user_pk", userPks,
+		"groups.pk")
+}
+
+// AllMembershipsByGroupPks returns the memberships of all of the groupPks in a
+// single query, ordered by user. It's used to batch load the users of many
+// groups at once.
+func (db *Database) AllMembershipsByGroupPks(ctx context.Context,
+	groupPks []int64) (_ []*MembershipRecord, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "AllMembershipsByGroupPks")
+	defer func() { done(err) }()
+	return db.allMembershipsWhereIn(ctx, "memberships.group_pk", groupPks,
+		"users.pk")
+}
+
+func (db *Database) allMembershipsWhereIn(ctx context.Context, column string,
+	pks []int64, orderBy string) ([]*MembershipRecord, error) {
+
+	if len(pks) == 0 {
+		// nothing to do
+		return nil, nil
+	}
+
+	values := make([]interface{}, 0, len(pks))
+	for _, pk := range pks {
+		values = append(values, pk)
+	}
+
+	queryRaw := "SELECT memberships.created, " +
+		"users.pk, users.uuid, users.created, users.id, users.first_name, " +
+		"users.last_name, users.status, " +
+		"groups.pk, groups.uuid, groups.created, groups.name, " +
+		"groups.description, groups.display_name, groups.owner, " +
+		"groups.labels " +
+		"FROM memberships " +
+		"JOIN users ON users.pk = memberships.user_pk " +
+		"JOIN groups ON groups.pk = memberships.group_pk " +
+		"WHERE " + column + " IN (?" + strings.Repeat(",?", len(pks)-1) + ") " +
+		"ORDER BY " + orderBy + ", memberships.pk"
+
+	stmt := db.Rebind(queryRaw) // cleans up sql as needed per driver (eg ?->$1)
+	Logger("stmt: <%s>, values: <%v>", stmt, values)
+
+	rows, err := db.DB.QueryContext(ctx, stmt, values...)
+	if err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+	defer rows.Close()
+
+	var memberships []*MembershipRecord
+	for rows.Next() {
+		m := &MembershipRecord{User: &User{}, Group: &Group{}}
+		err = rows.Scan(&m.Created,
+			&m.User.Pk, &m.User.Uuid, &m.User.Created, &m.User.Id,
+			&m.User.FirstName, &m.User.LastName, &m.User.Status,
+			&m.Group.Pk, &m.Group.Uuid, &m.Group.Created, &m.Group.Name,
+			&m.Group.Description, &m.Group.DisplayName, &m.Group.Owner,
+			&m.Group.Labels)
+		if err != nil {
+			return nil, dbErr.Wrap(err)
+		}
+		memberships = append(memberships, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+
+	return memberships, nil
+}
+
+// UserRefType is the type of attributes whose values refer to users. their
+// values are the uuids of the users, so that they survive renames.
+const UserRefType = "user"
+
+// AttributeValue is the value of an attribute of a user
+type AttributeValue struct {
+	UserPk int64
+	Name   string
+	Type   string
+	Value  string
+	RefID  string // the userid that a UserRefType value refers to, if any
+}
+
+// AllAttributeValuesByUserPks returns the attribute values of all of the
+// userPks in a single query, ordered by user and then attribute name. values
+// that refer to users that were deleted are left out.
+func (db *Database) AllAttributeValuesByUserPks(ctx context.Context,
+	userPks []int64) (_ []*AttributeValue, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "AllAttributeValuesByUserPks")
+	defer func() { done(err) }()
+
+	if len(userPks) == 0 {
+		// nothing to do
+		return nil, nil
+	}
+
+	values := make([]interface{}, 0, 1+len(userPks))
+	values = append(values, UserRefType)
+	for _, pk := range userPks {
+		values = append(values, pk)
+	}
+
+	queryRaw := "SELECT user_attributes.user_pk, attributes.name, " +
+		"attributes.type, user_attributes.value, refs.id " +
+		"FROM user_attributes " +
+		"JOIN attributes ON attributes.pk = user_attributes.attribute_pk " +
+		"LEFT JOIN users refs ON attributes.type = ? " +
+		"AND refs.uuid = user_attributes.value " +
+		"WHERE user_attributes.user_pk IN (?" +
+		strings.Repeat(",?", len(userPks)-1) + ") " +
+		"ORDER BY user_attributes.user_pk, attributes.name"
+
+	stmt := db.Rebind(queryRaw) // cleans up sql as needed per driver (eg ?->$1)
+	Logger("stmt: <%s>, values: <%v>", stmt, values)
+
+	rows, err := db.querier(ctx).QueryContext(ctx, stmt, values...)
+	if err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+	defer rows.Close()
+
+	var attributeValues []*AttributeValue
+	for rows.Next() {
+		v := &AttributeValue{}
+		var refID sql.NullString
+		err = rows.Scan(&v.UserPk, &v.Name, &v.Type, &v.Value, &refID)
+		if err != nil {
+			return nil, dbErr.Wrap(err)
+		}
+		if v.Type == UserRefType && !refID.Valid {
+			continue
+		}
+		v.RefID = refID.String
+		attributeValues = append(attributeValues, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+
+	return attributeValues, nil
+}
+
+// SetUserAttributes sets the values of attributes of a user, by attribute
+// pk, and removes the values of the attributes in remove
+func (db *Database) SetUserAttributes(ctx context.Context, userPk int64,
+	set map[int64]string, remove []int64) (err error) {
+
+	ctx, done := instrument(ctx, db.driver, "SetUserAttributes")
+	defer func() { done(err) }()
+
+	err = db.WithTx(ctx, func(ctx context.Context, tx *Tx) error {
+		if len(remove) > 0 {
+			values := make([]interface{}, 0, 1+len(remove))
+			values = append(values, userPk)
+			for _, pk := range remove {
+				values = append(values, pk)
+			}
+			stmt := db.Rebind("DELETE FROM user_attributes " +
+				"WHERE user_pk = ? AND attribute_pk IN (?" +
+				strings.Repeat(",?", len(remove)-1) + ")")
+			Logger("stmt: <%s>, values: <%v>", stmt, values)
+			_, err := tx.Tx.ExecContext(ctx, stmt, values...)
+			if err != nil {
+				return err
+			}
+		}
+
+		// both drivers support upserts with ON CONFLICT
+		stmt := db.Rebind("INSERT INTO user_attributes " +
+			"( user_pk, attribute_pk, value ) VALUES ( ?, ?, ? ) " +
+			"ON CONFLICT ( user_pk, attribute_pk ) " +
+			"DO UPDATE SET value = excluded.value")
+		for attributePk, value := range set {
+			Logger("stmt: <%s>, values: <%v>", stmt,
+				[]interface{}{userPk, attributePk, value})
+			_, err := tx.Tx.ExecContext(ctx, stmt, userPk, attributePk, value)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return dbErr.Wrap(err)
+	}
+	return nil
+}
+
+// HasOtherAttributeValue returns whether any user other than userPk has a
+// value of an attribute. it's used to keep the values of unique attributes
+// unique, so the value has to be set in the same transaction. postgres locks
+// the attribute until then, so that two users can't both take a value at
+// once; sqlite only has one writer at a time, so it fails the second one.
+func (db *Database) HasOtherAttributeValue(ctx context.Context,
+	attributePk int64, value string, userPk int64) (has bool, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "HasOtherAttributeValue")
+	defer func() { done(err) }()
+
+	err = db.WithTx(ctx, func(ctx context.Context, tx *Tx) error {
+		if db.driver == PostgresDriver {
+			stmt := db.Rebind("SELECT pk FROM attributes WHERE pk = ? " +
+				"FOR UPDATE")
+			Logger("stmt: <%s>, values: <%v>", stmt,
+				[]interface{}{attributePk})
+			_, err := tx.Tx.ExecContext(ctx, stmt, attributePk)
+			if err != nil {
+				return err
+			}
+		}
+
+		stmt := db.Rebind("SELECT EXISTS( SELECT 1 FROM user_attributes " +
+			"WHERE attribute_pk = ? AND value = ? AND user_pk <> ? )")
+		Logger("stmt: <%s>, values: <%v>", stmt,
+			[]interface{}{attributePk, value, userPk})
+		return tx.Tx.QueryRowContext(ctx, stmt, attributePk, value,
+			userPk).Scan(&has)
+	})
+	if err != nil {
+		return false, dbErr.Wrap(err)
+	}
+	return has, nil
+}
+
+// AttributeFilter matches the users that have a value of an attribute
+type AttributeFilter struct {
+	AttributePk int64
+	Value       string
+}
+
+// FilteredUsers returns up to limit users that match every filter, after pk
+// in ascending order, or before it in descending order if desc is set. it's
+// the keyset page of Limited_User_By_Pk_Greater_OrderBy_Asc_Pk and
+// Limited_User_By_Pk_Less_OrderBy_Desc_Pk for filtered lists.
+func (db *Database) FilteredUsers(ctx context.Context,
+	filters []AttributeFilter, pk int64, desc bool, limit int) (
+	_ []*User, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "FilteredUsers")
+	defer func() { done(err) }()
+
+	where, values := attributeFilterSQL(filters)
+	comparison, order := "users.pk > ?", "users.pk"
+	if desc {
+		comparison, order = "users.pk < ?", "users.pk DESC"
+	}
+	values = append([]interface{}{pk}, values...)
+	values = append(values, limit)
+
+	queryRaw := "SELECT users.pk, users.uuid, users.created, users.id, " +
+		"users.first_name, users.last_name, users.status FROM users " +
+		"WHERE " + comparison + where + " ORDER BY " + order + " LIMIT ?"
+
+	stmt := db.Rebind(queryRaw) // cleans up sql as needed per driver (eg ?->$1)
+	Logger("stmt: <%s>, values: <%v>", stmt, values)
+
+	rows, err := db.querier(ctx).QueryContext(ctx, stmt, values...)
+	if err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		u := &User{}
+		err = rows.Scan(&u.Pk, &u.Uuid, &u.Created, &u.Id, &u.FirstName,
+			&u.LastName, &u.Status)
+		if err != nil {
+			return nil, dbErr.Wrap(err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+
+	return users, nil
+}
+
+// CountFilteredUsers counts the users that match every filter
+func (db *Database) CountFilteredUsers(ctx context.Context,
+	filters []AttributeFilter) (count int64, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "CountFilteredUsers")
+	defer func() { done(err) }()
+
+	where, values := attributeFilterSQL(filters)
+	stmt := db.Rebind("SELECT COUNT(*) FROM users WHERE 1 = 1" + where)
+	Logger("stmt: <%s>, values: <%v>", stmt, values)
+
+	err = db.querier(ctx).QueryRowContext(ctx, stmt, values...).Scan(&count)
+	if err != nil {
+		return 0, dbErr.Wrap(err)
+	}
+	return count, nil
+}
+
+// attributeFilterSQL returns the conditions on users of filters, which are
+// checked against the attribute_pk and value index
+func attributeFilterSQL(filters []AttributeFilter) (string, []interface{}) {
+	var where strings.Builder
+	values := make([]interface{}, 0, 2*len(filters))
+	for _, f := range filters {
+		where.WriteString(" AND EXISTS( SELECT 1 FROM user_attributes " +
+			"WHERE user_attributes.user_pk = users.pk " +
+			"AND user_attributes.attribute_pk = ? " +
+			"AND user_attributes.value = ? )")
+		values = append(values, f.AttributePk, f.Value)
+	}
+	return where.String(), values
+}
+
+// ColumnFilter matches the rows whose column is equal to Value, or starts
+// with it if Prefix is set. values are compared lowercased unless CaseExact
+// is set.
+type ColumnFilter struct {
+	Column    string
+	Value     string
+	Prefix    bool
+	CaseExact bool
+}
+
+// ScanUsers returns up to limit users after pk in ascending order that match
+// filter, or every user if it's nil
+func (db *Database) ScanUsers(ctx context.Context, filter *ColumnFilter,
+	pk int64, limit int) (_ []*User, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "ScanUsers")
+	defer func() { done(err) }()
+
+	where, values, err := db.columnFilterSQL("users", filter)
+	if err != nil {
+		return nil, err
+	}
+	values = append([]interface{}{pk}, values...)
+	values = append(values, limit)
+
+	stmt := db.Rebind("SELECT users.pk, users.uuid, users.created, " +
+		"users.id, users.first_name, users.last_name, users.status " +
+		"FROM users WHERE users.pk > ?" + where +
+		" ORDER BY users.pk LIMIT ?")
+	Logger("stmt: <%s>, values: <%v>", stmt, values)
+
+	rows, err := db.querier(ctx).QueryContext(ctx, stmt, values...)
+	if err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		u := &User{}
+		err = rows.Scan(&u.Pk, &u.Uuid, &u.Created, &u.Id, &u.FirstName,
+			&u.LastName, &u.Status)
+		if err != nil {
+			return nil, dbErr.Wrap(err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+
+	return users, nil
+}
+
+// ScanGroups is ScanUsers for groups
+func (db *Database) ScanGroups(ctx context.Context, filter *ColumnFilter,
+	pk int64, limit int) (_ []*Group, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "ScanGroups")
+	defer func() { done(err) }()
+
+	where, values, err := db.columnFilterSQL("groups", filter)
+	if err != nil {
+		return nil, err
+	}
+	values = append([]interface{}{pk}, values...)
+	values = append(values, limit)
+
+	stmt := db.Rebind("SELECT groups.pk, groups.uuid, groups.created, " +
+		"groups.name, groups.description, groups.display_name, " +
+		"groups.owner, groups.labels FROM groups WHERE groups.pk > ?" +
+		where + " ORDER BY groups.pk LIMIT ?")
+	Logger("stmt: <%s>, values: <%v>", stmt, values)
+
+	rows, err := db.querier(ctx).QueryContext(ctx, stmt, values...)
+	if err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+	defer rows.Close()
+
+	var groups []*Group
+	for rows.Next() {
+		g := &Group{}
+		err = rows.Scan(&g.Pk, &g.Uuid, &g.Created, &g.Name, &g.Description,
+			&g.DisplayName, &g.Owner, &g.Labels)
+		if err != nil {
+			return nil, dbErr.Wrap(err)
+		}
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+
+	return groups, nil
+}
+
+// columnFilterSQL returns the condition of filter on table. prefixes are
+// compared with SUBSTR rather than LIKE, so that they don't need escaping
+// and are case sensitive on every driver.
+func (db *Database) columnFilterSQL(table string, filter *ColumnFilter) (
+	string, []interface{}, error) {
+
+	if filter == nil {
+		return "", nil, nil
+	}
+
+	t := findSchemaTable(db.DB.Schema(), table)
+	if t == nil || !slices.Contains(t.columns, filter.Column) {
+		return "", nil, dbErr.New("can't filter on %s.%s", table,
+			filter.Column)
+	}
+
+	column, value := table+"."+filter.Column, filter.Value
+	if !filter.CaseExact {
+		column, value = "LOWER("+column+")", strings.ToLower(value)
+	}
+	if filter.Prefix {
+		return " AND SUBSTR(" + column + ", 1, ?) = ?",
+			[]interface{}{utf8.RuneCountInString(value), value}, nil
+	}
+	return " AND " + column + " = ?", []interface{}{value}, nil
+}
+
+// The actions of the versions of users and groups, and of the changes to
+// memberships
+const (
+	VersionCreated = "created"
+	VersionUpdated = "updated"
+	VersionDeleted = "deleted"
+
+	MembershipAdded   = "added"
+	MembershipRemoved = "removed"
+)
+
+// the columns of the history tables, qualified by the alias of their table
+func userVersionColumns(alias string) string {
+	return qualify(alias, "pk", "created", "user_uuid", "version", "action",
+		"user_id", "first_name", "last_name", "status", "principal")
+}
+
+func groupVersionColumns(alias string) string {
+	return qualify(alias, "pk", "created", "group_uuid", "version", "action",
+		"name", "description", "display_name", "owner", "labels", "principal")
+}
+
+func membershipVersionColumns(alias string) string {
+	return qualify(alias, "pk", "created", "user_uuid", "group_uuid",
+		"version", "action", "user_id", "group_name", "principal")
+}
+
+func qualify(alias string, columns ...string) string {
+	return alias + "." + strings.Join(columns, ", "+alias+".")
+}
+
+func userVersionFields(v *UserVersion) []interface{} {
+	return []interface{}{&v.Pk, &v.Created, &v.UserUuid, &v.Version,
+		&v.Action, &v.UserId, &v.FirstName, &v.LastName, &v.Status,
+		&v.Principal}
+}
+
+func groupVersionFields(v *GroupVersion) []interface{} {
+	return []interface{}{&v.Pk, &v.Created, &v.GroupUuid, &v.Version,
+		&v.Action, &v.Name, &v.Description, &v.DisplayName, &v.Owner,
+		&v.Labels, &v.Principal}
+}
+
+func membershipVersionFields(v *MembershipVersion) []interface{} {
+	return []interface{}{&v.Pk, &v.Created, &v.UserUuid, &v.GroupUuid,
+		&v.Version, &v.Action, &v.UserId, &v.GroupName, &v.Principal}
+}
+
+// nextVersion returns the version after the last one in a history table of
+// the record that matches where
+func (db *Database) nextVersion(ctx context.Context, tx *Tx, table,
+	where string, values ...interface{}) (version int64, err error) {
+
+	stmt := db.Rebind("SELECT COALESCE(MAX(version), 0) + 1 FROM " + table +
+		" WHERE " + where)
+	Logger("stmt: <%s>, values: <%v>", stmt, values)
+
+	err = tx.Tx.QueryRowContext(ctx, stmt, values...).Scan(&version)
+	return version, err
+}
+
+// RecordUserVersion stores the next version of a user, after a change that
+// principal made
+func (db *Database) RecordUserVersion(ctx context.Context, user *User,
+	action, principal string) (err error) {
+
+	ctx, done := instrument(ctx, db.driver, "RecordUserVersion")
+	defer func() { done(err) }()
+
+	err = db.WithTx(ctx, func(ctx context.Context, tx *Tx) error {
+		version, err := db.nextVersion(ctx, tx, "user_versions",
+			"user_uuid = ?", user.Uuid)
+		if err != nil {
+			return err
+		}
+
+		values := []interface{}{db.Hooks.Now().UTC(), user.Uuid, version,
+			action, user.Id, user.FirstName, user.LastName, user.Status,
+			principal}
+		stmt := db.Rebind("INSERT INTO user_versions ( created, user_uuid, " +
+			"version, action, user_id, first_name, last_name, status, " +
+			"principal ) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ? )")
+		Logger("stmt: <%s>, values: <%v>", stmt, values)
+
+		_, err = tx.Tx.ExecContext(ctx, stmt, values...)
+		return err
+	})
+	if err != nil {
+		return dbErr.Wrap(err)
+	}
+	return nil
+}
+
+// RecordGroupVersion stores the next version of a group, after a change that
+// principal made
+func (db *Database) RecordGroupVersion(ctx context.Context, group *Group,
+	action, principal string) (err error) {
+
+	ctx, done := instrument(ctx, db.driver, "RecordGroupVersion")
+	defer func() { done(err) }()
+
+	err = db.WithTx(ctx, func(ctx context.Context, tx *Tx) error {
+		version, err := db.nextVersion(ctx, tx, "group_versions",
+			"group_uuid = ?", group.Uuid)
+		if err != nil {
+			return err
+		}
+
+		values := []interface{}{db.Hooks.Now().UTC(), group.Uuid, version,
+			action, group.Name, group.Description, group.DisplayName,
+			group.Owner, group.Labels, principal}
+		stmt := db.Rebind("INSERT INTO group_versions ( created, " +
+			"group_uuid, version, action, name, description, display_name, " +
+			"owner, labels, principal ) " +
+			"VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?, ? )")
+		Logger("stmt: <%s>, values: <%v>", stmt, values)
+
+		_, err = tx.Tx.ExecContext(ctx, stmt, values...)
+		return err
+	})
+	if err != nil {
+		return dbErr.Wrap(err)
+	}
+	return nil
+}
+
+// MembershipRef is a membership by the uuids and names of its user and group
+type MembershipRef struct {
+	UserUuid  string
+	UserId    string
+	GroupUuid string
+	GroupName string
+}
+
+// MembershipRefsByUserId returns the memberships of a user
+func (db *Database) MembershipRefsByUserId(ctx context.Context,
+	userID string) (_ []*MembershipRef, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "MembershipRefsByUserId")
+	defer func() { done(err) }()
+
+	return db.membershipRefsWhere(ctx, "users.id = ?", userID)
+}
+
+// MembershipRefsByGroupName returns the memberships of a group
+func (db *Database) MembershipRefsByGroupName(ctx context.Context,
+	groupName string) (_ []*MembershipRef, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "MembershipRefsByGroupName")
+	defer func() { done(err) }()
+
+	return db.membershipRefsWhere(ctx, "groups.name = ?", groupName)
+}
+
+func (db *Database) membershipRefsWhere(ctx context.Context, where string,
+	value string) ([]*MembershipRef, error) {
+
+	stmt := db.Rebind("SELECT users.uuid, users.id, groups.uuid, " +
+		"groups.name FROM memberships " +
+		"JOIN users ON users.pk = memberships.user_pk " +
+		"JOIN groups ON groups.pk = memberships.group_pk " +
+		"WHERE " + where + " ORDER BY memberships.pk")
+	Logger("stmt: <%s>, values: <%v>", stmt, value)
+
+	rows, err := db.querier(ctx).QueryContext(ctx, stmt, value)
+	if err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+	defer rows.Close()
+
+	var refs []*MembershipRef
+	for rows.Next() {
+		ref := &MembershipRef{}
+		err = rows.Scan(&ref.UserUuid, &ref.UserId, &ref.GroupUuid,
+			&ref.GroupName)
+		if err != nil {
+			return nil, dbErr.Wrap(err)
+		}
+		refs = append(refs, ref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+
+	return refs, nil
+}
+
+// RecordMembershipChanges stores a version of every membership that's in
+// after but not before, as added, or the other way around, as removed, after
+// a change that principal made
+func (db *Database) RecordMembershipChanges(ctx context.Context, before,
+	after []*MembershipRef, principal string) (err error) {
+
+	ctx, done := instrument(ctx, db.driver, "RecordMembershipChanges")
+	defer func() { done(err) }()
+
+	type change struct {
+		ref    *MembershipRef
+		action string
+	}
+	key := func(ref *MembershipRef) string {
+		return ref.UserUuid + "/" + ref.GroupUuid
+	}
+	was, is := map[string]bool{}, map[string]bool{}
+	for _, ref := range before {
+		was[key(ref)] = true
+	}
+	for _, ref := range after {
+		is[key(ref)] = true
+	}
+
+	var changes []change
+	for _, ref := range before {
+		if !is[key(ref)] {
+			changes = append(changes, change{ref, MembershipRemoved})
+		}
+	}
+	for _, ref := range after {
+		if !was[key(ref)] {
+			changes = append(changes, change{ref, MembershipAdded})
+		}
+	}
+	if len(changes) == 0 {
+		// nothing to do
+		return nil
+	}
+
+	stmt := db.Rebind("INSERT INTO membership_versions ( created, " +
+		"user_uuid, group_uuid, version, action, user_id, group_name, " +
+		"principal ) VALUES ( ?, ?, ?, ?, ?, ?, ?, ? )")
+	err = db.WithTx(ctx, func(ctx context.Context, tx *Tx) error {
+		for _, c := range changes {
+			version, err := db.nextVersion(ctx, tx, "membership_versions",
+				"user_uuid = ? AND group_uuid = ?", c.ref.UserUuid,
+				c.ref.GroupUuid)
+			if err != nil {
+				return err
+			}
+
+			values := []interface{}{db.Hooks.Now().UTC(), c.ref.UserUuid,
+				c.ref.GroupUuid, version, c.action, c.ref.UserId,
+				c.ref.GroupName, principal}
+			Logger("stmt: <%s>, values: <%v>", stmt, values)
+
+			_, err = tx.Tx.ExecContext(ctx, stmt, values...)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return dbErr.Wrap(err)
+	}
+	return nil
+}
+
+// UserVersions returns every version of the user that last had userID, even
+// if it was deleted, oldest first
+func (db *Database) UserVersions(ctx context.Context, userID string) (
+	_ []*UserVersion, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "UserVersions")
+	defer func() { done(err) }()
+
+	stmt := db.Rebind("SELECT " + userVersionColumns("v") +
+		" FROM user_versions v WHERE v.user_uuid = ( " +
+		"SELECT w.user_uuid FROM user_versions w WHERE w.user_id = ? " +
+		"ORDER BY w.pk DESC LIMIT 1 ) ORDER BY v.version")
+	Logger("stmt: <%s>, values: <%v>", stmt, userID)
+
+	rows, err := db.querier(ctx).QueryContext(ctx, stmt, userID)
+	if err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+	defer rows.Close()
+
+	var versions []*UserVersion
+	for rows.Next() {
+		v := &UserVersion{}
+		err = rows.Scan(userVersionFields(v)...)
+		if err != nil {
+			return nil, dbErr.Wrap(err)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+
+	return versions, nil
+}
+
+// GroupVersions returns every version of the group that last had groupName,
+// even if it was deleted, oldest first
+func (db *Database) GroupVersions(ctx context.Context, groupName string) (
+	_ []*GroupVersion, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "GroupVersions")
+	defer func() { done(err) }()
+
+	stmt := db.Rebind("SELECT " + groupVersionColumns("v") +
+		" FROM group_versions v WHERE v.group_uuid = ( " +
+		"SELECT w.group_uuid FROM group_versions w WHERE w.name = ? " +
+		"ORDER BY w.pk DESC LIMIT 1 ) ORDER BY v.version")
+	Logger("stmt: <%s>, values: <%v>", stmt, groupName)
+
+	rows, err := db.querier(ctx).QueryContext(ctx, stmt, groupName)
+	if err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+	defer rows.Close()
+
+	var versions []*GroupVersion
+	for rows.Next() {
+		v := &GroupVersion{}
+		err = rows.Scan(groupVersionFields(v)...)
+		if err != nil {
+			return nil, dbErr.Wrap(err)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+
+	return versions, nil
+}
+
+// MembershipVersionsByUserUuid returns every change to the memberships of a
+// user, oldest first
+func (db *Database) MembershipVersionsByUserUuid(ctx context.Context,
+	userUuid string) (_ []*MembershipVersion, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "MembershipVersionsByUserUuid")
+	defer func() { done(err) }()
+
+	return db.membershipVersionsWhere(ctx, "m.user_uuid = ?", userUuid)
+}
+
+// MembershipVersionsByGroupUuid returns every change to the memberships of a
+// group, oldest first
+func (db *Database) MembershipVersionsByGroupUuid(ctx context.Context,
+	groupUuid string) (_ []*MembershipVersion, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "MembershipVersionsByGroupUuid")
+	defer func() { done(err) }()
+
+	return db.membershipVersionsWhere(ctx, "m.group_uuid = ?", groupUuid)
+}
+
+func (db *Database) membershipVersionsWhere(ctx context.Context,
+	where string, value string) ([]*MembershipVersion, error) {
+
+	stmt := db.Rebind("SELECT " + membershipVersionColumns("m") +
+		" FROM membership_versions m WHERE " + where + " ORDER BY m.pk")
+	Logger("stmt: <%s>, values: <%v>", stmt, value)
+
+	rows, err := db.querier(ctx).QueryContext(ctx, stmt, value)
+	if err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+	defer rows.Close()
+
+	var versions []*MembershipVersion
+	for rows.Next() {
+		v := &MembershipVersion{}
+		err = rows.Scan(membershipVersionFields(v)...)
+		if err != nil {
+			return nil, dbErr.Wrap(err)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+
+	return versions, nil
+}
+
+// A record as of a time is its last version from then, unless that version
+// deleted it. versions are ordered by pk rather than by time, since changes
+// can be made within the same instant.
+
+// asOfUserVersion matches the version of user_versions alias that's current
+// as of a time
+func asOfUserVersion(alias string) string {
+	return alias + ".action <> ? AND " + alias + ".pk = ( " +
+		"SELECT MAX(x.pk) FROM user_versions x " +
+		"WHERE x.user_uuid = " + alias + ".user_uuid AND x.created <= ? )"
+}
+
+// asOfGroupVersion is asOfUserVersion for group_versions
+func asOfGroupVersion(alias string) string {
+	return alias + ".action <> ? AND " + alias + ".pk = ( " +
+		"SELECT MAX(x.pk) FROM group_versions x " +
+		"WHERE x.group_uuid = " + alias + ".group_uuid AND x.created <= ? )"
+}
+
+// asOfMembership matches the memberships of membership_versions m that
+// exist as of a time
+const asOfMembership = "m.action = ? AND m.pk = ( " +
+	"SELECT MAX(n.pk) FROM membership_versions n " +
+	"WHERE n.user_uuid = m.user_uuid AND n.group_uuid = m.group_uuid " +
+	"AND n.created <= ? )"
+
+// UserAsOf returns the version of the user with userID as of a time, and
+// when that user was created, or nil if there wasn't one
+func (db *Database) UserAsOf(ctx context.Context, userID string,
+	asOf time.Time) (_ *UserVersion, created time.Time, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "UserAsOf")
+	defer func() { done(err) }()
+
+	values := []interface{}{userID, VersionDeleted, asOf.UTC()}
+	stmt := db.Rebind("SELECT " + userVersionColumns("v") + ", f.created " +
+		"FROM user_versions v JOIN user_versions f " +
+		"ON f.user_uuid = v.user_uuid AND f.version = 1 " +
+		"WHERE v.user_id = ? AND " + asOfUserVersion("v"))
+	Logger("stmt: <%s>, values: <%v>", stmt, values)
+
+	v := &UserVersion{}
+	err = db.querier(ctx).QueryRowContext(ctx, stmt, values...).Scan(
+		append(userVersionFields(v), &created)...)
+	if err == sql.ErrNoRows {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, dbErr.Wrap(err)
+	}
+	return v, created, nil
+}
+
+// GroupAsOf returns the version of the group with groupName as of a time,
+// and when that group was created, or nil if there wasn't one
+func (db *Database) GroupAsOf(ctx context.Context, groupName string,
+	asOf time.Time) (_ *GroupVersion, created time.Time, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "GroupAsOf")
+	defer func() { done(err) }()
+
+	values := []interface{}{groupName, VersionDeleted, asOf.UTC()}
+	stmt := db.Rebind("SELECT " + groupVersionColumns("v") + ", f.created " +
+		"FROM group_versions v JOIN group_versions f " +
+		"ON f.group_uuid = v.group_uuid AND f.version = 1 " +
+		"WHERE v.name = ? AND " + asOfGroupVersion("v"))
+	Logger("stmt: <%s>, values: <%v>", stmt, values)
+
+	v := &GroupVersion{}
+	err = db.querier(ctx).QueryRowContext(ctx, stmt, values...).Scan(
+		append(groupVersionFields(v), &created)...)
+	if err == sql.ErrNoRows {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, dbErr.Wrap(err)
+	}
+	return v, created, nil
+}
+
+// MembersAsOf returns the versions of the members of a group as of a time,
+// in the order they were added
+func (db *Database) MembersAsOf(ctx context.Context, groupUuid string,
+	asOf time.Time) (_ []*UserVersion, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "MembersAsOf")
+	defer func() { done(err) }()
+
+	values := []interface{}{groupUuid, MembershipAdded, asOf.UTC(),
+		VersionDeleted, asOf.UTC()}
+	stmt := db.Rebind("SELECT " + userVersionColumns("u") +
+		" FROM membership_versions m " +
+		"JOIN user_versions u ON u.user_uuid = m.user_uuid " +
+		"WHERE m.group_uuid = ? AND " + asOfMembership +
+		" AND " + asOfUserVersion("u") + " ORDER BY m.pk")
+	Logger("stmt: <%s>, values: <%v>", stmt, values)
+
+	rows, err := db.querier(ctx).QueryContext(ctx, stmt, values...)
+	if err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+	defer rows.Close()
+
+	var versions []*UserVersion
+	for rows.Next() {
+		v := &UserVersion{}
+		err = rows.Scan(userVersionFields(v)...)
+		if err != nil {
+			return nil, dbErr.Wrap(err)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+
+	return versions, nil
+}
+
+// GroupsAsOf returns the versions of the groups of a user as of a time, in
+// the order the user was added to them
+func (db *Database) GroupsAsOf(ctx context.Context, userUuid string,
+	asOf time.Time) (_ []*GroupVersion, err error) {
+
+	ctx, done := instrument(ctx, db.driver, "GroupsAsOf")
+	defer func() { done(err) }()
+
+	values := []interface{}{userUuid, MembershipAdded, asOf.UTC(),
+		VersionDeleted, asOf.UTC()}
+	stmt := db.Rebind("SELECT " + groupVersionColumns("g") +
+		" FROM membership_versions m " +
+		"JOIN group_versions g ON g.group_uuid = m.group_uuid " +
+		"WHERE m.user_uuid = ? AND " + asOfMembership +
+		" AND " + asOfGroupVersion("g") + " ORDER BY m.pk")
+	Logger("stmt: <%s>, values: <%v>", stmt, values)
+
+	rows, err := db.querier(ctx).QueryContext(ctx, stmt, values...)
+	if err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+	defer rows.Close()
+
+	var versions []*GroupVersion
+	for rows.Next() {
+		v := &GroupVersion{}
+		err = rows.Scan(groupVersionFields(v)...)
+		if err != nil {
+			return nil, dbErr.Wrap(err)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbErr.Wrap(err)
+	}
+
+	return versions, nil
+}
@@ -1,9 +1,14 @@
 package database
 
 import (
+	"context"
+	"net/url"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestSetGroupMembership tests the SetGroupMembership method. It is expected
@@ -115,3 +120,304 @@ func TestSetUserMembership(test *testing.T) {
 	assert.Equal(t, 2, del)
 	assert.Equal(t, 0, noop)
 }
+
+// TestAllMembershipsByPks tests that the memberships of many users or groups
+// are loaded together with their users and groups in a single query
+func TestAllMembershipsByPks(test *testing.T) {
+	ctx, t := newDBTest(test)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newUser(ctx, "user2")
+	t.newUser(ctx, "user3")
+	t.newGroup(ctx, "group1")
+	t.newGroup(ctx, "group2")
+	t.newMembership(ctx, "user1", "group2")
+	t.newMembership(ctx, "user1", "group1")
+	t.newMembership(ctx, "user2", "group1")
+
+	user1, err := t.db.Get_User_By_Id(ctx, User_Id("user1"))
+	assert.NoError(t, err)
+	user3, err := t.db.Get_User_By_Id(ctx, User_Id("user3"))
+	assert.NoError(t, err)
+
+	memberships, err := t.db.AllMembershipsByUserPks(ctx,
+		[]int64{user1.Pk, user3.Pk})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(memberships))
+	assert.Equal(t, "user1", memberships[0].User.Id)
+	assert.Equal(t, "group1", memberships[0].Group.Name)
+	assert.Equal(t, "group2", memberships[1].Group.Name)
+	assert.False(t, memberships[0].Created.IsZero())
+
+	group1, err := t.db.Get_Group_By_Name(ctx, Group_Name("group1"))
+	assert.NoError(t, err)
+
+	memberships, err = t.db.AllMembershipsByGroupPks(ctx, []int64{group1.Pk})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(memberships))
+	assert.Equal(t, "user1", memberships[0].User.Id)
+	assert.Equal(t, "user2", memberships[1].User.Id)
+
+	memberships, err = t.db.AllMembershipsByGroupPks(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(memberships))
+}
+
+func TestAddRemoveGroupMembership(test *testing.T) {
+	ctx, t := newDBTest(test)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newUser(ctx, "user2")
+	t.newUser(ctx, "user3")
+	t.newGroup(ctx, "group1")
+	t.newMembership(ctx, "user1", "group1")
+
+	added, err := t.db.AddGroupMembership(ctx, "group1",
+		[]string{"user1", "user2"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	removed, err := t.db.RemoveGroupMembership(ctx, "group1",
+		[]string{"user1", "user3"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	users, err := t.db.All_User_By_Group_Name(ctx, Group_Name("group1"))
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "user2", users[0].Id)
+}
+
+func TestScan(test *testing.T) {
+	ctx, t := newDBTest(test)
+	defer t.cleanup()
+
+	t.newUser(ctx, "Alice")
+	t.newUser(ctx, "alfred")
+	t.newUser(ctx, "bob")
+	t.newUser(ctx, "al_x")
+	t.newGroup(ctx, "Admins")
+	t.newGroup(ctx, "admins")
+
+	userIDs := func(filter *ColumnFilter, pk int64, limit int) []string {
+		users, err := t.db.ScanUsers(ctx, filter, pk, limit)
+		assert.NoError(t, err)
+		ids := []string{}
+		for _, u := range users {
+			ids = append(ids, u.Id)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"Alice", "alfred", "bob", "al_x"},
+		userIDs(nil, 0, 10))
+	assert.Equal(t, []string{"Alice"},
+		userIDs(&ColumnFilter{Column: "id", Value: "ALICE"}, 0, 10))
+	assert.Equal(t, []string{"Alice", "alfred", "al_x"},
+		userIDs(&ColumnFilter{Column: "id", Value: "Al", Prefix: true}, 0, 10))
+	assert.Equal(t, []string{"Alice"}, userIDs(&ColumnFilter{Column: "id",
+		Value: "Al", Prefix: true, CaseExact: true}, 0, 10))
+	assert.Equal(t, []string{"al_x"}, userIDs(&ColumnFilter{Column: "id",
+		Value: "al_", Prefix: true}, 0, 10))
+
+	// it pages by pk
+	users, err := t.db.ScanUsers(ctx, nil, 0, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bob", "al_x"}, userIDs(nil, users[1].Pk, 10))
+
+	groups, err := t.db.ScanGroups(ctx, &ColumnFilter{Column: "name",
+		Value: "admins", CaseExact: true}, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, groups, 1)
+	assert.Equal(t, "admins", groups[0].Name)
+
+	_, err = t.db.ScanUsers(ctx, &ColumnFilter{Column: "pk; DROP TABLE users",
+		Value: "x"}, 0, 10)
+	assert.Error(t, err)
+}
+
+func TestUserAttributes(test *testing.T) {
+	ctx, t := newDBTest(test)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newUser(ctx, "user2")
+	user1, err := t.db.Get_User_By_Id(ctx, User_Id("user1"))
+	require.NoError(t, err)
+	user2, err := t.db.Get_User_By_Id(ctx, User_Id("user2"))
+	require.NoError(t, err)
+
+	team, err := t.db.Create_Attribute(ctx, Attribute_Name("team"),
+		Attribute_Type("string"), Attribute_IsRequired(false),
+		Attribute_IsUnique(false), Attribute_EnumValues(""))
+	require.NoError(t, err)
+	manager, err := t.db.Create_Attribute(ctx, Attribute_Name("manager"),
+		Attribute_Type(UserRefType), Attribute_IsRequired(false),
+		Attribute_IsUnique(false), Attribute_EnumValues(""))
+	require.NoError(t, err)
+
+	require.NoError(t, t.db.SetUserAttributes(ctx, user1.Pk,
+		map[int64]string{team.Pk: "infra", manager.Pk: user2.Uuid}, nil))
+	require.NoError(t, t.db.SetUserAttributes(ctx, user2.Pk,
+		map[int64]string{team.Pk: "infra"}, nil))
+	require.NoError(t, t.db.SetUserAttributes(ctx, user2.Pk,
+		map[int64]string{team.Pk: "web"}, nil))
+
+	values, err := t.db.AllAttributeValuesByUserPks(ctx,
+		[]int64{user1.Pk, user2.Pk})
+	require.NoError(t, err)
+	require.Len(t, values, 3)
+	assert.Equal(t, "manager", values[0].Name)
+	assert.Equal(t, "user2", values[0].RefID)
+	assert.Equal(t, "team", values[1].Name)
+	assert.Equal(t, "web", values[2].Value)
+
+	infra := []AttributeFilter{{AttributePk: team.Pk, Value: "infra"}}
+	users, err := t.db.FilteredUsers(ctx, infra, 0, false, 10)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "user1", users[0].Id)
+	count, err := t.db.CountFilteredUsers(ctx, infra)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	users, err = t.db.FilteredUsers(ctx, nil, user2.Pk, true, 10)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "user1", users[0].Id)
+
+	has, err := t.db.HasOtherAttributeValue(ctx, team.Pk, "infra", user1.Pk)
+	require.NoError(t, err)
+	assert.False(t, has)
+	has, err = t.db.HasOtherAttributeValue(ctx, team.Pk, "infra", user2.Pk)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	// references to deleted users are left out
+	_, err = t.db.Delete_User_By_Id(ctx, User_Id("user2"))
+	require.NoError(t, err)
+	require.NoError(t, t.db.SetUserAttributes(ctx, user1.Pk, nil,
+		[]int64{team.Pk}))
+	values, err = t.db.AllAttributeValuesByUserPks(ctx, []int64{user1.Pk})
+	require.NoError(t, err)
+	assert.Len(t, values, 0)
+}
+
+func TestUniqueAttributeValues(test *testing.T) {
+	ctx := context.Background()
+	dbURL, err := url.Parse("sqlite3:" + filepath.Join(test.TempDir(), "db") +
+		"?_busy_timeout=0")
+	require.NoError(test, err)
+	db, err := Connect(dbURL, nil)
+	require.NoError(test, err)
+	t := &dbTest{T: test, db: db}
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newUser(ctx, "user2")
+	user1, err := t.db.Get_User_By_Id(ctx, User_Id("user1"))
+	require.NoError(t, err)
+	user2, err := t.db.Get_User_By_Id(ctx, User_Id("user2"))
+	require.NoError(t, err)
+	email, err := t.db.Create_Attribute(ctx, Attribute_Name("email"),
+		Attribute_Type("email"), Attribute_IsRequired(false),
+		Attribute_IsUnique(true), Attribute_EnumValues(""))
+	require.NoError(t, err)
+
+	// two users check that a value is free at once, and only the first one
+	// to set it can
+	take := func(ctx context.Context, userPk int64) error {
+		has, err := t.db.HasOtherAttributeValue(ctx, email.Pk, "a@b.c",
+			userPk)
+		require.NoError(t, err)
+		require.False(t, has)
+		return t.db.SetUserAttributes(ctx, userPk,
+			map[int64]string{email.Pk: "a@b.c"}, nil)
+	}
+	err = t.db.WithTx(ctx, func(ctx1 context.Context, _ *Tx) error {
+		has, err := t.db.HasOtherAttributeValue(ctx1, email.Pk, "a@b.c",
+			user1.Pk)
+		require.NoError(t, err)
+		require.False(t, has)
+
+		err = t.db.WithTx(ctx, func(ctx2 context.Context, _ *Tx) error {
+			require.NoError(t, take(ctx1, user1.Pk))
+			return take(ctx2, user2.Pk)
+		})
+		assert.Error(t, err)
+		return nil
+	})
+	require.NoError(t, err)
+
+	values, err := t.db.AllAttributeValuesByUserPks(ctx,
+		[]int64{user1.Pk, user2.Pk})
+	require.NoError(t, err)
+	require.Len(t, values, 1)
+	assert.Equal(t, user1.Pk, values[0].UserPk)
+}
+
+func TestMembershipVersions(test *testing.T) {
+	ctx, t := newDBTest(test)
+	defer t.cleanup()
+
+	now := time.Unix(1700000000, 0)
+	t.db.Hooks.Now = func() time.Time { return now }
+
+	t.newUser(ctx, "user1")
+	t.newGroup(ctx, "group1")
+	user, err := t.db.Get_User_By_Id(ctx, User_Id("user1"))
+	require.NoError(t, err)
+	group, err := t.db.Get_Group_By_Name(ctx, Group_Name("group1"))
+	require.NoError(t, err)
+	require.NoError(t, t.db.RecordUserVersion(ctx, user, VersionCreated, ""))
+	require.NoError(t, t.db.RecordGroupVersion(ctx, group, VersionCreated,
+		"admin"))
+
+	before, err := t.db.MembershipRefsByGroupName(ctx, "group1")
+	require.NoError(t, err)
+	assert.Len(t, before, 0)
+	t.newMembership(ctx, "user1", "group1")
+	after, err := t.db.MembershipRefsByUserId(ctx, "user1")
+	require.NoError(t, err)
+	require.Len(t, after, 1)
+	require.NoError(t, t.db.RecordMembershipChanges(ctx, before, after,
+		"admin"))
+	require.NoError(t, t.db.RecordMembershipChanges(ctx, after, after,
+		"admin"))
+
+	members, err := t.db.MembersAsOf(ctx, group.Uuid, now)
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+	assert.Equal(t, "user1", members[0].UserId)
+	groups, err := t.db.GroupsAsOf(ctx, user.Uuid, now)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "group1", groups[0].Name)
+
+	// changes made in the same instant apply in the order they were made
+	require.NoError(t, t.db.RecordMembershipChanges(ctx, after, nil, "admin"))
+	members, err = t.db.MembersAsOf(ctx, group.Uuid, now)
+	require.NoError(t, err)
+	assert.Len(t, members, 0)
+	members, err = t.db.MembersAsOf(ctx, group.Uuid, now.Add(-time.Second))
+	require.NoError(t, err)
+	assert.Len(t, members, 0)
+
+	versions, err := t.db.MembershipVersionsByGroupUuid(ctx, group.Uuid)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, MembershipAdded, versions[0].Action)
+	assert.Equal(t, MembershipRemoved, versions[1].Action)
+	assert.Equal(t, int64(2), versions[1].Version)
+	assert.Equal(t, "admin", versions[1].Principal)
+	assert.True(t, versions[1].Created.Equal(now))
+
+	v, created, err := t.db.GroupAsOf(ctx, "group1", now)
+	require.NoError(t, err)
+	require.NotNil(t, v)
+	assert.True(t, created.Equal(now))
+	v, _, err = t.db.GroupAsOf(ctx, "group1", now.Add(-time.Second))
+	require.NoError(t, err)
+	assert.Nil(t, v)
+}
@@ -32,14 +32,16 @@ func (dbt *dbTest) cleanup() {
 func (dbt *dbTest) newUser(ctx context.Context, id string) string {
 	user, err := dbt.db.Create_User(ctx,
 		User_Uuid(util.MustUUID4()), User_Id(id),
-		User_FirstName(id+"first_name"), User_LastName(id+"last_name"))
+		User_FirstName(id+"first_name"), User_LastName(id+"last_name"),
+		User_Status("active"))
 	assert.NoError(dbt, err)
 	return user.Id
 }
 
 func (dbt *dbTest) newGroup(ctx context.Context, name string) string {
 	group, err := dbt.db.Create_Group(ctx, Group_Uuid(util.MustUUID4()),
-		Group_Name(name))
+		Group_Name(name), Group_Description(""), Group_DisplayName(""),
+		Group_Owner(""), Group_Labels(""))
 	assert.NoError(dbt, err)
 	return group.Name
 }
@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"demoapi/logging"
+	monitor "demoapi/prometheus"
+)
+
+// This file instruments every database operation with an OpenTelemetry span
+// and prometheus metrics. The dbx methods are wrapped by instrumented.dbx.go,
+// which is generated by gen_instrumented.go, and the handwritten queries call
+// instrument directly.
+
+var tracer = otel.Tracer("demoapi/database")
+
+// instrument starts a span for the database operation op and counts it. The
+// returned function has to be called with the error of the operation once
+// it's done, and logs it with the request id of ctx.
+func instrument(ctx context.Context, driver, op string) (context.Context,
+	func(error)) {
+
+	ctx, span := tracer.Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", driver),
+			attribute.String("db.operation", op)))
+	start := time.Now()
+
+	return ctx, func(err error) {
+		took := time.Since(start)
+		monitor.DatabaseQueryCounter.WithLabelValues(op, driver).Inc()
+		monitor.DatabaseQueryLatencyHistogram.WithLabelValues(op, driver).
+			Observe(took.Seconds())
+
+		entry := logging.Entry(ctx).WithFields(logrus.Fields{
+			"db.operation": op,
+			"duration":     took,
+		})
+		if err != nil {
+			monitor.DatabaseQueryErrorCounter.WithLabelValues(op, driver,
+				errorClass(err)).Inc()
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			entry.WithError(err).Warning("database query error")
+		} else {
+			entry.Debug("database query")
+		}
+		span.End()
+	}
+}
+
+// instrumentTx is instrument for a whole transaction. transactions are
+// measured separately from the queries they run, and every transaction that
+// fails is rolled back.
+func instrumentTx(ctx context.Context, driver string) (context.Context,
+	func(error)) {
+
+	ctx, span := tracer.Start(ctx, "transaction",
+		trace.WithAttributes(attribute.String("db.system", driver)))
+	start := time.Now()
+
+	return ctx, func(err error) {
+		monitor.DatabaseTransactionDuration.WithLabelValues(driver).Observe(
+			time.Since(start).Seconds())
+
+		if err != nil {
+			monitor.DatabaseTransactionRollbackCounter.WithLabelValues(driver).
+				Inc()
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// errorClass describes why a database operation failed, with few enough
+// values to be used as a metric label
+func errorClass(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, sql.ErrNoRows):
+		return "no_rows"
+	}
+
+	var dbxErr *Error
+	if errors.As(err, &dbxErr) {
+		switch dbxErr.Code {
+		case ErrorCode_UnsupportedDriver:
+			return "unsupported_driver"
+		case ErrorCode_NoRows:
+			return "no_rows"
+		case ErrorCode_TxDone:
+			return "tx_done"
+		case ErrorCode_TooManyRows:
+			return "too_many_rows"
+		case ErrorCode_ConstraintViolation:
+			return "constraint_violation"
+		case ErrorCode_EmptyUpdate:
+			return "empty_update"
+		}
+	}
+	return "unknown"
+}
+
+// instrumentedMethods wraps the dbx Methods. the wrappers are generated
+type instrumentedMethods struct {
+	Methods
+	driver string
+}
+
+// instrumentedDB replaces the dbMethods of a DB so that every dbx query, in
+// or out of a transaction, is instrumented
+type instrumentedDB struct {
+	*instrumentedMethods
+	db dbMethods
+}
+
+func instrumentDB(driver string, db dbMethods) *instrumentedDB {
+	return &instrumentedDB{
+		instrumentedMethods: &instrumentedMethods{Methods: db, driver: driver},
+		db:                  db,
+	}
+}
+
+func (obj *instrumentedDB) Schema() string { return obj.db.Schema() }
+
+func (obj *instrumentedDB) Rebind(s string) string { return obj.db.Rebind(s) }
+
+func (obj *instrumentedDB) makeErr(err error) error {
+	return obj.db.makeErr(err)
+}
+
+func (obj *instrumentedDB) wrapTx(tx *sql.Tx) txMethods {
+	inner := obj.db.wrapTx(tx)
+	return &instrumentedTx{
+		instrumentedMethods: &instrumentedMethods{Methods: inner,
+			driver: obj.driver},
+		tx: inner,
+	}
+}
+
+type instrumentedTx struct {
+	*instrumentedMethods
+	tx txMethods
+}
+
+func (obj *instrumentedTx) Rebind(s string) string { return obj.tx.Rebind(s) }
+
+func (obj *instrumentedTx) Commit() error { return obj.tx.Commit() }
+
+func (obj *instrumentedTx) Rollback() error { return obj.tx.Rollback() }
+
+func (obj *instrumentedTx) deleteAll(ctx context.Context) (int64, error) {
+	return obj.tx.deleteAll(ctx)
+}
+
+func (obj *instrumentedTx) makeErr(err error) error {
+	return obj.tx.makeErr(err)
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	monitor "demoapi/prometheus"
+)
+
+func TestInstrument(test *testing.T) {
+	ctx, t := newDBTest(test)
+	defer t.cleanup()
+
+	queries := monitor.DatabaseQueryCounter.WithLabelValues("Create_User",
+		SqliteDriver)
+	before := testutil.ToFloat64(queries)
+	t.newUser(ctx, "user1")
+	assert.Equal(t, before+1, testutil.ToFloat64(queries))
+
+	// failed queries are counted by why they failed
+	noRows := monitor.DatabaseQueryErrorCounter.WithLabelValues(
+		"Get_User_By_Id", SqliteDriver, "no_rows")
+	before = testutil.ToFloat64(noRows)
+	_, err := t.db.Get_User_By_Id(ctx, User_Id("missing"))
+	assert.Error(t, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(noRows))
+
+	// handwritten queries are instrumented too
+	queries = monitor.DatabaseQueryCounter.WithLabelValues(
+		"SetGroupMembership", SqliteDriver)
+	before = testutil.ToFloat64(queries)
+	t.newGroup(ctx, "group1")
+	_, _, _, err = t.db.SetGroupMembership(ctx, "group1", []string{"user1"})
+	assert.NoError(t, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(queries))
+
+	rollbacks := monitor.DatabaseTransactionRollbackCounter.WithLabelValues(
+		SqliteDriver)
+	before = testutil.ToFloat64(rollbacks)
+	err = t.db.WithTx(ctx, func(ctx context.Context, tx *Tx) error {
+		return errors.New("rolled back")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(rollbacks))
+}
+
+func TestWithTxJoins(test *testing.T) {
+	ctx, t := newDBTest(test)
+	defer t.cleanup()
+
+	committed := 0
+	err := t.db.WithTx(ctx, func(ctx context.Context, tx *Tx) error {
+		_, err := t.db.In(ctx).Create_User(ctx, User_Uuid("uuid1"),
+			User_Id("user1"), User_FirstName("first"), User_LastName("last"),
+			User_Status("active"))
+		assert.NoError(t, err)
+		AfterCommit(ctx, func() { committed++ })
+
+		// the inner transaction joins the outer one, so its statements are
+		// rolled back with it
+		err = t.db.WithTx(ctx, func(ctx context.Context, inner *Tx) error {
+			assert.Equal(t, tx, inner)
+			_, err := t.db.In(ctx).Create_Group(ctx, Group_Uuid("uuid2"),
+				Group_Name("group1"), Group_Description(""),
+				Group_DisplayName(""), Group_Owner(""), Group_Labels(""))
+			return err
+		})
+		assert.NoError(t, err)
+		return errors.New("rolled back")
+	})
+	assert.Error(t, err)
+	assert.Zero(t, committed)
+
+	user, err := t.db.Find_User_By_Id(ctx, User_Id("user1"))
+	assert.NoError(t, err)
+	assert.Nil(t, user)
+	exists, err := t.db.Has_Group_By_Name(ctx, Group_Name("group1"))
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	err = t.db.WithTx(ctx, func(ctx context.Context, tx *Tx) error {
+		AfterCommit(ctx, func() { committed++ })
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, committed)
+}
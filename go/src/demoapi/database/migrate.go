@@ -0,0 +1,243 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// This file contains the migrations that upgrade a database that was created
+// by an older version of the api. A new database is created with the whole
+// schema at the latest version. The version of a database is the number of
+// migrations it has had, stored in the schema_version table.
+
+// migration is a step of an upgrade. its tables, with their indexes, and its
+// columns are created as they are in the dbx schema, so that they're the same
+// for every driver. Only the changes that are missing are made, so a step can
+// be run on a database that already has some of them, like one created by a
+// build between two versions.
+type migration struct {
+	description string
+	tables      []string
+	columns     []migrationColumn
+
+	// backfill fills the new tables from the rows that are already there,
+	// after they're created. its statements have to skip what's been filled.
+	backfill []string
+}
+
+// migrationColumn is a column that's added to an existing table. the rows
+// that are already in the table get value, a sql literal.
+type migrationColumn struct {
+	table, name, value string
+}
+
+// migrations are run in order. ones that have been released can't be changed
+// or removed; new ones are added to the end.
+var migrations = []migration{
+	{
+		description: "store the responses to idempotent requests",
+		tables:      []string{"idempotent_requests"},
+	},
+	{
+		description: "add the metadata of groups",
+		columns: []migrationColumn{
+			{table: "groups", name: "description", value: "''"},
+			{table: "groups", name: "display_name", value: "''"},
+			{table: "groups", name: "owner", value: "''"},
+			{table: "groups", name: "labels", value: "''"},
+		},
+	},
+	{
+		description: "add the attributes of users",
+		tables:      []string{"attributes", "user_attributes"},
+	},
+	{
+		description: "add the status of users",
+		tables:      []string{"user_transitions"},
+		columns: []migrationColumn{
+			{table: "users", name: "status", value: "'active'"},
+		},
+	},
+	{
+		description: "add the history of users, groups and memberships",
+		tables: []string{"user_versions", "group_versions",
+			"membership_versions"},
+		backfill: []string{
+			"INSERT INTO user_versions ( created, user_uuid, version, " +
+				"action, user_id, first_name, last_name, status, principal ) " +
+				"SELECT users.created, users.uuid, 1, '" + VersionCreated +
+				"', users.id, users.first_name, users.last_name, " +
+				"users.status, '' FROM users WHERE NOT EXISTS ( " +
+				"SELECT 1 FROM user_versions v " +
+				"WHERE v.user_uuid = users.uuid ) ORDER BY users.pk",
+			"INSERT INTO group_versions ( created, group_uuid, version, " +
+				"action, name, description, display_name, owner, labels, " +
+				"principal ) SELECT groups.created, groups.uuid, 1, '" +
+				VersionCreated + "', groups.name, groups.description, " +
+				"groups.display_name, groups.owner, groups.labels, '' " +
+				"FROM groups WHERE NOT EXISTS ( " +
+				"SELECT 1 FROM group_versions v " +
+				"WHERE v.group_uuid = groups.uuid ) ORDER BY groups.pk",
+			"INSERT INTO membership_versions ( created, user_uuid, " +
+				"group_uuid, version, action, user_id, group_name, " +
+				"principal ) SELECT memberships.created, users.uuid, " +
+				"groups.uuid, 1, '" + MembershipAdded + "', users.id, " +
+				"groups.name, '' FROM memberships " +
+				"JOIN users ON users.pk = memberships.user_pk " +
+				"JOIN groups ON groups.pk = memberships.group_pk " +
+				"WHERE NOT EXISTS ( SELECT 1 FROM membership_versions v " +
+				"WHERE v.user_uuid = users.uuid " +
+				"AND v.group_uuid = groups.uuid ) ORDER BY memberships.pk",
+		},
+	},
+}
+
+// createRegexp matches the statements of the dbx schema that create a table
+// or an index of a table
+var createRegexp = regexp.MustCompile(`^CREATE (?:TABLE|INDEX \w+ ON) (\w+)`)
+
+// migrate runs the migrations that the database hasn't had yet. each one is
+// run in its own transaction, along with the update of the version.
+func (db *Database) migrate() error {
+	version, err := db.schemaVersion()
+	if err != nil {
+		return err
+	}
+
+	for version < len(migrations) {
+		m := migrations[version]
+		version++
+		logrus.Infof("migrating the database to version %d: %s", version,
+			m.description)
+
+		stmts, err := db.migrationStatements(m)
+		if err != nil {
+			return err
+		}
+		stmts = append(stmts, setSchemaVersion(version))
+
+		err = db.execTx(stmts)
+		if err != nil {
+			return dbErr.New("migrating to version %d: %s", version, err)
+		}
+	}
+	return nil
+}
+
+// schemaVersion returns the version of the database. databases from before
+// there were versions are version 0.
+func (db *Database) schemaVersion() (int, error) {
+	_, err := db.DB.Exec("CREATE TABLE IF NOT EXISTS schema_version ( " +
+		"version INTEGER NOT NULL )")
+	if err != nil {
+		return 0, dbErr.Wrap(err)
+	}
+
+	var version int
+	err = db.DB.QueryRow("SELECT version FROM schema_version").Scan(&version)
+	if err == sql.ErrNoRows {
+		_, err = db.DB.Exec("INSERT INTO schema_version ( version ) " +
+			"VALUES ( 0 )")
+	}
+	if err != nil {
+		return 0, dbErr.Wrap(err)
+	}
+	return version, nil
+}
+
+// initializeVersion marks a database that was created with the whole schema
+// as up to date
+func (db *Database) initializeVersion() error {
+	_, err := db.schemaVersion()
+	if err != nil {
+		return err
+	}
+	_, err = db.DB.Exec(setSchemaVersion(len(migrations)))
+	return dbErr.Wrap(err)
+}
+
+func setSchemaVersion(version int) string {
+	return fmt.Sprintf("UPDATE schema_version SET version = %d", version)
+}
+
+// migrationStatements returns the statements that make the changes of m that
+// the database doesn't have yet
+func (db *Database) migrationStatements(m migration) ([]string, error) {
+	var stmts []string
+	for _, stmt := range strings.Split(db.DB.Schema(), ";") {
+		stmt = strings.TrimSpace(stmt)
+		match := createRegexp.FindStringSubmatch(stmt)
+		if match == nil || !slices.Contains(m.tables, match[1]) {
+			continue
+		}
+		stmt = strings.Replace(stmt, "CREATE TABLE ",
+			"CREATE TABLE IF NOT EXISTS ", 1)
+		stmt = strings.Replace(stmt, "CREATE INDEX ",
+			"CREATE INDEX IF NOT EXISTS ", 1)
+		stmts = append(stmts, stmt)
+	}
+
+	for _, c := range m.columns {
+		if db.hasColumn(c.table, c.name) {
+			continue
+		}
+		def, err := db.columnDefinition(c.table, c.name)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, fmt.Sprintf(
+			"ALTER TABLE %s ADD COLUMN %s DEFAULT %s", c.table, def, c.value))
+	}
+	return append(stmts, m.backfill...), nil
+}
+
+// hasColumn returns whether the table has the column. it's checked outside of
+// the migration's transaction, since a failed statement aborts a postgres
+// transaction.
+func (db *Database) hasColumn(table, column string) bool {
+	rows, err := db.DB.Query("SELECT " + column + " FROM " + table +
+		" LIMIT 0")
+	if err != nil {
+		return false
+	}
+	_ = rows.Close()
+	return true
+}
+
+// columnDefinition returns the definition of a column in the dbx schema, like
+// "status TEXT NOT NULL"
+func (db *Database) columnDefinition(table, column string) (string, error) {
+	if t := findSchemaTable(db.DB.Schema(), table); t != nil {
+		if i := slices.Index(t.columns, column); i >= 0 {
+			return t.definitions[i], nil
+		}
+	}
+	return "", dbErr.New("column %s.%s isn't in the schema", table, column)
+}
+
+// execTx executes stmts in a transaction
+func (db *Database) execTx(stmts []string) (err error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return dbErr.Wrap(err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for _, stmt := range stmts {
+		Logger("stmt: <%s>", stmt)
+		_, err = tx.Exec(stmt)
+		if err != nil {
+			return dbErr.Wrap(err)
+		}
+	}
+	return dbErr.Wrap(tx.Commit())
+}
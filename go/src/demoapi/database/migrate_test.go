@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	dbURL, err := url.Parse("sqlite3:" + filepath.Join(t.TempDir(), "db"))
+	require.NoError(t, err)
+	connect := func() *Database {
+		u := *dbURL
+		db, err := Connect(&u, nil)
+		require.NoError(t, err)
+		return db
+	}
+
+	// a new database is up to date
+	db := connect()
+	version, err := db.schemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), version)
+	group, err := db.Create_Group(ctx, Group_Uuid("uuid1"),
+		Group_Name("group1"), Group_Description("first"),
+		Group_DisplayName(""), Group_Owner(""), Group_Labels(""))
+	require.NoError(t, err)
+	user, err := db.Create_User(ctx, User_Uuid("uuid2"), User_Id("user1"),
+		User_FirstName("fn"), User_LastName("ln"), User_Status("suspended"))
+	require.NoError(t, err)
+
+	// take the database back to before there were versions
+	added := map[string][]string{}
+	for _, m := range slices.Backward(migrations) {
+		for _, table := range slices.Backward(m.tables) {
+			_, err = db.DB.Exec("DROP TABLE " + table)
+			require.NoError(t, err)
+		}
+		for _, c := range m.columns {
+			added[c.table] = append(added[c.table], c.name)
+		}
+	}
+	for table, columns := range added {
+		dropColumns(t, db, table, columns)
+	}
+	// recreating users drops its memberships, so this one is added after
+	_, err = db.Create_Membership(ctx, Membership_UserPk(user.Pk),
+		Membership_GroupPk(group.Pk))
+	require.NoError(t, err)
+	_, err = db.DB.Exec("DROP TABLE schema_version")
+	require.NoError(t, err)
+	assert.Error(t, db.CheckSchema(ctx))
+	require.NoError(t, db.Close())
+
+	// the rows that were already there get the defaults of the new columns,
+	// and a first version
+	db = connect()
+	assert.NoError(t, db.CheckSchema(ctx))
+	version, err = db.schemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), version)
+	group, err = db.Get_Group_By_Name(ctx, Group_Name("group1"))
+	require.NoError(t, err)
+	assert.Equal(t, "uuid1", group.Uuid)
+	assert.Empty(t, group.Description)
+	user, err = db.Get_User_By_Id(ctx, User_Id("user1"))
+	require.NoError(t, err)
+	assert.Equal(t, "active", user.Status)
+	assertBaseline(t, db, user, group)
+
+	// the migrations only make the changes that are missing, so they can be
+	// run on a database that has some or all of them
+	_, err = db.DB.Exec(setSchemaVersion(0))
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+	db = connect()
+	defer db.Close()
+	assert.NoError(t, db.CheckSchema(ctx))
+	version, err = db.schemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), version)
+	assertBaseline(t, db, user, group)
+}
+
+// assertBaseline checks that the records from before there was history got
+// one version each, as they were when it started
+func assertBaseline(t *testing.T, db *Database, user *User, group *Group) {
+	ctx := context.Background()
+	users, err := db.UserVersions(ctx, user.Id)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, VersionCreated, users[0].Action)
+	assert.Equal(t, user.Status, users[0].Status)
+	assert.True(t, user.Created.Equal(users[0].Created))
+
+	groups, err := db.GroupVersions(ctx, group.Name)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, VersionCreated, groups[0].Action)
+
+	memberships, err := db.MembershipVersionsByUserUuid(ctx, user.Uuid)
+	require.NoError(t, err)
+	require.Len(t, memberships, 1)
+	assert.Equal(t, MembershipAdded, memberships[0].Action)
+	assert.Equal(t, group.Uuid, memberships[0].GroupUuid)
+	assert.Equal(t, int64(1), memberships[0].Version)
+}
+
+// dropColumns recreates table without columns, since the sqlite of the tests
+// doesn't have ALTER TABLE DROP COLUMN
+func dropColumns(t *testing.T, db *Database, table string, columns []string) {
+	var create string
+	for _, stmt := range strings.Split(db.DB.Schema(), ";") {
+		stmt = strings.TrimSpace(stmt)
+		if strings.HasPrefix(stmt, "CREATE TABLE "+table+" (") {
+			create = stmt
+		}
+	}
+	lines := strings.Split(create, "\n")
+	lines = slices.DeleteFunc(lines, func(line string) bool {
+		m := columnRegexp.FindStringSubmatch(line)
+		return m != nil && slices.Contains(columns, m[1])
+	})
+	st := findSchemaTable(db.DB.Schema(), table)
+	kept := slices.DeleteFunc(slices.Clone(st.columns),
+		func(c string) bool { return slices.Contains(columns, c) })
+
+	for _, stmt := range []string{
+		"CREATE TABLE kept AS SELECT " + strings.Join(kept, ", ") +
+			" FROM " + table,
+		"DROP TABLE " + table,
+		strings.Join(lines, "\n"),
+		"INSERT INTO " + table + " ( " + strings.Join(kept, ", ") +
+			" ) SELECT * FROM kept",
+		"DROP TABLE kept",
+	} {
+		_, err := db.DB.Exec(stmt)
+		require.NoError(t, err, stmt)
+	}
+}
@@ -272,15 +272,84 @@ func newpostgres(db *DB) *postgresDB {
 }
 
 func (obj *postgresDB) Schema() string {
-	return `CREATE TABLE groups (
+	return `CREATE TABLE attributes (
+	pk bigserial NOT NULL,
+	created timestamp NOT NULL,
+	name text NOT NULL,
+	type text NOT NULL,
+	is_required boolean NOT NULL,
+	is_unique boolean NOT NULL,
+	enum_values text NOT NULL,
+	PRIMARY KEY ( pk ),
+	UNIQUE ( name )
+);
+CREATE TABLE group_versions (
+	pk bigserial NOT NULL,
+	created timestamp NOT NULL,
+	group_uuid text NOT NULL,
+	version bigint NOT NULL,
+	action text NOT NULL,
+	name text NOT NULL,
+	description text NOT NULL,
+	display_name text NOT NULL,
+	owner text NOT NULL,
+	labels text NOT NULL,
+	principal text NOT NULL,
+	PRIMARY KEY ( pk ),
+	UNIQUE ( group_uuid, version )
+);
+CREATE TABLE groups (
 	pk bigserial NOT NULL,
 	uuid text NOT NULL,
 	created timestamp NOT NULL,
 	name text NOT NULL,
+	description text NOT NULL,
+	display_name text NOT NULL,
+	owner text NOT NULL,
+	labels text NOT NULL,
 	PRIMARY KEY ( pk ),
 	UNIQUE ( uuid ),
 	UNIQUE ( name )
 );
+CREATE TABLE idempotent_requests (
+	pk bigserial NOT NULL,
+	created timestamp NOT NULL,
+	idempotency_key text NOT NULL,
+	request_hash text NOT NULL,
+	status integer NOT NULL,
+	headers text NOT NULL,
+	body bytea NOT NULL,
+	expires timestamp NOT NULL,
+	PRIMARY KEY ( pk ),
+	UNIQUE ( idempotency_key )
+);
+CREATE TABLE membership_versions (
+	pk bigserial NOT NULL,
+	created timestamp NOT NULL,
+	user_uuid text NOT NULL,
+	group_uuid text NOT NULL,
+	version bigint NOT NULL,
+	action text NOT NULL,
+	user_id text NOT NULL,
+	group_name text NOT NULL,
+	principal text NOT NULL,
+	PRIMARY KEY ( pk ),
+	UNIQUE ( user_uuid, group_uuid, version )
+);
+CREATE TABLE user_versions (
+	pk bigserial NOT NULL,
+	created timestamp NOT NULL,
+	user_uuid text NOT NULL,
+	version bigint NOT NULL,
+	action text NOT NULL,
+	user_id text NOT NULL,
+	first_name text NOT NULL,
+	last_name text NOT NULL,
+	status text NOT NULL,
+	principal text NOT NULL,
+	PRIMARY KEY ( pk ),
+	UNIQUE ( user_uuid, version )
+);
 CREATE TABLE users (
 	pk bigserial NOT NULL,
 	uuid text NOT NULL,
@@ -288,6 +357,7 @@ CREATE TABLE users (
 	id text NOT NULL,
 	first_name text NOT NULL,
 	last_name text NOT NULL,
+	status text NOT NULL,
 	PRIMARY KEY ( pk ),
 	UNIQUE ( uuid ),
 	UNIQUE ( id )
@@ -299,7 +369,30 @@ CREATE TABLE memberships (
 	group_pk bigint NOT NULL REFERENCES groups( pk ) ON DELETE CASCADE,
 	PRIMARY KEY ( pk ),
 	UNIQUE ( user_pk, group_pk )
-);`
+);
+CREATE TABLE user_attributes (
+	pk bigserial NOT NULL,
+	user_pk bigint NOT NULL REFERENCES users( pk ) ON DELETE CASCADE,
+	attribute_pk bigint NOT NULL REFERENCES attributes( pk ) ON DELETE CASCADE,
+	value text NOT NULL,
+	PRIMARY KEY ( pk ),
+	UNIQUE ( user_pk, attribute_pk )
+);
+CREATE TABLE user_transitions (
+	pk bigserial NOT NULL,
+	created timestamp NOT NULL,
+	user_pk bigint NOT NULL REFERENCES users( pk ) ON DELETE CASCADE,
+	from_status text NOT NULL,
+	to_status text NOT NULL,
+	reason text NOT NULL,
+	principal text NOT NULL,
+	PRIMARY KEY ( pk )
+);
+CREATE INDEX group_versions_name_index ON group_versions ( name );
+CREATE INDEX membership_versions_group_uuid_index ON membership_versions ( group_uuid );
+CREATE INDEX user_attributes_attribute_pk_value_index ON user_attributes ( attribute_pk, value );
+CREATE INDEX user_transitions_user_pk_index ON user_transitions ( user_pk );
+CREATE INDEX user_versions_user_id_index ON user_versions ( user_id );`
 }
 
 func (obj *postgresDB) wrapTx(tx *sql.Tx) txMethods {
@@ -363,15 +456,84 @@ func newsqlite3(db *DB) *sqlite3DB {
 }
 
 func (obj *sqlite3DB) Schema() string {
-	return `CREATE TABLE groups (
+	return `CREATE TABLE attributes (
+	pk INTEGER NOT NULL,
+	created TIMESTAMP NOT NULL,
+	name TEXT NOT NULL,
+	type TEXT NOT NULL,
+	is_required INTEGER NOT NULL,
+	is_unique INTEGER NOT NULL,
+	enum_values TEXT NOT NULL,
+	PRIMARY KEY ( pk ),
+	UNIQUE ( name )
+);
+CREATE TABLE group_versions (
+	pk INTEGER NOT NULL,
+	created TIMESTAMP NOT NULL,
+	group_uuid TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	display_name TEXT NOT NULL,
+	owner TEXT NOT NULL,
+	labels TEXT NOT NULL,
+	principal TEXT NOT NULL,
+	PRIMARY KEY ( pk ),
+	UNIQUE ( group_uuid, version )
+);
+CREATE TABLE groups (
 	pk INTEGER NOT NULL,
 	uuid TEXT NOT NULL,
 	created TIMESTAMP NOT NULL,
 	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	display_name TEXT NOT NULL,
+	owner TEXT NOT NULL,
+	labels TEXT NOT NULL,
 	PRIMARY KEY ( pk ),
 	UNIQUE ( uuid ),
 	UNIQUE ( name )
 );
+CREATE TABLE idempotent_requests (
+	pk INTEGER NOT NULL,
+	created TIMESTAMP NOT NULL,
+	idempotency_key TEXT NOT NULL,
+	request_hash TEXT NOT NULL,
+	status INTEGER NOT NULL,
+	headers TEXT NOT NULL,
+	body BLOB NOT NULL,
+	expires TIMESTAMP NOT NULL,
+	PRIMARY KEY ( pk ),
+	UNIQUE ( idempotency_key )
+);
+CREATE TABLE membership_versions (
+	pk INTEGER NOT NULL,
+	created TIMESTAMP NOT NULL,
+	user_uuid TEXT NOT NULL,
+	group_uuid TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	group_name TEXT NOT NULL,
+	principal TEXT NOT NULL,
+	PRIMARY KEY ( pk ),
+	UNIQUE ( user_uuid, group_uuid, version )
+);
+CREATE TABLE user_versions (
+	pk INTEGER NOT NULL,
+	created TIMESTAMP NOT NULL,
+	user_uuid TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	first_name TEXT NOT NULL,
+	last_name TEXT NOT NULL,
+	status TEXT NOT NULL,
+	principal TEXT NOT NULL,
+	PRIMARY KEY ( pk ),
+	UNIQUE ( user_uuid, version )
+);
 CREATE TABLE users (
 	pk INTEGER NOT NULL,
 	uuid TEXT NOT NULL,
@@ -379,6 +541,7 @@ CREATE TABLE users (
 	id TEXT NOT NULL,
 	first_name TEXT NOT NULL,
 	last_name TEXT NOT NULL,
+	status TEXT NOT NULL,
 	PRIMARY KEY ( pk ),
 	UNIQUE ( uuid ),
 	UNIQUE ( id )
@@ -390,7 +553,30 @@ CREATE TABLE memberships (
 	group_pk INTEGER NOT NULL REFERENCES groups( pk ) ON DELETE CASCADE,
 	PRIMARY KEY ( pk ),
 	UNIQUE ( user_pk, group_pk )
-);`
+);
+CREATE TABLE user_attributes (
+	pk INTEGER NOT NULL,
+	user_pk INTEGER NOT NULL REFERENCES users( pk ) ON DELETE CASCADE,
+	attribute_pk INTEGER NOT NULL REFERENCES attributes( pk ) ON DELETE CASCADE,
+	value TEXT NOT NULL,
+	PRIMARY KEY ( pk ),
+	UNIQUE ( user_pk, attribute_pk )
+);
+CREATE TABLE user_transitions (
+	pk INTEGER NOT NULL,
+	created TIMESTAMP NOT NULL,
+	user_pk INTEGER NOT NULL REFERENCES users( pk ) ON DELETE CASCADE,
+	from_status TEXT NOT NULL,
+	to_status TEXT NOT NULL,
+	reason TEXT NOT NULL,
+	principal TEXT NOT NULL,
+	PRIMARY KEY ( pk )
+);
+CREATE INDEX group_versions_name_index ON group_versions ( name );
+CREATE INDEX membership_versions_group_uuid_index ON membership_versions ( group_uuid );
+CREATE INDEX user_attributes_attribute_pk_value_index ON user_attributes ( attribute_pk, value );
+CREATE INDEX user_transitions_user_pk_index ON user_transitions ( user_pk );
+CREATE INDEX user_versions_user_id_index ON user_versions ( user_id );`
 }
 
 func (obj *sqlite3DB) wrapTx(tx *sql.Tx) txMethods {
@@ -453,822 +639,3373 @@ nextval:
 	fmt.Fprint(f, "]")
 }
 
-type Group struct {
-	Pk      int64
-	Uuid    string
-	Created time.Time
-	Name    string
+type Attribute struct {
+	Pk         int64
+	Created    time.Time
+	Name       string
+	Type       string
+	IsRequired bool
+	IsUnique   bool
+	EnumValues string
 }
 
-func (Group) _Table() string { return "groups" }
+func (Attribute) _Table() string { return "attributes" }
 
-type Group_Update_Fields struct {
+type Attribute_Update_Fields struct {
 }
 
-type Group_Pk_Field struct {
+type Attribute_Pk_Field struct {
 	_set   bool
 	_null  bool
 	_value int64
 }
 
-func Group_Pk(v int64) Group_Pk_Field {
-	return Group_Pk_Field{_set: true, _value: v}
+func Attribute_Pk(v int64) Attribute_Pk_Field {
+	return Attribute_Pk_Field{_set: true, _value: v}
 }
 
-func (f Group_Pk_Field) value() interface{} {
+func (f Attribute_Pk_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (Group_Pk_Field) _Column() string { return "pk" }
+func (Attribute_Pk_Field) _Column() string { return "pk" }
 
-type Group_Uuid_Field struct {
+type Attribute_Created_Field struct {
 	_set   bool
 	_null  bool
-	_value string
+	_value time.Time
 }
 
-func Group_Uuid(v string) Group_Uuid_Field {
-	return Group_Uuid_Field{_set: true, _value: v}
+func Attribute_Created(v time.Time) Attribute_Created_Field {
+	v = toUTC(v)
+	return Attribute_Created_Field{_set: true, _value: v}
 }
 
-func (f Group_Uuid_Field) value() interface{} {
+func (f Attribute_Created_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (Group_Uuid_Field) _Column() string { return "uuid" }
+func (Attribute_Created_Field) _Column() string { return "created" }
 
-type Group_Created_Field struct {
+type Attribute_Name_Field struct {
 	_set   bool
 	_null  bool
-	_value time.Time
+	_value string
 }
 
-func Group_Created(v time.Time) Group_Created_Field {
-	v = toUTC(v)
-	return Group_Created_Field{_set: true, _value: v}
+func Attribute_Name(v string) Attribute_Name_Field {
+	return Attribute_Name_Field{_set: true, _value: v}
 }
 
-func (f Group_Created_Field) value() interface{} {
+func (f Attribute_Name_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (Group_Created_Field) _Column() string { return "created" }
+func (Attribute_Name_Field) _Column() string { return "name" }
 
-type Group_Name_Field struct {
+type Attribute_Type_Field struct {
 	_set   bool
 	_null  bool
 	_value string
 }
 
-func Group_Name(v string) Group_Name_Field {
-	return Group_Name_Field{_set: true, _value: v}
+func Attribute_Type(v string) Attribute_Type_Field {
+	return Attribute_Type_Field{_set: true, _value: v}
 }
 
-func (f Group_Name_Field) value() interface{} {
+func (f Attribute_Type_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (Group_Name_Field) _Column() string { return "name" }
+func (Attribute_Type_Field) _Column() string { return "type" }
 
-type User struct {
-	Pk        int64
-	Uuid      string
-	Created   time.Time
-	Id        string
-	FirstName string
-	LastName  string
+type Attribute_IsRequired_Field struct {
+	_set   bool
+	_null  bool
+	_value bool
 }
 
-func (User) _Table() string { return "users" }
+func Attribute_IsRequired(v bool) Attribute_IsRequired_Field {
+	return Attribute_IsRequired_Field{_set: true, _value: v}
+}
 
-type User_Update_Fields struct {
-	Id        User_Id_Field
-	FirstName User_FirstName_Field
-	LastName  User_LastName_Field
+func (f Attribute_IsRequired_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
 }
 
-type User_Pk_Field struct {
+func (Attribute_IsRequired_Field) _Column() string { return "is_required" }
+
+type Attribute_IsUnique_Field struct {
 	_set   bool
 	_null  bool
-	_value int64
+	_value bool
 }
 
-func User_Pk(v int64) User_Pk_Field {
-	return User_Pk_Field{_set: true, _value: v}
+func Attribute_IsUnique(v bool) Attribute_IsUnique_Field {
+	return Attribute_IsUnique_Field{_set: true, _value: v}
 }
 
-func (f User_Pk_Field) value() interface{} {
+func (f Attribute_IsUnique_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (User_Pk_Field) _Column() string { return "pk" }
+func (Attribute_IsUnique_Field) _Column() string { return "is_unique" }
 
-type User_Uuid_Field struct {
+type Attribute_EnumValues_Field struct {
 	_set   bool
 	_null  bool
 	_value string
 }
 
-func User_Uuid(v string) User_Uuid_Field {
-	return User_Uuid_Field{_set: true, _value: v}
+func Attribute_EnumValues(v string) Attribute_EnumValues_Field {
+	return Attribute_EnumValues_Field{_set: true, _value: v}
 }
 
-func (f User_Uuid_Field) value() interface{} {
+func (f Attribute_EnumValues_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (User_Uuid_Field) _Column() string { return "uuid" }
+func (Attribute_EnumValues_Field) _Column() string { return "enum_values" }
 
-type User_Created_Field struct {
+type GroupVersion struct {
+	Pk          int64
+	Created     time.Time
+	GroupUuid   string
+	Version     int64
+	Action      string
+	Name        string
+	Description string
+	DisplayName string
+	Owner       string
+	Labels      string
+	Principal   string
+}
+
+func (GroupVersion) _Table() string { return "group_versions" }
+
+type GroupVersion_Update_Fields struct {
+}
+
+type GroupVersion_Pk_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func GroupVersion_Pk(v int64) GroupVersion_Pk_Field {
+	return GroupVersion_Pk_Field{_set: true, _value: v}
+}
+
+func (f GroupVersion_Pk_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (GroupVersion_Pk_Field) _Column() string { return "pk" }
+
+type GroupVersion_Created_Field struct {
 	_set   bool
 	_null  bool
 	_value time.Time
 }
 
-func User_Created(v time.Time) User_Created_Field {
-	v = toUTC(v)
-	return User_Created_Field{_set: true, _value: v}
+func GroupVersion_Created(v time.Time) GroupVersion_Created_Field {
+	return GroupVersion_Created_Field{_set: true, _value: v}
 }
 
-func (f User_Created_Field) value() interface{} {
+func (f GroupVersion_Created_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (User_Created_Field) _Column() string { return "created" }
+func (GroupVersion_Created_Field) _Column() string { return "created" }
 
-type User_Id_Field struct {
+type GroupVersion_GroupUuid_Field struct {
 	_set   bool
 	_null  bool
 	_value string
 }
 
-func User_Id(v string) User_Id_Field {
-	return User_Id_Field{_set: true, _value: v}
+func GroupVersion_GroupUuid(v string) GroupVersion_GroupUuid_Field {
+	return GroupVersion_GroupUuid_Field{_set: true, _value: v}
 }
 
-func (f User_Id_Field) value() interface{} {
+func (f GroupVersion_GroupUuid_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (User_Id_Field) _Column() string { return "id" }
+func (GroupVersion_GroupUuid_Field) _Column() string { return "group_uuid" }
 
-type User_FirstName_Field struct {
+type GroupVersion_Version_Field struct {
 	_set   bool
 	_null  bool
-	_value string
+	_value int64
 }
 
-func User_FirstName(v string) User_FirstName_Field {
-	return User_FirstName_Field{_set: true, _value: v}
+func GroupVersion_Version(v int64) GroupVersion_Version_Field {
+	return GroupVersion_Version_Field{_set: true, _value: v}
 }
 
-func (f User_FirstName_Field) value() interface{} {
+func (f GroupVersion_Version_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (User_FirstName_Field) _Column() string { return "first_name" }
+func (GroupVersion_Version_Field) _Column() string { return "version" }
 
-type User_LastName_Field struct {
+type GroupVersion_Action_Field struct {
 	_set   bool
 	_null  bool
 	_value string
 }
 
-func User_LastName(v string) User_LastName_Field {
-	return User_LastName_Field{_set: true, _value: v}
+func GroupVersion_Action(v string) GroupVersion_Action_Field {
+	return GroupVersion_Action_Field{_set: true, _value: v}
 }
 
-func (f User_LastName_Field) value() interface{} {
+func (f GroupVersion_Action_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (User_LastName_Field) _Column() string { return "last_name" }
+func (GroupVersion_Action_Field) _Column() string { return "action" }
 
-type Membership struct {
-	Pk      int64
-	Created time.Time
-	UserPk  int64
-	GroupPk int64
+type GroupVersion_Name_Field struct {
+	_set   bool
+	_null  bool
+	_value string
 }
 
-func (Membership) _Table() string { return "memberships" }
+func GroupVersion_Name(v string) GroupVersion_Name_Field {
+	return GroupVersion_Name_Field{_set: true, _value: v}
+}
 
-type Membership_Update_Fields struct {
+func (f GroupVersion_Name_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
 }
 
-type Membership_Pk_Field struct {
+func (GroupVersion_Name_Field) _Column() string { return "name" }
+
+type GroupVersion_Description_Field struct {
 	_set   bool
 	_null  bool
-	_value int64
+	_value string
 }
 
-func Membership_Pk(v int64) Membership_Pk_Field {
-	return Membership_Pk_Field{_set: true, _value: v}
+func GroupVersion_Description(v string) GroupVersion_Description_Field {
+	return GroupVersion_Description_Field{_set: true, _value: v}
 }
 
-func (f Membership_Pk_Field) value() interface{} {
+func (f GroupVersion_Description_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (Membership_Pk_Field) _Column() string { return "pk" }
+func (GroupVersion_Description_Field) _Column() string { return "description" }
 
-type Membership_Created_Field struct {
+type GroupVersion_DisplayName_Field struct {
 	_set   bool
 	_null  bool
-	_value time.Time
+	_value string
 }
 
-func Membership_Created(v time.Time) Membership_Created_Field {
-	v = toUTC(v)
-	return Membership_Created_Field{_set: true, _value: v}
+func GroupVersion_DisplayName(v string) GroupVersion_DisplayName_Field {
+	return GroupVersion_DisplayName_Field{_set: true, _value: v}
 }
 
-func (f Membership_Created_Field) value() interface{} {
+func (f GroupVersion_DisplayName_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (Membership_Created_Field) _Column() string { return "created" }
+func (GroupVersion_DisplayName_Field) _Column() string { return "display_name" }
 
-type Membership_UserPk_Field struct {
+type GroupVersion_Owner_Field struct {
 	_set   bool
 	_null  bool
-	_value int64
+	_value string
 }
 
-func Membership_UserPk(v int64) Membership_UserPk_Field {
-	return Membership_UserPk_Field{_set: true, _value: v}
+func GroupVersion_Owner(v string) GroupVersion_Owner_Field {
+	return GroupVersion_Owner_Field{_set: true, _value: v}
 }
 
-func (f Membership_UserPk_Field) value() interface{} {
+func (f GroupVersion_Owner_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (Membership_UserPk_Field) _Column() string { return "user_pk" }
+func (GroupVersion_Owner_Field) _Column() string { return "owner" }
 
-type Membership_GroupPk_Field struct {
+type GroupVersion_Labels_Field struct {
 	_set   bool
 	_null  bool
-	_value int64
+	_value string
 }
 
-func Membership_GroupPk(v int64) Membership_GroupPk_Field {
-	return Membership_GroupPk_Field{_set: true, _value: v}
+func GroupVersion_Labels(v string) GroupVersion_Labels_Field {
+	return GroupVersion_Labels_Field{_set: true, _value: v}
 }
 
-func (f Membership_GroupPk_Field) value() interface{} {
+func (f GroupVersion_Labels_Field) value() interface{} {
 	if !f._set || f._null {
 		return nil
 	}
 	return f._value
 }
 
-func (Membership_GroupPk_Field) _Column() string { return "group_pk" }
+func (GroupVersion_Labels_Field) _Column() string { return "labels" }
 
-func toUTC(t time.Time) time.Time {
-	return t.UTC()
+type GroupVersion_Principal_Field struct {
+	_set   bool
+	_null  bool
+	_value string
 }
 
-func toDate(t time.Time) time.Time {
-	// keep up the minute portion so that translations between timezones will
-	// continue to reflect properly.
-	return t.Truncate(time.Minute)
+func GroupVersion_Principal(v string) GroupVersion_Principal_Field {
+	return GroupVersion_Principal_Field{_set: true, _value: v}
 }
 
-//
-// runtime support for building sql statements
-//
+func (f GroupVersion_Principal_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
 
-type __sqlbundle_SQL interface {
-	Render() string
+func (GroupVersion_Principal_Field) _Column() string { return "principal" }
 
-	private()
+type Group struct {
+	Pk          int64
+	Uuid        string
+	Created     time.Time
+	Name        string
+	Description string
+	DisplayName string
+	Owner       string
+	Labels      string
 }
 
-type __sqlbundle_Dialect interface {
-	Rebind(sql string) string
+func (Group) _Table() string { return "groups" }
+
+type Group_Update_Fields struct {
+	Name        Group_Name_Field
+	Description Group_Description_Field
+	DisplayName Group_DisplayName_Field
+	Owner       Group_Owner_Field
+	Labels      Group_Labels_Field
 }
 
-type __sqlbundle_RenderOp int
+type Group_Pk_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
 
-const (
-	__sqlbundle_NoFlatten __sqlbundle_RenderOp = iota
-	__sqlbundle_NoTerminate
-)
+func Group_Pk(v int64) Group_Pk_Field {
+	return Group_Pk_Field{_set: true, _value: v}
+}
+
+func (f Group_Pk_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (Group_Pk_Field) _Column() string { return "pk" }
+
+type Group_Uuid_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func Group_Uuid(v string) Group_Uuid_Field {
+	return Group_Uuid_Field{_set: true, _value: v}
+}
+
+func (f Group_Uuid_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (Group_Uuid_Field) _Column() string { return "uuid" }
+
+type Group_Created_Field struct {
+	_set   bool
+	_null  bool
+	_value time.Time
+}
+
+func Group_Created(v time.Time) Group_Created_Field {
+	v = toUTC(v)
+	return Group_Created_Field{_set: true, _value: v}
+}
+
+func (f Group_Created_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (Group_Created_Field) _Column() string { return "created" }
+
+type Group_Name_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func Group_Name(v string) Group_Name_Field {
+	return Group_Name_Field{_set: true, _value: v}
+}
+
+func (f Group_Name_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (Group_Name_Field) _Column() string { return "name" }
+
+type Group_Description_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func Group_Description(v string) Group_Description_Field {
+	return Group_Description_Field{_set: true, _value: v}
+}
+
+func (f Group_Description_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (Group_Description_Field) _Column() string { return "description" }
+
+type Group_DisplayName_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func Group_DisplayName(v string) Group_DisplayName_Field {
+	return Group_DisplayName_Field{_set: true, _value: v}
+}
+
+func (f Group_DisplayName_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (Group_DisplayName_Field) _Column() string { return "display_name" }
+
+type Group_Owner_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func Group_Owner(v string) Group_Owner_Field {
+	return Group_Owner_Field{_set: true, _value: v}
+}
+
+func (f Group_Owner_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (Group_Owner_Field) _Column() string { return "owner" }
+
+type Group_Labels_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func Group_Labels(v string) Group_Labels_Field {
+	return Group_Labels_Field{_set: true, _value: v}
+}
+
+func (f Group_Labels_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (Group_Labels_Field) _Column() string { return "labels" }
+
+type IdempotentRequest struct {
+	Pk             int64
+	Created        time.Time
+	IdempotencyKey string
+	RequestHash    string
+	Status         int
+	Headers        string
+	Body           []byte
+	Expires        time.Time
+}
+
+func (IdempotentRequest) _Table() string { return "idempotent_requests" }
+
+type IdempotentRequest_Update_Fields struct {
+	Status  IdempotentRequest_Status_Field
+	Headers IdempotentRequest_Headers_Field
+	Body    IdempotentRequest_Body_Field
+}
+
+type IdempotentRequest_Pk_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func IdempotentRequest_Pk(v int64) IdempotentRequest_Pk_Field {
+	return IdempotentRequest_Pk_Field{_set: true, _value: v}
+}
+
+func (f IdempotentRequest_Pk_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (IdempotentRequest_Pk_Field) _Column() string { return "pk" }
+
+type IdempotentRequest_Created_Field struct {
+	_set   bool
+	_null  bool
+	_value time.Time
+}
+
+func IdempotentRequest_Created(v time.Time) IdempotentRequest_Created_Field {
+	v = toUTC(v)
+	return IdempotentRequest_Created_Field{_set: true, _value: v}
+}
+
+func (f IdempotentRequest_Created_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (IdempotentRequest_Created_Field) _Column() string { return "created" }
+
+type IdempotentRequest_IdempotencyKey_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func IdempotentRequest_IdempotencyKey(v string) IdempotentRequest_IdempotencyKey_Field {
+	return IdempotentRequest_IdempotencyKey_Field{_set: true, _value: v}
+}
+
+func (f IdempotentRequest_IdempotencyKey_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (IdempotentRequest_IdempotencyKey_Field) _Column() string { return "idempotency_key" }
+
+type IdempotentRequest_RequestHash_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func IdempotentRequest_RequestHash(v string) IdempotentRequest_RequestHash_Field {
+	return IdempotentRequest_RequestHash_Field{_set: true, _value: v}
+}
+
+func (f IdempotentRequest_RequestHash_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (IdempotentRequest_RequestHash_Field) _Column() string { return "request_hash" }
+
+type IdempotentRequest_Status_Field struct {
+	_set   bool
+	_null  bool
+	_value int
+}
+
+func IdempotentRequest_Status(v int) IdempotentRequest_Status_Field {
+	return IdempotentRequest_Status_Field{_set: true, _value: v}
+}
+
+func (f IdempotentRequest_Status_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (IdempotentRequest_Status_Field) _Column() string { return "status" }
+
+type IdempotentRequest_Headers_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func IdempotentRequest_Headers(v string) IdempotentRequest_Headers_Field {
+	return IdempotentRequest_Headers_Field{_set: true, _value: v}
+}
+
+func (f IdempotentRequest_Headers_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (IdempotentRequest_Headers_Field) _Column() string { return "headers" }
+
+type IdempotentRequest_Body_Field struct {
+	_set   bool
+	_null  bool
+	_value []byte
+}
+
+func IdempotentRequest_Body(v []byte) IdempotentRequest_Body_Field {
+	return IdempotentRequest_Body_Field{_set: true, _value: v}
+}
+
+func (f IdempotentRequest_Body_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (IdempotentRequest_Body_Field) _Column() string { return "body" }
+
+type IdempotentRequest_Expires_Field struct {
+	_set   bool
+	_null  bool
+	_value time.Time
+}
+
+func IdempotentRequest_Expires(v time.Time) IdempotentRequest_Expires_Field {
+	v = toUTC(v)
+	return IdempotentRequest_Expires_Field{_set: true, _value: v}
+}
+
+func (f IdempotentRequest_Expires_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (IdempotentRequest_Expires_Field) _Column() string { return "expires" }
+
+type MembershipVersion struct {
+	Pk        int64
+	Created   time.Time
+	UserUuid  string
+	GroupUuid string
+	Version   int64
+	Action    string
+	UserId    string
+	GroupName string
+	Principal string
+}
+
+func (MembershipVersion) _Table() string { return "membership_versions" }
+
+type MembershipVersion_Update_Fields struct {
+}
+
+type MembershipVersion_Pk_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func MembershipVersion_Pk(v int64) MembershipVersion_Pk_Field {
+	return MembershipVersion_Pk_Field{_set: true, _value: v}
+}
+
+func (f MembershipVersion_Pk_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (MembershipVersion_Pk_Field) _Column() string { return "pk" }
+
+type MembershipVersion_Created_Field struct {
+	_set   bool
+	_null  bool
+	_value time.Time
+}
+
+func MembershipVersion_Created(v time.Time) MembershipVersion_Created_Field {
+	return MembershipVersion_Created_Field{_set: true, _value: v}
+}
+
+func (f MembershipVersion_Created_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (MembershipVersion_Created_Field) _Column() string { return "created" }
+
+type MembershipVersion_UserUuid_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func MembershipVersion_UserUuid(v string) MembershipVersion_UserUuid_Field {
+	return MembershipVersion_UserUuid_Field{_set: true, _value: v}
+}
+
+func (f MembershipVersion_UserUuid_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (MembershipVersion_UserUuid_Field) _Column() string { return "user_uuid" }
+
+type MembershipVersion_GroupUuid_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func MembershipVersion_GroupUuid(v string) MembershipVersion_GroupUuid_Field {
+	return MembershipVersion_GroupUuid_Field{_set: true, _value: v}
+}
+
+func (f MembershipVersion_GroupUuid_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (MembershipVersion_GroupUuid_Field) _Column() string { return "group_uuid" }
+
+type MembershipVersion_Version_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func MembershipVersion_Version(v int64) MembershipVersion_Version_Field {
+	return MembershipVersion_Version_Field{_set: true, _value: v}
+}
+
+func (f MembershipVersion_Version_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (MembershipVersion_Version_Field) _Column() string { return "version" }
+
+type MembershipVersion_Action_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func MembershipVersion_Action(v string) MembershipVersion_Action_Field {
+	return MembershipVersion_Action_Field{_set: true, _value: v}
+}
+
+func (f MembershipVersion_Action_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (MembershipVersion_Action_Field) _Column() string { return "action" }
+
+type MembershipVersion_UserId_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func MembershipVersion_UserId(v string) MembershipVersion_UserId_Field {
+	return MembershipVersion_UserId_Field{_set: true, _value: v}
+}
+
+func (f MembershipVersion_UserId_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (MembershipVersion_UserId_Field) _Column() string { return "user_id" }
+
+type MembershipVersion_GroupName_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func MembershipVersion_GroupName(v string) MembershipVersion_GroupName_Field {
+	return MembershipVersion_GroupName_Field{_set: true, _value: v}
+}
+
+func (f MembershipVersion_GroupName_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (MembershipVersion_GroupName_Field) _Column() string { return "group_name" }
+
+type MembershipVersion_Principal_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func MembershipVersion_Principal(v string) MembershipVersion_Principal_Field {
+	return MembershipVersion_Principal_Field{_set: true, _value: v}
+}
+
+func (f MembershipVersion_Principal_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (MembershipVersion_Principal_Field) _Column() string { return "principal" }
+
+type UserVersion struct {
+	Pk        int64
+	Created   time.Time
+	UserUuid  string
+	Version   int64
+	Action    string
+	UserId    string
+	FirstName string
+	LastName  string
+	Status    string
+	Principal string
+}
+
+func (UserVersion) _Table() string { return "user_versions" }
+
+type UserVersion_Update_Fields struct {
+}
+
+type UserVersion_Pk_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func UserVersion_Pk(v int64) UserVersion_Pk_Field {
+	return UserVersion_Pk_Field{_set: true, _value: v}
+}
+
+func (f UserVersion_Pk_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserVersion_Pk_Field) _Column() string { return "pk" }
+
+type UserVersion_Created_Field struct {
+	_set   bool
+	_null  bool
+	_value time.Time
+}
+
+func UserVersion_Created(v time.Time) UserVersion_Created_Field {
+	return UserVersion_Created_Field{_set: true, _value: v}
+}
+
+func (f UserVersion_Created_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserVersion_Created_Field) _Column() string { return "created" }
+
+type UserVersion_UserUuid_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func UserVersion_UserUuid(v string) UserVersion_UserUuid_Field {
+	return UserVersion_UserUuid_Field{_set: true, _value: v}
+}
+
+func (f UserVersion_UserUuid_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserVersion_UserUuid_Field) _Column() string { return "user_uuid" }
+
+type UserVersion_Version_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func UserVersion_Version(v int64) UserVersion_Version_Field {
+	return UserVersion_Version_Field{_set: true, _value: v}
+}
+
+func (f UserVersion_Version_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserVersion_Version_Field) _Column() string { return "version" }
+
+type UserVersion_Action_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func UserVersion_Action(v string) UserVersion_Action_Field {
+	return UserVersion_Action_Field{_set: true, _value: v}
+}
+
+func (f UserVersion_Action_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserVersion_Action_Field) _Column() string { return "action" }
+
+type UserVersion_UserId_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func UserVersion_UserId(v string) UserVersion_UserId_Field {
+	return UserVersion_UserId_Field{_set: true, _value: v}
+}
+
+func (f UserVersion_UserId_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserVersion_UserId_Field) _Column() string { return "user_id" }
+
+type UserVersion_FirstName_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func UserVersion_FirstName(v string) UserVersion_FirstName_Field {
+	return UserVersion_FirstName_Field{_set: true, _value: v}
+}
+
+func (f UserVersion_FirstName_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserVersion_FirstName_Field) _Column() string { return "first_name" }
+
+type UserVersion_LastName_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func UserVersion_LastName(v string) UserVersion_LastName_Field {
+	return UserVersion_LastName_Field{_set: true, _value: v}
+}
+
+func (f UserVersion_LastName_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserVersion_LastName_Field) _Column() string { return "last_name" }
+
+type UserVersion_Status_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func UserVersion_Status(v string) UserVersion_Status_Field {
+	return UserVersion_Status_Field{_set: true, _value: v}
+}
+
+func (f UserVersion_Status_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserVersion_Status_Field) _Column() string { return "status" }
+
+type UserVersion_Principal_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func UserVersion_Principal(v string) UserVersion_Principal_Field {
+	return UserVersion_Principal_Field{_set: true, _value: v}
+}
+
+func (f UserVersion_Principal_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserVersion_Principal_Field) _Column() string { return "principal" }
+
+type User struct {
+	Pk        int64
+	Uuid      string
+	Created   time.Time
+	Id        string
+	FirstName string
+	LastName  string
+	Status    string
+}
+
+func (User) _Table() string { return "users" }
+
+type User_Update_Fields struct {
+	Id        User_Id_Field
+	FirstName User_FirstName_Field
+	LastName  User_LastName_Field
+	Status    User_Status_Field
+}
+
+type User_Pk_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func User_Pk(v int64) User_Pk_Field {
+	return User_Pk_Field{_set: true, _value: v}
+}
+
+func (f User_Pk_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (User_Pk_Field) _Column() string { return "pk" }
+
+type User_Uuid_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func User_Uuid(v string) User_Uuid_Field {
+	return User_Uuid_Field{_set: true, _value: v}
+}
+
+func (f User_Uuid_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (User_Uuid_Field) _Column() string { return "uuid" }
+
+type User_Created_Field struct {
+	_set   bool
+	_null  bool
+	_value time.Time
+}
+
+func User_Created(v time.Time) User_Created_Field {
+	v = toUTC(v)
+	return User_Created_Field{_set: true, _value: v}
+}
+
+func (f User_Created_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (User_Created_Field) _Column() string { return "created" }
+
+type User_Id_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func User_Id(v string) User_Id_Field {
+	return User_Id_Field{_set: true, _value: v}
+}
+
+func (f User_Id_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (User_Id_Field) _Column() string { return "id" }
+
+type User_FirstName_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func User_FirstName(v string) User_FirstName_Field {
+	return User_FirstName_Field{_set: true, _value: v}
+}
+
+func (f User_FirstName_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (User_FirstName_Field) _Column() string { return "first_name" }
+
+type User_LastName_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func User_LastName(v string) User_LastName_Field {
+	return User_LastName_Field{_set: true, _value: v}
+}
+
+func (f User_LastName_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (User_LastName_Field) _Column() string { return "last_name" }
+
+type User_Status_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func User_Status(v string) User_Status_Field {
+	return User_Status_Field{_set: true, _value: v}
+}
+
+func (f User_Status_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (User_Status_Field) _Column() string { return "status" }
+
+type UserTransition struct {
+	Pk         int64
+	Created    time.Time
+	UserPk     int64
+	FromStatus string
+	ToStatus   string
+	Reason     string
+	Principal  string
+}
+
+func (UserTransition) _Table() string { return "user_transitions" }
+
+type UserTransition_Update_Fields struct {
+}
+
+type UserTransition_Pk_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func UserTransition_Pk(v int64) UserTransition_Pk_Field {
+	return UserTransition_Pk_Field{_set: true, _value: v}
+}
+
+func (f UserTransition_Pk_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserTransition_Pk_Field) _Column() string { return "pk" }
+
+type UserTransition_Created_Field struct {
+	_set   bool
+	_null  bool
+	_value time.Time
+}
+
+func UserTransition_Created(v time.Time) UserTransition_Created_Field {
+	return UserTransition_Created_Field{_set: true, _value: v}
+}
+
+func (f UserTransition_Created_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserTransition_Created_Field) _Column() string { return "created" }
+
+type UserTransition_UserPk_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func UserTransition_UserPk(v int64) UserTransition_UserPk_Field {
+	return UserTransition_UserPk_Field{_set: true, _value: v}
+}
+
+func (f UserTransition_UserPk_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserTransition_UserPk_Field) _Column() string { return "user_pk" }
+
+type UserTransition_FromStatus_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func UserTransition_FromStatus(v string) UserTransition_FromStatus_Field {
+	return UserTransition_FromStatus_Field{_set: true, _value: v}
+}
+
+func (f UserTransition_FromStatus_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserTransition_FromStatus_Field) _Column() string { return "from_status" }
+
+type UserTransition_ToStatus_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func UserTransition_ToStatus(v string) UserTransition_ToStatus_Field {
+	return UserTransition_ToStatus_Field{_set: true, _value: v}
+}
+
+func (f UserTransition_ToStatus_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserTransition_ToStatus_Field) _Column() string { return "to_status" }
+
+type UserTransition_Reason_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func UserTransition_Reason(v string) UserTransition_Reason_Field {
+	return UserTransition_Reason_Field{_set: true, _value: v}
+}
+
+func (f UserTransition_Reason_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserTransition_Reason_Field) _Column() string { return "reason" }
+
+type UserTransition_Principal_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func UserTransition_Principal(v string) UserTransition_Principal_Field {
+	return UserTransition_Principal_Field{_set: true, _value: v}
+}
+
+func (f UserTransition_Principal_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserTransition_Principal_Field) _Column() string { return "principal" }
+
+type Membership struct {
+	Pk      int64
+	Created time.Time
+	UserPk  int64
+	GroupPk int64
+}
+
+func (Membership) _Table() string { return "memberships" }
+
+type Membership_Update_Fields struct {
+}
+
+type Membership_Pk_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func Membership_Pk(v int64) Membership_Pk_Field {
+	return Membership_Pk_Field{_set: true, _value: v}
+}
+
+func (f Membership_Pk_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (Membership_Pk_Field) _Column() string { return "pk" }
+
+type Membership_Created_Field struct {
+	_set   bool
+	_null  bool
+	_value time.Time
+}
+
+func Membership_Created(v time.Time) Membership_Created_Field {
+	v = toUTC(v)
+	return Membership_Created_Field{_set: true, _value: v}
+}
+
+func (f Membership_Created_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (Membership_Created_Field) _Column() string { return "created" }
+
+type Membership_UserPk_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func Membership_UserPk(v int64) Membership_UserPk_Field {
+	return Membership_UserPk_Field{_set: true, _value: v}
+}
+
+func (f Membership_UserPk_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (Membership_UserPk_Field) _Column() string { return "user_pk" }
+
+type Membership_GroupPk_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func Membership_GroupPk(v int64) Membership_GroupPk_Field {
+	return Membership_GroupPk_Field{_set: true, _value: v}
+}
+
+func (f Membership_GroupPk_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (Membership_GroupPk_Field) _Column() string { return "group_pk" }
+
+type UserAttribute struct {
+	Pk          int64
+	UserPk      int64
+	AttributePk int64
+	Value       string
+}
+
+func (UserAttribute) _Table() string { return "user_attributes" }
+
+type UserAttribute_Update_Fields struct {
+}
+
+type UserAttribute_Pk_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func UserAttribute_Pk(v int64) UserAttribute_Pk_Field {
+	return UserAttribute_Pk_Field{_set: true, _value: v}
+}
+
+func (f UserAttribute_Pk_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserAttribute_Pk_Field) _Column() string { return "pk" }
+
+type UserAttribute_UserPk_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func UserAttribute_UserPk(v int64) UserAttribute_UserPk_Field {
+	return UserAttribute_UserPk_Field{_set: true, _value: v}
+}
+
+func (f UserAttribute_UserPk_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserAttribute_UserPk_Field) _Column() string { return "user_pk" }
+
+type UserAttribute_AttributePk_Field struct {
+	_set   bool
+	_null  bool
+	_value int64
+}
+
+func UserAttribute_AttributePk(v int64) UserAttribute_AttributePk_Field {
+	return UserAttribute_AttributePk_Field{_set: true, _value: v}
+}
+
+func (f UserAttribute_AttributePk_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserAttribute_AttributePk_Field) _Column() string { return "attribute_pk" }
+
+type UserAttribute_Value_Field struct {
+	_set   bool
+	_null  bool
+	_value string
+}
+
+func UserAttribute_Value(v string) UserAttribute_Value_Field {
+	return UserAttribute_Value_Field{_set: true, _value: v}
+}
+
+func (f UserAttribute_Value_Field) value() interface{} {
+	if !f._set || f._null {
+		return nil
+	}
+	return f._value
+}
+
+func (UserAttribute_Value_Field) _Column() string { return "value" }
+
+func toUTC(t time.Time) time.Time {
+	return t.UTC()
+}
+
+func toDate(t time.Time) time.Time {
+	// keep up the minute portion so that translations between timezones will
+	// continue to reflect properly.
+	return t.Truncate(time.Minute)
+}
+
+//
+// runtime support for building sql statements
+//
+
+type __sqlbundle_SQL interface {
+	Render() string
+
+	private()
+}
+
+type __sqlbundle_Dialect interface {
+	Rebind(sql string) string
+}
+
+type __sqlbundle_RenderOp int
+
+const (
+	__sqlbundle_NoFlatten __sqlbundle_RenderOp = iota
+	__sqlbundle_NoTerminate
+)
 
 func __sqlbundle_Render(dialect __sqlbundle_Dialect, sql __sqlbundle_SQL, ops ...__sqlbundle_RenderOp) string {
 	out := sql.Render()
 
-	flatten := true
-	terminate := true
-	for _, op := range ops {
-		switch op {
-		case __sqlbundle_NoFlatten:
-			flatten = false
-		case __sqlbundle_NoTerminate:
-			terminate = false
-		}
+	flatten := true
+	terminate := true
+	for _, op := range ops {
+		switch op {
+		case __sqlbundle_NoFlatten:
+			flatten = false
+		case __sqlbundle_NoTerminate:
+			terminate = false
+		}
+	}
+
+	if flatten {
+		out = __sqlbundle_flattenSQL(out)
+	}
+	if terminate {
+		out += ";"
+	}
+
+	return dialect.Rebind(out)
+}
+
+func __sqlbundle_flattenSQL(x string) string {
+	// trim whitespace from beginning and end
+	s, e := 0, len(x)-1
+	for s < len(x) && (x[s] == ' ' || x[s] == '\t' || x[s] == '\n') {
+		s++
+	}
+	for s <= e && (x[e] == ' ' || x[e] == '\t' || x[e] == '\n') {
+		e--
+	}
+	if s > e {
+		return ""
+	}
+	x = x[s : e+1]
+
+	// check for whitespace that needs fixing
+	wasSpace := false
+	for i := 0; i < len(x); i++ {
+		r := x[i]
+		justSpace := r == ' '
+		if (wasSpace && justSpace) || r == '\t' || r == '\n' {
+			// whitespace detected, start writing a new string
+			var result strings.Builder
+			result.Grow(len(x))
+			if wasSpace {
+				result.WriteString(x[:i-1])
+			} else {
+				result.WriteString(x[:i])
+			}
+			for p := i; p < len(x); p++ {
+				for p < len(x) && (x[p] == ' ' || x[p] == '\t' || x[p] == '\n') {
+					p++
+				}
+				result.WriteByte(' ')
+
+				start := p
+				for p < len(x) && !(x[p] == ' ' || x[p] == '\t' || x[p] == '\n') {
+					p++
+				}
+				result.WriteString(x[start:p])
+			}
+
+			return result.String()
+		}
+		wasSpace = justSpace
+	}
+
+	// no problematic whitespace found
+	return x
+}
+
+// this type is specially named to match up with the name returned by the
+// dialect impl in the sql package.
+type __sqlbundle_postgres struct{}
+
+func (p __sqlbundle_postgres) Rebind(sql string) string {
+	out := make([]byte, 0, len(sql)+10)
+
+	j := 1
+	for i := 0; i < len(sql); i++ {
+		ch := sql[i]
+		if ch != '?' {
+			out = append(out, ch)
+			continue
+		}
+
+		out = append(out, '$')
+		out = append(out, strconv.Itoa(j)...)
+		j++
+	}
+
+	return string(out)
+}
+
+// this type is specially named to match up with the name returned by the
+// dialect impl in the sql package.
+type __sqlbundle_sqlite3 struct{}
+
+func (s __sqlbundle_sqlite3) Rebind(sql string) string {
+	return sql
+}
+
+type __sqlbundle_Literal string
+
+func (__sqlbundle_Literal) private() {}
+
+func (l __sqlbundle_Literal) Render() string { return string(l) }
+
+type __sqlbundle_Literals struct {
+	Join string
+	SQLs []__sqlbundle_SQL
+}
+
+func (__sqlbundle_Literals) private() {}
+
+func (l __sqlbundle_Literals) Render() string {
+	var out bytes.Buffer
+
+	first := true
+	for _, sql := range l.SQLs {
+		if sql == nil {
+			continue
+		}
+		if !first {
+			out.WriteString(l.Join)
+		}
+		first = false
+		out.WriteString(sql.Render())
+	}
+
+	return out.String()
+}
+
+type __sqlbundle_Condition struct {
+	// set at compile/embed time
+	Name  string
+	Left  string
+	Equal bool
+	Right string
+
+	// set at runtime
+	Null bool
+}
+
+func (*__sqlbundle_Condition) private() {}
+
+func (c *__sqlbundle_Condition) Render() string {
+	// TODO(jeff): maybe check if we can use placeholders instead of the
+	// literal null: this would make the templates easier.
+
+	switch {
+	case c.Equal && c.Null:
+		return c.Left + " is null"
+	case c.Equal && !c.Null:
+		return c.Left + " = " + c.Right
+	case !c.Equal && c.Null:
+		return c.Left + " is not null"
+	case !c.Equal && !c.Null:
+		return c.Left + " != " + c.Right
+	default:
+		panic("unhandled case")
+	}
+}
+
+type __sqlbundle_Hole struct {
+	// set at compiile/embed time
+	Name string
+
+	// set at runtime
+	SQL __sqlbundle_SQL
+}
+
+func (*__sqlbundle_Hole) private() {}
+
+func (h *__sqlbundle_Hole) Render() string { return h.SQL.Render() }
+
+//
+// end runtime support for building sql statements
+//
+
+func (obj *postgresImpl) Create_Attribute(ctx context.Context,
+	attribute_name Attribute_Name_Field,
+	attribute_type Attribute_Type_Field,
+	attribute_is_required Attribute_IsRequired_Field,
+	attribute_is_unique Attribute_IsUnique_Field,
+	attribute_enum_values Attribute_EnumValues_Field) (
+	attribute *Attribute, err error) {
+
+	__now := obj.db.Hooks.Now().UTC()
+	__created_val := __now.UTC()
+	__name_val := attribute_name.value()
+	__type_val := attribute_type.value()
+	__is_required_val := attribute_is_required.value()
+	__is_unique_val := attribute_is_unique.value()
+	__enum_values_val := attribute_enum_values.value()
+
+	var __embed_stmt = __sqlbundle_Literal("INSERT INTO attributes ( created, name, type, is_required, is_unique, enum_values ) VALUES ( ?, ?, ?, ?, ?, ? ) RETURNING attributes.pk, attributes.created, attributes.name, attributes.type, attributes.is_required, attributes.is_unique, attributes.enum_values")
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __created_val, __name_val, __type_val, __is_required_val, __is_unique_val, __enum_values_val)
+
+	attribute = &Attribute{}
+	err = obj.driver.QueryRow(__stmt, __created_val, __name_val, __type_val, __is_required_val, __is_unique_val, __enum_values_val).Scan(&attribute.Pk, &attribute.Created, &attribute.Name, &attribute.Type, &attribute.IsRequired, &attribute.IsUnique, &attribute.EnumValues)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return attribute, nil
+
+}
+
+func (obj *postgresImpl) Create_User(ctx context.Context,
+	user_uuid User_Uuid_Field,
+	user_id User_Id_Field,
+	user_first_name User_FirstName_Field,
+	user_last_name User_LastName_Field,
+	user_status User_Status_Field) (
+	user *User, err error) {
+
+	__now := obj.db.Hooks.Now().UTC()
+	__uuid_val := user_uuid.value()
+	__created_val := __now.UTC()
+	__id_val := user_id.value()
+	__first_name_val := user_first_name.value()
+	__last_name_val := user_last_name.value()
+	__status_val := user_status.value()
+
+	var __embed_stmt = __sqlbundle_Literal("INSERT INTO users ( uuid, created, id, first_name, last_name, status ) VALUES ( ?, ?, ?, ?, ?, ? ) RETURNING users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status")
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __uuid_val, __created_val, __id_val, __first_name_val, __last_name_val, __status_val)
+
+	user = &User{}
+	err = obj.driver.QueryRow(__stmt, __uuid_val, __created_val, __id_val, __first_name_val, __last_name_val, __status_val).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return user, nil
+
+}
+
+func (obj *postgresImpl) Create_UserTransition(ctx context.Context,
+	user_transition_user_pk UserTransition_UserPk_Field,
+	user_transition_from_status UserTransition_FromStatus_Field,
+	user_transition_to_status UserTransition_ToStatus_Field,
+	user_transition_reason UserTransition_Reason_Field,
+	user_transition_principal UserTransition_Principal_Field) (
+	user_transition *UserTransition, err error) {
+
+	__now := obj.db.Hooks.Now().UTC()
+	__created_val := __now.UTC()
+	__user_pk_val := user_transition_user_pk.value()
+	__from_status_val := user_transition_from_status.value()
+	__to_status_val := user_transition_to_status.value()
+	__reason_val := user_transition_reason.value()
+	__principal_val := user_transition_principal.value()
+
+	var __embed_stmt = __sqlbundle_Literal("INSERT INTO user_transitions ( created, user_pk, from_status, to_status, reason, principal ) VALUES ( ?, ?, ?, ?, ?, ? ) RETURNING user_transitions.pk, user_transitions.created, user_transitions.user_pk, user_transitions.from_status, user_transitions.to_status, user_transitions.reason, user_transitions.principal")
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __created_val, __user_pk_val, __from_status_val, __to_status_val, __reason_val, __principal_val)
+
+	user_transition = &UserTransition{}
+	err = obj.driver.QueryRow(__stmt, __created_val, __user_pk_val, __from_status_val, __to_status_val, __reason_val, __principal_val).Scan(&user_transition.Pk, &user_transition.Created, &user_transition.UserPk, &user_transition.FromStatus, &user_transition.ToStatus, &user_transition.Reason, &user_transition.Principal)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return user_transition, nil
+
+}
+
+func (obj *postgresImpl) Create_Group(ctx context.Context,
+	group_uuid Group_Uuid_Field,
+	group_name Group_Name_Field,
+	group_description Group_Description_Field,
+	group_display_name Group_DisplayName_Field,
+	group_owner Group_Owner_Field,
+	group_labels Group_Labels_Field) (
+	group *Group, err error) {
+
+	__now := obj.db.Hooks.Now().UTC()
+	__uuid_val := group_uuid.value()
+	__created_val := __now.UTC()
+	__name_val := group_name.value()
+	__description_val := group_description.value()
+	__display_name_val := group_display_name.value()
+	__owner_val := group_owner.value()
+	__labels_val := group_labels.value()
+
+	var __embed_stmt = __sqlbundle_Literal("INSERT INTO groups ( uuid, created, name, description, display_name, owner, labels ) VALUES ( ?, ?, ?, ?, ?, ?, ? ) RETURNING groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels")
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __uuid_val, __created_val, __name_val, __description_val, __display_name_val, __owner_val, __labels_val)
+
+	group = &Group{}
+	err = obj.driver.QueryRow(__stmt, __uuid_val, __created_val, __name_val, __description_val, __display_name_val, __owner_val, __labels_val).Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return group, nil
+
+}
+
+func (obj *postgresImpl) Create_Membership(ctx context.Context,
+	membership_user_pk Membership_UserPk_Field,
+	membership_group_pk Membership_GroupPk_Field) (
+	membership *Membership, err error) {
+
+	__now := obj.db.Hooks.Now().UTC()
+	__created_val := __now.UTC()
+	__user_pk_val := membership_user_pk.value()
+	__group_pk_val := membership_group_pk.value()
+
+	var __embed_stmt = __sqlbundle_Literal("INSERT INTO memberships ( created, user_pk, group_pk ) VALUES ( ?, ?, ? ) RETURNING memberships.pk, memberships.created, memberships.user_pk, memberships.group_pk")
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __created_val, __user_pk_val, __group_pk_val)
+
+	membership = &Membership{}
+	err = obj.driver.QueryRow(__stmt, __created_val, __user_pk_val, __group_pk_val).Scan(&membership.Pk, &membership.Created, &membership.UserPk, &membership.GroupPk)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return membership, nil
+
+}
+
+func (obj *postgresImpl) Create_IdempotentRequest(ctx context.Context,
+	idempotent_request_idempotency_key IdempotentRequest_IdempotencyKey_Field,
+	idempotent_request_request_hash IdempotentRequest_RequestHash_Field,
+	idempotent_request_status IdempotentRequest_Status_Field,
+	idempotent_request_headers IdempotentRequest_Headers_Field,
+	idempotent_request_body IdempotentRequest_Body_Field,
+	idempotent_request_expires IdempotentRequest_Expires_Field) (
+	idempotent_request *IdempotentRequest, err error) {
+
+	__now := obj.db.Hooks.Now().UTC()
+	__created_val := __now.UTC()
+	__idempotency_key_val := idempotent_request_idempotency_key.value()
+	__request_hash_val := idempotent_request_request_hash.value()
+	__status_val := idempotent_request_status.value()
+	__headers_val := idempotent_request_headers.value()
+	__body_val := idempotent_request_body.value()
+	__expires_val := idempotent_request_expires.value()
+
+	var __embed_stmt = __sqlbundle_Literal("INSERT INTO idempotent_requests ( created, idempotency_key, request_hash, status, headers, body, expires ) VALUES ( ?, ?, ?, ?, ?, ?, ? ) RETURNING idempotent_requests.pk, idempotent_requests.created, idempotent_requests.idempotency_key, idempotent_requests.request_hash, idempotent_requests.status, idempotent_requests.headers, idempotent_requests.body, idempotent_requests.expires")
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __created_val, __idempotency_key_val, __request_hash_val, __status_val, __headers_val, __body_val, __expires_val)
+
+	idempotent_request = &IdempotentRequest{}
+	err = obj.driver.QueryRow(__stmt, __created_val, __idempotency_key_val, __request_hash_val, __status_val, __headers_val, __body_val, __expires_val).Scan(&idempotent_request.Pk, &idempotent_request.Created, &idempotent_request.IdempotencyKey, &idempotent_request.RequestHash, &idempotent_request.Status, &idempotent_request.Headers, &idempotent_request.Body, &idempotent_request.Expires)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return idempotent_request, nil
+
+}
+
+func (obj *postgresImpl) Find_Attribute_By_Name(ctx context.Context,
+	attribute_name Attribute_Name_Field) (
+	attribute *Attribute, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT attributes.pk, attributes.created, attributes.name, attributes.type, attributes.is_required, attributes.is_unique, attributes.enum_values FROM attributes WHERE attributes.name = ?")
+
+	var __values []interface{}
+	__values = append(__values, attribute_name.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	attribute = &Attribute{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&attribute.Pk, &attribute.Created, &attribute.Name, &attribute.Type, &attribute.IsRequired, &attribute.IsUnique, &attribute.EnumValues)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return attribute, nil
+
+}
+
+func (obj *postgresImpl) All_UserTransition_By_UserPk_OrderBy_Asc_Pk(ctx context.Context,
+	user_transition_user_pk UserTransition_UserPk_Field) (
+	rows []*UserTransition, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT user_transitions.pk, user_transitions.created, user_transitions.user_pk, user_transitions.from_status, user_transitions.to_status, user_transitions.reason, user_transitions.principal FROM user_transitions WHERE user_transitions.user_pk = ? ORDER BY user_transitions.pk")
+
+	var __values []interface{}
+	__values = append(__values, user_transition_user_pk.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__rows, err := obj.driver.Query(__stmt, __values...)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	defer __rows.Close()
+
+	for __rows.Next() {
+		user_transition := &UserTransition{}
+		err = __rows.Scan(&user_transition.Pk, &user_transition.Created, &user_transition.UserPk, &user_transition.FromStatus, &user_transition.ToStatus, &user_transition.Reason, &user_transition.Principal)
+		if err != nil {
+			return nil, obj.makeErr(err)
+		}
+		rows = append(rows, user_transition)
+	}
+	if err := __rows.Err(); err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return rows, nil
+
+}
+
+func (obj *postgresImpl) All_Attribute_OrderBy_Asc_Name(ctx context.Context) (
+	rows []*Attribute, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT attributes.pk, attributes.created, attributes.name, attributes.type, attributes.is_required, attributes.is_unique, attributes.enum_values FROM attributes ORDER BY attributes.name")
+
+	var __values []interface{}
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__rows, err := obj.driver.Query(__stmt, __values...)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	defer __rows.Close()
+
+	for __rows.Next() {
+		attribute := &Attribute{}
+		err = __rows.Scan(&attribute.Pk, &attribute.Created, &attribute.Name, &attribute.Type, &attribute.IsRequired, &attribute.IsUnique, &attribute.EnumValues)
+		if err != nil {
+			return nil, obj.makeErr(err)
+		}
+		rows = append(rows, attribute)
+	}
+	if err := __rows.Err(); err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return rows, nil
+
+}
+
+func (obj *postgresImpl) Find_User_By_Id(ctx context.Context,
+	user_id User_Id_Field) (
+	user *User, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status FROM users WHERE users.id = ?")
+
+	var __values []interface{}
+	__values = append(__values, user_id.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	user = &User{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return user, nil
+
+}
+
+func (obj *postgresImpl) Get_User_By_Id(ctx context.Context,
+	user_id User_Id_Field) (
+	user *User, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status FROM users WHERE users.id = ?")
+
+	var __values []interface{}
+	__values = append(__values, user_id.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	user = &User{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return user, nil
+
+}
+
+func (obj *postgresImpl) Find_User_By_Uuid(ctx context.Context,
+	user_uuid User_Uuid_Field) (
+	user *User, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status FROM users WHERE users.uuid = ?")
+
+	var __values []interface{}
+	__values = append(__values, user_uuid.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	user = &User{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return user, nil
+
+}
+
+func (obj *postgresImpl) Paged_User(ctx context.Context,
+	limit int, ctoken string) (
+	rows []*User, ctokenout string, err error) {
+
+	if ctoken == "" {
+		ctoken = "0"
+	}
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status, users.pk FROM users WHERE users.pk > ? ORDER BY users.pk LIMIT ?")
+
+	var __values []interface{}
+	__values = append(__values)
+
+	__values = append(__values, ctoken, limit)
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__rows, err := obj.driver.Query(__stmt, __values...)
+	if err != nil {
+		return nil, "", obj.makeErr(err)
+	}
+	defer __rows.Close()
+
+	__pk := int64(0)
+	for __rows.Next() {
+		user := &User{}
+		err = __rows.Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status, &__pk)
+		if err != nil {
+			return nil, "", obj.makeErr(err)
+		}
+		rows = append(rows, user)
+	}
+	if err := __rows.Err(); err != nil {
+		return nil, "", obj.makeErr(err)
+	}
+
+	if limit > 0 {
+		if len(rows) == limit {
+			ctokenout = fmt.Sprint(__pk)
+		}
+	} else {
+		ctokenout = ctoken
+	}
+
+	return rows, ctokenout, nil
+
+}
+
+func (obj *postgresImpl) Count_User(ctx context.Context) (
+	count int64, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT COUNT(*) FROM users")
+
+	var __values []interface{}
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&count)
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	return count, nil
+
+}
+
+func (obj *postgresImpl) Limited_User_OrderBy_Asc_Pk(ctx context.Context,
+	limit int, offset int64) (
+	rows []*User, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status FROM users ORDER BY users.pk LIMIT ? OFFSET ?")
+
+	var __values []interface{}
+
+	__values = append(__values, limit, offset)
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__rows, err := obj.driver.Query(__stmt, __values...)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	defer __rows.Close()
+
+	for __rows.Next() {
+		user := &User{}
+		err = __rows.Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
+		if err != nil {
+			return nil, obj.makeErr(err)
+		}
+		rows = append(rows, user)
+	}
+	if err := __rows.Err(); err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return rows, nil
+
+}
+
+func (obj *postgresImpl) Limited_User_By_Pk_Less_OrderBy_Desc_Pk(ctx context.Context,
+	user_pk_less User_Pk_Field,
+	limit int, offset int64) (
+	rows []*User, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status FROM users WHERE users.pk < ? ORDER BY users.pk DESC LIMIT ? OFFSET ?")
+
+	var __values []interface{}
+	__values = append(__values, user_pk_less.value())
+
+	__values = append(__values, limit, offset)
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__rows, err := obj.driver.Query(__stmt, __values...)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	defer __rows.Close()
+
+	for __rows.Next() {
+		user := &User{}
+		err = __rows.Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
+		if err != nil {
+			return nil, obj.makeErr(err)
+		}
+		rows = append(rows, user)
+	}
+	if err := __rows.Err(); err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return rows, nil
+
+}
+
+func (obj *postgresImpl) Limited_User_By_Pk_Greater_OrderBy_Asc_Pk(ctx context.Context,
+	user_pk_greater User_Pk_Field,
+	limit int, offset int64) (
+	rows []*User, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status FROM users WHERE users.pk > ? ORDER BY users.pk LIMIT ? OFFSET ?")
+
+	var __values []interface{}
+	__values = append(__values, user_pk_greater.value())
+
+	__values = append(__values, limit, offset)
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__rows, err := obj.driver.Query(__stmt, __values...)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	defer __rows.Close()
+
+	for __rows.Next() {
+		user := &User{}
+		err = __rows.Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
+		if err != nil {
+			return nil, obj.makeErr(err)
+		}
+		rows = append(rows, user)
+	}
+	if err := __rows.Err(); err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return rows, nil
+
+}
+
+func (obj *postgresImpl) Has_Group_By_Name(ctx context.Context,
+	group_name Group_Name_Field) (
+	has bool, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT EXISTS( SELECT 1 FROM groups WHERE groups.name = ? )")
+
+	var __values []interface{}
+	__values = append(__values, group_name.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&has)
+	if err != nil {
+		return false, obj.makeErr(err)
+	}
+	return has, nil
+
+}
+
+func (obj *postgresImpl) Find_Group_By_Name(ctx context.Context,
+	group_name Group_Name_Field) (
+	group *Group, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels FROM groups WHERE groups.name = ?")
+
+	var __values []interface{}
+	__values = append(__values, group_name.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	group = &Group{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return group, nil
+
+}
+
+func (obj *postgresImpl) Get_Group_By_Name(ctx context.Context,
+	group_name Group_Name_Field) (
+	group *Group, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels FROM groups WHERE groups.name = ?")
+
+	var __values []interface{}
+	__values = append(__values, group_name.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	group = &Group{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return group, nil
+
+}
+
+func (obj *postgresImpl) Find_Group_By_Uuid(ctx context.Context,
+	group_uuid Group_Uuid_Field) (
+	group *Group, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels FROM groups WHERE groups.uuid = ?")
+
+	var __values []interface{}
+	__values = append(__values, group_uuid.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	group = &Group{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return group, nil
+
+}
+
+func (obj *postgresImpl) Paged_Group(ctx context.Context,
+	limit int, ctoken string) (
+	rows []*Group, ctokenout string, err error) {
+
+	if ctoken == "" {
+		ctoken = "0"
+	}
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels, groups.pk FROM groups WHERE groups.pk > ? ORDER BY groups.pk LIMIT ?")
+
+	var __values []interface{}
+	__values = append(__values)
+
+	__values = append(__values, ctoken, limit)
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__rows, err := obj.driver.Query(__stmt, __values...)
+	if err != nil {
+		return nil, "", obj.makeErr(err)
+	}
+	defer __rows.Close()
+
+	__pk := int64(0)
+	for __rows.Next() {
+		group := &Group{}
+		err = __rows.Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels, &__pk)
+		if err != nil {
+			return nil, "", obj.makeErr(err)
+		}
+		rows = append(rows, group)
+	}
+	if err := __rows.Err(); err != nil {
+		return nil, "", obj.makeErr(err)
+	}
+
+	if limit > 0 {
+		if len(rows) == limit {
+			ctokenout = fmt.Sprint(__pk)
+		}
+	} else {
+		ctokenout = ctoken
+	}
+
+	return rows, ctokenout, nil
+
+}
+
+func (obj *postgresImpl) Count_Group(ctx context.Context) (
+	count int64, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT COUNT(*) FROM groups")
+
+	var __values []interface{}
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&count)
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	return count, nil
+
+}
+
+func (obj *postgresImpl) Limited_Group_OrderBy_Asc_Pk(ctx context.Context,
+	limit int, offset int64) (
+	rows []*Group, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels FROM groups ORDER BY groups.pk LIMIT ? OFFSET ?")
+
+	var __values []interface{}
+
+	__values = append(__values, limit, offset)
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__rows, err := obj.driver.Query(__stmt, __values...)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	defer __rows.Close()
+
+	for __rows.Next() {
+		group := &Group{}
+		err = __rows.Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
+		if err != nil {
+			return nil, obj.makeErr(err)
+		}
+		rows = append(rows, group)
+	}
+	if err := __rows.Err(); err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return rows, nil
+
+}
+
+func (obj *postgresImpl) Limited_Group_By_Pk_Less_OrderBy_Desc_Pk(ctx context.Context,
+	group_pk_less Group_Pk_Field,
+	limit int, offset int64) (
+	rows []*Group, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels FROM groups WHERE groups.pk < ? ORDER BY groups.pk DESC LIMIT ? OFFSET ?")
+
+	var __values []interface{}
+	__values = append(__values, group_pk_less.value())
+
+	__values = append(__values, limit, offset)
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__rows, err := obj.driver.Query(__stmt, __values...)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	defer __rows.Close()
+
+	for __rows.Next() {
+		group := &Group{}
+		err = __rows.Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
+		if err != nil {
+			return nil, obj.makeErr(err)
+		}
+		rows = append(rows, group)
+	}
+	if err := __rows.Err(); err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return rows, nil
+
+}
+
+func (obj *postgresImpl) Limited_Group_By_Pk_Greater_OrderBy_Asc_Pk(ctx context.Context,
+	group_pk_greater Group_Pk_Field,
+	limit int, offset int64) (
+	rows []*Group, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels FROM groups WHERE groups.pk > ? ORDER BY groups.pk LIMIT ? OFFSET ?")
+
+	var __values []interface{}
+	__values = append(__values, group_pk_greater.value())
+
+	__values = append(__values, limit, offset)
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__rows, err := obj.driver.Query(__stmt, __values...)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	defer __rows.Close()
+
+	for __rows.Next() {
+		group := &Group{}
+		err = __rows.Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
+		if err != nil {
+			return nil, obj.makeErr(err)
+		}
+		rows = append(rows, group)
+	}
+	if err := __rows.Err(); err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return rows, nil
+
+}
+
+func (obj *postgresImpl) All_User_By_Group_Name(ctx context.Context,
+	group_name Group_Name_Field) (
+	rows []*User, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status FROM users  JOIN memberships ON users.pk = memberships.user_pk  JOIN groups ON memberships.group_pk = groups.pk WHERE groups.name = ?")
+
+	var __values []interface{}
+	__values = append(__values, group_name.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__rows, err := obj.driver.Query(__stmt, __values...)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	defer __rows.Close()
+
+	for __rows.Next() {
+		user := &User{}
+		err = __rows.Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
+		if err != nil {
+			return nil, obj.makeErr(err)
+		}
+		rows = append(rows, user)
+	}
+	if err := __rows.Err(); err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return rows, nil
+
+}
+
+func (obj *postgresImpl) All_Group_By_User_Id(ctx context.Context,
+	user_id User_Id_Field) (
+	rows []*Group, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels FROM groups  JOIN memberships ON groups.pk = memberships.group_pk  JOIN users ON memberships.user_pk = users.pk WHERE users.id = ?")
+
+	var __values []interface{}
+	__values = append(__values, user_id.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__rows, err := obj.driver.Query(__stmt, __values...)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	defer __rows.Close()
+
+	for __rows.Next() {
+		group := &Group{}
+		err = __rows.Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
+		if err != nil {
+			return nil, obj.makeErr(err)
+		}
+		rows = append(rows, group)
+	}
+	if err := __rows.Err(); err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return rows, nil
+
+}
+
+func (obj *postgresImpl) Find_IdempotentRequest_By_IdempotencyKey(ctx context.Context,
+	idempotent_request_idempotency_key IdempotentRequest_IdempotencyKey_Field) (
+	idempotent_request *IdempotentRequest, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT idempotent_requests.pk, idempotent_requests.created, idempotent_requests.idempotency_key, idempotent_requests.request_hash, idempotent_requests.status, idempotent_requests.headers, idempotent_requests.body, idempotent_requests.expires FROM idempotent_requests WHERE idempotent_requests.idempotency_key = ?")
+
+	var __values []interface{}
+	__values = append(__values, idempotent_request_idempotency_key.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	idempotent_request = &IdempotentRequest{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&idempotent_request.Pk, &idempotent_request.Created, &idempotent_request.IdempotencyKey, &idempotent_request.RequestHash, &idempotent_request.Status, &idempotent_request.Headers, &idempotent_request.Body, &idempotent_request.Expires)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return idempotent_request, nil
+
+}
+
+func (obj *postgresImpl) Update_User_By_Id(ctx context.Context,
+	user_id User_Id_Field,
+	update User_Update_Fields) (
+	user *User, err error) {
+	var __sets = &__sqlbundle_Hole{}
+
+	var __embed_stmt = __sqlbundle_Literals{Join: "", SQLs: []__sqlbundle_SQL{__sqlbundle_Literal("UPDATE users SET "), __sets, __sqlbundle_Literal(" WHERE users.id = ? RETURNING users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status")}}
+
+	__sets_sql := __sqlbundle_Literals{Join: ", "}
+	var __values []interface{}
+	var __args []interface{}
+
+	if update.Id._set {
+		__values = append(__values, update.Id.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("id = ?"))
+	}
+
+	if update.FirstName._set {
+		__values = append(__values, update.FirstName.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("first_name = ?"))
+	}
+
+	if update.LastName._set {
+		__values = append(__values, update.LastName.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("last_name = ?"))
+	}
+
+	if update.Status._set {
+		__values = append(__values, update.Status.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("status = ?"))
+	}
+
+	if len(__sets_sql.SQLs) == 0 {
+		return nil, emptyUpdate()
+	}
+
+	__args = append(__args, user_id.value())
+
+	__values = append(__values, __args...)
+	__sets.SQL = __sets_sql
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	user = &User{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return user, nil
+}
 
-	if flatten {
-		out = __sqlbundle_flattenSQL(out)
+func (obj *postgresImpl) Update_Group_By_Name(ctx context.Context,
+	group_name Group_Name_Field,
+	update Group_Update_Fields) (
+	group *Group, err error) {
+	var __sets = &__sqlbundle_Hole{}
+
+	var __embed_stmt = __sqlbundle_Literals{Join: "", SQLs: []__sqlbundle_SQL{__sqlbundle_Literal("UPDATE groups SET "), __sets, __sqlbundle_Literal(" WHERE groups.name = ? RETURNING groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels")}}
+
+	__sets_sql := __sqlbundle_Literals{Join: ", "}
+	var __values []interface{}
+	var __args []interface{}
+
+	if update.Name._set {
+		__values = append(__values, update.Name.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("name = ?"))
 	}
-	if terminate {
-		out += ";"
+
+	if update.Description._set {
+		__values = append(__values, update.Description.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("description = ?"))
 	}
 
-	return dialect.Rebind(out)
+	if update.DisplayName._set {
+		__values = append(__values, update.DisplayName.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("display_name = ?"))
+	}
+
+	if update.Owner._set {
+		__values = append(__values, update.Owner.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("owner = ?"))
+	}
+
+	if update.Labels._set {
+		__values = append(__values, update.Labels.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("labels = ?"))
+	}
+
+	if len(__sets_sql.SQLs) == 0 {
+		return nil, emptyUpdate()
+	}
+
+	__args = append(__args, group_name.value())
+
+	__values = append(__values, __args...)
+	__sets.SQL = __sets_sql
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	group = &Group{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return group, nil
 }
 
-func __sqlbundle_flattenSQL(x string) string {
-	// trim whitespace from beginning and end
-	s, e := 0, len(x)-1
-	for s < len(x) && (x[s] == ' ' || x[s] == '\t' || x[s] == '\n') {
-		s++
+func (obj *postgresImpl) Update_IdempotentRequest_By_IdempotencyKey(ctx context.Context,
+	idempotent_request_idempotency_key IdempotentRequest_IdempotencyKey_Field,
+	update IdempotentRequest_Update_Fields) (
+	idempotent_request *IdempotentRequest, err error) {
+	var __sets = &__sqlbundle_Hole{}
+
+	var __embed_stmt = __sqlbundle_Literals{Join: "", SQLs: []__sqlbundle_SQL{__sqlbundle_Literal("UPDATE idempotent_requests SET "), __sets, __sqlbundle_Literal(" WHERE idempotent_requests.idempotency_key = ? RETURNING idempotent_requests.pk, idempotent_requests.created, idempotent_requests.idempotency_key, idempotent_requests.request_hash, idempotent_requests.status, idempotent_requests.headers, idempotent_requests.body, idempotent_requests.expires")}}
+
+	__sets_sql := __sqlbundle_Literals{Join: ", "}
+	var __values []interface{}
+	var __args []interface{}
+
+	if update.Status._set {
+		__values = append(__values, update.Status.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("status = ?"))
 	}
-	for s <= e && (x[e] == ' ' || x[e] == '\t' || x[e] == '\n') {
-		e--
+
+	if update.Headers._set {
+		__values = append(__values, update.Headers.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("headers = ?"))
 	}
-	if s > e {
-		return ""
+
+	if update.Body._set {
+		__values = append(__values, update.Body.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("body = ?"))
 	}
-	x = x[s : e+1]
 
-	// check for whitespace that needs fixing
-	wasSpace := false
-	for i := 0; i < len(x); i++ {
-		r := x[i]
-		justSpace := r == ' '
-		if (wasSpace && justSpace) || r == '\t' || r == '\n' {
-			// whitespace detected, start writing a new string
-			var result strings.Builder
-			result.Grow(len(x))
-			if wasSpace {
-				result.WriteString(x[:i-1])
-			} else {
-				result.WriteString(x[:i])
-			}
-			for p := i; p < len(x); p++ {
-				for p < len(x) && (x[p] == ' ' || x[p] == '\t' || x[p] == '\n') {
-					p++
-				}
-				result.WriteByte(' ')
+	if len(__sets_sql.SQLs) == 0 {
+		return nil, emptyUpdate()
+	}
 
-				start := p
-				for p < len(x) && !(x[p] == ' ' || x[p] == '\t' || x[p] == '\n') {
-					p++
-				}
-				result.WriteString(x[start:p])
-			}
+	__args = append(__args, idempotent_request_idempotency_key.value())
 
-			return result.String()
-		}
-		wasSpace = justSpace
+	__values = append(__values, __args...)
+	__sets.SQL = __sets_sql
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	idempotent_request = &IdempotentRequest{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&idempotent_request.Pk, &idempotent_request.Created, &idempotent_request.IdempotencyKey, &idempotent_request.RequestHash, &idempotent_request.Status, &idempotent_request.Headers, &idempotent_request.Body, &idempotent_request.Expires)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, obj.makeErr(err)
 	}
+	return idempotent_request, nil
+}
+
+func (obj *postgresImpl) Delete_Attribute_By_Name(ctx context.Context,
+	attribute_name Attribute_Name_Field) (
+	deleted bool, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("DELETE FROM attributes WHERE attributes.name = ?")
+
+	var __values []interface{}
+	__values = append(__values, attribute_name.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__res, err := obj.driver.Exec(__stmt, __values...)
+	if err != nil {
+		return false, obj.makeErr(err)
+	}
+
+	__count, err := __res.RowsAffected()
+	if err != nil {
+		return false, obj.makeErr(err)
+	}
+
+	return __count > 0, nil
 
-	// no problematic whitespace found
-	return x
 }
 
-// this type is specially named to match up with the name returned by the
-// dialect impl in the sql package.
-type __sqlbundle_postgres struct{}
+func (obj *postgresImpl) Delete_User_By_Id(ctx context.Context,
+	user_id User_Id_Field) (
+	deleted bool, err error) {
 
-func (p __sqlbundle_postgres) Rebind(sql string) string {
-	out := make([]byte, 0, len(sql)+10)
+	var __embed_stmt = __sqlbundle_Literal("DELETE FROM users WHERE users.id = ?")
 
-	j := 1
-	for i := 0; i < len(sql); i++ {
-		ch := sql[i]
-		if ch != '?' {
-			out = append(out, ch)
-			continue
-		}
+	var __values []interface{}
+	__values = append(__values, user_id.value())
 
-		out = append(out, '$')
-		out = append(out, strconv.Itoa(j)...)
-		j++
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__res, err := obj.driver.Exec(__stmt, __values...)
+	if err != nil {
+		return false, obj.makeErr(err)
 	}
 
-	return string(out)
+	__count, err := __res.RowsAffected()
+	if err != nil {
+		return false, obj.makeErr(err)
+	}
+
+	return __count > 0, nil
+
 }
 
-// this type is specially named to match up with the name returned by the
-// dialect impl in the sql package.
-type __sqlbundle_sqlite3 struct{}
+func (obj *postgresImpl) Delete_Group_By_Name(ctx context.Context,
+	group_name Group_Name_Field) (
+	deleted bool, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("DELETE FROM groups WHERE groups.name = ?")
+
+	var __values []interface{}
+	__values = append(__values, group_name.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__res, err := obj.driver.Exec(__stmt, __values...)
+	if err != nil {
+		return false, obj.makeErr(err)
+	}
+
+	__count, err := __res.RowsAffected()
+	if err != nil {
+		return false, obj.makeErr(err)
+	}
+
+	return __count > 0, nil
 
-func (s __sqlbundle_sqlite3) Rebind(sql string) string {
-	return sql
 }
 
-type __sqlbundle_Literal string
+func (obj *postgresImpl) Delete_Membership_By_User_Id(ctx context.Context,
+	user_id User_Id_Field) (
+	count int64, err error) {
 
-func (__sqlbundle_Literal) private() {}
+	var __embed_stmt = __sqlbundle_Literal("DELETE FROM memberships WHERE memberships.pk IN (SELECT memberships.pk FROM memberships  JOIN users ON memberships.user_pk = users.pk WHERE users.id = ?)")
 
-func (l __sqlbundle_Literal) Render() string { return string(l) }
+	var __values []interface{}
+	__values = append(__values, user_id.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__res, err := obj.driver.Exec(__stmt, __values...)
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	return count, nil
 
-type __sqlbundle_Literals struct {
-	Join string
-	SQLs []__sqlbundle_SQL
 }
 
-func (__sqlbundle_Literals) private() {}
+func (obj *postgresImpl) Delete_Membership_By_Group_Name(ctx context.Context,
+	group_name Group_Name_Field) (
+	count int64, err error) {
 
-func (l __sqlbundle_Literals) Render() string {
-	var out bytes.Buffer
+	var __embed_stmt = __sqlbundle_Literal("DELETE FROM memberships WHERE memberships.pk IN (SELECT memberships.pk FROM memberships  JOIN groups ON memberships.group_pk = groups.pk WHERE groups.name = ?)")
 
-	first := true
-	for _, sql := range l.SQLs {
-		if sql == nil {
-			continue
-		}
-		if !first {
-			out.WriteString(l.Join)
-		}
-		first = false
-		out.WriteString(sql.Render())
+	var __values []interface{}
+	__values = append(__values, group_name.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__res, err := obj.driver.Exec(__stmt, __values...)
+	if err != nil {
+		return 0, obj.makeErr(err)
 	}
 
-	return out.String()
-}
+	count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
 
-type __sqlbundle_Condition struct {
-	// set at compile/embed time
-	Name  string
-	Left  string
-	Equal bool
-	Right string
+	return count, nil
 
-	// set at runtime
-	Null bool
 }
 
-func (*__sqlbundle_Condition) private() {}
+func (obj *postgresImpl) Delete_IdempotentRequest_By_IdempotencyKey(ctx context.Context,
+	idempotent_request_idempotency_key IdempotentRequest_IdempotencyKey_Field) (
+	deleted bool, err error) {
 
-func (c *__sqlbundle_Condition) Render() string {
-	// TODO(jeff): maybe check if we can use placeholders instead of the
-	// literal null: this would make the templates easier.
+	var __embed_stmt = __sqlbundle_Literal("DELETE FROM idempotent_requests WHERE idempotent_requests.idempotency_key = ?")
 
-	switch {
-	case c.Equal && c.Null:
-		return c.Left + " is null"
-	case c.Equal && !c.Null:
-		return c.Left + " = " + c.Right
-	case !c.Equal && c.Null:
-		return c.Left + " is not null"
-	case !c.Equal && !c.Null:
-		return c.Left + " != " + c.Right
-	default:
-		panic("unhandled case")
-	}
-}
+	var __values []interface{}
+	__values = append(__values, idempotent_request_idempotency_key.value())
 
-type __sqlbundle_Hole struct {
-	// set at compiile/embed time
-	Name string
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
 
-	// set at runtime
-	SQL __sqlbundle_SQL
-}
+	__res, err := obj.driver.Exec(__stmt, __values...)
+	if err != nil {
+		return false, obj.makeErr(err)
+	}
 
-func (*__sqlbundle_Hole) private() {}
+	__count, err := __res.RowsAffected()
+	if err != nil {
+		return false, obj.makeErr(err)
+	}
 
-func (h *__sqlbundle_Hole) Render() string { return h.SQL.Render() }
+	return __count > 0, nil
 
-//
-// end runtime support for building sql statements
-//
+}
 
-func (obj *postgresImpl) Create_User(ctx context.Context,
-	user_uuid User_Uuid_Field,
-	user_id User_Id_Field,
-	user_first_name User_FirstName_Field,
-	user_last_name User_LastName_Field) (
-	user *User, err error) {
+func (obj *postgresImpl) Delete_IdempotentRequest_By_Expires_Less(ctx context.Context,
+	idempotent_request_expires_less IdempotentRequest_Expires_Field) (
+	count int64, err error) {
 
-	__now := obj.db.Hooks.Now().UTC()
-	__uuid_val := user_uuid.value()
-	__created_val := __now.UTC()
-	__id_val := user_id.value()
-	__first_name_val := user_first_name.value()
-	__last_name_val := user_last_name.value()
+	var __embed_stmt = __sqlbundle_Literal("DELETE FROM idempotent_requests WHERE idempotent_requests.expires < ?")
 
-	var __embed_stmt = __sqlbundle_Literal("INSERT INTO users ( uuid, created, id, first_name, last_name ) VALUES ( ?, ?, ?, ?, ? ) RETURNING users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name")
+	var __values []interface{}
+	__values = append(__values, idempotent_request_expires_less.value())
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
-	obj.logStmt(__stmt, __uuid_val, __created_val, __id_val, __first_name_val, __last_name_val)
+	obj.logStmt(__stmt, __values...)
 
-	user = &User{}
-	err = obj.driver.QueryRow(__stmt, __uuid_val, __created_val, __id_val, __first_name_val, __last_name_val).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName)
+	__res, err := obj.driver.Exec(__stmt, __values...)
 	if err != nil {
-		return nil, obj.makeErr(err)
+		return 0, obj.makeErr(err)
 	}
-	return user, nil
+
+	count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	return count, nil
 
 }
 
-func (obj *postgresImpl) Create_Group(ctx context.Context,
-	group_uuid Group_Uuid_Field,
-	group_name Group_Name_Field) (
-	group *Group, err error) {
+func (impl postgresImpl) isConstraintError(err error) (
+	constraint string, ok bool) {
+	if e, ok := err.(*pq.Error); ok {
+		if e.Code.Class() == "23" {
+			return e.Constraint, true
+		}
+	}
+	return "", false
+}
 
-	__now := obj.db.Hooks.Now().UTC()
-	__uuid_val := group_uuid.value()
-	__created_val := __now.UTC()
-	__name_val := group_name.value()
+func (obj *postgresImpl) deleteAll(ctx context.Context) (count int64, err error) {
+	var __res sql.Result
+	var __count int64
+	__res, err = obj.driver.Exec("DELETE FROM user_transitions;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
 
-	var __embed_stmt = __sqlbundle_Literal("INSERT INTO groups ( uuid, created, name ) VALUES ( ?, ?, ? ) RETURNING groups.pk, groups.uuid, groups.created, groups.name")
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.Exec("DELETE FROM user_attributes;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
 
-	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
-	obj.logStmt(__stmt, __uuid_val, __created_val, __name_val)
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.Exec("DELETE FROM memberships;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
 
-	group = &Group{}
-	err = obj.driver.QueryRow(__stmt, __uuid_val, __created_val, __name_val).Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name)
+	__count, err = __res.RowsAffected()
 	if err != nil {
-		return nil, obj.makeErr(err)
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.Exec("DELETE FROM users;")
+	if err != nil {
+		return 0, obj.makeErr(err)
 	}
-	return group, nil
 
-}
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.Exec("DELETE FROM user_versions;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
 
-func (obj *postgresImpl) Create_Membership(ctx context.Context,
-	membership_user_pk Membership_UserPk_Field,
-	membership_group_pk Membership_GroupPk_Field) (
-	membership *Membership, err error) {
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.Exec("DELETE FROM membership_versions;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
 
-	__now := obj.db.Hooks.Now().UTC()
-	__created_val := __now.UTC()
-	__user_pk_val := membership_user_pk.value()
-	__group_pk_val := membership_group_pk.value()
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.Exec("DELETE FROM idempotent_requests;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
 
-	var __embed_stmt = __sqlbundle_Literal("INSERT INTO memberships ( created, user_pk, group_pk ) VALUES ( ?, ?, ? ) RETURNING memberships.pk, memberships.created, memberships.user_pk, memberships.group_pk")
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.Exec("DELETE FROM groups;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
 
-	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
-	obj.logStmt(__stmt, __created_val, __user_pk_val, __group_pk_val)
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.Exec("DELETE FROM group_versions;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
 
-	membership = &Membership{}
-	err = obj.driver.QueryRow(__stmt, __created_val, __user_pk_val, __group_pk_val).Scan(&membership.Pk, &membership.Created, &membership.UserPk, &membership.GroupPk)
+	__count, err = __res.RowsAffected()
 	if err != nil {
-		return nil, obj.makeErr(err)
+		return 0, obj.makeErr(err)
 	}
-	return membership, nil
+	count += __count
+	__res, err = obj.driver.Exec("DELETE FROM attributes;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+
+	return count, nil
 
 }
 
-func (obj *postgresImpl) Find_User_By_Id(ctx context.Context,
-	user_id User_Id_Field) (
-	user *User, err error) {
+func (obj *sqlite3Impl) Create_Attribute(ctx context.Context,
+	attribute_name Attribute_Name_Field,
+	attribute_type Attribute_Type_Field,
+	attribute_is_required Attribute_IsRequired_Field,
+	attribute_is_unique Attribute_IsUnique_Field,
+	attribute_enum_values Attribute_EnumValues_Field) (
+	attribute *Attribute, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name FROM users WHERE users.id = ?")
+	__now := obj.db.Hooks.Now().UTC()
+	__created_val := __now.UTC()
+	__name_val := attribute_name.value()
+	__type_val := attribute_type.value()
+	__is_required_val := attribute_is_required.value()
+	__is_unique_val := attribute_is_unique.value()
+	__enum_values_val := attribute_enum_values.value()
 
-	var __values []interface{}
-	__values = append(__values, user_id.value())
+	var __embed_stmt = __sqlbundle_Literal("INSERT INTO attributes ( created, name, type, is_required, is_unique, enum_values ) VALUES ( ?, ?, ?, ?, ?, ? )")
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
-	obj.logStmt(__stmt, __values...)
+	obj.logStmt(__stmt, __created_val, __name_val, __type_val, __is_required_val, __is_unique_val, __enum_values_val)
 
-	user = &User{}
-	err = obj.driver.QueryRow(__stmt, __values...).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName)
-	if err == sql.ErrNoRows {
-		return nil, nil
+	__res, err := obj.driver.Exec(__stmt, __created_val, __name_val, __type_val, __is_required_val, __is_unique_val, __enum_values_val)
+	if err != nil {
+		return nil, obj.makeErr(err)
 	}
+	__pk, err := __res.LastInsertId()
 	if err != nil {
 		return nil, obj.makeErr(err)
 	}
-	return user, nil
+	return obj.getLastAttribute(ctx, __pk)
 
 }
 
-func (obj *postgresImpl) Get_User_By_Id(ctx context.Context,
-	user_id User_Id_Field) (
+func (obj *sqlite3Impl) Create_User(ctx context.Context,
+	user_uuid User_Uuid_Field,
+	user_id User_Id_Field,
+	user_first_name User_FirstName_Field,
+	user_last_name User_LastName_Field,
+	user_status User_Status_Field) (
 	user *User, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name FROM users WHERE users.id = ?")
+	__now := obj.db.Hooks.Now().UTC()
+	__uuid_val := user_uuid.value()
+	__created_val := __now.UTC()
+	__id_val := user_id.value()
+	__first_name_val := user_first_name.value()
+	__last_name_val := user_last_name.value()
+	__status_val := user_status.value()
 
-	var __values []interface{}
-	__values = append(__values, user_id.value())
+	var __embed_stmt = __sqlbundle_Literal("INSERT INTO users ( uuid, created, id, first_name, last_name, status ) VALUES ( ?, ?, ?, ?, ?, ? )")
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
-	obj.logStmt(__stmt, __values...)
+	obj.logStmt(__stmt, __uuid_val, __created_val, __id_val, __first_name_val, __last_name_val, __status_val)
 
-	user = &User{}
-	err = obj.driver.QueryRow(__stmt, __values...).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName)
+	__res, err := obj.driver.Exec(__stmt, __uuid_val, __created_val, __id_val, __first_name_val, __last_name_val, __status_val)
 	if err != nil {
 		return nil, obj.makeErr(err)
 	}
-	return user, nil
-
-}
-
-func (obj *postgresImpl) Paged_User(ctx context.Context,
-	limit int, ctoken string) (
-	rows []*User, ctokenout string, err error) {
-
-	if ctoken == "" {
-		ctoken = "0"
+	__pk, err := __res.LastInsertId()
+	if err != nil {
+		return nil, obj.makeErr(err)
 	}
+	return obj.getLastUser(ctx, __pk)
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.pk FROM users WHERE users.pk > ? ORDER BY users.pk LIMIT ?")
+}
 
-	var __values []interface{}
-	__values = append(__values)
+func (obj *sqlite3Impl) Create_UserTransition(ctx context.Context,
+	user_transition_user_pk UserTransition_UserPk_Field,
+	user_transition_from_status UserTransition_FromStatus_Field,
+	user_transition_to_status UserTransition_ToStatus_Field,
+	user_transition_reason UserTransition_Reason_Field,
+	user_transition_principal UserTransition_Principal_Field) (
+	user_transition *UserTransition, err error) {
 
-	__values = append(__values, ctoken, limit)
+	__now := obj.db.Hooks.Now().UTC()
+	__created_val := __now.UTC()
+	__user_pk_val := user_transition_user_pk.value()
+	__from_status_val := user_transition_from_status.value()
+	__to_status_val := user_transition_to_status.value()
+	__reason_val := user_transition_reason.value()
+	__principal_val := user_transition_principal.value()
+
+	var __embed_stmt = __sqlbundle_Literal("INSERT INTO user_transitions ( created, user_pk, from_status, to_status, reason, principal ) VALUES ( ?, ?, ?, ?, ?, ? )")
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
-	obj.logStmt(__stmt, __values...)
+	obj.logStmt(__stmt, __created_val, __user_pk_val, __from_status_val, __to_status_val, __reason_val, __principal_val)
 
-	__rows, err := obj.driver.Query(__stmt, __values...)
+	__res, err := obj.driver.Exec(__stmt, __created_val, __user_pk_val, __from_status_val, __to_status_val, __reason_val, __principal_val)
 	if err != nil {
-		return nil, "", obj.makeErr(err)
-	}
-	defer __rows.Close()
-
-	__pk := int64(0)
-	for __rows.Next() {
-		user := &User{}
-		err = __rows.Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &__pk)
-		if err != nil {
-			return nil, "", obj.makeErr(err)
-		}
-		rows = append(rows, user)
-	}
-	if err := __rows.Err(); err != nil {
-		return nil, "", obj.makeErr(err)
+		return nil, obj.makeErr(err)
 	}
-
-	if limit > 0 {
-		if len(rows) == limit {
-			ctokenout = fmt.Sprint(__pk)
-		}
-	} else {
-		ctokenout = ctoken
+	__pk, err := __res.LastInsertId()
+	if err != nil {
+		return nil, obj.makeErr(err)
 	}
-
-	return rows, ctokenout, nil
+	return obj.getLastUserTransition(ctx, __pk)
 
 }
 
-func (obj *postgresImpl) Has_Group_By_Name(ctx context.Context,
-	group_name Group_Name_Field) (
-	has bool, err error) {
+func (obj *sqlite3Impl) Create_Group(ctx context.Context,
+	group_uuid Group_Uuid_Field,
+	group_name Group_Name_Field,
+	group_description Group_Description_Field,
+	group_display_name Group_DisplayName_Field,
+	group_owner Group_Owner_Field,
+	group_labels Group_Labels_Field) (
+	group *Group, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT EXISTS( SELECT 1 FROM groups WHERE groups.name = ? )")
+	__now := obj.db.Hooks.Now().UTC()
+	__uuid_val := group_uuid.value()
+	__created_val := __now.UTC()
+	__name_val := group_name.value()
+	__description_val := group_description.value()
+	__display_name_val := group_display_name.value()
+	__owner_val := group_owner.value()
+	__labels_val := group_labels.value()
 
-	var __values []interface{}
-	__values = append(__values, group_name.value())
+	var __embed_stmt = __sqlbundle_Literal("INSERT INTO groups ( uuid, created, name, description, display_name, owner, labels ) VALUES ( ?, ?, ?, ?, ?, ?, ? )")
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
-	obj.logStmt(__stmt, __values...)
+	obj.logStmt(__stmt, __uuid_val, __created_val, __name_val, __description_val, __display_name_val, __owner_val, __labels_val)
 
-	err = obj.driver.QueryRow(__stmt, __values...).Scan(&has)
+	__res, err := obj.driver.Exec(__stmt, __uuid_val, __created_val, __name_val, __description_val, __display_name_val, __owner_val, __labels_val)
 	if err != nil {
-		return false, obj.makeErr(err)
+		return nil, obj.makeErr(err)
 	}
-	return has, nil
+	__pk, err := __res.LastInsertId()
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return obj.getLastGroup(ctx, __pk)
 
 }
 
-func (obj *postgresImpl) Find_Group_By_Name(ctx context.Context,
-	group_name Group_Name_Field) (
-	group *Group, err error) {
+func (obj *sqlite3Impl) Create_Membership(ctx context.Context,
+	membership_user_pk Membership_UserPk_Field,
+	membership_group_pk Membership_GroupPk_Field) (
+	membership *Membership, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name FROM groups WHERE groups.name = ?")
+	__now := obj.db.Hooks.Now().UTC()
+	__created_val := __now.UTC()
+	__user_pk_val := membership_user_pk.value()
+	__group_pk_val := membership_group_pk.value()
 
-	var __values []interface{}
-	__values = append(__values, group_name.value())
+	var __embed_stmt = __sqlbundle_Literal("INSERT INTO memberships ( created, user_pk, group_pk ) VALUES ( ?, ?, ? )")
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
-	obj.logStmt(__stmt, __values...)
+	obj.logStmt(__stmt, __created_val, __user_pk_val, __group_pk_val)
 
-	group = &Group{}
-	err = obj.driver.QueryRow(__stmt, __values...).Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name)
-	if err == sql.ErrNoRows {
-		return nil, nil
+	__res, err := obj.driver.Exec(__stmt, __created_val, __user_pk_val, __group_pk_val)
+	if err != nil {
+		return nil, obj.makeErr(err)
 	}
+	__pk, err := __res.LastInsertId()
 	if err != nil {
 		return nil, obj.makeErr(err)
 	}
-	return group, nil
+	return obj.getLastMembership(ctx, __pk)
 
 }
 
-func (obj *postgresImpl) Get_Group_By_Name(ctx context.Context,
-	group_name Group_Name_Field) (
-	group *Group, err error) {
+func (obj *sqlite3Impl) Create_IdempotentRequest(ctx context.Context,
+	idempotent_request_idempotency_key IdempotentRequest_IdempotencyKey_Field,
+	idempotent_request_request_hash IdempotentRequest_RequestHash_Field,
+	idempotent_request_status IdempotentRequest_Status_Field,
+	idempotent_request_headers IdempotentRequest_Headers_Field,
+	idempotent_request_body IdempotentRequest_Body_Field,
+	idempotent_request_expires IdempotentRequest_Expires_Field) (
+	idempotent_request *IdempotentRequest, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name FROM groups WHERE groups.name = ?")
+	__now := obj.db.Hooks.Now().UTC()
+	__created_val := __now.UTC()
+	__idempotency_key_val := idempotent_request_idempotency_key.value()
+	__request_hash_val := idempotent_request_request_hash.value()
+	__status_val := idempotent_request_status.value()
+	__headers_val := idempotent_request_headers.value()
+	__body_val := idempotent_request_body.value()
+	__expires_val := idempotent_request_expires.value()
 
-	var __values []interface{}
-	__values = append(__values, group_name.value())
+	var __embed_stmt = __sqlbundle_Literal("INSERT INTO idempotent_requests ( created, idempotency_key, request_hash, status, headers, body, expires ) VALUES ( ?, ?, ?, ?, ?, ?, ? )")
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
-	obj.logStmt(__stmt, __values...)
+	obj.logStmt(__stmt, __created_val, __idempotency_key_val, __request_hash_val, __status_val, __headers_val, __body_val, __expires_val)
 
-	group = &Group{}
-	err = obj.driver.QueryRow(__stmt, __values...).Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name)
+	__res, err := obj.driver.Exec(__stmt, __created_val, __idempotency_key_val, __request_hash_val, __status_val, __headers_val, __body_val, __expires_val)
 	if err != nil {
 		return nil, obj.makeErr(err)
 	}
-	return group, nil
+	__pk, err := __res.LastInsertId()
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return obj.getLastIdempotentRequest(ctx, __pk)
 
 }
 
-func (obj *postgresImpl) Paged_Group(ctx context.Context,
-	limit int, ctoken string) (
-	rows []*Group, ctokenout string, err error) {
-
-	if ctoken == "" {
-		ctoken = "0"
-	}
+func (obj *sqlite3Impl) Find_Attribute_By_Name(ctx context.Context,
+	attribute_name Attribute_Name_Field) (
+	attribute *Attribute, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.pk FROM groups WHERE groups.pk > ? ORDER BY groups.pk LIMIT ?")
+	var __embed_stmt = __sqlbundle_Literal("SELECT attributes.pk, attributes.created, attributes.name, attributes.type, attributes.is_required, attributes.is_unique, attributes.enum_values FROM attributes WHERE attributes.name = ?")
 
 	var __values []interface{}
-	__values = append(__values)
-
-	__values = append(__values, ctoken, limit)
+	__values = append(__values, attribute_name.value())
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, __values...)
 
-	__rows, err := obj.driver.Query(__stmt, __values...)
-	if err != nil {
-		return nil, "", obj.makeErr(err)
-	}
-	defer __rows.Close()
-
-	__pk := int64(0)
-	for __rows.Next() {
-		group := &Group{}
-		err = __rows.Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &__pk)
-		if err != nil {
-			return nil, "", obj.makeErr(err)
-		}
-		rows = append(rows, group)
-	}
-	if err := __rows.Err(); err != nil {
-		return nil, "", obj.makeErr(err)
+	attribute = &Attribute{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&attribute.Pk, &attribute.Created, &attribute.Name, &attribute.Type, &attribute.IsRequired, &attribute.IsUnique, &attribute.EnumValues)
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-
-	if limit > 0 {
-		if len(rows) == limit {
-			ctokenout = fmt.Sprint(__pk)
-		}
-	} else {
-		ctokenout = ctoken
+	if err != nil {
+		return nil, obj.makeErr(err)
 	}
-
-	return rows, ctokenout, nil
+	return attribute, nil
 
 }
 
-func (obj *postgresImpl) All_User_By_Group_Name(ctx context.Context,
-	group_name Group_Name_Field) (
-	rows []*User, err error) {
+func (obj *sqlite3Impl) All_UserTransition_By_UserPk_OrderBy_Asc_Pk(ctx context.Context,
+	user_transition_user_pk UserTransition_UserPk_Field) (
+	rows []*UserTransition, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name FROM users  JOIN memberships ON users.pk = memberships.user_pk  JOIN groups ON memberships.group_pk = groups.pk WHERE groups.name = ?")
+	var __embed_stmt = __sqlbundle_Literal("SELECT user_transitions.pk, user_transitions.created, user_transitions.user_pk, user_transitions.from_status, user_transitions.to_status, user_transitions.reason, user_transitions.principal FROM user_transitions WHERE user_transitions.user_pk = ? ORDER BY user_transitions.pk")
 
 	var __values []interface{}
-	__values = append(__values, group_name.value())
+	__values = append(__values, user_transition_user_pk.value())
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, __values...)
@@ -1280,12 +4017,12 @@ func (obj *postgresImpl) All_User_By_Group_Name(ctx context.Context,
 	defer __rows.Close()
 
 	for __rows.Next() {
-		user := &User{}
-		err = __rows.Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName)
+		user_transition := &UserTransition{}
+		err = __rows.Scan(&user_transition.Pk, &user_transition.Created, &user_transition.UserPk, &user_transition.FromStatus, &user_transition.ToStatus, &user_transition.Reason, &user_transition.Principal)
 		if err != nil {
 			return nil, obj.makeErr(err)
 		}
-		rows = append(rows, user)
+		rows = append(rows, user_transition)
 	}
 	if err := __rows.Err(); err != nil {
 		return nil, obj.makeErr(err)
@@ -1294,14 +4031,12 @@ func (obj *postgresImpl) All_User_By_Group_Name(ctx context.Context,
 
 }
 
-func (obj *postgresImpl) All_Group_By_User_Id(ctx context.Context,
-	user_id User_Id_Field) (
-	rows []*Group, err error) {
+func (obj *sqlite3Impl) All_Attribute_OrderBy_Asc_Name(ctx context.Context) (
+	rows []*Attribute, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name FROM groups  JOIN memberships ON groups.pk = memberships.group_pk  JOIN users ON memberships.user_pk = users.pk WHERE users.id = ?")
+	var __embed_stmt = __sqlbundle_Literal("SELECT attributes.pk, attributes.created, attributes.name, attributes.type, attributes.is_required, attributes.is_unique, attributes.enum_values FROM attributes ORDER BY attributes.name")
 
 	var __values []interface{}
-	__values = append(__values, user_id.value())
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, __values...)
@@ -1313,12 +4048,12 @@ func (obj *postgresImpl) All_Group_By_User_Id(ctx context.Context,
 	defer __rows.Close()
 
 	for __rows.Next() {
-		group := &Group{}
-		err = __rows.Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name)
+		attribute := &Attribute{}
+		err = __rows.Scan(&attribute.Pk, &attribute.Created, &attribute.Name, &attribute.Type, &attribute.IsRequired, &attribute.IsUnique, &attribute.EnumValues)
 		if err != nil {
 			return nil, obj.makeErr(err)
 		}
-		rows = append(rows, group)
+		rows = append(rows, attribute)
 	}
 	if err := __rows.Err(); err != nil {
 		return nil, obj.makeErr(err)
@@ -1327,47 +4062,20 @@ func (obj *postgresImpl) All_Group_By_User_Id(ctx context.Context,
 
 }
 
-func (obj *postgresImpl) Update_User_By_Id(ctx context.Context,
-	user_id User_Id_Field,
-	update User_Update_Fields) (
+func (obj *sqlite3Impl) Find_User_By_Id(ctx context.Context,
+	user_id User_Id_Field) (
 	user *User, err error) {
-	var __sets = &__sqlbundle_Hole{}
 
-	var __embed_stmt = __sqlbundle_Literals{Join: "", SQLs: []__sqlbundle_SQL{__sqlbundle_Literal("UPDATE users SET "), __sets, __sqlbundle_Literal(" WHERE users.id = ? RETURNING users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name")}}
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status FROM users WHERE users.id = ?")
 
-	__sets_sql := __sqlbundle_Literals{Join: ", "}
 	var __values []interface{}
-	var __args []interface{}
-
-	if update.Id._set {
-		__values = append(__values, update.Id.value())
-		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("id = ?"))
-	}
-
-	if update.FirstName._set {
-		__values = append(__values, update.FirstName.value())
-		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("first_name = ?"))
-	}
-
-	if update.LastName._set {
-		__values = append(__values, update.LastName.value())
-		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("last_name = ?"))
-	}
-
-	if len(__sets_sql.SQLs) == 0 {
-		return nil, emptyUpdate()
-	}
-
-	__args = append(__args, user_id.value())
-
-	__values = append(__values, __args...)
-	__sets.SQL = __sets_sql
+	__values = append(__values, user_id.value())
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, __values...)
 
 	user = &User{}
-	err = obj.driver.QueryRow(__stmt, __values...).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName)
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -1375,13 +4083,14 @@ func (obj *postgresImpl) Update_User_By_Id(ctx context.Context,
 		return nil, obj.makeErr(err)
 	}
 	return user, nil
+
 }
 
-func (obj *postgresImpl) Delete_User_By_Id(ctx context.Context,
+func (obj *sqlite3Impl) Get_User_By_Id(ctx context.Context,
 	user_id User_Id_Field) (
-	deleted bool, err error) {
+	user *User, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("DELETE FROM users WHERE users.id = ?")
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status FROM users WHERE users.id = ?")
 
 	var __values []interface{}
 	__values = append(__values, user_id.value())
@@ -1389,64 +4098,99 @@ func (obj *postgresImpl) Delete_User_By_Id(ctx context.Context,
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, __values...)
 
-	__res, err := obj.driver.Exec(__stmt, __values...)
+	user = &User{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
 	if err != nil {
-		return false, obj.makeErr(err)
+		return nil, obj.makeErr(err)
 	}
+	return user, nil
 
-	__count, err := __res.RowsAffected()
+}
+
+func (obj *sqlite3Impl) Find_User_By_Uuid(ctx context.Context,
+	user_uuid User_Uuid_Field) (
+	user *User, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status FROM users WHERE users.uuid = ?")
+
+	var __values []interface{}
+	__values = append(__values, user_uuid.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	user = &User{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
-		return false, obj.makeErr(err)
+		return nil, obj.makeErr(err)
 	}
-
-	return __count > 0, nil
+	return user, nil
 
 }
 
-func (obj *postgresImpl) Delete_Group_By_Name(ctx context.Context,
-	group_name Group_Name_Field) (
-	deleted bool, err error) {
+func (obj *sqlite3Impl) Paged_User(ctx context.Context,
+	limit int, ctoken string) (
+	rows []*User, ctokenout string, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("DELETE FROM groups WHERE groups.name = ?")
+	if ctoken == "" {
+		ctoken = "0"
+	}
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status, users.pk FROM users WHERE users.pk > ? ORDER BY users.pk LIMIT ?")
 
 	var __values []interface{}
-	__values = append(__values, group_name.value())
+	__values = append(__values)
+
+	__values = append(__values, ctoken, limit)
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, __values...)
 
-	__res, err := obj.driver.Exec(__stmt, __values...)
+	__rows, err := obj.driver.Query(__stmt, __values...)
 	if err != nil {
-		return false, obj.makeErr(err)
+		return nil, "", obj.makeErr(err)
 	}
+	defer __rows.Close()
 
-	__count, err := __res.RowsAffected()
-	if err != nil {
-		return false, obj.makeErr(err)
+	__pk := int64(0)
+	for __rows.Next() {
+		user := &User{}
+		err = __rows.Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status, &__pk)
+		if err != nil {
+			return nil, "", obj.makeErr(err)
+		}
+		rows = append(rows, user)
+	}
+	if err := __rows.Err(); err != nil {
+		return nil, "", obj.makeErr(err)
+	}
+
+	if limit > 0 {
+		if len(rows) == limit {
+			ctokenout = fmt.Sprint(__pk)
+		}
+	} else {
+		ctokenout = ctoken
 	}
 
-	return __count > 0, nil
+	return rows, ctokenout, nil
 
 }
 
-func (obj *postgresImpl) Delete_Membership_By_User_Id(ctx context.Context,
-	user_id User_Id_Field) (
+func (obj *sqlite3Impl) Count_User(ctx context.Context) (
 	count int64, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("DELETE FROM memberships WHERE memberships.pk IN (SELECT memberships.pk FROM memberships  JOIN users ON memberships.user_pk = users.pk WHERE users.id = ?)")
+	var __embed_stmt = __sqlbundle_Literal("SELECT COUNT(*) FROM users")
 
 	var __values []interface{}
-	__values = append(__values, user_id.value())
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, __values...)
 
-	__res, err := obj.driver.Exec(__stmt, __values...)
-	if err != nil {
-		return 0, obj.makeErr(err)
-	}
-
-	count, err = __res.RowsAffected()
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&count)
 	if err != nil {
 		return 0, obj.makeErr(err)
 	}
@@ -1455,219 +4199,210 @@ func (obj *postgresImpl) Delete_Membership_By_User_Id(ctx context.Context,
 
 }
 
-func (obj *postgresImpl) Delete_Membership_By_Group_Name(ctx context.Context,
-	group_name Group_Name_Field) (
-	count int64, err error) {
+func (obj *sqlite3Impl) Limited_User_OrderBy_Asc_Pk(ctx context.Context,
+	limit int, offset int64) (
+	rows []*User, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("DELETE FROM memberships WHERE memberships.pk IN (SELECT memberships.pk FROM memberships  JOIN groups ON memberships.group_pk = groups.pk WHERE groups.name = ?)")
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status FROM users ORDER BY users.pk LIMIT ? OFFSET ?")
 
 	var __values []interface{}
-	__values = append(__values, group_name.value())
+
+	__values = append(__values, limit, offset)
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, __values...)
 
-	__res, err := obj.driver.Exec(__stmt, __values...)
+	__rows, err := obj.driver.Query(__stmt, __values...)
 	if err != nil {
-		return 0, obj.makeErr(err)
+		return nil, obj.makeErr(err)
 	}
+	defer __rows.Close()
 
-	count, err = __res.RowsAffected()
-	if err != nil {
-		return 0, obj.makeErr(err)
+	for __rows.Next() {
+		user := &User{}
+		err = __rows.Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
+		if err != nil {
+			return nil, obj.makeErr(err)
+		}
+		rows = append(rows, user)
 	}
-
-	return count, nil
+	if err := __rows.Err(); err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return rows, nil
 
 }
 
-func (impl postgresImpl) isConstraintError(err error) (
-	constraint string, ok bool) {
-	if e, ok := err.(*pq.Error); ok {
-		if e.Code.Class() == "23" {
-			return e.Constraint, true
-		}
-	}
-	return "", false
-}
+func (obj *sqlite3Impl) Limited_User_By_Pk_Less_OrderBy_Desc_Pk(ctx context.Context,
+	user_pk_less User_Pk_Field,
+	limit int, offset int64) (
+	rows []*User, err error) {
 
-func (obj *postgresImpl) deleteAll(ctx context.Context) (count int64, err error) {
-	var __res sql.Result
-	var __count int64
-	__res, err = obj.driver.Exec("DELETE FROM memberships;")
-	if err != nil {
-		return 0, obj.makeErr(err)
-	}
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status FROM users WHERE users.pk < ? ORDER BY users.pk DESC LIMIT ? OFFSET ?")
 
-	__count, err = __res.RowsAffected()
-	if err != nil {
-		return 0, obj.makeErr(err)
-	}
-	count += __count
-	__res, err = obj.driver.Exec("DELETE FROM users;")
-	if err != nil {
-		return 0, obj.makeErr(err)
-	}
+	var __values []interface{}
+	__values = append(__values, user_pk_less.value())
 
-	__count, err = __res.RowsAffected()
-	if err != nil {
-		return 0, obj.makeErr(err)
-	}
-	count += __count
-	__res, err = obj.driver.Exec("DELETE FROM groups;")
-	if err != nil {
-		return 0, obj.makeErr(err)
-	}
+	__values = append(__values, limit, offset)
 
-	__count, err = __res.RowsAffected()
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__rows, err := obj.driver.Query(__stmt, __values...)
 	if err != nil {
-		return 0, obj.makeErr(err)
+		return nil, obj.makeErr(err)
 	}
-	count += __count
+	defer __rows.Close()
 
-	return count, nil
+	for __rows.Next() {
+		user := &User{}
+		err = __rows.Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
+		if err != nil {
+			return nil, obj.makeErr(err)
+		}
+		rows = append(rows, user)
+	}
+	if err := __rows.Err(); err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return rows, nil
 
 }
 
-func (obj *sqlite3Impl) Create_User(ctx context.Context,
-	user_uuid User_Uuid_Field,
-	user_id User_Id_Field,
-	user_first_name User_FirstName_Field,
-	user_last_name User_LastName_Field) (
-	user *User, err error) {
+func (obj *sqlite3Impl) Limited_User_By_Pk_Greater_OrderBy_Asc_Pk(ctx context.Context,
+	user_pk_greater User_Pk_Field,
+	limit int, offset int64) (
+	rows []*User, err error) {
 
-	__now := obj.db.Hooks.Now().UTC()
-	__uuid_val := user_uuid.value()
-	__created_val := __now.UTC()
-	__id_val := user_id.value()
-	__first_name_val := user_first_name.value()
-	__last_name_val := user_last_name.value()
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status FROM users WHERE users.pk > ? ORDER BY users.pk LIMIT ? OFFSET ?")
 
-	var __embed_stmt = __sqlbundle_Literal("INSERT INTO users ( uuid, created, id, first_name, last_name ) VALUES ( ?, ?, ?, ?, ? )")
+	var __values []interface{}
+	__values = append(__values, user_pk_greater.value())
+
+	__values = append(__values, limit, offset)
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
-	obj.logStmt(__stmt, __uuid_val, __created_val, __id_val, __first_name_val, __last_name_val)
+	obj.logStmt(__stmt, __values...)
 
-	__res, err := obj.driver.Exec(__stmt, __uuid_val, __created_val, __id_val, __first_name_val, __last_name_val)
+	__rows, err := obj.driver.Query(__stmt, __values...)
 	if err != nil {
 		return nil, obj.makeErr(err)
 	}
-	__pk, err := __res.LastInsertId()
-	if err != nil {
+	defer __rows.Close()
+
+	for __rows.Next() {
+		user := &User{}
+		err = __rows.Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
+		if err != nil {
+			return nil, obj.makeErr(err)
+		}
+		rows = append(rows, user)
+	}
+	if err := __rows.Err(); err != nil {
 		return nil, obj.makeErr(err)
 	}
-	return obj.getLastUser(ctx, __pk)
+	return rows, nil
 
 }
 
-func (obj *sqlite3Impl) Create_Group(ctx context.Context,
-	group_uuid Group_Uuid_Field,
+func (obj *sqlite3Impl) Has_Group_By_Name(ctx context.Context,
 	group_name Group_Name_Field) (
-	group *Group, err error) {
+	has bool, err error) {
 
-	__now := obj.db.Hooks.Now().UTC()
-	__uuid_val := group_uuid.value()
-	__created_val := __now.UTC()
-	__name_val := group_name.value()
+	var __embed_stmt = __sqlbundle_Literal("SELECT EXISTS( SELECT 1 FROM groups WHERE groups.name = ? )")
 
-	var __embed_stmt = __sqlbundle_Literal("INSERT INTO groups ( uuid, created, name ) VALUES ( ?, ?, ? )")
+	var __values []interface{}
+	__values = append(__values, group_name.value())
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
-	obj.logStmt(__stmt, __uuid_val, __created_val, __name_val)
+	obj.logStmt(__stmt, __values...)
 
-	__res, err := obj.driver.Exec(__stmt, __uuid_val, __created_val, __name_val)
-	if err != nil {
-		return nil, obj.makeErr(err)
-	}
-	__pk, err := __res.LastInsertId()
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&has)
 	if err != nil {
-		return nil, obj.makeErr(err)
+		return false, obj.makeErr(err)
 	}
-	return obj.getLastGroup(ctx, __pk)
+	return has, nil
 
 }
 
-func (obj *sqlite3Impl) Create_Membership(ctx context.Context,
-	membership_user_pk Membership_UserPk_Field,
-	membership_group_pk Membership_GroupPk_Field) (
-	membership *Membership, err error) {
+func (obj *sqlite3Impl) Find_Group_By_Name(ctx context.Context,
+	group_name Group_Name_Field) (
+	group *Group, err error) {
 
-	__now := obj.db.Hooks.Now().UTC()
-	__created_val := __now.UTC()
-	__user_pk_val := membership_user_pk.value()
-	__group_pk_val := membership_group_pk.value()
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels FROM groups WHERE groups.name = ?")
 
-	var __embed_stmt = __sqlbundle_Literal("INSERT INTO memberships ( created, user_pk, group_pk ) VALUES ( ?, ?, ? )")
+	var __values []interface{}
+	__values = append(__values, group_name.value())
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
-	obj.logStmt(__stmt, __created_val, __user_pk_val, __group_pk_val)
+	obj.logStmt(__stmt, __values...)
 
-	__res, err := obj.driver.Exec(__stmt, __created_val, __user_pk_val, __group_pk_val)
-	if err != nil {
-		return nil, obj.makeErr(err)
+	group = &Group{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	__pk, err := __res.LastInsertId()
 	if err != nil {
 		return nil, obj.makeErr(err)
 	}
-	return obj.getLastMembership(ctx, __pk)
+	return group, nil
 
 }
 
-func (obj *sqlite3Impl) Find_User_By_Id(ctx context.Context,
-	user_id User_Id_Field) (
-	user *User, err error) {
+func (obj *sqlite3Impl) Get_Group_By_Name(ctx context.Context,
+	group_name Group_Name_Field) (
+	group *Group, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name FROM users WHERE users.id = ?")
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels FROM groups WHERE groups.name = ?")
 
 	var __values []interface{}
-	__values = append(__values, user_id.value())
+	__values = append(__values, group_name.value())
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, __values...)
 
-	user = &User{}
-	err = obj.driver.QueryRow(__stmt, __values...).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
+	group = &Group{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
 	if err != nil {
 		return nil, obj.makeErr(err)
 	}
-	return user, nil
+	return group, nil
 
 }
 
-func (obj *sqlite3Impl) Get_User_By_Id(ctx context.Context,
-	user_id User_Id_Field) (
-	user *User, err error) {
+func (obj *sqlite3Impl) Find_Group_By_Uuid(ctx context.Context,
+	group_uuid Group_Uuid_Field) (
+	group *Group, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name FROM users WHERE users.id = ?")
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels FROM groups WHERE groups.uuid = ?")
 
 	var __values []interface{}
-	__values = append(__values, user_id.value())
+	__values = append(__values, group_uuid.value())
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, __values...)
 
-	user = &User{}
-	err = obj.driver.QueryRow(__stmt, __values...).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName)
+	group = &Group{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, obj.makeErr(err)
 	}
-	return user, nil
+	return group, nil
 
 }
 
-func (obj *sqlite3Impl) Paged_User(ctx context.Context,
+func (obj *sqlite3Impl) Paged_Group(ctx context.Context,
 	limit int, ctoken string) (
-	rows []*User, ctokenout string, err error) {
+	rows []*Group, ctokenout string, err error) {
 
 	if ctoken == "" {
 		ctoken = "0"
 	}
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.pk FROM users WHERE users.pk > ? ORDER BY users.pk LIMIT ?")
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels, groups.pk FROM groups WHERE groups.pk > ? ORDER BY groups.pk LIMIT ?")
 
 	var __values []interface{}
 	__values = append(__values)
@@ -1685,12 +4420,12 @@ func (obj *sqlite3Impl) Paged_User(ctx context.Context,
 
 	__pk := int64(0)
 	for __rows.Next() {
-		user := &User{}
-		err = __rows.Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &__pk)
+		group := &Group{}
+		err = __rows.Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels, &__pk)
 		if err != nil {
 			return nil, "", obj.makeErr(err)
 		}
-		rows = append(rows, user)
+		rows = append(rows, group)
 	}
 	if err := __rows.Err(); err != nil {
 		return nil, "", obj.makeErr(err)
@@ -1708,117 +4443,128 @@ func (obj *sqlite3Impl) Paged_User(ctx context.Context,
 
 }
 
-func (obj *sqlite3Impl) Has_Group_By_Name(ctx context.Context,
-	group_name Group_Name_Field) (
-	has bool, err error) {
+func (obj *sqlite3Impl) Count_Group(ctx context.Context) (
+	count int64, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT EXISTS( SELECT 1 FROM groups WHERE groups.name = ? )")
+	var __embed_stmt = __sqlbundle_Literal("SELECT COUNT(*) FROM groups")
 
 	var __values []interface{}
-	__values = append(__values, group_name.value())
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, __values...)
 
-	err = obj.driver.QueryRow(__stmt, __values...).Scan(&has)
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&count)
 	if err != nil {
-		return false, obj.makeErr(err)
+		return 0, obj.makeErr(err)
 	}
-	return has, nil
+
+	return count, nil
 
 }
 
-func (obj *sqlite3Impl) Find_Group_By_Name(ctx context.Context,
-	group_name Group_Name_Field) (
-	group *Group, err error) {
+func (obj *sqlite3Impl) Limited_Group_OrderBy_Asc_Pk(ctx context.Context,
+	limit int, offset int64) (
+	rows []*Group, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name FROM groups WHERE groups.name = ?")
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels FROM groups ORDER BY groups.pk LIMIT ? OFFSET ?")
 
 	var __values []interface{}
-	__values = append(__values, group_name.value())
+
+	__values = append(__values, limit, offset)
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, __values...)
 
-	group = &Group{}
-	err = obj.driver.QueryRow(__stmt, __values...).Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
+	__rows, err := obj.driver.Query(__stmt, __values...)
 	if err != nil {
 		return nil, obj.makeErr(err)
 	}
-	return group, nil
+	defer __rows.Close()
+
+	for __rows.Next() {
+		group := &Group{}
+		err = __rows.Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
+		if err != nil {
+			return nil, obj.makeErr(err)
+		}
+		rows = append(rows, group)
+	}
+	if err := __rows.Err(); err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return rows, nil
 
 }
 
-func (obj *sqlite3Impl) Get_Group_By_Name(ctx context.Context,
-	group_name Group_Name_Field) (
-	group *Group, err error) {
+func (obj *sqlite3Impl) Limited_Group_By_Pk_Less_OrderBy_Desc_Pk(ctx context.Context,
+	group_pk_less Group_Pk_Field,
+	limit int, offset int64) (
+	rows []*Group, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name FROM groups WHERE groups.name = ?")
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels FROM groups WHERE groups.pk < ? ORDER BY groups.pk DESC LIMIT ? OFFSET ?")
 
 	var __values []interface{}
-	__values = append(__values, group_name.value())
+	__values = append(__values, group_pk_less.value())
+
+	__values = append(__values, limit, offset)
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, __values...)
 
-	group = &Group{}
-	err = obj.driver.QueryRow(__stmt, __values...).Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name)
-	if err != nil {
+	__rows, err := obj.driver.Query(__stmt, __values...)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	defer __rows.Close()
+
+	for __rows.Next() {
+		group := &Group{}
+		err = __rows.Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
+		if err != nil {
+			return nil, obj.makeErr(err)
+		}
+		rows = append(rows, group)
+	}
+	if err := __rows.Err(); err != nil {
 		return nil, obj.makeErr(err)
 	}
-	return group, nil
+	return rows, nil
 
 }
 
-func (obj *sqlite3Impl) Paged_Group(ctx context.Context,
-	limit int, ctoken string) (
-	rows []*Group, ctokenout string, err error) {
-
-	if ctoken == "" {
-		ctoken = "0"
-	}
+func (obj *sqlite3Impl) Limited_Group_By_Pk_Greater_OrderBy_Asc_Pk(ctx context.Context,
+	group_pk_greater Group_Pk_Field,
+	limit int, offset int64) (
+	rows []*Group, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.pk FROM groups WHERE groups.pk > ? ORDER BY groups.pk LIMIT ?")
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels FROM groups WHERE groups.pk > ? ORDER BY groups.pk LIMIT ? OFFSET ?")
 
 	var __values []interface{}
-	__values = append(__values)
+	__values = append(__values, group_pk_greater.value())
 
-	__values = append(__values, ctoken, limit)
+	__values = append(__values, limit, offset)
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, __values...)
 
 	__rows, err := obj.driver.Query(__stmt, __values...)
 	if err != nil {
-		return nil, "", obj.makeErr(err)
+		return nil, obj.makeErr(err)
 	}
 	defer __rows.Close()
 
-	__pk := int64(0)
 	for __rows.Next() {
 		group := &Group{}
-		err = __rows.Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &__pk)
+		err = __rows.Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
 		if err != nil {
-			return nil, "", obj.makeErr(err)
+			return nil, obj.makeErr(err)
 		}
 		rows = append(rows, group)
 	}
 	if err := __rows.Err(); err != nil {
-		return nil, "", obj.makeErr(err)
-	}
-
-	if limit > 0 {
-		if len(rows) == limit {
-			ctokenout = fmt.Sprint(__pk)
-		}
-	} else {
-		ctokenout = ctoken
+		return nil, obj.makeErr(err)
 	}
-
-	return rows, ctokenout, nil
+	return rows, nil
 
 }
 
@@ -1826,7 +4572,7 @@ func (obj *sqlite3Impl) All_User_By_Group_Name(ctx context.Context,
 	group_name Group_Name_Field) (
 	rows []*User, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name FROM users  JOIN memberships ON users.pk = memberships.user_pk  JOIN groups ON memberships.group_pk = groups.pk WHERE groups.name = ?")
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status FROM users  JOIN memberships ON users.pk = memberships.user_pk  JOIN groups ON memberships.group_pk = groups.pk WHERE groups.name = ?")
 
 	var __values []interface{}
 	__values = append(__values, group_name.value())
@@ -1842,7 +4588,7 @@ func (obj *sqlite3Impl) All_User_By_Group_Name(ctx context.Context,
 
 	for __rows.Next() {
 		user := &User{}
-		err = __rows.Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName)
+		err = __rows.Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
 		if err != nil {
 			return nil, obj.makeErr(err)
 		}
@@ -1859,7 +4605,7 @@ func (obj *sqlite3Impl) All_Group_By_User_Id(ctx context.Context,
 	user_id User_Id_Field) (
 	rows []*Group, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name FROM groups  JOIN memberships ON groups.pk = memberships.group_pk  JOIN users ON memberships.user_pk = users.pk WHERE users.id = ?")
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels FROM groups  JOIN memberships ON groups.pk = memberships.group_pk  JOIN users ON memberships.user_pk = users.pk WHERE users.id = ?")
 
 	var __values []interface{}
 	__values = append(__values, user_id.value())
@@ -1875,7 +4621,7 @@ func (obj *sqlite3Impl) All_Group_By_User_Id(ctx context.Context,
 
 	for __rows.Next() {
 		group := &Group{}
-		err = __rows.Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name)
+		err = __rows.Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
 		if err != nil {
 			return nil, obj.makeErr(err)
 		}
@@ -1888,6 +4634,30 @@ func (obj *sqlite3Impl) All_Group_By_User_Id(ctx context.Context,
 
 }
 
+func (obj *sqlite3Impl) Find_IdempotentRequest_By_IdempotencyKey(ctx context.Context,
+	idempotent_request_idempotency_key IdempotentRequest_IdempotencyKey_Field) (
+	idempotent_request *IdempotentRequest, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT idempotent_requests.pk, idempotent_requests.created, idempotent_requests.idempotency_key, idempotent_requests.request_hash, idempotent_requests.status, idempotent_requests.headers, idempotent_requests.body, idempotent_requests.expires FROM idempotent_requests WHERE idempotent_requests.idempotency_key = ?")
+
+	var __values []interface{}
+	__values = append(__values, idempotent_request_idempotency_key.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	idempotent_request = &IdempotentRequest{}
+	err = obj.driver.QueryRow(__stmt, __values...).Scan(&idempotent_request.Pk, &idempotent_request.Created, &idempotent_request.IdempotencyKey, &idempotent_request.RequestHash, &idempotent_request.Status, &idempotent_request.Headers, &idempotent_request.Body, &idempotent_request.Expires)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return idempotent_request, nil
+
+}
+
 func (obj *sqlite3Impl) Update_User_By_Id(ctx context.Context,
 	user_id User_Id_Field,
 	update User_Update_Fields) (
@@ -1915,6 +4685,11 @@ func (obj *sqlite3Impl) Update_User_By_Id(ctx context.Context,
 		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("last_name = ?"))
 	}
 
+	if update.Status._set {
+		__values = append(__values, update.Status.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("status = ?"))
+	}
+
 	if len(__sets_sql.SQLs) == 0 {
 		return nil, emptyUpdate()
 	}
@@ -1933,12 +4708,12 @@ func (obj *sqlite3Impl) Update_User_By_Id(ctx context.Context,
 		return nil, obj.makeErr(err)
 	}
 
-	var __embed_stmt_get = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name FROM users WHERE users.id = ?")
+	var __embed_stmt_get = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status FROM users WHERE users.id = ?")
 
 	var __stmt_get = __sqlbundle_Render(obj.dialect, __embed_stmt_get)
 	obj.logStmt("(IMPLIED) "+__stmt_get, __args...)
 
-	err = obj.driver.QueryRow(__stmt_get, __args...).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName)
+	err = obj.driver.QueryRow(__stmt_get, __args...).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -1948,6 +4723,162 @@ func (obj *sqlite3Impl) Update_User_By_Id(ctx context.Context,
 	return user, nil
 }
 
+func (obj *sqlite3Impl) Update_Group_By_Name(ctx context.Context,
+	group_name Group_Name_Field,
+	update Group_Update_Fields) (
+	group *Group, err error) {
+	var __sets = &__sqlbundle_Hole{}
+
+	var __embed_stmt = __sqlbundle_Literals{Join: "", SQLs: []__sqlbundle_SQL{__sqlbundle_Literal("UPDATE groups SET "), __sets, __sqlbundle_Literal(" WHERE groups.name = ?")}}
+
+	__sets_sql := __sqlbundle_Literals{Join: ", "}
+	var __values []interface{}
+	var __args []interface{}
+
+	if update.Name._set {
+		__values = append(__values, update.Name.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("name = ?"))
+	}
+
+	if update.Description._set {
+		__values = append(__values, update.Description.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("description = ?"))
+	}
+
+	if update.DisplayName._set {
+		__values = append(__values, update.DisplayName.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("display_name = ?"))
+	}
+
+	if update.Owner._set {
+		__values = append(__values, update.Owner.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("owner = ?"))
+	}
+
+	if update.Labels._set {
+		__values = append(__values, update.Labels.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("labels = ?"))
+	}
+
+	if len(__sets_sql.SQLs) == 0 {
+		return nil, emptyUpdate()
+	}
+
+	__args = append(__args, group_name.value())
+
+	__values = append(__values, __args...)
+	__sets.SQL = __sets_sql
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	group = &Group{}
+	_, err = obj.driver.Exec(__stmt, __values...)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+
+	var __embed_stmt_get = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels FROM groups WHERE groups.name = ?")
+
+	var __stmt_get = __sqlbundle_Render(obj.dialect, __embed_stmt_get)
+	obj.logStmt("(IMPLIED) "+__stmt_get, __args...)
+
+	err = obj.driver.QueryRow(__stmt_get, __args...).Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return group, nil
+}
+
+func (obj *sqlite3Impl) Update_IdempotentRequest_By_IdempotencyKey(ctx context.Context,
+	idempotent_request_idempotency_key IdempotentRequest_IdempotencyKey_Field,
+	update IdempotentRequest_Update_Fields) (
+	idempotent_request *IdempotentRequest, err error) {
+	var __sets = &__sqlbundle_Hole{}
+
+	var __embed_stmt = __sqlbundle_Literals{Join: "", SQLs: []__sqlbundle_SQL{__sqlbundle_Literal("UPDATE idempotent_requests SET "), __sets, __sqlbundle_Literal(" WHERE idempotent_requests.idempotency_key = ?")}}
+
+	__sets_sql := __sqlbundle_Literals{Join: ", "}
+	var __values []interface{}
+	var __args []interface{}
+
+	if update.Status._set {
+		__values = append(__values, update.Status.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("status = ?"))
+	}
+
+	if update.Headers._set {
+		__values = append(__values, update.Headers.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("headers = ?"))
+	}
+
+	if update.Body._set {
+		__values = append(__values, update.Body.value())
+		__sets_sql.SQLs = append(__sets_sql.SQLs, __sqlbundle_Literal("body = ?"))
+	}
+
+	if len(__sets_sql.SQLs) == 0 {
+		return nil, emptyUpdate()
+	}
+
+	__args = append(__args, idempotent_request_idempotency_key.value())
+
+	__values = append(__values, __args...)
+	__sets.SQL = __sets_sql
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	idempotent_request = &IdempotentRequest{}
+	_, err = obj.driver.Exec(__stmt, __values...)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+
+	var __embed_stmt_get = __sqlbundle_Literal("SELECT idempotent_requests.pk, idempotent_requests.created, idempotent_requests.idempotency_key, idempotent_requests.request_hash, idempotent_requests.status, idempotent_requests.headers, idempotent_requests.body, idempotent_requests.expires FROM idempotent_requests WHERE idempotent_requests.idempotency_key = ?")
+
+	var __stmt_get = __sqlbundle_Render(obj.dialect, __embed_stmt_get)
+	obj.logStmt("(IMPLIED) "+__stmt_get, __args...)
+
+	err = obj.driver.QueryRow(__stmt_get, __args...).Scan(&idempotent_request.Pk, &idempotent_request.Created, &idempotent_request.IdempotencyKey, &idempotent_request.RequestHash, &idempotent_request.Status, &idempotent_request.Headers, &idempotent_request.Body, &idempotent_request.Expires)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return idempotent_request, nil
+}
+
+func (obj *sqlite3Impl) Delete_Attribute_By_Name(ctx context.Context,
+	attribute_name Attribute_Name_Field) (
+	deleted bool, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("DELETE FROM attributes WHERE attributes.name = ?")
+
+	var __values []interface{}
+	__values = append(__values, attribute_name.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__res, err := obj.driver.Exec(__stmt, __values...)
+	if err != nil {
+		return false, obj.makeErr(err)
+	}
+
+	__count, err := __res.RowsAffected()
+	if err != nil {
+		return false, obj.makeErr(err)
+	}
+
+	return __count > 0, nil
+
+}
+
 func (obj *sqlite3Impl) Delete_User_By_Id(ctx context.Context,
 	user_id User_Id_Field) (
 	deleted bool, err error) {
@@ -2052,17 +4983,87 @@ func (obj *sqlite3Impl) Delete_Membership_By_Group_Name(ctx context.Context,
 
 }
 
+func (obj *sqlite3Impl) Delete_IdempotentRequest_By_IdempotencyKey(ctx context.Context,
+	idempotent_request_idempotency_key IdempotentRequest_IdempotencyKey_Field) (
+	deleted bool, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("DELETE FROM idempotent_requests WHERE idempotent_requests.idempotency_key = ?")
+
+	var __values []interface{}
+	__values = append(__values, idempotent_request_idempotency_key.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__res, err := obj.driver.Exec(__stmt, __values...)
+	if err != nil {
+		return false, obj.makeErr(err)
+	}
+
+	__count, err := __res.RowsAffected()
+	if err != nil {
+		return false, obj.makeErr(err)
+	}
+
+	return __count > 0, nil
+
+}
+
+func (obj *sqlite3Impl) Delete_IdempotentRequest_By_Expires_Less(ctx context.Context,
+	idempotent_request_expires_less IdempotentRequest_Expires_Field) (
+	count int64, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("DELETE FROM idempotent_requests WHERE idempotent_requests.expires < ?")
+
+	var __values []interface{}
+	__values = append(__values, idempotent_request_expires_less.value())
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, __values...)
+
+	__res, err := obj.driver.Exec(__stmt, __values...)
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	return count, nil
+
+}
+
+func (obj *sqlite3Impl) getLastAttribute(ctx context.Context,
+	pk int64) (
+	attribute *Attribute, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT attributes.pk, attributes.created, attributes.name, attributes.type, attributes.is_required, attributes.is_unique, attributes.enum_values FROM attributes WHERE _rowid_ = ?")
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, pk)
+
+	attribute = &Attribute{}
+	err = obj.driver.QueryRow(__stmt, pk).Scan(&attribute.Pk, &attribute.Created, &attribute.Name, &attribute.Type, &attribute.IsRequired, &attribute.IsUnique, &attribute.EnumValues)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return attribute, nil
+
+}
+
 func (obj *sqlite3Impl) getLastUser(ctx context.Context,
 	pk int64) (
 	user *User, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name FROM users WHERE _rowid_ = ?")
+	var __embed_stmt = __sqlbundle_Literal("SELECT users.pk, users.uuid, users.created, users.id, users.first_name, users.last_name, users.status FROM users WHERE _rowid_ = ?")
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, pk)
 
 	user = &User{}
-	err = obj.driver.QueryRow(__stmt, pk).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName)
+	err = obj.driver.QueryRow(__stmt, pk).Scan(&user.Pk, &user.Uuid, &user.Created, &user.Id, &user.FirstName, &user.LastName, &user.Status)
 	if err != nil {
 		return nil, obj.makeErr(err)
 	}
@@ -2070,17 +5071,35 @@ func (obj *sqlite3Impl) getLastUser(ctx context.Context,
 
 }
 
+func (obj *sqlite3Impl) getLastUserTransition(ctx context.Context,
+	pk int64) (
+	user_transition *UserTransition, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT user_transitions.pk, user_transitions.created, user_transitions.user_pk, user_transitions.from_status, user_transitions.to_status, user_transitions.reason, user_transitions.principal FROM user_transitions WHERE _rowid_ = ?")
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, pk)
+
+	user_transition = &UserTransition{}
+	err = obj.driver.QueryRow(__stmt, pk).Scan(&user_transition.Pk, &user_transition.Created, &user_transition.UserPk, &user_transition.FromStatus, &user_transition.ToStatus, &user_transition.Reason, &user_transition.Principal)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return user_transition, nil
+
+}
+
 func (obj *sqlite3Impl) getLastGroup(ctx context.Context,
 	pk int64) (
 	group *Group, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name FROM groups WHERE _rowid_ = ?")
+	var __embed_stmt = __sqlbundle_Literal("SELECT groups.pk, groups.uuid, groups.created, groups.name, groups.description, groups.display_name, groups.owner, groups.labels FROM groups WHERE _rowid_ = ?")
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, pk)
 
 	group = &Group{}
-	err = obj.driver.QueryRow(__stmt, pk).Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name)
+	err = obj.driver.QueryRow(__stmt, pk).Scan(&group.Pk, &group.Uuid, &group.Created, &group.Name, &group.Description, &group.DisplayName, &group.Owner, &group.Labels)
 	if err != nil {
 		return nil, obj.makeErr(err)
 	}
@@ -2088,21 +5107,39 @@ func (obj *sqlite3Impl) getLastGroup(ctx context.Context,
 
 }
 
-func (obj *sqlite3Impl) getLastMembership(ctx context.Context,
+func (obj *sqlite3Impl) getLastMembership(ctx context.Context,
+	pk int64) (
+	membership *Membership, err error) {
+
+	var __embed_stmt = __sqlbundle_Literal("SELECT memberships.pk, memberships.created, memberships.user_pk, memberships.group_pk FROM memberships WHERE _rowid_ = ?")
+
+	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
+	obj.logStmt(__stmt, pk)
+
+	membership = &Membership{}
+	err = obj.driver.QueryRow(__stmt, pk).Scan(&membership.Pk, &membership.Created, &membership.UserPk, &membership.GroupPk)
+	if err != nil {
+		return nil, obj.makeErr(err)
+	}
+	return membership, nil
+
+}
+
+func (obj *sqlite3Impl) getLastIdempotentRequest(ctx context.Context,
 	pk int64) (
-	membership *Membership, err error) {
+	idempotent_request *IdempotentRequest, err error) {
 
-	var __embed_stmt = __sqlbundle_Literal("SELECT memberships.pk, memberships.created, memberships.user_pk, memberships.group_pk FROM memberships WHERE _rowid_ = ?")
+	var __embed_stmt = __sqlbundle_Literal("SELECT idempotent_requests.pk, idempotent_requests.created, idempotent_requests.idempotency_key, idempotent_requests.request_hash, idempotent_requests.status, idempotent_requests.headers, idempotent_requests.body, idempotent_requests.expires FROM idempotent_requests WHERE _rowid_ = ?")
 
 	var __stmt = __sqlbundle_Render(obj.dialect, __embed_stmt)
 	obj.logStmt(__stmt, pk)
 
-	membership = &Membership{}
-	err = obj.driver.QueryRow(__stmt, pk).Scan(&membership.Pk, &membership.Created, &membership.UserPk, &membership.GroupPk)
+	idempotent_request = &IdempotentRequest{}
+	err = obj.driver.QueryRow(__stmt, pk).Scan(&idempotent_request.Pk, &idempotent_request.Created, &idempotent_request.IdempotencyKey, &idempotent_request.RequestHash, &idempotent_request.Status, &idempotent_request.Headers, &idempotent_request.Body, &idempotent_request.Expires)
 	if err != nil {
 		return nil, obj.makeErr(err)
 	}
-	return membership, nil
+	return idempotent_request, nil
 
 }
 
@@ -2124,6 +5161,26 @@ func (impl sqlite3Impl) isConstraintError(err error) (
 func (obj *sqlite3Impl) deleteAll(ctx context.Context) (count int64, err error) {
 	var __res sql.Result
 	var __count int64
+	__res, err = obj.driver.Exec("DELETE FROM user_transitions;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.Exec("DELETE FROM user_attributes;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
 	__res, err = obj.driver.Exec("DELETE FROM memberships;")
 	if err != nil {
 		return 0, obj.makeErr(err)
@@ -2139,6 +5196,36 @@ func (obj *sqlite3Impl) deleteAll(ctx context.Context) (count int64, err error)
 		return 0, obj.makeErr(err)
 	}
 
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.Exec("DELETE FROM user_versions;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.Exec("DELETE FROM membership_versions;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.Exec("DELETE FROM idempotent_requests;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
 	__count, err = __res.RowsAffected()
 	if err != nil {
 		return 0, obj.makeErr(err)
@@ -2149,6 +5236,26 @@ func (obj *sqlite3Impl) deleteAll(ctx context.Context) (count int64, err error)
 		return 0, obj.makeErr(err)
 	}
 
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.Exec("DELETE FROM group_versions;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
+	__count, err = __res.RowsAffected()
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+	count += __count
+	__res, err = obj.driver.Exec("DELETE FROM attributes;")
+	if err != nil {
+		return 0, obj.makeErr(err)
+	}
+
 	__count, err = __res.RowsAffected()
 	if err != nil {
 		return 0, obj.makeErr(err)
@@ -2201,6 +5308,15 @@ func (rx *Rx) Rollback() (err error) {
 	return err
 }
 
+func (rx *Rx) All_Attribute_OrderBy_Asc_Name(ctx context.Context) (
+	rows []*Attribute, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.All_Attribute_OrderBy_Asc_Name(ctx)
+}
+
 func (rx *Rx) All_Group_By_User_Id(ctx context.Context,
 	user_id User_Id_Field) (
 	rows []*Group, err error) {
@@ -2211,6 +5327,16 @@ func (rx *Rx) All_Group_By_User_Id(ctx context.Context,
 	return tx.All_Group_By_User_Id(ctx, user_id)
 }
 
+func (rx *Rx) All_UserTransition_By_UserPk_OrderBy_Asc_Pk(ctx context.Context,
+	user_transition_user_pk UserTransition_UserPk_Field) (
+	rows []*UserTransition, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.All_UserTransition_By_UserPk_OrderBy_Asc_Pk(ctx, user_transition_user_pk)
+}
+
 func (rx *Rx) All_User_By_Group_Name(ctx context.Context,
 	group_name Group_Name_Field) (
 	rows []*User, err error) {
@@ -2221,15 +5347,68 @@ func (rx *Rx) All_User_By_Group_Name(ctx context.Context,
 	return tx.All_User_By_Group_Name(ctx, group_name)
 }
 
+func (rx *Rx) Count_Group(ctx context.Context) (
+	count int64, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Count_Group(ctx)
+}
+
+func (rx *Rx) Count_User(ctx context.Context) (
+	count int64, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Count_User(ctx)
+}
+
+func (rx *Rx) Create_Attribute(ctx context.Context,
+	attribute_name Attribute_Name_Field,
+	attribute_type Attribute_Type_Field,
+	attribute_is_required Attribute_IsRequired_Field,
+	attribute_is_unique Attribute_IsUnique_Field,
+	attribute_enum_values Attribute_EnumValues_Field) (
+	attribute *Attribute, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Create_Attribute(ctx, attribute_name, attribute_type, attribute_is_required, attribute_is_unique, attribute_enum_values)
+
+}
+
 func (rx *Rx) Create_Group(ctx context.Context,
 	group_uuid Group_Uuid_Field,
-	group_name Group_Name_Field) (
+	group_name Group_Name_Field,
+	group_description Group_Description_Field,
+	group_display_name Group_DisplayName_Field,
+	group_owner Group_Owner_Field,
+	group_labels Group_Labels_Field) (
 	group *Group, err error) {
 	var tx *Tx
 	if tx, err = rx.getTx(ctx); err != nil {
 		return
 	}
-	return tx.Create_Group(ctx, group_uuid, group_name)
+	return tx.Create_Group(ctx, group_uuid, group_name, group_description, group_display_name, group_owner, group_labels)
+
+}
+
+func (rx *Rx) Create_IdempotentRequest(ctx context.Context,
+	idempotent_request_idempotency_key IdempotentRequest_IdempotencyKey_Field,
+	idempotent_request_request_hash IdempotentRequest_RequestHash_Field,
+	idempotent_request_status IdempotentRequest_Status_Field,
+	idempotent_request_headers IdempotentRequest_Headers_Field,
+	idempotent_request_body IdempotentRequest_Body_Field,
+	idempotent_request_expires IdempotentRequest_Expires_Field) (
+	idempotent_request *IdempotentRequest, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Create_IdempotentRequest(ctx, idempotent_request_idempotency_key, idempotent_request_request_hash, idempotent_request_status, idempotent_request_headers, idempotent_request_body, idempotent_request_expires)
 
 }
 
@@ -2249,14 +5428,40 @@ func (rx *Rx) Create_User(ctx context.Context,
 	user_uuid User_Uuid_Field,
 	user_id User_Id_Field,
 	user_first_name User_FirstName_Field,
-	user_last_name User_LastName_Field) (
+	user_last_name User_LastName_Field,
+	user_status User_Status_Field) (
 	user *User, err error) {
 	var tx *Tx
 	if tx, err = rx.getTx(ctx); err != nil {
 		return
 	}
-	return tx.Create_User(ctx, user_uuid, user_id, user_first_name, user_last_name)
+	return tx.Create_User(ctx, user_uuid, user_id, user_first_name, user_last_name, user_status)
+
+}
+
+func (rx *Rx) Create_UserTransition(ctx context.Context,
+	user_transition_user_pk UserTransition_UserPk_Field,
+	user_transition_from_status UserTransition_FromStatus_Field,
+	user_transition_to_status UserTransition_ToStatus_Field,
+	user_transition_reason UserTransition_Reason_Field,
+	user_transition_principal UserTransition_Principal_Field) (
+	user_transition *UserTransition, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Create_UserTransition(ctx, user_transition_user_pk, user_transition_from_status, user_transition_to_status, user_transition_reason, user_transition_principal)
+
+}
 
+func (rx *Rx) Delete_Attribute_By_Name(ctx context.Context,
+	attribute_name Attribute_Name_Field) (
+	deleted bool, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Delete_Attribute_By_Name(ctx, attribute_name)
 }
 
 func (rx *Rx) Delete_Group_By_Name(ctx context.Context,
@@ -2269,6 +5474,26 @@ func (rx *Rx) Delete_Group_By_Name(ctx context.Context,
 	return tx.Delete_Group_By_Name(ctx, group_name)
 }
 
+func (rx *Rx) Delete_IdempotentRequest_By_Expires_Less(ctx context.Context,
+	idempotent_request_expires_less IdempotentRequest_Expires_Field) (
+	count int64, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Delete_IdempotentRequest_By_Expires_Less(ctx, idempotent_request_expires_less)
+}
+
+func (rx *Rx) Delete_IdempotentRequest_By_IdempotencyKey(ctx context.Context,
+	idempotent_request_idempotency_key IdempotentRequest_IdempotencyKey_Field) (
+	deleted bool, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Delete_IdempotentRequest_By_IdempotencyKey(ctx, idempotent_request_idempotency_key)
+}
+
 func (rx *Rx) Delete_Membership_By_Group_Name(ctx context.Context,
 	group_name Group_Name_Field) (
 	count int64, err error) {
@@ -2301,6 +5526,16 @@ func (rx *Rx) Delete_User_By_Id(ctx context.Context,
 	return tx.Delete_User_By_Id(ctx, user_id)
 }
 
+func (rx *Rx) Find_Attribute_By_Name(ctx context.Context,
+	attribute_name Attribute_Name_Field) (
+	attribute *Attribute, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Find_Attribute_By_Name(ctx, attribute_name)
+}
+
 func (rx *Rx) Find_Group_By_Name(ctx context.Context,
 	group_name Group_Name_Field) (
 	group *Group, err error) {
@@ -2311,6 +5546,26 @@ func (rx *Rx) Find_Group_By_Name(ctx context.Context,
 	return tx.Find_Group_By_Name(ctx, group_name)
 }
 
+func (rx *Rx) Find_Group_By_Uuid(ctx context.Context,
+	group_uuid Group_Uuid_Field) (
+	group *Group, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Find_Group_By_Uuid(ctx, group_uuid)
+}
+
+func (rx *Rx) Find_IdempotentRequest_By_IdempotencyKey(ctx context.Context,
+	idempotent_request_idempotency_key IdempotentRequest_IdempotencyKey_Field) (
+	idempotent_request *IdempotentRequest, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Find_IdempotentRequest_By_IdempotencyKey(ctx, idempotent_request_idempotency_key)
+}
+
 func (rx *Rx) Find_User_By_Id(ctx context.Context,
 	user_id User_Id_Field) (
 	user *User, err error) {
@@ -2321,6 +5576,16 @@ func (rx *Rx) Find_User_By_Id(ctx context.Context,
 	return tx.Find_User_By_Id(ctx, user_id)
 }
 
+func (rx *Rx) Find_User_By_Uuid(ctx context.Context,
+	user_uuid User_Uuid_Field) (
+	user *User, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Find_User_By_Uuid(ctx, user_uuid)
+}
+
 func (rx *Rx) Get_Group_By_Name(ctx context.Context,
 	group_name Group_Name_Field) (
 	group *Group, err error) {
@@ -2351,6 +5616,70 @@ func (rx *Rx) Has_Group_By_Name(ctx context.Context,
 	return tx.Has_Group_By_Name(ctx, group_name)
 }
 
+func (rx *Rx) Limited_Group_By_Pk_Greater_OrderBy_Asc_Pk(ctx context.Context,
+	group_pk_greater Group_Pk_Field,
+	limit int, offset int64) (
+	rows []*Group, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Limited_Group_By_Pk_Greater_OrderBy_Asc_Pk(ctx, group_pk_greater, limit, offset)
+}
+
+func (rx *Rx) Limited_Group_By_Pk_Less_OrderBy_Desc_Pk(ctx context.Context,
+	group_pk_less Group_Pk_Field,
+	limit int, offset int64) (
+	rows []*Group, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Limited_Group_By_Pk_Less_OrderBy_Desc_Pk(ctx, group_pk_less, limit, offset)
+}
+
+func (rx *Rx) Limited_Group_OrderBy_Asc_Pk(ctx context.Context,
+	limit int, offset int64) (
+	rows []*Group, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Limited_Group_OrderBy_Asc_Pk(ctx, limit, offset)
+}
+
+func (rx *Rx) Limited_User_By_Pk_Greater_OrderBy_Asc_Pk(ctx context.Context,
+	user_pk_greater User_Pk_Field,
+	limit int, offset int64) (
+	rows []*User, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Limited_User_By_Pk_Greater_OrderBy_Asc_Pk(ctx, user_pk_greater, limit, offset)
+}
+
+func (rx *Rx) Limited_User_By_Pk_Less_OrderBy_Desc_Pk(ctx context.Context,
+	user_pk_less User_Pk_Field,
+	limit int, offset int64) (
+	rows []*User, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Limited_User_By_Pk_Less_OrderBy_Desc_Pk(ctx, user_pk_less, limit, offset)
+}
+
+func (rx *Rx) Limited_User_OrderBy_Asc_Pk(ctx context.Context,
+	limit int, offset int64) (
+	rows []*User, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Limited_User_OrderBy_Asc_Pk(ctx, limit, offset)
+}
+
 func (rx *Rx) Paged_Group(ctx context.Context,
 	limit int, ctoken string) (
 	rows []*Group, ctokenout string, err error) {
@@ -2371,6 +5700,28 @@ func (rx *Rx) Paged_User(ctx context.Context,
 	return tx.Paged_User(ctx, limit, ctoken)
 }
 
+func (rx *Rx) Update_Group_By_Name(ctx context.Context,
+	group_name Group_Name_Field,
+	update Group_Update_Fields) (
+	group *Group, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Update_Group_By_Name(ctx, group_name, update)
+}
+
+func (rx *Rx) Update_IdempotentRequest_By_IdempotencyKey(ctx context.Context,
+	idempotent_request_idempotency_key IdempotentRequest_IdempotencyKey_Field,
+	update IdempotentRequest_Update_Fields) (
+	idempotent_request *IdempotentRequest, err error) {
+	var tx *Tx
+	if tx, err = rx.getTx(ctx); err != nil {
+		return
+	}
+	return tx.Update_IdempotentRequest_By_IdempotencyKey(ctx, idempotent_request_idempotency_key, update)
+}
+
 func (rx *Rx) Update_User_By_Id(ctx context.Context,
 	user_id User_Id_Field,
 	update User_Update_Fields) (
@@ -2383,19 +5734,53 @@ func (rx *Rx) Update_User_By_Id(ctx context.Context,
 }
 
 type Methods interface {
+	All_Attribute_OrderBy_Asc_Name(ctx context.Context) (
+		rows []*Attribute, err error)
+
 	All_Group_By_User_Id(ctx context.Context,
 		user_id User_Id_Field) (
 		rows []*Group, err error)
 
+	All_UserTransition_By_UserPk_OrderBy_Asc_Pk(ctx context.Context,
+		user_transition_user_pk UserTransition_UserPk_Field) (
+		rows []*UserTransition, err error)
+
 	All_User_By_Group_Name(ctx context.Context,
 		group_name Group_Name_Field) (
 		rows []*User, err error)
 
+	Count_Group(ctx context.Context) (
+		count int64, err error)
+
+	Count_User(ctx context.Context) (
+		count int64, err error)
+
+	Create_Attribute(ctx context.Context,
+		attribute_name Attribute_Name_Field,
+		attribute_type Attribute_Type_Field,
+		attribute_is_required Attribute_IsRequired_Field,
+		attribute_is_unique Attribute_IsUnique_Field,
+		attribute_enum_values Attribute_EnumValues_Field) (
+		attribute *Attribute, err error)
+
 	Create_Group(ctx context.Context,
 		group_uuid Group_Uuid_Field,
-		group_name Group_Name_Field) (
+		group_name Group_Name_Field,
+		group_description Group_Description_Field,
+		group_display_name Group_DisplayName_Field,
+		group_owner Group_Owner_Field,
+		group_labels Group_Labels_Field) (
 		group *Group, err error)
 
+	Create_IdempotentRequest(ctx context.Context,
+		idempotent_request_idempotency_key IdempotentRequest_IdempotencyKey_Field,
+		idempotent_request_request_hash IdempotentRequest_RequestHash_Field,
+		idempotent_request_status IdempotentRequest_Status_Field,
+		idempotent_request_headers IdempotentRequest_Headers_Field,
+		idempotent_request_body IdempotentRequest_Body_Field,
+		idempotent_request_expires IdempotentRequest_Expires_Field) (
+		idempotent_request *IdempotentRequest, err error)
+
 	Create_Membership(ctx context.Context,
 		membership_user_pk Membership_UserPk_Field,
 		membership_group_pk Membership_GroupPk_Field) (
@@ -2405,13 +5790,34 @@ type Methods interface {
 		user_uuid User_Uuid_Field,
 		user_id User_Id_Field,
 		user_first_name User_FirstName_Field,
-		user_last_name User_LastName_Field) (
+		user_last_name User_LastName_Field,
+		user_status User_Status_Field) (
 		user *User, err error)
 
+	Create_UserTransition(ctx context.Context,
+		user_transition_user_pk UserTransition_UserPk_Field,
+		user_transition_from_status UserTransition_FromStatus_Field,
+		user_transition_to_status UserTransition_ToStatus_Field,
+		user_transition_reason UserTransition_Reason_Field,
+		user_transition_principal UserTransition_Principal_Field) (
+		user_transition *UserTransition, err error)
+
+	Delete_Attribute_By_Name(ctx context.Context,
+		attribute_name Attribute_Name_Field) (
+		deleted bool, err error)
+
 	Delete_Group_By_Name(ctx context.Context,
 		group_name Group_Name_Field) (
 		deleted bool, err error)
 
+	Delete_IdempotentRequest_By_Expires_Less(ctx context.Context,
+		idempotent_request_expires_less IdempotentRequest_Expires_Field) (
+		count int64, err error)
+
+	Delete_IdempotentRequest_By_IdempotencyKey(ctx context.Context,
+		idempotent_request_idempotency_key IdempotentRequest_IdempotencyKey_Field) (
+		deleted bool, err error)
+
 	Delete_Membership_By_Group_Name(ctx context.Context,
 		group_name Group_Name_Field) (
 		count int64, err error)
@@ -2424,14 +5830,30 @@ type Methods interface {
 		user_id User_Id_Field) (
 		deleted bool, err error)
 
+	Find_Attribute_By_Name(ctx context.Context,
+		attribute_name Attribute_Name_Field) (
+		attribute *Attribute, err error)
+
 	Find_Group_By_Name(ctx context.Context,
 		group_name Group_Name_Field) (
 		group *Group, err error)
 
+	Find_Group_By_Uuid(ctx context.Context,
+		group_uuid Group_Uuid_Field) (
+		group *Group, err error)
+
+	Find_IdempotentRequest_By_IdempotencyKey(ctx context.Context,
+		idempotent_request_idempotency_key IdempotentRequest_IdempotencyKey_Field) (
+		idempotent_request *IdempotentRequest, err error)
+
 	Find_User_By_Id(ctx context.Context,
 		user_id User_Id_Field) (
 		user *User, err error)
 
+	Find_User_By_Uuid(ctx context.Context,
+		user_uuid User_Uuid_Field) (
+		user *User, err error)
+
 	Get_Group_By_Name(ctx context.Context,
 		group_name Group_Name_Field) (
 		group *Group, err error)
@@ -2444,6 +5866,34 @@ type Methods interface {
 		group_name Group_Name_Field) (
 		has bool, err error)
 
+	Limited_Group_By_Pk_Greater_OrderBy_Asc_Pk(ctx context.Context,
+		group_pk_greater Group_Pk_Field,
+		limit int, offset int64) (
+		rows []*Group, err error)
+
+	Limited_Group_By_Pk_Less_OrderBy_Desc_Pk(ctx context.Context,
+		group_pk_less Group_Pk_Field,
+		limit int, offset int64) (
+		rows []*Group, err error)
+
+	Limited_Group_OrderBy_Asc_Pk(ctx context.Context,
+		limit int, offset int64) (
+		rows []*Group, err error)
+
+	Limited_User_By_Pk_Greater_OrderBy_Asc_Pk(ctx context.Context,
+		user_pk_greater User_Pk_Field,
+		limit int, offset int64) (
+		rows []*User, err error)
+
+	Limited_User_By_Pk_Less_OrderBy_Desc_Pk(ctx context.Context,
+		user_pk_less User_Pk_Field,
+		limit int, offset int64) (
+		rows []*User, err error)
+
+	Limited_User_OrderBy_Asc_Pk(ctx context.Context,
+		limit int, offset int64) (
+		rows []*User, err error)
+
 	Paged_Group(ctx context.Context,
 		limit int, ctoken string) (
 		rows []*Group, ctokenout string, err error)
@@ -2452,6 +5902,16 @@ type Methods interface {
 		limit int, ctoken string) (
 		rows []*User, ctokenout string, err error)
 
+	Update_Group_By_Name(ctx context.Context,
+		group_name Group_Name_Field,
+		update Group_Update_Fields) (
+		group *Group, err error)
+
+	Update_IdempotentRequest_By_IdempotencyKey(ctx context.Context,
+		idempotent_request_idempotency_key IdempotentRequest_IdempotencyKey_Field,
+		update IdempotentRequest_Update_Fields) (
+		idempotent_request *IdempotentRequest, err error)
+
 	Update_User_By_Id(ctx context.Context,
 		user_id User_Id_Field,
 		update User_Update_Fields) (
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+)
+
+// Written is returned by a Handler that has already written its response,
+// like a middleware that replays a stored one. nothing more is written for it
+var Written = &Response{}
+
+// Captured is a response that was rendered but not sent yet
+type Captured struct {
+	Status int
+	Body   []byte
+}
+
+// Capture renders the response of h, including errors, without sending it,
+// so that middleware can look at it or store it first. the headers that h
+// sets are set on w, since they're sent either way.
+func Capture(ctx context.Context, w http.ResponseWriter, r *http.Request,
+	h Handler) *Captured {
+
+	rec := &recorder{header: w.Header()}
+	resp, err := h(ctx, rec, r)
+	writeResponse(rec, r, resp, err)
+
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &Captured{Status: status, Body: rec.body.Bytes()}
+}
+
+// Send writes the captured response to w
+func (c *Captured) Send(w http.ResponseWriter) error {
+	w.WriteHeader(c.Status)
+	_, err := w.Write(c.Body)
+	return err
+}
+
+// recorder is an http.ResponseWriter that keeps the response in memory
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header { return r.header }
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(p)
+}
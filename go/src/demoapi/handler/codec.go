@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	he "demoapi/httperror"
+	"demoapi/msgpack"
+)
+
+// Codec encodes response bodies in a media type and, if it has a Decode,
+// decodes request bodies in it. Values are always converted to json first,
+// so their json tags and marshalers apply to every format.
+type Codec struct {
+	MediaType   string   // like "application/json"
+	Aliases     []string // other names of the media type
+	ContentType string   // of responses, with any parameters
+	Encode      func(w io.Writer, v interface{}) error
+	Decode      func(r io.Reader, v interface{}) error // nil if it can't
+	// CanEncode returns whether v can be encoded. nil if any value can
+	CanEncode func(v interface{}) bool
+}
+
+// CSVMarshaler is implemented by values that can be flattened into csv rows.
+// the first row is the header.
+type CSVMarshaler interface {
+	MarshalCSV() ([][]string, error)
+}
+
+// the codecs in order of preference, for clients that accept anything
+var codecs = []*Codec{
+	{
+		MediaType:   "application/json",
+		ContentType: "application/json; charset=utf-8",
+		Encode: func(w io.Writer, v interface{}) error {
+			return JSONBody{Value: v}.Encode(w)
+		},
+		Decode: func(r io.Reader, v interface{}) error {
+			return json.NewDecoder(r).Decode(v)
+		},
+	},
+	{
+		MediaType:   "application/yaml",
+		Aliases:     []string{"application/x-yaml", "text/yaml"},
+		ContentType: "application/yaml; charset=utf-8",
+		Encode: func(w io.Writer, v interface{}) error {
+			generic, err := toGeneric(v)
+			if err != nil {
+				return err
+			}
+			encoder := yaml.NewEncoder(w)
+			encoder.SetIndent(2)
+			if err := encoder.Encode(generic); err != nil {
+				return err
+			}
+			return encoder.Close()
+		},
+		Decode: func(r io.Reader, v interface{}) error {
+			return fromGeneric(r, v, func(data []byte) (interface{}, error) {
+				var generic interface{}
+				err := yaml.Unmarshal(data, &generic)
+				return generic, err
+			})
+		},
+	},
+	{
+		MediaType: "application/msgpack",
+		Aliases: []string{"application/x-msgpack",
+			"application/vnd.msgpack"},
+		ContentType: "application/msgpack",
+		Encode: func(w io.Writer, v interface{}) error {
+			generic, err := toGeneric(v)
+			if err != nil {
+				return err
+			}
+			b, err := msgpack.Marshal(generic)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(b)
+			return err
+		},
+		Decode: func(r io.Reader, v interface{}) error {
+			return fromGeneric(r, v, msgpack.Unmarshal)
+		},
+	},
+	{
+		MediaType:   "text/csv",
+		ContentType: "text/csv; charset=utf-8",
+		Encode: func(w io.Writer, v interface{}) error {
+			rows, err := v.(CSVMarshaler).MarshalCSV()
+			if err != nil {
+				return err
+			}
+			return csv.NewWriter(w).WriteAll(rows)
+		},
+		CanEncode: func(v interface{}) bool {
+			_, ok := v.(CSVMarshaler)
+			return ok
+		},
+	},
+}
+
+// Codecs returns every codec, in order of preference
+func Codecs() []*Codec {
+	return append([]*Codec(nil), codecs...)
+}
+
+// names returns whether mediaType is a name of the codec
+func (c *Codec) names(mediaType string) bool {
+	if mediaType == c.MediaType {
+		return true
+	}
+	for _, alias := range c.Aliases {
+		if mediaType == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateCodec picks the codec that encodes v in the media type that the
+// client prefers. Clients that don't send an Accept header get json.
+func negotiateCodec(accept string, v interface{}) (*Codec, error) {
+	if strings.TrimSpace(accept) == "" {
+		return codecs[0], nil
+	}
+
+	for _, mediaRange := range parseAccept(accept) {
+		for _, c := range codecs {
+			if c.CanEncode != nil && !c.CanEncode(v) {
+				continue
+			}
+			switch {
+			case mediaRange == "*/*",
+				strings.HasSuffix(mediaRange, "/*") &&
+					strings.HasPrefix(c.MediaType, mediaRange[:len(
+						mediaRange)-1]),
+				c.names(mediaRange):
+				return c, nil
+			}
+		}
+	}
+
+	var supported []string
+	for _, c := range codecs {
+		if c.CanEncode == nil || c.CanEncode(v) {
+			supported = append(supported, c.MediaType)
+		}
+	}
+	return nil, he.CodeNotAcceptable.New("can't respond with %q. this "+
+		"response is available as %s", accept, strings.Join(supported, ", "))
+}
+
+// parseAccept returns the media ranges of an Accept header, most preferred
+// first. ranges that the client doesn't accept, with a q of 0, are left out.
+func parseAccept(accept string) []string {
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(raw, 64)
+			if err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	mediaTypes := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		mediaTypes = append(mediaTypes, r.mediaType)
+	}
+	return mediaTypes
+}
+
+// Decode decodes the body of r into v in the format of its Content-Type.
+// Bodies without a Content-Type are decoded as json. Unsupported types are a
+// 415 and bodies that can't be decoded are a 400.
+func Decode(r *http.Request, v interface{}) error {
+	return DecodeBody(r.Header.Get("Content-Type"), r.Body, v)
+}
+
+// DecodeBody is Decode for a body that was already read from its request
+func DecodeBody(contentType string, body io.Reader, v interface{}) error {
+	c, err := decoderFor(contentType)
+	if err != nil {
+		return err
+	}
+	return he.BadRequest.Wrap(c.Decode(body, v))
+}
+
+func decoderFor(contentType string) (*Codec, error) {
+	if contentType == "" {
+		return codecs[0], nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, he.CodeUnsupportedMediaType.New("invalid Content-Type "+
+			"%q: %s", contentType, err)
+	}
+	// structured syntax like application/scim+json is still json
+	if strings.HasSuffix(mediaType, "+json") {
+		return codecs[0], nil
+	}
+
+	var supported []string
+	for _, c := range codecs {
+		if c.Decode == nil {
+			continue
+		}
+		if c.names(mediaType) {
+			return c, nil
+		}
+		supported = append(supported, c.MediaType)
+	}
+	return nil, he.CodeUnsupportedMediaType.New("can't decode %q. use %s",
+		mediaType, strings.Join(supported, ", "))
+}
+
+// toGeneric converts v to the maps, slices and scalars that it would be as
+// json. numbers are int64 if they're whole, and float64 otherwise.
+func toGeneric(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return plainNumbers(generic), nil
+}
+
+func plainNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i := range v {
+			v[i] = plainNumbers(v[i])
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = plainNumbers(v[key])
+		}
+	}
+	return v
+}
+
+// fromGeneric decodes a body with unmarshal and then decodes the result into
+// v as json
+func fromGeneric(r io.Reader, v interface{},
+	unmarshal func([]byte) (interface{}, error)) error {
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return io.EOF // like an empty json body
+	}
+	generic, err := unmarshal(data)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	he "demoapi/httperror"
+	"demoapi/msgpack"
+)
+
+type codecTestValue struct {
+	Name  string   `json:"name"`
+	Count int      `json:"count"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+func (v codecTestValue) MarshalCSV() ([][]string, error) {
+	return [][]string{{"name", "count"}, {v.Name, "1"}}, nil
+}
+
+func TestNegotiate(t *testing.T) {
+	serve := func(accept string, value interface{}) *httptest.ResponseRecorder {
+		h := Handler(func(ctx context.Context, w http.ResponseWriter,
+			r *http.Request) (*Response, error) {
+			return OK(value), nil
+		})
+		r := httptest.NewRequest("GET", "/", nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	value := codecTestValue{Name: "a", Count: 2, Tags: []string{"x"}}
+
+	w := serve("", value)
+	assert.Equal(t, "application/json; charset=utf-8",
+		w.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+	assert.Equal(t, `{"name":"a","count":2,"tags":["x"]}`, w.Body.String())
+
+	w = serve("application/yaml", value)
+	assert.Equal(t, "application/yaml; charset=utf-8",
+		w.Header().Get("Content-Type"))
+	assert.Equal(t, "count: 2\nname: a\ntags:\n  - x\n", w.Body.String())
+
+	w = serve("application/x-msgpack", value)
+	assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+	decoded, err := msgpack.Unmarshal(w.Body.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "a", decoded.(map[string]interface{})["name"])
+
+	// the most preferred type that can be encoded wins
+	w = serve("text/csv, application/json;q=0.5", value)
+	assert.Equal(t, "name,count\na,1\n", w.Body.String())
+	w = serve("text/csv;q=0.1, application/yaml", value)
+	assert.Equal(t, "application/yaml; charset=utf-8",
+		w.Header().Get("Content-Type"))
+	w = serve("text/csv, application/*;q=0.5", map[string]int{"a": 1})
+	assert.Equal(t, "application/json; charset=utf-8",
+		w.Header().Get("Content-Type"))
+	w = serve("*/*", value)
+	assert.Equal(t, "application/json; charset=utf-8",
+		w.Header().Get("Content-Type"))
+
+	for _, accept := range []string{"text/html", "application/json;q=0"} {
+		w = serve(accept, value)
+		assert.Equal(t, http.StatusNotAcceptable, w.Code, accept)
+		assert.Equal(t, he.ProblemContentType, w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "not_acceptable")
+	}
+	// maps can't be flattened into csv
+	w = serve("text/csv", map[string]int{"a": 1})
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+	// json bodies aren't negotiated
+	h := Handler(func(ctx context.Context, w http.ResponseWriter,
+		r *http.Request) (*Response, error) {
+		return &Response{Body: JSONBody{Value: value}}, nil
+	})
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", "text/html")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Vary"))
+}
+
+func TestDecode(t *testing.T) {
+	decode := func(contentType, body string) (codecTestValue, error) {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		var v codecTestValue
+		err := Decode(r, &v)
+		return v, err
+	}
+	expected := codecTestValue{Name: "a", Count: 2}
+
+	for _, test := range []struct {
+		contentType string
+		body        string
+	}{
+		{"", `{"name":"a","count":2}`},
+		{"application/json; charset=utf-8", `{"name":"a","count":2}`},
+		{"application/scim+json", `{"name":"a","count":2}`},
+		{"application/yaml", "name: a\ncount: 2\n"},
+		{"text/yaml", "{name: a, count: 2}"},
+		{"application/msgpack", "\x82\xa4name\xa1a\xa5count\x02"},
+	} {
+		v, err := decode(test.contentType, test.body)
+		require.NoError(t, err, test.contentType)
+		assert.Equal(t, expected, v, test.contentType)
+	}
+
+	for _, contentType := range []string{"text/csv", "text/plain",
+		"application/x-www-form-urlencoded", "not a type"} {
+		_, err := decode(contentType, `{"name":"a"}`)
+		assert.True(t, he.UnsupportedMediaType.Has(err), contentType)
+		assert.Equal(t, http.StatusUnsupportedMediaType,
+			he.StatusCodeByError(err))
+	}
+
+	for _, test := range []struct {
+		contentType string
+		body        string
+	}{
+		{"", `{"name":`},
+		{"application/yaml", ""},
+		{"application/yaml", "name: [a"},
+		{"application/yaml", "count: many"},
+		{"application/msgpack", "\x92\xc3"},
+	} {
+		_, err := decode(test.contentType, test.body)
+		assert.True(t, he.BadRequest.Has(err), "%q", test.body)
+	}
+}
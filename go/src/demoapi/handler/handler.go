@@ -5,19 +5,17 @@ import (
 	"net/http"
 )
 
-// TODO(sam): instead of an `interface{}`, create a "Serializable" interface
-// that is returned by this Handler interface... just something that is a
-// "Writer"? It just needs to be able to output a []byte, like Marshalers
-
 // Handler is an http.Handler interface with a more expressive function
 // signature that ensures that all responses, including unexpected errors, are
-// returned in a consistent JSON format.
+// returned in a consistent format. Handlers return a Response, which
+// middleware can look at or replace before it's written. Errors are written
+// as RFC 7807 problem details.
 type Handler func(context.Context, http.ResponseWriter, *http.Request) (
-	interface{}, error)
+	*Response, error)
 
 type HandlerFunc func(Handler) Handler
 
 func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	b, err := h(r.Context(), w, r)
-	jsonResponse(w, b, err)
+	resp, err := h(r.Context(), w, r)
+	writeResponse(w, r, resp, err)
 }
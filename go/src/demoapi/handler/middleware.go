@@ -17,13 +17,20 @@ func MiddlewareChain(middlewares ...HandlerFunc) middlewareChain {
 	return middlewares
 }
 
+// Append returns a new chain with middlewares added to the end. mwc isn't
+// changed, so a chain can be extended more than once
 func (mwc middlewareChain) Append(middlewares ...HandlerFunc) middlewareChain {
-	return append(mwc, middlewares...)
+	chain := make(middlewareChain, 0, len(mwc)+len(middlewares))
+	return append(append(chain, mwc...), middlewares...)
 }
 
+// JSON wraps h in the middleware chain. the handler and each middleware get
+// their own span, so a trace shows where the time in a request went.
 func (mwc middlewareChain) JSON(h Handler) Handler {
+	h = traced(funcName(h), h)
 	for i := range mwc {
-		h = mwc[len(mwc)-1-i](h)
+		mw := mwc[len(mwc)-1-i]
+		h = traced(funcName(mw), mw(h))
 	}
 	return h
 }
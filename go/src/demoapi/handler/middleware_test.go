@@ -48,36 +48,60 @@ func TestMiddlewareChainAppend(t *testing.T) {
 	assert.Equal(t, `pre9pre5pre5pre9post9post9"3"`, w2.Body.String())
 }
 
-func handler0(context.Context, http.ResponseWriter, *http.Request) (interface{},
+func TestMiddlewareChainInspect(t *testing.T) {
+	// middleware can look at and change the response of the handler
+	inspect := func(h Handler) Handler {
+		return Handler(func(ctx context.Context, w http.ResponseWriter,
+			r *http.Request) (*Response, error) {
+			resp, err := h(ctx, w, r)
+			if err != nil {
+				return nil, err
+			}
+			if resp.Value() == "2" {
+				resp.Status = http.StatusAccepted
+			}
+			return resp, nil
+		})
+	}
+	h := MiddlewareChain(inspect).JSON(handler2)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, `"2"`, w.Body.String())
+}
+
+func handler0(context.Context, http.ResponseWriter, *http.Request) (*Response,
 	error) {
-	return "0", nil
+	return OK("0"), nil
 }
 
-func handler1(context.Context, http.ResponseWriter, *http.Request) (interface{},
+func handler1(context.Context, http.ResponseWriter, *http.Request) (*Response,
 	error) {
-	return "1", nil
+	return OK("1"), nil
 }
 
-func handler2(context.Context, http.ResponseWriter, *http.Request) (interface{},
+func handler2(context.Context, http.ResponseWriter, *http.Request) (*Response,
 	error) {
-	return "2", nil
+	return OK("2"), nil
 }
 
-func handler3(context.Context, http.ResponseWriter, *http.Request) (interface{},
+func handler3(context.Context, http.ResponseWriter, *http.Request) (*Response,
 	error) {
-	return "3", nil
+	return OK("3"), nil
 }
 
 func middlewareNothing(h Handler) Handler {
 	return Handler(func(ctx context.Context, w http.ResponseWriter,
-		r *http.Request) (interface{}, error) {
+		r *http.Request) (*Response, error) {
 		return h(ctx, w, r)
 	})
 }
 
 func middlewarePre5(h Handler) Handler {
 	return Handler(func(ctx context.Context, w http.ResponseWriter,
-		r *http.Request) (interface{}, error) {
+		r *http.Request) (*Response, error) {
 		_, err := w.Write([]byte("pre5"))
 		if err != nil {
 			return nil, err
@@ -88,7 +112,7 @@ func middlewarePre5(h Handler) Handler {
 
 func middlewareSandwich9(h Handler) Handler {
 	return Handler(func(ctx context.Context, w http.ResponseWriter,
-		r *http.Request) (interface{}, error) {
+		r *http.Request) (*Response, error) {
 		_, err := w.Write([]byte("pre9"))
 		if err != nil {
 			return nil, err
@@ -1,53 +1,219 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
-	"fmt"
+	"io"
 	"net/http"
-
-	"github.com/sirupsen/logrus"
+	"sync/atomic"
 
 	he "demoapi/httperror"
+	"demoapi/logging"
 )
 
-func jsonResponse(w http.ResponseWriter, obj interface{}, err error) {
-	writeJSONError := func(jsonErr error) {
-		statusCode := he.StatusCodeByError(jsonErr)
-		w.WriteHeader(statusCode)
-
-		errStr := fmt.Sprintf("%s", jsonErr)
-		jsonErrObj := map[string]string{"error": errStr}
-		jsonObj, err := json.Marshal(jsonErrObj)
-		if err != nil {
-			logrus.Warningf("failed to convert %v to json", jsonErrObj)
-			w.Header().Set("Content-Type", "text/plain")
-			w.Write([]byte(errStr))
-			return
-		}
+var developerMode int32
 
-		w.Write(jsonObj)
-		return
+// SetDeveloperMode sets whether the text of unexpected errors is included in
+// error responses. Otherwise they only say that something went wrong, since
+// they can include internal messages like database errors. It can be changed
+// while requests are being served.
+func SetDeveloperMode(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
 	}
+	atomic.StoreInt32(&developerMode, v)
+}
+
+// DeveloperMode returns whether developer mode is enabled
+func DeveloperMode() bool {
+	return atomic.LoadInt32(&developerMode) != 0
+}
+
+// Response is the result of a Handler: the status, the headers and a body
+// that knows how to encode itself. A nil Response is a 200 with a short json
+// body.
+type Response struct {
+	Status int         // 200 if unset
+	Header http.Header // added to the headers that middleware already set
+	Body   Encoder     // no body if nil
+}
 
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+// Encoder encodes the body of a Response
+type Encoder interface {
+	ContentType() string
+	Encode(w io.Writer) error
+}
+
+// JSONBody is a body that's always encoded as json, whatever the client
+// accepts
+type JSONBody struct {
+	Value interface{}
+}
+
+func (b JSONBody) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+func (b JSONBody) Encode(w io.Writer) error {
+	buf, err := json.Marshal(b.Value)
 	if err != nil {
-		writeJSONError(err)
-		return
+		return err
 	}
+	_, err = w.Write(buf)
+	return err
+}
 
-	if obj == nil {
-		obj = map[string]string{"response": "okay!"}
+// ValueBody is a body that's encoded in the format that the client asks for
+// with its Accept header. see Codecs. it's json unless it's negotiated.
+type ValueBody struct {
+	Value interface{}
+}
+
+func (b ValueBody) ContentType() string {
+	return codecs[0].ContentType
+}
+
+func (b ValueBody) Encode(w io.Writer) error {
+	return codecs[0].Encode(w, b.Value)
+}
+
+// negotiated is a ValueBody in the codec that was negotiated for it
+type negotiated struct {
+	codec *Codec
+	value interface{}
+}
+
+func (b negotiated) ContentType() string { return b.codec.ContentType }
+
+func (b negotiated) Encode(w io.Writer) error {
+	return b.codec.Encode(w, b.value)
+}
+
+// OK responds with a 200 and body in the format that the client accepts
+func OK(body interface{}) *Response {
+	return &Response{Status: http.StatusOK, Body: ValueBody{Value: body}}
+}
+
+// Created responds with a 201, the location of the new resource and body in
+// the format that the client accepts
+func Created(location string, body interface{}) *Response {
+	return &Response{
+		Status: http.StatusCreated,
+		Header: http.Header{"Location": {location}},
+		Body:   ValueBody{Value: body},
 	}
+}
 
-	jsonBytes, err := json.Marshal(obj)
+// NoContent responds with a 204 and no body
+func NoContent() *Response {
+	return &Response{Status: http.StatusNoContent}
+}
+
+// Value returns the value of a json or negotiated body, so that middleware
+// and tests can look at what a handler returned. it's nil for any other body.
+func (resp *Response) Value() interface{} {
+	if resp == nil {
+		return nil
+	}
+	switch b := resp.Body.(type) {
+	case JSONBody:
+		return b.Value
+	case ValueBody:
+		return b.Value
+	}
+	return nil
+}
+
+// problemResponse records err and describes it as a problem
+func problemResponse(r *http.Request, err error) *Response {
+	he.Record(r.Context(), err)
+	problem := he.ProblemByError(err, logging.RequestID(r.Context()),
+		DeveloperMode())
+	if problem.Status == http.StatusInternalServerError {
+		logging.Entry(r.Context()).Errorf("request failed: %+v", err)
+	}
+
+	return &Response{
+		Status: problem.Status,
+		Header: http.Header{"Content-Type": {he.ProblemContentType}},
+		Body:   JSONBody{Value: problem},
+	}
+}
+
+// negotiate returns resp with its body in the codec that the client prefers,
+// or a 406 if it doesn't accept any of them
+func negotiate(r *http.Request, resp *Response, b ValueBody) *Response {
+	codec, err := negotiateCodec(r.Header.Get("Accept"), b.Value)
 	if err != nil {
-		writeJSONError(err)
-		return
+		resp = problemResponse(r, err)
+	} else {
+		copied := *resp
+		copied.Body = negotiated{codec: codec, value: b.Value}
+		resp = &copied
+	}
+	resp.Header = resp.Header.Clone()
+	if resp.Header == nil {
+		resp.Header = http.Header{}
 	}
+	resp.Header.Add("Vary", "Accept")
+	return resp
+}
+
+func writeResponse(w http.ResponseWriter, r *http.Request, resp *Response,
+	err error) {
 
-	_, err = w.Write(jsonBytes)
 	if err != nil {
-		writeJSONError(err)
+		resp = problemResponse(r, err)
+	}
+	if resp == Written {
 		return
 	}
+	if resp == nil {
+		resp = OK(map[string]string{"response": "okay!"})
+	}
+	if b, ok := resp.Body.(ValueBody); ok {
+		resp = negotiate(r, resp, b)
+	}
+
+	// the body is encoded before anything is written, so that a body that
+	// can't be encoded can still be reported as an error
+	var body bytes.Buffer
+	if resp.Body != nil {
+		if err := resp.Body.Encode(&body); err != nil {
+			resp = problemResponse(r, err)
+			body.Reset()
+			if err := resp.Body.Encode(&body); err != nil {
+				logging.Entry(r.Context()).Warningf("failed to encode %v",
+					resp.Value())
+				resp.Header.Set("Content-Type", "text/plain")
+				body.Reset()
+				body.WriteString(http.StatusText(resp.Status))
+			}
+		}
+	}
+
+	header := w.Header()
+	for name, values := range resp.Header {
+		header[name] = values
+	}
+	if resp.Body != nil && header.Get("Content-Type") == "" {
+		header.Set("Content-Type", resp.Body.ContentType())
+	}
+
+	// a 200 is left implicit, since middleware might have written already
+	if resp.Status != 0 && resp.Status != http.StatusOK {
+		w.WriteHeader(resp.Status)
+	}
+	if body.Len() == 0 {
+		return
+	}
+
+	_, err = w.Write(body.Bytes())
+	if err != nil {
+		// the status and headers have already been written, so the best that
+		// can be done is to log it
+		logging.Entry(r.Context()).Warningf("failed to write response: %s",
+			err)
+	}
 }
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeebo/errs"
+
+	he "demoapi/httperror"
+)
+
+func TestProblemResponse(t *testing.T) {
+	problem := func(err error) (*httptest.ResponseRecorder, he.Problem) {
+		h := Handler(func(ctx context.Context, w http.ResponseWriter,
+			r *http.Request) (*Response, error) {
+			return nil, err
+		})
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+		p := he.Problem{}
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&p))
+		return w, p
+	}
+
+	w, p := problem(he.CodeUserNotFound.New("userID %q doesn't exist", "u"))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, he.ProblemContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "user_not_found", p.Code)
+	assert.Equal(t, "urn:demoapi:problem:user_not_found", p.Type)
+	assert.Equal(t, http.StatusNotFound, p.Status)
+	assert.Equal(t, `not found: userID "u" doesn't exist`, p.Detail)
+
+	w, p = problem(he.BadRequest.Wrap(he.InvalidFields{
+		{Field: "limit", Message: "must be a number"}}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid_fields", p.Code)
+	assert.Equal(t, 1, len(p.Fields))
+
+	// unexpected errors are hidden outside of developer mode
+	internal := errs.New("database: connection refused")
+	w, p = problem(internal)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "internal", p.Code)
+	assert.NotContains(t, p.Detail, "database")
+
+	SetDeveloperMode(true)
+	defer SetDeveloperMode(false)
+	_, p = problem(internal)
+	assert.Equal(t, "database: connection refused", p.Detail)
+}
+
+func TestResponse(t *testing.T) {
+	serve := func(resp *Response) *httptest.ResponseRecorder {
+		h := Handler(func(ctx context.Context, w http.ResponseWriter,
+			r *http.Request) (*Response, error) {
+			return resp, nil
+		})
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		return w
+	}
+
+	w := serve(Created("/users/u", map[string]string{"userid": "u"}))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/users/u", w.Header().Get("Location"))
+	assert.Equal(t, "application/json; charset=utf-8",
+		w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"userid":"u"}`, w.Body.String())
+
+	w = serve(NoContent())
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Body.String())
+
+	w = serve(nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"response":"okay!"}`, w.Body.String())
+
+	// bodies that can't be encoded are errors
+	w = serve(OK(func() {}))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, he.ProblemContentType, w.Header().Get("Content-Type"))
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var tracer = otel.Tracer("demoapi/handler")
+
+// traced wraps h in a span called name. the span's context is passed to h as
+// both ctx and the context of r
+func traced(name string, h Handler) Handler {
+	return Handler(func(ctx context.Context, w http.ResponseWriter,
+		r *http.Request) (*Response, error) {
+
+		ctx, span := tracer.Start(ctx, name)
+		defer span.End()
+
+		resp, err := h(ctx, w, r.WithContext(ctx))
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return resp, err
+	})
+}
+
+// funcName returns a readable name for a function value, like
+// "server.(*Server).Authenticated"
+func funcName(f interface{}) string {
+	name := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+	return strings.TrimSuffix(name, "-fm")
+}
@@ -0,0 +1,149 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeebo/errs"
+)
+
+// This package decides whether the api is ready to serve requests. Anything
+// the api depends on, like the database, registers a Check, and the readiness
+// endpoint reports the status of every one of them.
+
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+
+	// DefaultTimeout is how long checks are given when no timeout is set
+	DefaultTimeout = 2 * time.Second
+)
+
+var timeoutErr = errs.Class("timeout")
+
+// Check is a dependency of the api that has to be working for the api to be
+// ready
+type Check interface {
+	// Check returns an error if the dependency isn't working. ctx is canceled
+	// once the check has taken too long.
+	Check(ctx context.Context) error
+}
+
+// CheckFunc is a function that implements Check
+type CheckFunc func(ctx context.Context) error
+
+func (f CheckFunc) Check(ctx context.Context) error { return f(ctx) }
+
+// Result is the outcome of a single check
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of every check. Status is only StatusOK if every
+// check passed.
+type Report struct {
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	Checks []*Result `json:"checks"`
+}
+
+// OK returns whether the api is ready
+func (r *Report) OK() bool { return r.Status == StatusOK }
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the registered checks. Every check is run concurrently and
+// given at most the timeout to finish.
+type Checker struct {
+	timeout      time.Duration
+	shuttingDown int32
+
+	mu     sync.Mutex
+	checks []namedCheck
+}
+
+// NewChecker returns a Checker without any checks
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a check. checks are reported in the order they're registered
+func (c *Checker) Register(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// ShutDown marks the api as not ready for the rest of its life, so that load
+// balancers drain traffic away from it while in-flight requests finish
+func (c *Checker) ShutDown() {
+	atomic.StoreInt32(&c.shuttingDown, 1)
+}
+
+// Run runs every check and reports their outcomes
+func (c *Checker) Run(ctx context.Context) *Report {
+	c.mu.Lock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := &Report{Status: StatusOK, Checks: make([]*Result, len(checks))}
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check namedCheck) {
+			defer wg.Done()
+			report.Checks[i] = run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusFailed
+		}
+	}
+	if atomic.LoadInt32(&c.shuttingDown) != 0 {
+		report.Status = StatusFailed
+		report.Error = "shutting down"
+	}
+	return report
+}
+
+func run(ctx context.Context, check namedCheck) *Result {
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() { errCh <- check.check.Check(ctx) }()
+
+	// checks that ignore ctx are abandoned rather than waited for
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = timeoutErr.Wrap(ctx.Err())
+	}
+
+	result := &Result{
+		Name:      check.name,
+		Status:    StatusOK,
+		LatencyMS: float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+	return result
+}
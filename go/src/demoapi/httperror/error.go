@@ -1,17 +1,28 @@
 package httperror
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/zeebo/errs"
+	"google.golang.org/grpc/codes"
 )
 
 var (
-	BadRequest      = errs.Class("bad request")     // 400
-	Unauthenticated = errs.Class("unauthenticated") // 401
-	Unauthorized    = errs.Class("unauthorized")    // 403
-	NotFound        = errs.Class("not found")       // 404
-	Unexpected      = errs.Class("internal")        // 500
+	BadRequest           = errs.Class("bad request")            // 400
+	Unauthenticated      = errs.Class("unauthenticated")        // 401
+	Unauthorized         = errs.Class("unauthorized")           // 403
+	NotFound             = errs.Class("not found")              // 404
+	NotAcceptable        = errs.Class("not acceptable")         // 406
+	Conflict             = errs.Class("conflict")               // 409
+	FailedPrecondition   = errs.Class("failed precondition")    // 409
+	Gone                 = errs.Class("gone")                   // 410
+	RequestTooLarge      = errs.Class("request too large")      // 413
+	UnsupportedMediaType = errs.Class("unsupported media type") // 415
+	Unprocessable        = errs.Class("unprocessable")          // 422
+	TooManyRequests      = errs.Class("too many requests")      // 429
+	Unexpected           = errs.Class("internal")               // 500
 )
 
 func StatusCodeByError(err error) int {
@@ -24,6 +35,70 @@ func StatusCodeByError(err error) int {
 		return http.StatusForbidden
 	case NotFound.Has(err):
 		return http.StatusNotFound
+	case NotAcceptable.Has(err):
+		return http.StatusNotAcceptable
+	case Conflict.Has(err), FailedPrecondition.Has(err):
+		return http.StatusConflict
+	case Gone.Has(err):
+		return http.StatusGone
+	case RequestTooLarge.Has(err):
+		return http.StatusRequestEntityTooLarge
+	case UnsupportedMediaType.Has(err):
+		return http.StatusUnsupportedMediaType
+	case Unprocessable.Has(err):
+		return http.StatusUnprocessableEntity
+	case TooManyRequests.Has(err):
+		return http.StatusTooManyRequests
 	}
 	return http.StatusInternalServerError
 }
+
+// GRPCCodeByError is the grpc equivalent of StatusCodeByError
+func GRPCCodeByError(err error) codes.Code {
+	switch {
+	case BadRequest.Has(err):
+		return codes.InvalidArgument
+	case Unauthenticated.Has(err):
+		return codes.Unauthenticated
+	case Unauthorized.Has(err):
+		return codes.PermissionDenied
+	case NotFound.Has(err), Gone.Has(err):
+		return codes.NotFound
+	case NotAcceptable.Has(err), UnsupportedMediaType.Has(err):
+		return codes.InvalidArgument
+	case Conflict.Has(err):
+		return codes.AlreadyExists
+	case FailedPrecondition.Has(err), Unprocessable.Has(err):
+		return codes.FailedPrecondition
+	case RequestTooLarge.Has(err), TooManyRequests.Has(err):
+		return codes.ResourceExhausted
+	}
+	return codes.Internal
+}
+
+// FieldError describes why a single field of a request is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// InvalidFields lists every invalid field of a request. it's returned wrapped
+// in BadRequest so that the fields can be included in the response
+type InvalidFields []FieldError
+
+func (e InvalidFields) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, f := range e {
+		msgs = append(msgs, f.Field+" "+f.Message)
+	}
+	return "invalid fields: " + strings.Join(msgs, ", ")
+}
+
+// FieldsByError returns the invalid fields of err, if it has any
+func FieldsByError(err error) InvalidFields {
+	var fields InvalidFields
+	if errors.As(err, &fields) {
+		return fields
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package httperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/zeebo/errs"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix namespaces the problem type uris. the type of a problem is
+// its prefixed code, like "urn:demoapi:problem:user_not_found"
+const problemTypePrefix = "urn:demoapi:problem:"
+
+// Code is an entry in the error catalog. Codes are stable, machine readable
+// identifiers that clients can branch on instead of the error text, which can
+// change at any time.
+type Code struct {
+	Code   string `json:"code"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	class  *errs.Class
+}
+
+var catalog []*Code
+
+// The error catalog. Errors created with a class, like `NotFound.New`, get
+// the generic code of their class. Use a more specific code, like
+// `CodeUserNotFound.New`, when clients might want to tell errors apart.
+var (
+	CodeBadRequest    = register("bad_request", "Bad Request", &BadRequest)
+	CodeInvalidFields = register("invalid_fields", "Invalid Fields",
+		&BadRequest)
+	CodeInvalidCursor = register("invalid_cursor", "Invalid Cursor",
+		&BadRequest)
+	CodeUnauthenticated = register("unauthenticated", "Unauthenticated",
+		&Unauthenticated)
+	CodePermissionDenied = register("permission_denied", "Permission Denied",
+		&Unauthorized)
+	CodeNotFound      = register("not_found", "Not Found", &NotFound)
+	CodeUserNotFound  = register("user_not_found", "User Not Found", &NotFound)
+	CodeGroupNotFound = register("group_not_found", "Group Not Found",
+		&NotFound)
+	CodeAttributeNotFound = register("attribute_not_found",
+		"Attribute Not Found", &NotFound)
+	CodeNotAcceptable = register("not_acceptable", "Not Acceptable",
+		&NotAcceptable)
+	CodeConflict    = register("conflict", "Conflict", &Conflict)
+	CodeUserExists  = register("user_exists", "User Exists", &Conflict)
+	CodeGroupExists = register("group_exists", "Group Exists",
+		&Conflict)
+	CodeInvalidTransition = register("invalid_transition",
+		"Invalid Transition", &FailedPrecondition)
+	CodeIdempotencyKeyInProgress = register("idempotency_key_in_progress",
+		"Idempotency Key In Progress", &Conflict)
+	CodeUnversionedPathRemoved = register("unversioned_path_removed",
+		"Unversioned Path Removed", &Gone)
+	CodeRequestTooLarge = register("request_too_large", "Request Too Large",
+		&RequestTooLarge)
+	CodeUnsupportedMediaType = register("unsupported_media_type",
+		"Unsupported Media Type", &UnsupportedMediaType)
+	CodeIdempotencyKeyReused = register("idempotency_key_reused",
+		"Idempotency Key Reused", &Unprocessable)
+	CodeRateLimited = register("rate_limited", "Too Many Requests",
+		&TooManyRequests)
+	CodeInternal = register("internal", "Internal Server Error", &Unexpected)
+)
+
+func register(code, title string, class *errs.Class) *Code {
+	c := &Code{
+		Code:   code,
+		Title:  title,
+		Status: StatusCodeByError(class.New("")),
+		class:  class,
+	}
+	catalog = append(catalog, c)
+	return c
+}
+
+// Catalog returns every error code, in the order they were registered
+func Catalog() []*Code {
+	return append([]*Code(nil), catalog...)
+}
+
+// New returns an error of the code's class that is identified by the code
+func (c *Code) New(format string, args ...interface{}) error {
+	return c.Wrap(fmt.Errorf(format, args...))
+}
+
+// Wrap returns err wrapped in the code's class and identified by the code
+func (c *Code) Wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	// the class has to be outermost, since errs.Class.Has only looks through
+	// other errs errors
+	return c.class.Wrap(&codedError{code: c, err: err})
+}
+
+type codedError struct {
+	code *Code
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+// CodeByError returns the most specific code for err
+func CodeByError(err error) *Code {
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	if FieldsByError(err) != nil {
+		return CodeInvalidFields
+	}
+
+	return CodeByStatus(StatusCodeByError(err))
+}
+
+// CodeByStatus returns the generic code of an http status, for responses that
+// failed without an error, like requests that didn't match a route. it
+// returns nil for statuses that aren't errors.
+func CodeByStatus(status int) *Code {
+	if status < http.StatusBadRequest {
+		return nil
+	}
+	for _, c := range catalog {
+		if c.Status == status {
+			return c // the generic code of a class is registered first
+		}
+	}
+	if status < http.StatusInternalServerError {
+		return CodeBadRequest
+	}
+	return CodeInternal
+}
+
+// Detail returns the text of err that is safe to show to clients. unexpected
+// errors can include internal messages, like database errors, so their text
+// is only shown in developer mode.
+func Detail(err error, developerMode bool) string {
+	status := StatusCodeByError(err)
+	if developerMode || status != http.StatusInternalServerError {
+		return err.Error()
+	}
+	return "an unexpected error occurred"
+}
+
+// Problem is an RFC 7807 problem details object. Code and Fields are
+// extension members.
+type Problem struct {
+	Type     string        `json:"type"`
+	Title    string        `json:"title"`
+	Status   int           `json:"status"`
+	Detail   string        `json:"detail"`
+	Instance string        `json:"instance,omitempty"`
+	Code     string        `json:"code"`
+	Fields   InvalidFields `json:"fields,omitempty"`
+}
+
+// ProblemByError describes err as a problem. instance identifies the request
+// that failed.
+func ProblemByError(err error, instance string, developerMode bool) *Problem {
+	code := CodeByError(err)
+	return &Problem{
+		Type:     problemTypePrefix + code.Code,
+		Title:    code.Title,
+		Status:   StatusCodeByError(err),
+		Detail:   Detail(err, developerMode),
+		Instance: instance,
+		Code:     code.Code,
+		Fields:   FieldsByError(err),
+	}
+}
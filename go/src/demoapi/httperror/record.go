@@ -0,0 +1,34 @@
+package httperror
+
+import (
+	"context"
+)
+
+type recorderKey struct{}
+
+type recorder struct {
+	err error
+}
+
+// WithRecorder returns a context that the error of a response can be recorded
+// in. Middleware that runs before the handlers, like the metrics middleware,
+// uses it to learn why a request failed, since only the status is written.
+func WithRecorder(ctx context.Context) context.Context {
+	return context.WithValue(ctx, recorderKey{}, &recorder{})
+}
+
+// Record records err as the error of the response. It does nothing if ctx
+// wasn't returned by WithRecorder.
+func Record(ctx context.Context, err error) {
+	if rec, ok := ctx.Value(recorderKey{}).(*recorder); ok {
+		rec.err = err
+	}
+}
+
+// Recorded returns the error recorded for the response, if there was one
+func Recorded(ctx context.Context) error {
+	if rec, ok := ctx.Value(recorderKey{}).(*recorder); ok {
+		return rec.err
+	}
+	return nil
+}
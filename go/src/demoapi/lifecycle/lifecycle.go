@@ -0,0 +1,192 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zeebo/errs"
+)
+
+// This package runs the servers and background workers of the process and
+// shuts all of them down together, either when the process is signaled or
+// when one of them fails.
+
+var lifecycleErr = errs.Class("lifecycle")
+
+// Server is implemented by http.Server and server.GRPCServer
+type Server interface {
+	// ListenAndServe serves until Shutdown is called. it returns
+	// http.ErrServerClosed, or nil, as soon as Shutdown is called, before the
+	// in-flight requests have finished.
+	ListenAndServe() error
+
+	// Shutdown stops accepting new requests and waits for the in-flight ones
+	// to finish until ctx is done
+	Shutdown(ctx context.Context) error
+}
+
+// Worker runs in the background until ctx is canceled
+type Worker func(ctx context.Context) error
+
+// Closer releases a resource once every server and worker has stopped
+type Closer func(ctx context.Context) error
+
+type named struct {
+	name   string
+	server Server
+	worker Worker
+	closer Closer
+}
+
+// Manager runs servers and workers until the process receives SIGINT or
+// SIGTERM. Shutting down happens in order: the OnShutdown hooks are called,
+// the servers drain their in-flight requests and the workers are stopped,
+// all within the timeout, and then the closers are closed in the reverse
+// order that they were added.
+type Manager struct {
+	timeout    time.Duration
+	servers    []named
+	workers    []named
+	closers    []named
+	onShutdown []func()
+}
+
+// New returns a Manager that gives servers and workers timeout to stop, and
+// closers timeout to close
+func New(timeout time.Duration) *Manager {
+	return &Manager{timeout: timeout}
+}
+
+// AddServer adds a server that's started by Run
+func (m *Manager) AddServer(name string, s Server) {
+	m.servers = append(m.servers, named{name: name, server: s})
+}
+
+// AddWorker adds a background worker that's started by Run
+func (m *Manager) AddWorker(name string, w Worker) {
+	m.workers = append(m.workers, named{name: name, worker: w})
+}
+
+// AddCloser adds a resource, like the database, that's closed once
+// everything that uses it has stopped
+func (m *Manager) AddCloser(name string, c Closer) {
+	m.closers = append(m.closers, named{name: name, closer: c})
+}
+
+// OnShutdown adds a function that's called as soon as shutdown begins, before
+// the servers stop accepting requests
+func (m *Manager) OnShutdown(fn func()) {
+	m.onShutdown = append(m.onShutdown, fn)
+}
+
+// Run starts every server and worker and blocks until they have all stopped
+// and every closer has been closed. It returns an error if a server or
+// worker failed, like a server that couldn't bind its address, so that the
+// process can exit non-zero.
+func (m *Manager) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
+	defer cancelWorkers()
+
+	failures := make(chan error, len(m.servers)+len(m.workers))
+	var servers, workers sync.WaitGroup
+
+	for _, s := range m.servers {
+		servers.Add(1)
+		go func(s named) {
+			defer servers.Done()
+			logrus.Infof("starting %s", s.name)
+			err := s.server.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				failures <- lifecycleErr.New("%s failed: %s", s.name, err)
+			}
+		}(s)
+	}
+
+	for _, w := range m.workers {
+		workers.Add(1)
+		go func(w named) {
+			defer workers.Done()
+			err := w.worker(workerCtx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				failures <- lifecycleErr.New("%s failed: %s", w.name, err)
+			}
+		}(w)
+	}
+
+	var failure error
+	select {
+	case <-ctx.Done():
+		logrus.Info("shutting down...")
+	case failure = <-failures:
+		logrus.Errorf("shutting down after a failure: %s", failure)
+	}
+
+	for _, fn := range m.onShutdown {
+		fn()
+	}
+
+	// set the timeout with a new context (the last one has been canceled) in
+	// case something takes forever to drain
+	shutdownCtx, cancel := context.WithTimeout(context.Background(),
+		m.timeout)
+	defer cancel()
+
+	// ListenAndServe returns as soon as Shutdown is called, so the servers
+	// have drained once their Shutdown calls return
+	var drains sync.WaitGroup
+	for _, s := range m.servers {
+		drains.Add(1)
+		go func(s named) {
+			defer drains.Done()
+			if err := s.server.Shutdown(shutdownCtx); err != nil {
+				logrus.Warningf("%s didn't drain in time: %s", s.name, err)
+			}
+		}(s)
+	}
+	cancelWorkers()
+
+	stopped := make(chan struct{})
+	go func() {
+		servers.Wait()
+		drains.Wait()
+		workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		logrus.Debug("servers and workers gracefully stopped")
+	case <-shutdownCtx.Done():
+		logrus.Warning("servers and workers didn't stop in time")
+	}
+
+	closeCtx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	var group errs.Group
+	group.Add(failure)
+	for done := false; !done; {
+		select {
+		case err := <-failures:
+			group.Add(err)
+		default:
+			done = true
+		}
+	}
+	for i := len(m.closers) - 1; i >= 0; i-- {
+		c := m.closers[i]
+		if err := c.closer(closeCtx); err != nil {
+			group.Add(lifecycleErr.New("closing %s: %s", c.name, err))
+		}
+	}
+	return group.Err()
+}
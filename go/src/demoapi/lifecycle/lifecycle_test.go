@@ -0,0 +1,114 @@
+package lifecycle
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var calls []string
+	m := New(time.Second)
+	m.AddServer("server", &http.Server{Addr: "127.0.0.1:0"})
+	m.AddWorker("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		calls = append(calls, "worker stopped")
+		return ctx.Err()
+	})
+	m.AddCloser("first", func(context.Context) error {
+		calls = append(calls, "first closed")
+		return nil
+	})
+	m.AddCloser("second", func(context.Context) error {
+		calls = append(calls, "second closed")
+		return nil
+	})
+	m.OnShutdown(func() { calls = append(calls, "shutdown") })
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+	assert.NoError(t, m.Run(ctx))
+	assert.Equal(t, []string{"shutdown", "worker stopped", "second closed",
+		"first closed"}, calls)
+}
+
+func TestRunBindFailure(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer lis.Close()
+
+	closed := false
+	m := New(time.Second)
+	m.AddServer("server", &http.Server{Addr: lis.Addr().String()})
+	m.AddCloser("database", func(context.Context) error {
+		closed = true
+		return nil
+	})
+
+	// a server that can't bind shuts everything else down
+	err = m.Run(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "server failed")
+	assert.True(t, closed)
+}
+
+// listenerServer serves on a listener that's already bound, so that the test
+// knows its address
+type listenerServer struct {
+	*http.Server
+	lis net.Listener
+}
+
+func (s *listenerServer) ListenAndServe() error { return s.Serve(s.lis) }
+
+func TestRunDrainsRequests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	var finished atomic.Bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		finished.Store(true)
+	})
+
+	var closedAfterRequest bool
+	m := New(time.Second)
+	m.AddServer("server", &listenerServer{
+		Server: &http.Server{Handler: handler}, lis: lis})
+	m.AddCloser("database", func(context.Context) error {
+		closedAfterRequest = finished.Load()
+		return nil
+	})
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + lis.Addr().String())
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	// the request in flight when shutdown began finishes before the
+	// closers run
+	assert.NoError(t, m.Run(ctx))
+	assert.True(t, closedAfterRequest)
+	assert.Equal(t, http.StatusOK, <-status)
+}
@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDMiddleware takes the request id from the X-Request-ID header, or
+// generates one, and stores it in the request context. The id is echoed back
+// in the X-Request-ID response header.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := RequestIDOrNew(r.Header.Get(RequestIDHeader))
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), requestID)))
+	})
+}
+
+// AccessLog logs a line for every request once it has been served. It must be
+// used after RequestIDMiddleware so the line has the request id.
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+
+		defer func() {
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK // nothing was written
+			}
+			Entry(r.Context()).WithFields(logrus.Fields{
+				"method":   r.Method,
+				"path":     r.URL.Path,
+				"status":   status,
+				"bytes":    ww.BytesWritten(),
+				"duration": time.Since(start).String(),
+				"remote":   r.RemoteAddr,
+			}).Info("request")
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+
+	"demoapi/util"
+)
+
+// This package ties log entries to the request that caused them. Every request
+// gets an id, taken from the X-Request-ID header or generated, that is stored
+// in its context and added as a field to the log entries returned by Entry.
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	RequestIDField  = "request_id"
+	TraceIDField    = "trace_id"
+
+	// incoming ids that are longer than this are replaced
+	maxRequestIDLength = 128
+)
+
+type ctxKey int
+
+const requestIDKey ctxKey = iota
+
+// WithRequestID returns a copy of ctx that carries the request id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request id carried by ctx, or "" if there isn't one
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// Entry returns a log entry with the request id and trace id of ctx as
+// fields. Use it instead of the logrus package functions when there's a
+// context.
+func Entry(ctx context.Context) *logrus.Entry {
+	entry := logrus.NewEntry(logrus.StandardLogger())
+	if requestID := RequestID(ctx); requestID != "" {
+		entry = entry.WithField(RequestIDField, requestID)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		entry = entry.WithField(TraceIDField, sc.TraceID().String())
+	}
+	return entry
+}
+
+// RequestIDOrNew returns the id provided by a client if it's safe to log and
+// echo back, and a new id otherwise
+func RequestIDOrNew(provided string) string {
+	if provided == "" || len(provided) > maxRequestIDLength {
+		return util.MustUUID4()
+	}
+	for _, c := range provided {
+		// printable ascii only, so an id can't forge log lines or headers
+		if c < ' ' || c > '~' {
+			return util.MustUUID4()
+		}
+	}
+	return provided
+}
+
+// SetFormat sets the format of the standard logger to "text" or "json"
+func SetFormat(format string) {
+	switch format {
+	case "json":
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		logrus.SetFormatter(&logrus.TextFormatter{})
+	}
+}
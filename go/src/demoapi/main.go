@@ -2,18 +2,22 @@ package main
 
 import (
 	"context"
-	"net/http"
 	"os"
-	"os/signal"
-	"sync"
-	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/zeebo/errs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"demoapi/config"
+	"demoapi/database"
+	"demoapi/handler"
+	"demoapi/lifecycle"
+	"demoapi/logging"
 	"demoapi/prometheus"
 	api "demoapi/server"
+	"demoapi/tlsconfig"
+	"demoapi/tracing"
 )
 
 //
@@ -33,78 +37,103 @@ func main() {
 
 func run() error {
 	// pulls in flag files, flag values, and environment variables
+	if config.PrintRequested() {
+		return config.Print(os.Stdout)
+	}
 	conf, err := config.Parse()
 	if err != nil {
 		return errs.New("configuration error: %+v", err)
 	}
 
-	// set loglevel defined in config
-	logrus.SetLevel(conf.LogLevel)
-
-	// create a context that we can cancel
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// the reloadable settings are applied again on SIGHUP or when the config
+	// file changes
+	applySettings(conf)
+	store := config.NewStore(conf)
+	store.OnReload(applySettings)
 
-	// stay alive for all goroutines to finish
-	wg := sync.WaitGroup{}
+	// the lifecycle manager runs every server until SIGINT or SIGTERM, then
+	// drains them within the graceful shutdown timeout
+	manager := lifecycle.New(conf.GracefulShutdownTimeout)
+	ctx := context.Background()
 
-	// initialize the metric server
-	metricServer, metricMiddleware, err := prometheus.NewHTTPServer(conf)
+	// set up the exporter for request traces. the spans of the last requests
+	// are flushed once the servers have drained
+	shutdownTracing, err := tracing.Setup(ctx, conf, version)
 	if err != nil {
 		return err
 	}
+	manager.AddCloser("tracing", shutdownTracing)
 
-	// initialize the api server
-	apiServer, err := api.NewHTTPServer(conf, metricMiddleware)
+	// initialize the metric server. both http servers serve tls if it's
+	// configured, and reload their certificate files when they change
+	metricHTTPServer, err := prometheus.NewHTTPServer(conf.MetricAddress)
+	if err != nil {
+		return err
+	}
+	metricServer, err := tlsconfig.NewServer(metricHTTPServer, conf.MetricTLS)
 	if err != nil {
 		return err
 	}
 
-	// service 1 - start the metric server
-	wg.Add(1)
-	go gracefullyServe(ctx, &wg, metricServer, conf.GracefulShutdownTimeout)
+	// connect to the database shared by the http and grpc apis
+	// TODO(sam): pass through database configs
+	db, err := database.Connect(conf.DBURL, nil)
+	if err != nil {
+		return err
+	}
+	manager.AddCloser("database", func(context.Context) error {
+		return db.Close()
+	})
+	apiService := api.New(db, conf)
+	store.OnReload(apiService.Reload)
+	manager.AddWorker("config watcher", store.Watch)
+	apiServer, err := tlsconfig.NewServer(api.NewHTTPServer(apiService),
+		conf.APITLS)
+	if err != nil {
+		return err
+	}
+	manager.AddWorker("metric tls watcher", metricServer.Watch)
+	manager.AddWorker("api tls watcher", apiServer.Watch)
+	manager.AddWorker("idempotency key sweeper",
+		apiService.SweepIdempotentRequests)
+
+	// report not ready while the servers drain
+	manager.OnShutdown(apiService.ShutDown)
+
+	// service 1 - the metric server
+	manager.AddServer("metric server", metricServer)
+
+	// service 2 - the api server
+	manager.AddServer("api server", apiServer)
+
+	// service 3 - the grpc api server, if it's configured. it serves the same
+	// tls as the api server, including its reloads and client certificates
+	if conf.GRPCAddress != "" {
+		opts := prometheus.GRPCServerOptions(conf.APISlug)
+		if apiServer.TLSConfig != nil {
+			opts = append(opts,
+				grpc.Creds(credentials.NewTLS(apiServer.TLSConfig)))
+		}
+		manager.AddServer("grpc server", api.NewGRPCServer(apiService,
+			opts...))
+	}
 
-	// service 2 - start the api server
 	logrus.Infof("starting demoapi version %q", version)
-	wg.Add(1)
-	go gracefullyServe(ctx, &wg, apiServer, conf.GracefulShutdownTimeout)
-
-	// listen for C-c interrupt
-	interruptWaiter := make(chan os.Signal, 1)
-	signal.Notify(interruptWaiter, os.Interrupt)
-	<-interruptWaiter // block until interrupt signal received
-
-	cancel() // cancel context and let gracefullyServes spin down
-	wg.Wait()
+	if err := manager.Run(ctx); err != nil {
+		return err
+	}
 
 	logrus.Info("shut down")
 	return nil
 }
 
-func gracefullyServe(ctx context.Context, wg *sync.WaitGroup, s *http.Server,
-	shutdownTimeout time.Duration) {
-
-	defer wg.Done()
-	go func() {
-		// start the server
-		if err := s.ListenAndServe(); err != nil {
-			logrus.Errorf("%s", err)
-		}
-	}()
-
-	<-ctx.Done() // blocks until the context is cancelled
-	logrus.Info("shutting down...")
-
-	// set timeout with a new context (the last one has been canceled) incase
-	// something takes forever after interrupt
-	shutdownCtx, cancel := context.WithTimeout(context.Background(),
-		shutdownTimeout)
-	defer cancel()
-
-	go func() {
-		_ = s.Shutdown(shutdownCtx)
-		// ignore "Error shutting down server: context canceled"
-	}()
+// applySettings applies the reloadable settings that are global to the
+// process
+func applySettings(conf *config.Configs) {
+	// set loglevel and format defined in config
+	logrus.SetLevel(conf.LogLevel)
+	logging.SetFormat(conf.LogFormat)
 
-	logrus.Debug("server gracefully stopped")
+	// only show the text of unexpected errors to clients in developer mode
+	handler.SetDeveloperMode(conf.DeveloperMode)
 }
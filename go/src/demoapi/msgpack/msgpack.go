@@ -0,0 +1,333 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/zeebo/errs"
+)
+
+// This package encodes the values that encoding/json decodes into an
+// interface{} as MessagePack, and decodes MessagePack back into them. Values
+// are converted to and from json first (see handler/codec.go), so their json
+// tags and marshalers apply to every format.
+//
+// https://github.com/msgpack/msgpack/blob/master/spec.md
+
+var msgpackErr = errs.Class("msgpack")
+
+// maxDepth bounds how deeply arrays and maps can be nested when decoding
+const maxDepth = 100
+
+// Marshal encodes a json value: nil, bool, int64, float64, json.Number,
+// string, []interface{} or map[string]interface{}. map keys are sorted, so
+// the same value is always encoded the same way.
+func Marshal(v interface{}) ([]byte, error) {
+	return appendValue(nil, v)
+}
+
+func appendValue(buf []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, 0xc0), nil
+	case bool:
+		if v {
+			return append(buf, 0xc3), nil
+		}
+		return append(buf, 0xc2), nil
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return appendInt(buf, i), nil
+		}
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return appendUint(buf, u), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, msgpackErr.Wrap(err)
+		}
+		return appendFloat(buf, f), nil
+	case int64:
+		return appendInt(buf, v), nil
+	case float64:
+		return appendFloat(buf, v), nil
+	case string:
+		return appendString(buf, v), nil
+	case []interface{}:
+		buf = appendLength(buf, len(v), 0x90, 0xdc)
+		for _, item := range v {
+			var err error
+			buf, err = appendValue(buf, item)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		buf = appendLength(buf, len(v), 0x80, 0xde)
+		for _, key := range keys {
+			buf = appendString(buf, key)
+			var err error
+			buf, err = appendValue(buf, v[key])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	}
+	return nil, msgpackErr.New("can't encode a %T", v)
+}
+
+func appendInt(buf []byte, i int64) []byte {
+	switch {
+	case i >= 0:
+		return appendUint(buf, uint64(i))
+	case i >= -32:
+		return append(buf, byte(i))
+	case i >= math.MinInt8:
+		return append(buf, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xd2), uint32(i))
+	}
+	return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(i))
+}
+
+func appendUint(buf []byte, u uint64) []byte {
+	switch {
+	case u <= 0x7f:
+		return append(buf, byte(u))
+	case u <= math.MaxUint8:
+		return append(buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xcd), uint16(u))
+	case u <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xce), uint32(u))
+	}
+	return binary.BigEndian.AppendUint64(append(buf, 0xcf), u)
+}
+
+func appendFloat(buf []byte, f float64) []byte {
+	return binary.BigEndian.AppendUint64(append(buf, 0xcb),
+		math.Float64bits(f))
+}
+
+func appendString(buf []byte, s string) []byte {
+	switch n := len(s); {
+	case n <= 31:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = binary.BigEndian.AppendUint16(append(buf, 0xda), uint16(n))
+	default:
+		buf = binary.BigEndian.AppendUint32(append(buf, 0xdb), uint32(n))
+	}
+	return append(buf, s...)
+}
+
+// appendLength appends the header of an array or map. fix is the format of
+// up to 15 items and format16 is followed by the 32 bit format.
+func appendLength(buf []byte, n int, fix, format16 byte) []byte {
+	switch {
+	case n <= 15:
+		return append(buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, format16),
+			uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(buf, format16+1), uint32(n))
+}
+
+// Unmarshal decodes a single MessagePack value into the types that
+// encoding/json uses for an interface{}. integers are decoded as
+// json.Number, and binary data as a string. extension types aren't
+// supported.
+func Unmarshal(data []byte) (interface{}, error) {
+	d := &decoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, msgpackErr.New("%d bytes after the value",
+			len(d.data)-d.pos)
+	}
+	return v, nil
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, msgpackErr.New("unexpected end of data")
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// length reads a big endian length of size bytes
+func (d *decoder) length(size int) (int, error) {
+	b, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	// every item takes at least a byte, so longer lengths can't be valid
+	if n > uint64(len(d.data)-d.pos) {
+		return 0, msgpackErr.New("length %d is longer than the data", n)
+	}
+	return int(n), nil
+}
+
+func (d *decoder) value(depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, msgpackErr.New("nested more than %d levels", maxDepth)
+	}
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c := b[0]; {
+	case c <= 0x7f:
+		return json.Number(strconv.Itoa(int(c))), nil
+	case c >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(c)))), nil
+	case c&0xf0 == 0x80:
+		return d.object(int(c&0x0f), depth)
+	case c&0xf0 == 0x90:
+		return d.array(int(c&0x0f), depth)
+	case c&0xe0 == 0xa0:
+		return d.str(int(c & 0x1f))
+	}
+
+	switch b[0] {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xd9:
+		return d.strWithLength(1)
+	case 0xc5, 0xda:
+		return d.strWithLength(2)
+	case 0xc6, 0xdb:
+		return d.strWithLength(4)
+	case 0xca:
+		raw, err := d.next(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(raw))),
+			nil
+	case 0xcb:
+		raw, err := d.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(raw)), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		raw, err := d.next(1 << (b[0] - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		var u uint64
+		for _, c := range raw {
+			u = u<<8 | uint64(c)
+		}
+		return json.Number(strconv.FormatUint(u, 10)), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b[0] - 0xd0)
+		raw, err := d.next(size)
+		if err != nil {
+			return nil, err
+		}
+		var u uint64
+		for _, c := range raw {
+			u = u<<8 | uint64(c)
+		}
+		// sign extend from the size of the integer
+		shift := 64 - 8*uint(size)
+		i := int64(u<<shift) >> shift
+		return json.Number(strconv.FormatInt(i, 10)), nil
+	case 0xdc, 0xdd:
+		n, err := d.length(2 << (b[0] - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(n, depth)
+	case 0xde, 0xdf:
+		n, err := d.length(2 << (b[0] - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.object(n, depth)
+	}
+	return nil, msgpackErr.New("unsupported format 0x%02x", b[0])
+}
+
+func (d *decoder) str(n int) (string, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (d *decoder) strWithLength(size int) (string, error) {
+	n, err := d.length(size)
+	if err != nil {
+		return "", err
+	}
+	return d.str(n)
+}
+
+func (d *decoder) array(n, depth int) (interface{}, error) {
+	arr := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+	}
+	return arr, nil
+}
+
+func (d *decoder) object(n, depth int) (interface{}, error) {
+	obj := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		k, ok := key.(string)
+		if !ok {
+			return nil, msgpackErr.New("map keys have to be strings")
+		}
+		obj[k], err = d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
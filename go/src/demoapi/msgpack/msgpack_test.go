@@ -0,0 +1,75 @@
+package msgpack
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshal(t *testing.T) {
+	for _, test := range []struct {
+		value interface{}
+		bytes []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{json.Number("5"), []byte{0x05}},
+		{int64(-40), []byte{0xd0, 0xd8}},
+		{json.Number("-1"), []byte{0xff}},
+		{json.Number("200"), []byte{0xcc, 0xc8}},
+		{json.Number("-200"), []byte{0xd1, 0xff, 0x38}},
+		{json.Number("1.5"), []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"abc", []byte{0xa3, 'a', 'b', 'c'}},
+		{[]interface{}{true, nil}, []byte{0x92, 0xc3, 0xc0}},
+		{map[string]interface{}{"b": false, "a": "x"},
+			[]byte{0x82, 0xa1, 'a', 0xa1, 'x', 0xa1, 'b', 0xc2}},
+	} {
+		b, err := Marshal(test.value)
+		require.NoError(t, err)
+		assert.Equal(t, test.bytes, b, "%#v", test.value)
+	}
+
+	_, err := Marshal(struct{}{})
+	assert.Error(t, err)
+}
+
+func TestRoundTrip(t *testing.T) {
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(`{
+		"userid": "user1",
+		"created": 1600000000,
+		"score": -70000,
+		"ratio": 0.25,
+		"groups": ["a", "b"],
+		"tags": {"long": "` + strings.Repeat("x", 300) + `"},
+		"missing": null
+	}`))
+	decoder.UseNumber()
+	require.NoError(t, decoder.Decode(&value))
+
+	b, err := Marshal(value)
+	require.NoError(t, err)
+	decoded, err := Unmarshal(b)
+	require.NoError(t, err)
+
+	// floats are decoded as float64, like encoding/json does
+	value.(map[string]interface{})["ratio"] = 0.25
+	assert.Equal(t, value, decoded)
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	for _, data := range [][]byte{
+		{},
+		{0x92, 0xc3},                   // array that ends early
+		{0xdb, 0xff, 0xff, 0xff, 0xff}, // string longer than the data
+		{0x81, 0x01, 0x02},             // map with an integer key
+		{0xc1},                         // never used
+		{0xc3, 0xc3},                   // trailing data
+	} {
+		_, err := Unmarshal(data)
+		assert.Error(t, err, "%x", data)
+	}
+}
@@ -0,0 +1,100 @@
+package prometheus
+
+import (
+	"database/sql"
+	"sync"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// dbStatsCollector exports the sql.DBStats of a connection pool
+type dbStatsCollector struct {
+	db *sql.DB
+
+	maxOpen           *prom.Desc
+	open              *prom.Desc
+	inUse             *prom.Desc
+	idle              *prom.Desc
+	waitCount         *prom.Desc
+	waitDuration      *prom.Desc
+	maxIdleClosed     *prom.Desc
+	maxIdleTimeClosed *prom.Desc
+	maxLifetimeClosed *prom.Desc
+}
+
+func newDBStatsCollector(db *sql.DB, driver string) *dbStatsCollector {
+	labels := prom.Labels{"driver": driver}
+	desc := func(name, help string) *prom.Desc {
+		return prom.NewDesc("db_"+name, help, nil, labels)
+	}
+	return &dbStatsCollector{
+		db: db,
+		maxOpen: desc("max_open_connections",
+			"Maximum number of open connections to the database"),
+		open: desc("open_connections",
+			"The number of established connections, in use and idle"),
+		inUse: desc("in_use_connections",
+			"The number of connections currently in use"),
+		idle: desc("idle_connections", "The number of idle connections"),
+		waitCount: desc("wait_count_total",
+			"The total number of connections waited for"),
+		waitDuration: desc("wait_duration_seconds_total",
+			"The total time blocked waiting for a new connection"),
+		maxIdleClosed: desc("max_idle_closed_total",
+			"The total number of connections closed due to max_idle"),
+		maxIdleTimeClosed: desc("max_idle_time_closed_total",
+			"The total number of connections closed due to max_idle_time"),
+		maxLifetimeClosed: desc("max_lifetime_closed_total",
+			"The total number of connections closed due to max_lifetime"),
+	}
+}
+
+func (c *dbStatsCollector) Describe(ch chan<- *prom.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.maxIdleClosed
+	ch <- c.maxIdleTimeClosed
+	ch <- c.maxLifetimeClosed
+}
+
+func (c *dbStatsCollector) Collect(ch chan<- prom.Metric) {
+	stats := c.db.Stats()
+	gauge := func(desc *prom.Desc, v float64) {
+		ch <- prom.MustNewConstMetric(desc, prom.GaugeValue, v)
+	}
+	counter := func(desc *prom.Desc, v float64) {
+		ch <- prom.MustNewConstMetric(desc, prom.CounterValue, v)
+	}
+	gauge(c.maxOpen, float64(stats.MaxOpenConnections))
+	gauge(c.open, float64(stats.OpenConnections))
+	gauge(c.inUse, float64(stats.InUse))
+	gauge(c.idle, float64(stats.Idle))
+	counter(c.waitCount, float64(stats.WaitCount))
+	counter(c.waitDuration, stats.WaitDuration.Seconds())
+	counter(c.maxIdleClosed, float64(stats.MaxIdleClosed))
+	counter(c.maxIdleTimeClosed, float64(stats.MaxIdleTimeClosed))
+	counter(c.maxLifetimeClosed, float64(stats.MaxLifetimeClosed))
+}
+
+var (
+	dbStatsMu sync.Mutex
+	dbStats   *dbStatsCollector
+)
+
+// RegisterDBStats exports the connection pool stats of db. Only one pool is
+// exported at a time, so connecting to a database again replaces the stats of
+// the previous connection.
+func RegisterDBStats(db *sql.DB, driver string) {
+	dbStatsMu.Lock()
+	defer dbStatsMu.Unlock()
+
+	if dbStats != nil {
+		prom.Unregister(dbStats)
+	}
+	dbStats = newDBStatsCollector(db, driver)
+	prom.MustRegister(dbStats)
+}
@@ -0,0 +1,56 @@
+package prometheus
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCServerOptions returns the grpc equivalent of basicMetricsMiddleware. the
+// interceptors collect basic metrics on all unary and streaming rpcs
+func GRPCServerOptions(service string) []grpc.ServerOption {
+	labels := prometheus.Labels{"service": service}
+	inFlight := grpcRequestInFlightGauge.With(labels)
+	counter := grpcRequestCounter.MustCurryWith(labels)
+	duration := grpcRequestDuration.MustCurryWith(labels)
+
+	observe := func(method string, start time.Time, err error) {
+		code := status.Code(err).String()
+		counter.WithLabelValues(code, method).Inc()
+		duration.WithLabelValues(code, method).Observe(
+			time.Since(start).Seconds())
+	}
+
+	unary := func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (
+		interface{}, error) {
+
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observe(info.FullMethod, start, err)
+		return resp, err
+	}
+
+	stream := func(srv interface{}, ss grpc.ServerStream,
+		info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		err := handler(srv, ss)
+		observe(info.FullMethod, start, err)
+		return err
+	}
+
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary),
+		grpc.ChainStreamInterceptor(stream),
+	}
+}
@@ -10,6 +10,10 @@ import (
 //     UsersGauge.Inc()
 //     UsersGauge.Set(10)
 //
+// and with labels:
+//
+//     DatabaseQueryCounter.WithLabelValues("Create_User", "sqlite3").Inc()
+//
 
 var (
 	// collectors used in the basic http request middleware
@@ -22,21 +26,55 @@ var (
 		prom.CounterOpts{
 			Name: "http_requests_total",
 			Help: "A counter of HTTP requests served",
-		}, []string{"service", "code", "method"})
+		}, []string{"service", "code", "method", "route", "error"})
 	httpRequestDuration = prom.NewHistogramVec(
 		prom.HistogramOpts{
 			Name:    "http_requests_duration_seconds",
 			Help:    "A histogram of HTTP request durations",
 			Buckets: []float64{0.01, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
-		}, []string{"service", "code", "method"})
+		}, []string{"service", "code", "method", "route", "error"})
+	httpRequestSize = prom.NewHistogramVec(
+		prom.HistogramOpts{
+			Name:    "http_request_size_bytes",
+			Help:    "A histogram of HTTP request body sizes",
+			Buckets: []float64{100, 1000, 10000, 100000, 1000000},
+		}, []string{"service", "method", "route"})
 	httpResponseSize = prom.NewHistogramVec(
 		prom.HistogramOpts{
 			Name:    "http_response_size_bytes",
 			Help:    "A histogram of HTTP response sizes",
 			Buckets: []float64{100, 1000, 10000, 100000, 1000000},
+		}, []string{"service", "method", "route"})
+
+	// collectors used in the basic grpc interceptors
+	grpcRequestInFlightGauge = prom.NewGaugeVec(
+		prom.GaugeOpts{
+			Name: "grpc_requests_in_flight",
+			Help: "A gauge of active rpcs",
 		}, []string{"service"})
+	grpcRequestCounter = prom.NewCounterVec(
+		prom.CounterOpts{
+			Name: "grpc_requests_total",
+			Help: "A counter of rpcs served",
+		}, []string{"service", "code", "method"})
+	grpcRequestDuration = prom.NewHistogramVec(
+		prom.HistogramOpts{
+			Name:    "grpc_requests_duration_seconds",
+			Help:    "A histogram of rpc durations",
+			Buckets: []float64{0.01, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		}, []string{"service", "code", "method"})
 
 	// exported metrics
+	ConfigReloadCounter = prom.NewCounterVec(
+		prom.CounterOpts{
+			Name: "config_reloads_total",
+			Help: "Counter of configuration reloads by result",
+		}, []string{"result"})
+	RateLimitRejectionCounter = prom.NewCounterVec(
+		prom.CounterOpts{
+			Name: "rate_limit_rejections_total",
+			Help: "Counter of requests rejected by the rate limits",
+		}, []string{"service", "class", "key"})
 	UserGauge = prom.NewGauge(
 		prom.GaugeOpts{
 			Name: "db_users",
@@ -52,17 +90,33 @@ var (
 			Name: "db_memberships",
 			Help: "Gauge of memberships in the database",
 		})
-	DatabaseQueryCounter = prom.NewCounter(
+	DatabaseQueryCounter = prom.NewCounterVec(
 		prom.CounterOpts{
 			Name: "db_queries_total",
-			Help: "Counter of team.getConversationHistoryWithin calls",
-		})
-	DatabaseQueryLatencyHistogram = prom.NewHistogram(
+			Help: "Counter of database queries",
+		}, []string{"op", "driver"})
+	DatabaseQueryLatencyHistogram = prom.NewHistogramVec(
 		prom.HistogramOpts{
 			Name:    "db_queries_latency_seconds",
 			Help:    "A histogram of database query latencies in seconds",
 			Buckets: []float64{0.01, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
-		})
+		}, []string{"op", "driver"})
+	DatabaseQueryErrorCounter = prom.NewCounterVec(
+		prom.CounterOpts{
+			Name: "db_query_errors_total",
+			Help: "Counter of failed database queries",
+		}, []string{"op", "driver", "class"})
+	DatabaseTransactionDuration = prom.NewHistogramVec(
+		prom.HistogramOpts{
+			Name:    "db_transactions_duration_seconds",
+			Help:    "A histogram of database transaction durations in seconds",
+			Buckets: []float64{0.01, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		}, []string{"driver"})
+	DatabaseTransactionRollbackCounter = prom.NewCounterVec(
+		prom.CounterOpts{
+			Name: "db_transaction_rollbacks_total",
+			Help: "Counter of database transactions that were rolled back",
+		}, []string{"driver"})
 )
 
 func init() {
@@ -73,13 +127,24 @@ func init() {
 		httpRequestInFlightGauge,
 		httpRequestCounter,
 		httpRequestDuration,
+		httpRequestSize,
 		httpResponseSize,
 
+		// built in grpc request collectors
+		grpcRequestInFlightGauge,
+		grpcRequestCounter,
+		grpcRequestDuration,
+
 		// exported collectors to be used throughout application
+		ConfigReloadCounter,
+		RateLimitRejectionCounter,
 		UserGauge,
 		GroupGauge,
 		MembershipGauge,
 		DatabaseQueryCounter,
 		DatabaseQueryLatencyHistogram,
+		DatabaseQueryErrorCounter,
+		DatabaseTransactionDuration,
+		DatabaseTransactionRollbackCounter,
 	)
 }
@@ -1,57 +1,110 @@
 package prometheus
 
 import (
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
-	"demoapi/config"
 	"demoapi/handler"
+	he "demoapi/httperror"
 )
 
-// NewHTTPServer constructs a new http.Server to listen for connections and
-// serve responses for the prometheus metric collection service
-func NewHTTPServer(configs *config.Configs) (*http.Server,
-	handler.MiddlewareWrapper, error) {
-
+// NewHTTPServer constructs a new http.Server to listen for connections on
+// address and serve responses for the prometheus metric collection service
+func NewHTTPServer(address string) (*http.Server, error) {
 	monitorHandler := http.NewServeMux()
 	monitorHandler.Handle("/metrics", promhttp.Handler())
 
 	s := &http.Server{
-		Addr:    configs.MetricAddress,
+		Addr:    address,
 		Handler: monitorHandler,
 	}
 
-	return s, basicMetricsMiddleware(configs.APISlug), nil
+	return s, nil
 }
 
-// basicMetricsMiddleware will return an http handler that can be used as
-// middleware to wrap other servers and collect basic metrics on all requests
-func basicMetricsMiddleware(service string) handler.MiddlewareWrapper {
-	return func(h http.Handler) http.Handler {
-
-		// TODO(sam): verify that these capture request path info
-		h = promhttp.InstrumentHandlerInFlight(
-			httpRequestInFlightGauge.With(
-				prometheus.Labels{"service": service}),
-			h)
-
-		h = promhttp.InstrumentHandlerCounter(
-			httpRequestCounter.MustCurryWith(
-				prometheus.Labels{"service": service}),
-			h)
-
-		h = promhttp.InstrumentHandlerDuration(
-			httpRequestDuration.MustCurryWith(
-				prometheus.Labels{"service": service}),
-			h)
-
-		h = promhttp.InstrumentHandlerResponseSize(
-			httpResponseSize.MustCurryWith(
-				prometheus.Labels{"service": service}),
-			h)
-
-		return h
+// unmatchedRoute is the route label of requests that didn't match a route
+const unmatchedRoute = "unmatched"
+
+// BasicMetricsMiddleware returns middleware that collects basic metrics on all
+// requests. It has to be used inside of a chi router, since requests are
+// labeled with the route pattern that they matched, like "/users/{userID}",
+// rather than their path, which would be a new label for every id. Failed
+// requests are also labeled with their error code, like "user_not_found".
+func BasicMetricsMiddleware(service string) handler.MiddlewareWrapper {
+	labels := prometheus.Labels{"service": service}
+	inFlight := httpRequestInFlightGauge.With(labels)
+	counter := httpRequestCounter.MustCurryWith(labels)
+	duration := httpRequestDuration.MustCurryWith(labels)
+	requestSize := httpRequestSize.MustCurryWith(labels)
+	responseSize := httpResponseSize.MustCurryWith(labels)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			start := time.Now()
+			ctx := he.WithRecorder(r.Context())
+			body := &countingReader{ReadCloser: r.Body}
+			if r.Body != nil {
+				r.Body = body
+			}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			// the route context is shared with the router, so the pattern is
+			// complete once the request has been served
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(ctx); rctx != nil {
+				if pattern := rctx.RoutePattern(); pattern != "" {
+					route = pattern
+				}
+			}
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK // nothing was written
+			}
+
+			errCode := ""
+			if err := he.Recorded(ctx); err != nil {
+				errCode = he.CodeByError(err).Code
+			} else if code := he.CodeByStatus(status); code != nil {
+				errCode = code.Code
+			}
+
+			size := r.ContentLength
+			if size < 0 {
+				size = body.n // the length wasn't known up front
+			}
+
+			code := strconv.Itoa(status)
+			counter.WithLabelValues(code, r.Method, route, errCode).Inc()
+			duration.WithLabelValues(code, r.Method, route, errCode).Observe(
+				time.Since(start).Seconds())
+			requestSize.WithLabelValues(r.Method, route).Observe(float64(size))
+			responseSize.WithLabelValues(r.Method, route).Observe(
+				float64(ww.BytesWritten()))
+		})
 	}
 }
+
+// countingReader counts the bytes read from a request body
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// how often buckets that have refilled are forgotten, so that clients that
+// went away don't use memory forever
+const sweepInterval = time.Minute
+
+// Limit is a token bucket that refills PerMinute tokens a minute and holds at
+// most Burst of them. every request takes a token. a Limit without a rate is
+// unlimited.
+type Limit struct {
+	PerMinute int
+	Burst     int
+}
+
+// Unlimited returns whether requests are never limited
+func (l Limit) Unlimited() bool {
+	return l.PerMinute <= 0
+}
+
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d/min burst %d", l.PerMinute, l.Burst)
+}
+
+// perSecond returns the refill rate in tokens a second
+func (l Limit) perSecond() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+// Limits are the limits of every route class
+type Limits struct {
+	Reads  Limit
+	Writes Limit
+	Bulk   Limit // writes with lists of members, that are expensive to apply
+}
+
+// Result describes a request that was checked against a Limit
+type Result struct {
+	Allowed   bool
+	Limit     int           // the most requests that can be made at once
+	Remaining int           // requests that can be made right away
+	Reset     time.Duration // until the bucket is full again
+	// RetryAfter is how long to wait until the next request is allowed. it's
+	// zero for allowed requests
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// refill adds the tokens that limit refilled since the bucket was updated
+func (b *bucket) refill(limit Limit, now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	b.tokens = math.Min(float64(limit.Burst),
+		b.tokens+elapsed*limit.perSecond())
+	b.updated = now
+}
+
+// Limiter has a token bucket for every key, like every client
+type Limiter struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewLimiter returns a Limiter without any buckets
+func NewLimiter() *Limiter {
+	return &Limiter{now: time.Now, buckets: map[string]*bucket{}}
+}
+
+// Allow takes a token from the bucket of key if it has one. limit is passed
+// on every call so that it can be changed while requests are being served.
+// buckets start out full.
+func (l *Limiter) Allow(key string, limit Limit) Result {
+	if limit.Unlimited() {
+		return Result{Allowed: true}
+	}
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(limit, now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		l.buckets[key] = b
+	}
+	b.refill(limit, now)
+
+	result := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / limit.perSecond())
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = seconds((float64(limit.Burst) - b.tokens) /
+		limit.perSecond())
+	return result
+}
+
+// sweep forgets the buckets that have refilled. a new bucket starts out full,
+// so it's the same as keeping them
+func (l *Limiter) sweep(limit Limit, now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		b.refill(limit, now)
+		if b.tokens >= float64(limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
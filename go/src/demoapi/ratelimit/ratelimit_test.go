@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := NewLimiter()
+	limiter.now = func() time.Time { return now }
+	limit := Limit{PerMinute: 60, Burst: 2}
+
+	// buckets start out full
+	result := limiter.Allow("client1", limit)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Limit)
+	assert.Equal(t, 1, result.Remaining)
+	assert.Equal(t, time.Second, result.Reset)
+	assert.True(t, limiter.Allow("client1", limit).Allowed)
+
+	result = limiter.Allow("client1", limit)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 2*time.Second, result.Reset)
+
+	// every client has its own bucket
+	assert.True(t, limiter.Allow("client2", limit).Allowed)
+
+	// a token is refilled every second
+	now = now.Add(500 * time.Millisecond)
+	result = limiter.Allow("client1", limit)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, limiter.Allow("client1", limit).Allowed)
+
+	// limits can change between requests
+	assert.True(t, limiter.Allow("client1", Limit{}).Allowed)
+
+	// refilled buckets are forgotten
+	now = now.Add(sweepInterval)
+	limiter.Allow("client3", limit)
+	assert.Len(t, limiter.buckets, 1)
+}
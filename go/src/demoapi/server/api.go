@@ -2,18 +2,13 @@ package server
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
 	"net/url"
-	"strconv"
 
 	"github.com/go-chi/chi"
-	"github.com/sirupsen/logrus"
 
-	"demoapi/database"
+	h "demoapi/handler"
 	he "demoapi/httperror"
-	monitor "demoapi/prometheus"
-	"demoapi/util"
 )
 
 const (
@@ -23,153 +18,95 @@ const (
 // Health is a simple endpoint that can be used to help determine server health
 // `GET /`
 func (s *Server) Health(ctx context.Context, w http.ResponseWriter,
-	r *http.Request) (interface{}, error) {
-	return map[string]string{"health": "okay!"}, nil
+	r *http.Request) (*h.Response, error) {
+	return h.OK(map[string]string{"health": "okay!"}), nil
 }
 
-// GetUser returns the matching user record or 404 if none exist.
-// `GET /users/<userID>`
+// GetUser returns the matching user record or 404 if none exist. With
+// `as_of`, the user is returned as it was at that time, without attributes.
+// `GET /v1/users/<userID>?as_of=<timestamp>`
 func (s *Server) GetUser(ctx context.Context, w http.ResponseWriter,
-	r *http.Request) (interface{}, error) {
+	r *http.Request) (*h.Response, error) {
 
 	userID := chi.URLParam(r, "userID")
 	if userID == "" {
 		return nil, he.BadRequest.New("incomplete path. missing userID")
 	}
 
-	user, err := s.DB.Find_User_By_Id(ctx, database.User_Id(userID))
+	at, err := asOf(r.URL.Query())
 	if err != nil {
 		return nil, err
 	}
 
-	if user == nil {
-		return nil, he.NotFound.New("userID %q doesn't exist", userID)
+	var user *User
+	if at != nil {
+		user, err = s.getUserAsOf(ctx, userID, *at)
+	} else {
+		user, err = s.getUser(ctx, userID)
 	}
-
-	groups, err := s.DB.All_Group_By_User_Id(ctx, database.User_Id(userID))
 	if err != nil {
 		return nil, err
 	}
 
 	resp := &RootJSON{
-		User: apiUser(user, groups),
+		User: user,
 	}
 
-	return resp, nil
+	return h.OK(resp), nil
 }
 
 // CreateUser creates a new user record. The body of the request should be a
-// valid user record.
-// `POST /users`
+// valid user record. Responds with a 201 and the location of the user.
+// `POST /v1/users`
 func (s *Server) CreateUser(ctx context.Context, w http.ResponseWriter,
-	r *http.Request) (interface{}, error) {
+	r *http.Request) (*h.Response, error) {
 
 	userJSON := User{}
-	err := json.NewDecoder(r.Body).Decode(&userJSON)
-	if err != nil {
-		return nil, he.BadRequest.Wrap(err)
-	}
-
-	if userJSON.FirstName == "" || userJSON.LastName == "" || userJSON.ID == "" {
-		return nil, he.BadRequest.New("required fields missing")
-	}
-
-	user, err := s.DB.Create_User(ctx,
-		database.User_Uuid(util.MustUUID4()),
-		database.User_Id(userJSON.ID),
-		database.User_FirstName(userJSON.FirstName),
-		database.User_LastName(userJSON.LastName))
+	err := h.Decode(r, &userJSON)
 	if err != nil {
-		// TODO(sam): catch unique constaint violations and return
-		// 400 instead of 500
 		return nil, err
 	}
 
-	monitor.UserGauge.Inc()
-
-	// TODO(sam): do this transactionally with the previous query
-	if len(userJSON.Groups) > 0 {
-		added, removed, unchanged, err := s.DB.SetUserMembership(ctx, user.Id,
-			parseMembership(userJSON.Groups))
-		if err != nil {
-			return nil, err
-		}
-
-		logrus.Debugf("memberships - added: %d, removed: %d, unchanged: %d", added,
-			removed, unchanged)
-
-		monitor.MembershipGauge.Add(float64(added))
-		monitor.MembershipGauge.Sub(float64(removed))
-	}
-
-	groups, err := s.DB.All_Group_By_User_Id(ctx, database.User_Id(user.Id))
+	user, err := s.createUser(ctx, &userJSON)
 	if err != nil {
 		return nil, err
 	}
 
 	resp := &RootJSON{
-		User: apiUser(user, groups),
+		User: user,
 	}
 
-	return resp, nil
+	return h.Created(apiV1+"/users/"+url.PathEscape(user.ID), resp), nil
 }
 
-// DeleteUser deletes a user record. Returns 404 if the user doesn't exist.
-// `DELETE /users/<userID>`
+// DeleteUser deletes a user record. Responds with a 204, or a 404 if the user
+// doesn't exist.
+// `DELETE /v1/users/<userID>`
 func (s *Server) DeleteUser(ctx context.Context, w http.ResponseWriter,
-	r *http.Request) (interface{}, error) {
+	r *http.Request) (*h.Response, error) {
 
 	userID := chi.URLParam(r, "userID")
 	if userID == "" {
 		return nil, he.BadRequest.New("incomplete path. missing userID")
 	}
 
-	deleted, err := s.DB.Delete_User_By_Id(ctx, database.User_Id(userID))
+	err := s.deleteUser(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
-
-	if !deleted {
-		return nil, he.NotFound.New("userID %q doesn't exist", userID)
-	}
-
-	monitor.UserGauge.Dec()
-
-	return nil, nil
+	return h.NoContent(), nil
 }
 
 // UpdateUser updates an existing user record. The body of the request should
 // be a valid user record. PUTs to a non-existent user should return a 404.
-// `PUT /users/<userID>`
+// `PUT /v1/users/<userID>`
 func (s *Server) UpdateUser(ctx context.Context, w http.ResponseWriter,
-	r *http.Request) (interface{}, error) {
+	r *http.Request) (*h.Response, error) {
 
 	userJSON := User{}
-	err := json.NewDecoder(r.Body).Decode(&userJSON)
+	err := h.Decode(r, &userJSON)
 	if err != nil {
-		return nil, he.BadRequest.Wrap(err)
-	}
-
-	updateUserData, updateMemberships := false, len(userJSON.Groups) > 0
-	userUpdates := database.User_Update_Fields{}
-
-	if userJSON.ID != "" {
-		userUpdates.Id = database.User_Id(userJSON.ID)
-		updateUserData = true
-	}
-
-	if userJSON.FirstName != "" {
-		userUpdates.FirstName = database.User_FirstName(userJSON.FirstName)
-		updateUserData = true
-	}
-
-	if userJSON.LastName != "" {
-		userUpdates.LastName = database.User_LastName(userJSON.LastName)
-		updateUserData = true
-	}
-
-	if !updateUserData && !updateMemberships {
-		return nil, he.BadRequest.New("no updates in request")
+		return nil, err
 	}
 
 	userID := chi.URLParam(r, "userID")
@@ -177,125 +114,94 @@ func (s *Server) UpdateUser(ctx context.Context, w http.ResponseWriter,
 		return nil, he.BadRequest.New("incomplete path. missing userID")
 	}
 
-	user, err := s.DB.Find_User_By_Id(ctx, database.User_Id(userID))
-	if err != nil {
-		return nil, err
-	}
-
-	if user == nil {
-		return nil, he.NotFound.New("userID %q doesn't exist", userID)
-	}
-
-	// TODO(sam): figure out how to extract "emptyUpdateError" from orm so that
-	// a separate DB call isn't necessary. don't use dbx?
-	if updateUserData {
-		user, err = s.DB.Update_User_By_Id(ctx, database.User_Id(userID),
-			userUpdates)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	// TODO(sam): do this transactionally with the previous query
-	added, removed, unchanged, err := s.DB.SetUserMembership(ctx, user.Id,
-		parseMembership(userJSON.Groups))
-	if err != nil {
-		return nil, err
-	}
-
-	logrus.Debugf("memberships - added: %d, removed: %d, unchanged: %d", added,
-		removed, unchanged)
-
-	monitor.MembershipGauge.Add(float64(added))
-	monitor.MembershipGauge.Sub(float64(removed))
-
-	groups, err := s.DB.All_Group_By_User_Id(ctx, database.User_Id(user.Id))
+	user, err := s.updateUser(ctx, userID, &userJSON)
 	if err != nil {
 		return nil, err
 	}
 
 	resp := &RootJSON{
-		User: apiUser(user, groups),
+		User: user,
 	}
 
-	return resp, nil
+	return h.OK(resp), nil
 }
 
 // GetMemberships returns a JSON list of user ids containing the members of
-// that group. Should return a 404 if the group doesn't exist.
-// `GET /groups/<groupName>`
+// that group. Should return a 404 if the group doesn't exist. With
+// `active_only=true`, suspended and deprovisioned users are left out. With
+// `as_of`, the group and its members are returned as they were at that time.
+// `GET /v1/groups/<groupName>?active_only=true&as_of=<timestamp>`
 func (s *Server) GetMemberships(ctx context.Context, w http.ResponseWriter,
-	r *http.Request) (interface{}, error) {
+	r *http.Request) (*h.Response, error) {
 
 	groupName := chi.URLParam(r, "groupName")
 	if groupName == "" {
 		return nil, he.BadRequest.New("incomplete path. missing groupName")
 	}
 
-	groupExists, err := s.DB.Has_Group_By_Name(ctx,
-		database.Group_Name(groupName))
+	activeOnly, err := boolParam(r.URL.Query(), activeOnlyParam)
 	if err != nil {
 		return nil, err
 	}
 
-	if !groupExists {
-		return nil, he.NotFound.New("groupName %q doesn't exist", groupName)
+	at, err := asOf(r.URL.Query())
+	if err != nil {
+		return nil, err
 	}
 
-	// this could return an empty set
-	users, err := s.DB.All_User_By_Group_Name(ctx, database.Group_Name(groupName))
+	var group *Group
+	if at != nil {
+		group, err = s.getGroupAsOf(ctx, groupName, *at, activeOnly)
+	} else {
+		group, err = s.getGroup(ctx, groupName, activeOnly)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	// the members are listed on their own, like before groups had metadata
+	members := group.Users
+	group.Users = nil
 	resp := &RootJSON{
-		Members: apiMembers(users),
+		Group:     group,
+		Members:   members,
+		membersOf: groupName,
 	}
 
-	return resp, nil
+	return h.OK(resp), nil
 }
 
 // CreateGroup creates an empty group. POSTs to an existing group should be
 // treated as errors and flagged with the appropriate HTTP status code. The
-// body should contain a `name` parameter
-// `POST /groups`
+// body should contain a `name` parameter. Responds with a 201 and the location
+// of the group.
+// `POST /v1/groups`
 func (s *Server) CreateGroup(ctx context.Context, w http.ResponseWriter,
-	r *http.Request) (interface{}, error) {
+	r *http.Request) (*h.Response, error) {
 
 	groupJSON := Group{}
-	err := json.NewDecoder(r.Body).Decode(&groupJSON)
+	err := h.Decode(r, &groupJSON)
 	if err != nil {
-		return nil, he.BadRequest.Wrap(err)
-	}
-
-	if groupJSON.Name == "" {
-		return nil, he.BadRequest.New("required fields missing")
+		return nil, err
 	}
 
-	// database enforces uniqueness constraint on group name
-	group, err := s.DB.Create_Group(ctx,
-		database.Group_Uuid(util.MustUUID4()),
-		database.Group_Name(groupJSON.Name))
+	group, err := s.createGroup(ctx, &groupJSON)
 	if err != nil {
-		// TODO(sam): catch unique constaint violations and return
-		// 400 instead of 500
 		return nil, err
 	}
 
-	monitor.GroupGauge.Inc()
-
 	resp := &RootJSON{
-		Group: apiGroup(group, nil),
+		Group: group,
 	}
 
-	return resp, nil
+	return h.Created(apiV1+"/groups/"+url.PathEscape(group.Name), resp), nil
 }
 
 // UpdateMembership updates the membership list for the group. The body of the
 // request should be a JSON list describing the group's members.
-// `PUT /groups/<groupName>`
+// `PUT /v1/groups/<groupName>`
 func (s *Server) UpdateMembership(ctx context.Context, w http.ResponseWriter,
-	r *http.Request) (interface{}, error) {
+	r *http.Request) (*h.Response, error) {
 
 	groupName := chi.URLParam(r, "groupName")
 	if groupName == "" {
@@ -307,114 +213,130 @@ func (s *Server) UpdateMembership(ctx context.Context, w http.ResponseWriter,
 	}
 
 	membersJSON := members{}
-	err := json.NewDecoder(r.Body).Decode(&membersJSON)
-	if err != nil {
-		return nil, he.BadRequest.Wrap(err)
-	}
-
-	added, removed, unchanged, err := s.DB.SetGroupMembership(ctx, groupName,
-		membersJSON.UserIDs)
+	err := h.Decode(r, &membersJSON)
 	if err != nil {
-		return nil, he.Unexpected.Wrap(err)
+		return nil, err
 	}
 
-	logrus.Debugf("memberships - added: %d, removed: %d, unchanged: %d", added,
-		removed, unchanged)
-
-	monitor.MembershipGauge.Add(float64(added))
-	monitor.MembershipGauge.Sub(float64(removed))
-
-	return nil, nil
+	return nil, s.updateMembership(ctx, groupName, membersJSON.UserIDs)
 }
 
-// DeleteGroup deletes a group.
-// `DELETE /groups/<groupName>`
-func (s *Server) DeleteGroup(ctx context.Context, w http.ResponseWriter,
-	r *http.Request) (interface{}, error) {
-	return nil, nil
+// UpdateGroup renames a group or updates its display name, description, owner
+// or labels. The body should be a group with the fields to update, where ""
+// clears one. Responds with the updated group and its members.
+// `PATCH /v1/groups/<groupName>`
+func (s *Server) UpdateGroup(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
 
 	groupName := chi.URLParam(r, "groupName")
 	if groupName == "" {
 		return nil, he.BadRequest.New("incomplete path. missing groupName")
 	}
 
-	deleted, err := s.DB.Delete_Group_By_Name(ctx,
-		database.Group_Name(groupName))
+	groupJSON := Group{}
+	err := h.Decode(r, &groupJSON)
 	if err != nil {
 		return nil, err
 	}
 
-	if !deleted {
-		return nil, he.NotFound.New("groupName %q doesn't exist", groupName)
+	group, err := s.updateGroup(ctx, groupName, &groupJSON)
+	if err != nil {
+		return nil, err
 	}
 
-	monitor.GroupGauge.Dec()
+	resp := &RootJSON{
+		Group: group,
+	}
 
-	return nil, nil
+	return h.OK(resp), nil
 }
 
-// getPaginationLimit will get the limit provided in the query parameter and
-// use that, unless it's more than the Limit const hardcoded above.
-func getPaginationLimit(queryParams url.Values, queryKey string) (int, error) {
-	pageLimitStr := queryParams.Get(queryKey)
-	limit := PaginationLimit
-	if pageLimitStr != "" {
-		pageLimit, err := strconv.Atoi(pageLimitStr)
-		if err != nil {
-			return 0, err
-		}
+// DeleteGroup deletes a group. Responds with a 204.
+// `DELETE /v1/groups/<groupName>`
+func (s *Server) DeleteGroup(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
 
-		limit = util.Min(limit, pageLimit)
+	groupName := chi.URLParam(r, "groupName")
+	if groupName == "" {
+		return nil, he.BadRequest.New("incomplete path. missing groupName")
+	}
+
+	err := s.deleteGroup(ctx, groupName)
+	if err != nil {
+		return nil, err
 	}
-	return limit, nil
+	return h.NoContent(), nil
 }
 
-// PagedUsers returns all possible users with pagination
-// `GET /users?token=231&limit=20`
+// PagedUsers returns all possible users with pagination. Users can be
+// filtered by the values of their attributes, like `attr.team=infra`.
+// `GET /v1/users?token=<token>&limit=20&include_total=true&attr.<name>=<value>`
 func (s *Server) PagedUsers(ctx context.Context, w http.ResponseWriter,
-	r *http.Request) (interface{}, error) {
+	r *http.Request) (*h.Response, error) {
 
-	queryParams := r.URL.Query()
-	token := queryParams.Get("token")
-	limit, err := getPaginationLimit(queryParams, "limit")
+	query := r.URL.Query()
+	cur, err := s.pageCursor(query, "users", attributeFilters(query))
 	if err != nil {
-		return nil, he.BadRequest.Wrap(err)
+		return nil, err
+	}
+	total, err := includeTotal(query)
+	if err != nil {
+		return nil, err
 	}
 
-	users, nextToken, err := s.DB.Paged_User(ctx, limit, token)
+	users, next, prev, err := s.pagedUsers(ctx, cur)
 	if err != nil {
 		return nil, err
 	}
 
 	resp := &RootJSON{
-		Users:    apiUsers(users),
-		NextPage: apiNextPage(r.URL, nextToken),
+		Users:    users,
+		NextPage: s.page(r.URL, next),
+		PrevPage: s.page(r.URL, prev),
+	}
+	if total {
+		count, err := s.countUsers(ctx, cur)
+		if err != nil {
+			return nil, err
+		}
+		resp.Total = &count
 	}
 
-	return resp, nil
+	return pageResponse(r.URL, resp), nil
 }
 
 // PagedGroups returns all possible groups with pagination
-// `GET /groups?token=231&limit=20`
+// `GET /v1/groups?token=<token>&limit=20&include_total=true`
 func (s *Server) PagedGroups(ctx context.Context, w http.ResponseWriter,
-	r *http.Request) (interface{}, error) {
+	r *http.Request) (*h.Response, error) {
 
-	queryParams := r.URL.Query()
-	token := queryParams.Get("token")
-	limit, err := getPaginationLimit(queryParams, "limit")
+	query := r.URL.Query()
+	cur, err := s.pageCursor(query, "groups", nil)
 	if err != nil {
-		return nil, he.BadRequest.Wrap(err)
+		return nil, err
+	}
+	total, err := includeTotal(query)
+	if err != nil {
+		return nil, err
 	}
 
-	groups, nextToken, err := s.DB.Paged_Group(ctx, limit, token)
+	groups, next, prev, err := s.pagedGroups(ctx, cur)
 	if err != nil {
 		return nil, err
 	}
 
 	resp := &RootJSON{
-		Groups:   apiGroups(groups),
-		NextPage: apiNextPage(r.URL, nextToken),
+		Groups:   groups,
+		NextPage: s.page(r.URL, next),
+		PrevPage: s.page(r.URL, prev),
+	}
+	if total {
+		count, err := s.DB.Count_Group(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resp.Total = &count
 	}
 
-	return resp, nil
+	return pageResponse(r.URL, resp), nil
 }
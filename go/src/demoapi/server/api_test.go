@@ -1,13 +1,17 @@
 package server
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"demoapi/database"
+	he "demoapi/httperror"
 )
 
 func TestHealth(baseTest *testing.T) {
@@ -19,7 +23,7 @@ func TestHealth(baseTest *testing.T) {
 	resp, err := t.server.Health(ctx, w, r)
 	assert.NoError(t, err)
 
-	json, ok := resp.(map[string]string)
+	json, ok := resp.Value().(map[string]string)
 	assert.True(t, ok)
 
 	assert.Equal(t, json["health"], "okay!")
@@ -32,11 +36,11 @@ func TestGetUser(baseTest *testing.T) {
 	t.newUser(ctx, "user1")
 
 	w := httptest.NewRecorder()
-	r := httptest.NewRequest(http.MethodGet, "/users", nil)
+	r := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
 	resp, err := t.server.PagedUsers(ctx, w, r)
 	assert.NoError(t, err)
 
-	json, ok := resp.(*RootJSON)
+	json, ok := resp.Value().(*RootJSON)
 	assert.True(t, ok)
 	assert.Equal(t, len(json.Users), 1)
 	assert.Equal(t, json.Users[0].ID, "user1")
@@ -51,15 +55,101 @@ func TestCreateUser(baseTest *testing.T) {
 	w := httptest.NewRecorder()
 	user := User{FirstName: "fn", LastName: "ln", ID: "user1",
 		Groups: []Membership{"group1"}}
-	r := jsonRequest(t, http.MethodPost, "/users", nil, user)
+	r := jsonRequest(t, http.MethodPost, "/v1/users", nil, user)
 	resp, err := t.server.CreateUser(ctx, w, r)
 	assert.NoError(t, err)
 
-	json, ok := resp.(*RootJSON)
+	assert.Equal(t, http.StatusCreated, resp.Status)
+	assert.Equal(t, "/v1/users/user1", resp.Header.Get("Location"))
+
+	json, ok := resp.Value().(*RootJSON)
 	assert.True(t, ok)
 	assert.Equal(t, json.User.ID, "user1")
 	assert.Equal(t, len(json.User.Groups), 1)
 	assert.Equal(t, json.User.Groups[0], Membership("group1"))
+
+	// a group that doesn't exist fails the whole request
+	user = User{FirstName: "fn", LastName: "ln", ID: "user2",
+		Groups: []Membership{"group1", "nope"}}
+	r = jsonRequest(t, http.MethodPost, "/v1/users", nil, user)
+	_, err = t.server.CreateUser(ctx, w, r)
+	assert.True(t, he.BadRequest.Has(err), err)
+	_, err = t.server.getUser(ctx, "user2")
+	assert.Equal(t, he.CodeUserNotFound, he.CodeByError(err))
+	versions, err := t.server.DB.UserVersions(ctx, "user2")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+
+	user = User{FirstName: "new", Groups: []Membership{"nope"}}
+	_, err = t.server.updateUser(ctx, "user1", &user)
+	assert.True(t, he.BadRequest.Has(err), err)
+	got, err := t.server.getUser(ctx, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, "fn", got.FirstName)
+	assert.Equal(t, []Membership{"group1"}, got.Groups)
+}
+
+func TestUpdateUserGroups(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newGroup(ctx, "group1")
+	t.newMembership(ctx, "user1", "group1")
+
+	// memberships are only replaced when the body has groups
+	put := func(body string) *User {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPut, "/v1/users/user1",
+			strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		t.server.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		resp := &RootJSON{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+		return resp.User
+	}
+	got := put(`{"first_name":"z"}`)
+	assert.Equal(t, "z", got.FirstName)
+	assert.Equal(t, []Membership{"group1"}, got.Groups)
+	got = put(`{"groups":[]}`)
+	assert.Empty(t, got.Groups)
+}
+
+func TestDuplicates(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newUser(ctx, "user2")
+	t.newGroup(ctx, "group1")
+	t.newGroup(ctx, "group2")
+
+	do := func(method, target string, body interface{}) (int, string) {
+		w := httptest.NewRecorder()
+		t.server.ServeHTTP(w, jsonRequest(t, method, target, nil, body))
+		resp := testResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp),
+			w.Body.String())
+		return w.Code, resp.Code
+	}
+
+	for _, test := range []struct {
+		method, target string
+		body           interface{}
+		code           string
+	}{
+		{http.MethodPost, "/v1/users",
+			User{ID: "user1", FirstName: "fn", LastName: "ln"}, "user_exists"},
+		{http.MethodPut, "/v1/users/user2", User{ID: "user1"}, "user_exists"},
+		{http.MethodPost, "/v1/groups", Group{Name: "group1"}, "group_exists"},
+		{http.MethodPatch, "/v1/groups/group2", Group{Name: "group1"},
+			"group_exists"},
+	} {
+		status, code := do(test.method, test.target, test.body)
+		assert.Equal(t, http.StatusConflict, status, test.target)
+		assert.Equal(t, test.code, code, test.target)
+	}
 }
 
 func TestBulkJoin(baseTest *testing.T) {
@@ -77,7 +167,8 @@ func TestBulkJoin(baseTest *testing.T) {
 
 	w := httptest.NewRecorder()
 	reqBody["userids"] = []string{"user1", "user2"}
-	r := jsonRequest(t, http.MethodPut, "/groups/group1", pathParams, reqBody)
+	r := jsonRequest(t, http.MethodPut, "/v1/groups/group1", pathParams,
+		reqBody)
 	_, err := t.server.UpdateMembership(ctx, w, r)
 	assert.NoError(t, err)
 
@@ -88,7 +179,8 @@ func TestBulkJoin(baseTest *testing.T) {
 
 	w = httptest.NewRecorder()
 	reqBody["userids"] = []string{"user3"}
-	r = jsonRequest(t, http.MethodPut, "/groups/group1", pathParams, reqBody)
+	r = jsonRequest(t, http.MethodPut, "/v1/groups/group1", pathParams,
+		reqBody)
 	_, err = t.server.UpdateMembership(ctx, w, r)
 	assert.NoError(t, err)
 
@@ -98,3 +190,154 @@ func TestBulkJoin(baseTest *testing.T) {
 	assert.Equal(t, len(users), 1)
 	assert.Equal(t, users[0].Id, "user3")
 }
+
+func TestUpdateGroup(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newGroup(ctx, "grop1")
+	t.newMembership(ctx, "user1", "grop1")
+	before, err := t.server.DB.Get_Group_By_Name(ctx,
+		database.Group_Name("grop1"))
+	require.NoError(t, err)
+
+	do := func(method, target string, body interface{}) (int, *RootJSON) {
+		w := httptest.NewRecorder()
+		t.server.ServeHTTP(w, jsonRequest(t, method, target, nil, body))
+		resp := &RootJSON{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp),
+			w.Body.String())
+		return w.Code, resp
+	}
+
+	// a rename keeps the uuid and members of the group
+	status, resp := do(http.MethodPatch, "/v1/groups/grop1", Group{
+		Name: "group1", DisplayName: "Group 1", Description: "the first",
+		Owner: "user1", Labels: map[string]string{"team": "infra"}})
+	assert.Equal(t, http.StatusOK, status)
+	require.NotNil(t, resp.Group)
+	assert.Equal(t, "group1", resp.Group.Name)
+	assert.Equal(t, []Membership{"user1"}, resp.Group.Users)
+	after, err := t.server.DB.Get_Group_By_Name(ctx,
+		database.Group_Name("group1"))
+	require.NoError(t, err)
+	assert.Equal(t, before.Uuid, after.Uuid)
+
+	status, resp = do(http.MethodGet, "/v1/groups/group1", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "Group 1", resp.Group.DisplayName)
+	assert.Equal(t, "the first", resp.Group.Description)
+	assert.Equal(t, "user1", resp.Group.Owner)
+	assert.Equal(t, map[string]string{"team": "infra"}, resp.Group.Labels)
+	assert.Equal(t, []Membership{"user1"}, resp.Members)
+
+	// the other fields are kept, and empty labels remove them
+	status, resp = do(http.MethodPatch, "/v1/groups/group1",
+		map[string]interface{}{"description": "renamed",
+			"labels": map[string]string{}})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "renamed", resp.Group.Description)
+	assert.Equal(t, "Group 1", resp.Group.DisplayName)
+	assert.Nil(t, resp.Group.Labels)
+
+	status, resp = do(http.MethodGet, "/v1/groups", nil)
+	assert.Equal(t, http.StatusOK, status)
+	require.Len(t, resp.Groups, 1)
+	assert.Equal(t, "renamed", resp.Groups[0].Description)
+
+	// fields that are set to "" or null are cleared
+	status, resp = do(http.MethodPatch, "/v1/groups/group1",
+		map[string]interface{}{"description": "", "display_name": nil,
+			"owner": ""})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, resp.Group.Description)
+	assert.Empty(t, resp.Group.DisplayName)
+	assert.Empty(t, resp.Group.Owner)
+	assert.Equal(t, "group1", resp.Group.Name)
+
+	status, _ = do(http.MethodPatch, "/v1/groups/group1", Group{
+		Labels: map[string]string{"": "empty"}})
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = do(http.MethodPatch, "/v1/groups/group1", Group{})
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = do(http.MethodPatch, "/v1/groups/missing", Group{
+		Description: "missing"})
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// a change is only made along with its version
+	_, err = t.server.DB.DB.Exec("DROP TABLE group_versions")
+	require.NoError(t, err)
+	status, _ = do(http.MethodPost, "/v1/groups", Group{Name: "group2"})
+	assert.Equal(t, http.StatusInternalServerError, status)
+	status, _ = do(http.MethodGet, "/v1/groups/group2", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = do(http.MethodPatch, "/v1/groups/group1", Group{
+		Description: "unversioned"})
+	assert.Equal(t, http.StatusInternalServerError, status)
+	status, resp = do(http.MethodGet, "/v1/groups/group1", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, resp.Group.Description)
+}
+
+func TestContentNegotiation(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newUser(ctx, "user2")
+	t.newGroup(ctx, "group1")
+	t.newMembership(ctx, "user1", "group1")
+	t.newMembership(ctx, "user2", "group1")
+
+	get := func(target, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		r.Header.Set("Accept", accept)
+		t.server.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("/v1/groups/group1", "text/csv")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "group,userid\ngroup1,user1\ngroup1,user2\n",
+		w.Body.String())
+
+	w = get("/v1/users", "text/csv")
+	assert.Equal(t, http.StatusOK, w.Code)
+	rows := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Equal(t, 3, len(rows))
+	assert.Equal(t, "userid,uuid,first_name,last_name,created,status,group", rows[0])
+	assert.True(t, strings.HasPrefix(rows[1], "user1,"))
+
+	w = get("/v1/users/user1", "text/csv")
+	rows = strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Equal(t, 2, len(rows))
+	assert.True(t, strings.HasSuffix(rows[1], ",group1"))
+
+	w = get("/v1/groups/group1", "application/yaml")
+	assert.Contains(t, w.Body.String(), "group:\n")
+	assert.True(t, strings.HasSuffix(w.Body.String(),
+		"members:\n  - user1\n  - user2\n"), w.Body.String())
+
+	w = get("/v1/groups/group1", "text/html")
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+	// bodies are decoded by their Content-Type
+	post := func(contentType, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/users",
+			strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		t.server.ServeHTTP(w, r)
+		return w
+	}
+	w = post("application/yaml",
+		"userid: user3\nfirst_name: fn\nlast_name: ln\n")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, http.StatusBadRequest,
+		post("application/yaml", "userid: user4\n").Code)
+	assert.Equal(t, http.StatusUnsupportedMediaType,
+		post("text/csv", "userid,first_name,last_name\nuser4,fn,ln\n").Code)
+}
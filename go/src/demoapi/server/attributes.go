@@ -0,0 +1,472 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+
+	"demoapi/database"
+	h "demoapi/handler"
+	he "demoapi/httperror"
+)
+
+// Admins can define attributes, like an email or a manager, that users can
+// have values of without changes to the schema. values are checked against
+// the type of their attribute when users are created or updated, and stored
+// as text in a table of their own, normalized so that `GET /v1/users` can
+// filter by them with an index.
+
+const (
+	attributeString = "string"
+	attributeInt    = "int"
+	attributeBool   = "bool"
+	attributeEmail  = "email"
+	attributeEnum   = "enum"
+	attributeUser   = database.UserRefType // a userid
+
+	// attributeFilterPrefix starts the query parameters of `GET /v1/users`
+	// that filter by attribute, like "attr.team=infra"
+	attributeFilterPrefix = "attr."
+)
+
+// attributeTypes are the types of attributes, in the order they're
+// documented
+var attributeTypes = []string{attributeString, attributeInt, attributeBool,
+	attributeEmail, attributeEnum, attributeUser}
+
+// attribute names are used in query parameters, so they're kept simple
+var attributeNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// GetAttributes returns every attribute definition, ordered by name
+// `GET /v1/attributes`
+func (s *Server) GetAttributes(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	attributes, err := s.DB.All_Attribute_OrderBy_Asc_Name(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &RootJSON{
+		Attributes: apiAttributes(attributes),
+	}
+
+	return h.OK(resp), nil
+}
+
+// GetAttribute returns an attribute definition
+// `GET /v1/attributes/<attributeName>`
+func (s *Server) GetAttribute(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	attributeName := chi.URLParam(r, "attributeName")
+	if attributeName == "" {
+		return nil, he.BadRequest.New("incomplete path. missing attributeName")
+	}
+
+	attribute, err := s.getAttribute(ctx, attributeName)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &RootJSON{
+		Attribute: attribute,
+	}
+
+	return h.OK(resp), nil
+}
+
+// CreateAttribute defines an attribute. The body should contain its `name`
+// and `type`, and the `values` of an enum. Responds with a 201 and the
+// location of the attribute.
+// `POST /v1/attributes`
+func (s *Server) CreateAttribute(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	attributeJSON := Attribute{}
+	err := h.Decode(r, &attributeJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	attribute, err := s.createAttribute(ctx, &attributeJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &RootJSON{
+		Attribute: attribute,
+	}
+
+	return h.Created(apiV1+"/attributes/"+url.PathEscape(attribute.Name),
+		resp), nil
+}
+
+// DeleteAttribute deletes an attribute definition and every value of it.
+// Responds with a 204.
+// `DELETE /v1/attributes/<attributeName>`
+func (s *Server) DeleteAttribute(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	attributeName := chi.URLParam(r, "attributeName")
+	if attributeName == "" {
+		return nil, he.BadRequest.New("incomplete path. missing attributeName")
+	}
+
+	deleted, err := s.DB.Delete_Attribute_By_Name(ctx,
+		database.Attribute_Name(attributeName))
+	if err != nil {
+		return nil, err
+	}
+
+	if !deleted {
+		return nil, he.CodeAttributeNotFound.New(
+			"attribute %q doesn't exist", attributeName)
+	}
+	return h.NoContent(), nil
+}
+
+// getAttribute returns the matching attribute definition or NotFound
+func (s *Server) getAttribute(ctx context.Context,
+	attributeName string) (*Attribute, error) {
+
+	attribute, err := s.DB.In(ctx).Find_Attribute_By_Name(ctx,
+		database.Attribute_Name(attributeName))
+	if err != nil {
+		return nil, err
+	}
+
+	if attribute == nil {
+		return nil, he.CodeAttributeNotFound.New(
+			"attribute %q doesn't exist", attributeName)
+	}
+
+	return apiAttribute(attribute), nil
+}
+
+// createAttribute defines a new attribute
+func (s *Server) createAttribute(ctx context.Context,
+	attributeJSON *Attribute) (*Attribute, error) {
+
+	db := s.DB.In(ctx)
+
+	var invalid he.InvalidFields
+	if !attributeNameRegexp.MatchString(attributeJSON.Name) {
+		invalid = append(invalid, he.FieldError{Field: "name",
+			Message: "must be lowercase letters, digits and underscores, " +
+				"starting with a letter"})
+	}
+	if !slices.Contains(attributeTypes, attributeJSON.Type) {
+		invalid = append(invalid, he.FieldError{Field: "type",
+			Message: "must be one of " + strings.Join(attributeTypes, ", ")})
+	}
+	switch {
+	case attributeJSON.Type == attributeEnum &&
+		len(attributeJSON.Values) == 0:
+		invalid = append(invalid, he.FieldError{Field: "values",
+			Message: "are required by an enum"})
+	case attributeJSON.Type != attributeEnum &&
+		len(attributeJSON.Values) > 0:
+		invalid = append(invalid, he.FieldError{Field: "values",
+			Message: "are only allowed for an enum"})
+	}
+	if len(invalid) > 0 {
+		return nil, he.BadRequest.Wrap(invalid)
+	}
+
+	existing, err := db.Find_Attribute_By_Name(ctx,
+		database.Attribute_Name(attributeJSON.Name))
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, he.Conflict.New("attribute %q already exists",
+			attributeJSON.Name)
+	}
+
+	enumValues := ""
+	if len(attributeJSON.Values) > 0 {
+		values, err := json.Marshal(attributeJSON.Values)
+		if err != nil {
+			return nil, err
+		}
+		enumValues = string(values)
+	}
+
+	attribute, err := db.Create_Attribute(ctx,
+		database.Attribute_Name(attributeJSON.Name),
+		database.Attribute_Type(attributeJSON.Type),
+		database.Attribute_IsRequired(attributeJSON.Required),
+		database.Attribute_IsUnique(attributeJSON.Unique),
+		database.Attribute_EnumValues(enumValues))
+	if err != nil {
+		return nil, err
+	}
+
+	return apiAttribute(attribute), nil
+}
+
+// attributeValues are the changes to the attribute values of a user, by
+// attribute pk
+type attributeValues struct {
+	set    map[int64]string
+	remove []int64
+}
+
+// checkAttributes validates the attribute values of userJSON, of the user
+// with userPk or of a new user if it's 0, and normalizes them to be stored.
+// a null value removes the value of an attribute. every required attribute
+// needs a value when a user is created, and keeps it afterwards. it has to
+// run in the transaction that sets the values, so that the values of unique
+// attributes stay unique.
+func (s *Server) checkAttributes(ctx context.Context, userPk int64,
+	userJSON *User) (*attributeValues, error) {
+
+	db := s.DB.In(ctx)
+	definitions, err := db.All_Attribute_OrderBy_Asc_Name(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	values := &attributeValues{set: map[int64]string{}}
+	var invalid he.InvalidFields
+	for _, def := range definitions {
+		field := "attributes." + def.Name
+		value, ok := userJSON.Attributes[def.Name]
+		switch {
+		case value == nil && def.IsRequired && (ok || userPk == 0):
+			invalid = append(invalid, he.FieldError{Field: field,
+				Message: "is required"})
+		case value == nil && ok:
+			values.remove = append(values.remove, def.Pk)
+		case value != nil:
+			normalized, message, err := s.normalizeAttribute(ctx, def, value)
+			if err != nil {
+				return nil, err
+			}
+			if message != "" {
+				invalid = append(invalid, he.FieldError{Field: field,
+					Message: message})
+				continue
+			}
+			values.set[def.Pk] = normalized
+		}
+	}
+
+	defined := make(map[string]bool, len(definitions))
+	for _, def := range definitions {
+		defined[def.Name] = true
+	}
+	for name := range userJSON.Attributes {
+		if !defined[name] {
+			invalid = append(invalid, he.FieldError{
+				Field: "attributes." + name, Message: "isn't an attribute"})
+		}
+	}
+
+	if len(invalid) > 0 {
+		sort.Slice(invalid, func(i, j int) bool {
+			return invalid[i].Field < invalid[j].Field
+		})
+		return nil, he.BadRequest.Wrap(invalid)
+	}
+
+	for _, def := range definitions {
+		value, ok := values.set[def.Pk]
+		if !def.IsUnique || !ok {
+			continue
+		}
+		taken, err := s.DB.HasOtherAttributeValue(ctx, def.Pk, value, userPk)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, he.Conflict.New("another user already has %s %v",
+				def.Name, userJSON.Attributes[def.Name])
+		}
+	}
+
+	return values, nil
+}
+
+// normalizeAttribute returns the value of def to store for a value in a
+// request, or why it's invalid
+func (s *Server) normalizeAttribute(ctx context.Context,
+	def *database.Attribute, value interface{}) (
+	normalized, message string, err error) {
+
+	switch def.Type {
+	case attributeInt:
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) || math.Abs(n) > 1<<53 {
+			return "", "must be an integer", nil
+		}
+		return strconv.FormatInt(int64(n), 10), "", nil
+
+	case attributeBool:
+		b, ok := value.(bool)
+		if !ok {
+			return "", "must be a boolean", nil
+		}
+		return strconv.FormatBool(b), "", nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return "", "must be a string", nil
+	}
+
+	switch def.Type {
+	case attributeEmail:
+		address, err := mail.ParseAddress(str)
+		if err != nil || address.Address != str {
+			return "", "must be an email address", nil
+		}
+
+	case attributeEnum:
+		values := apiAttribute(def).Values
+		if !slices.Contains(values, str) {
+			return "", "must be one of " + strings.Join(values, ", "), nil
+		}
+
+	case attributeUser:
+		user, err := s.DB.In(ctx).Find_User_By_Id(ctx, database.User_Id(str))
+		if err != nil {
+			return "", "", err
+		}
+		if user == nil {
+			return "", fmt.Sprintf("userID %q doesn't exist", str), nil
+		}
+		return user.Uuid, "", nil
+	}
+
+	return str, "", nil
+}
+
+// addAttributes adds the attribute values of users, the database records of
+// ds, to ds
+func (s *Server) addAttributes(ctx context.Context, users []*database.User,
+	ds []*User) error {
+
+	pks := make([]int64, 0, len(users))
+	for _, u := range users {
+		pks = append(pks, u.Pk)
+	}
+
+	values, err := s.DB.AllAttributeValuesByUserPks(ctx, pks)
+	if err != nil {
+		return err
+	}
+
+	byPk := make(map[int64]map[string]interface{}, len(users))
+	for _, v := range values {
+		if byPk[v.UserPk] == nil {
+			byPk[v.UserPk] = map[string]interface{}{}
+		}
+		byPk[v.UserPk][v.Name] = apiAttributeValue(v)
+	}
+	for i, u := range users {
+		ds[i].Attributes = byPk[u.Pk]
+	}
+	return nil
+}
+
+// apiAttributeValue is the json value of a stored attribute value
+func apiAttributeValue(v *database.AttributeValue) interface{} {
+	switch v.Type {
+	case attributeInt:
+		n, _ := strconv.ParseInt(v.Value, 10, 64) // normalized when stored
+		return n
+	case attributeBool:
+		return v.Value == "true"
+	case attributeUser:
+		return v.RefID
+	}
+	return v.Value
+}
+
+// attributeFilters returns the filters of a `GET /v1/users` query, by
+// attribute name
+func attributeFilters(query url.Values) map[string]string {
+	var filters map[string]string
+	for param, values := range query {
+		if !strings.HasPrefix(param, attributeFilterPrefix) ||
+			len(values) == 0 {
+			continue
+		}
+		if filters == nil {
+			filters = map[string]string{}
+		}
+		filters[strings.TrimPrefix(param, attributeFilterPrefix)] = values[0]
+	}
+	return filters
+}
+
+// databaseFilters returns the database filters of attribute filters. a
+// filter that can't match any value, like on a user that doesn't exist,
+// still filters every user out.
+func (s *Server) databaseFilters(ctx context.Context,
+	filters map[string]string) ([]database.AttributeFilter, error) {
+
+	db := s.DB.In(ctx)
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	dbFilters := make([]database.AttributeFilter, 0, len(filters))
+	for _, name := range names {
+		def, err := db.Find_Attribute_By_Name(ctx,
+			database.Attribute_Name(name))
+		if err != nil {
+			return nil, err
+		}
+		if def == nil {
+			return nil, he.BadRequest.Wrap(he.InvalidFields{{
+				Field:   attributeFilterPrefix + name,
+				Message: "isn't an attribute",
+			}})
+		}
+
+		// query parameters are strings, so they're decoded like json values
+		// of the attribute's type first
+		var value interface{} = filters[name]
+		switch def.Type {
+		case attributeInt:
+			n, err := strconv.ParseFloat(filters[name], 64)
+			if err == nil {
+				value = n
+			}
+		case attributeBool:
+			b, err := strconv.ParseBool(filters[name])
+			if err == nil {
+				value = b
+			}
+		}
+		normalized, message, err := s.normalizeAttribute(ctx, def, value)
+		if err != nil {
+			return nil, err
+		}
+		if message != "" && def.Type != attributeUser {
+			return nil, he.BadRequest.Wrap(he.InvalidFields{{
+				Field: attributeFilterPrefix + name, Message: message}})
+		}
+		dbFilters = append(dbFilters, database.AttributeFilter{
+			AttributePk: def.Pk,
+			Value:       normalized,
+		})
+	}
+	return dbFilters, nil
+}
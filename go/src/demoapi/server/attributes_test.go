@@ -0,0 +1,168 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	he "demoapi/httperror"
+)
+
+func TestAttributes(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "manager")
+
+	do := func(method, target string, body interface{}) (int, *RootJSON) {
+		w := httptest.NewRecorder()
+		t.server.ServeHTTP(w, jsonRequest(t, method, target, nil, body))
+		resp := &RootJSON{}
+		if w.Body.Len() > 0 {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp),
+				w.Body.String())
+		}
+		return w.Code, resp
+	}
+	problem := func(method, target string, body interface{}) (int,
+		*he.Problem) {
+
+		w := httptest.NewRecorder()
+		t.server.ServeHTTP(w, jsonRequest(t, method, target, nil, body))
+		resp := &he.Problem{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp),
+			w.Body.String())
+		return w.Code, resp
+	}
+
+	for _, attribute := range []Attribute{
+		{Name: "team", Type: "enum", Values: []string{"infra", "web"}},
+		{Name: "email", Type: "email", Unique: true},
+		{Name: "level", Type: "int", Required: true},
+		{Name: "remote", Type: "bool"},
+		{Name: "manager", Type: "user"},
+	} {
+		status, resp := do(http.MethodPost, "/v1/attributes", attribute)
+		require.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, attribute.Name, resp.Attribute.Name)
+	}
+
+	status, resp := do(http.MethodGet, "/v1/attributes", nil)
+	assert.Equal(t, http.StatusOK, status)
+	require.Len(t, resp.Attributes, 5)
+	assert.Equal(t, "email", resp.Attributes[0].Name)
+	assert.True(t, resp.Attributes[0].Unique)
+
+	status, resp = do(http.MethodGet, "/v1/attributes/team", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"infra", "web"}, resp.Attribute.Values)
+
+	status, _ = do(http.MethodPost, "/v1/attributes",
+		Attribute{Name: "team", Type: "string"})
+	assert.Equal(t, http.StatusConflict, status)
+	status, p := problem(http.MethodPost, "/v1/attributes",
+		Attribute{Name: "Bad Name", Type: "enum"})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, he.InvalidFields{
+		{Field: "name", Message: "must be lowercase letters, digits and " +
+			"underscores, starting with a letter"},
+		{Field: "values", Message: "are required by an enum"},
+	}, p.Fields)
+
+	// values are typed, and user references are userids
+	status, resp = do(http.MethodPost, "/v1/users", User{
+		ID: "user1", FirstName: "first", LastName: "last",
+		Attributes: map[string]interface{}{"team": "infra",
+			"email": "user1@example.com", "level": 3, "remote": true,
+			"manager": "manager"}})
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, map[string]interface{}{"team": "infra",
+		"email": "user1@example.com", "level": float64(3), "remote": true,
+		"manager": "manager"}, resp.User.Attributes)
+
+	status, p = problem(http.MethodPost, "/v1/users", User{
+		ID: "user2", FirstName: "first", LastName: "last",
+		Attributes: map[string]interface{}{"team": "db", "email": "nope",
+			"remote": "yes", "manager": "missing", "shoe_size": 9}})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, he.InvalidFields{
+		{Field: "attributes.email", Message: "must be an email address"},
+		{Field: "attributes.level", Message: "is required"},
+		{Field: "attributes.manager",
+			Message: `userID "missing" doesn't exist`},
+		{Field: "attributes.remote", Message: "must be a boolean"},
+		{Field: "attributes.shoe_size", Message: "isn't an attribute"},
+		{Field: "attributes.team", Message: "must be one of infra, web"},
+	}, p.Fields)
+
+	status, _ = do(http.MethodPost, "/v1/users", User{
+		ID: "user2", FirstName: "first", LastName: "last",
+		Attributes: map[string]interface{}{"level": 1,
+			"email": "user1@example.com"}})
+	assert.Equal(t, http.StatusConflict, status)
+
+	status, _ = do(http.MethodPost, "/v1/users", User{
+		ID: "user2", FirstName: "first", LastName: "last",
+		Attributes: map[string]interface{}{"level": 1, "team": "web"}})
+	require.Equal(t, http.StatusCreated, status)
+
+	// updates merge the values, and null removes one. they keep the groups.
+	t.newGroup(ctx, "group1")
+	t.newMembership(ctx, "user1", "group1")
+	status, resp = do(http.MethodPut, "/v1/users/user1",
+		map[string]interface{}{"attributes": map[string]interface{}{
+			"level": 4, "remote": nil}})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []Membership{"group1"}, resp.User.Groups)
+	assert.Equal(t, float64(4), resp.User.Attributes["level"])
+	assert.NotContains(t, resp.User.Attributes, "remote")
+	assert.Equal(t, "infra", resp.User.Attributes["team"])
+
+	status, p = problem(http.MethodPut, "/v1/users/user1",
+		map[string]interface{}{"attributes": map[string]interface{}{
+			"level": nil}})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "attributes.level", p.Fields[0].Field)
+
+	// users can be filtered by the values of their attributes
+	status, resp = do(http.MethodGet,
+		"/v1/users?attr.team=infra&include_total=true", nil)
+	assert.Equal(t, http.StatusOK, status)
+	require.Len(t, resp.Users, 1)
+	assert.Equal(t, "user1", resp.Users[0].ID)
+	require.NotNil(t, resp.Total)
+	assert.Equal(t, int64(1), *resp.Total)
+
+	status, resp = do(http.MethodGet, "/v1/users?attr.level=1", nil)
+	assert.Equal(t, http.StatusOK, status)
+	require.Len(t, resp.Users, 1)
+	assert.Equal(t, "user2", resp.Users[0].ID)
+
+	status, resp = do(http.MethodGet, "/v1/users?attr.manager=manager", nil)
+	assert.Equal(t, http.StatusOK, status)
+	require.Len(t, resp.Users, 1)
+	assert.Equal(t, "user1", resp.Users[0].ID)
+
+	status, resp = do(http.MethodGet, "/v1/users?attr.manager=missing", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, resp.Users)
+
+	status, p = problem(http.MethodGet, "/v1/users?attr.level=high", nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "attr.level", p.Fields[0].Field)
+	status, _ = do(http.MethodGet, "/v1/users?attr.shoe_size=9", nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// deleting an attribute deletes its values
+	status, _ = do(http.MethodDelete, "/v1/attributes/team", nil)
+	assert.Equal(t, http.StatusNoContent, status)
+	status, _ = do(http.MethodDelete, "/v1/attributes/team", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	status, resp = do(http.MethodGet, "/v1/users/user1", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.NotContains(t, resp.User.Attributes, "team")
+}
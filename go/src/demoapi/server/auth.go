@@ -2,14 +2,15 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"net/http"
 	"strings"
 
-	"github.com/sirupsen/logrus"
 	"github.com/zeebo/errs"
 
 	"demoapi/handler"
 	he "demoapi/httperror"
+	"demoapi/logging"
 )
 
 func validateToken(token string) error {
@@ -21,27 +22,75 @@ func validateToken(token string) error {
 	return nil
 }
 
+type principalKey struct{}
+
+// withPrincipal returns a copy of ctx carrying the authenticated principal
+func withPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// principal returns who made the request, if they were authenticated with a
+// client certificate. it's empty otherwise
+func principal(ctx context.Context) string {
+	p, _ := ctx.Value(principalKey{}).(string)
+	return p
+}
+
+// clientCertSubject returns the subject of a verified client certificate,
+// like "CN=client,O=demoapi". it's empty without mutual tls
+func clientCertSubject(state *tls.ConnectionState) string {
+	if state == nil || len(state.VerifiedChains) == 0 ||
+		len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return state.VerifiedChains[0][0].Subject.String()
+}
+
+// Authenticated rejects requests without a valid bearer token, unless
+// insecure_requests_mode is set. A client certificate that was verified
+// against api_tls_client_ca_file authenticates a request without a token, and
+// its subject becomes the principal of the request.
 func (s *Server) Authenticated(h handler.Handler) handler.Handler {
 	return handler.Handler(func(ctx context.Context, w http.ResponseWriter,
-		r *http.Request) (interface{}, error) {
+		r *http.Request) (*handler.Response, error) {
 
-		logrus.Debugf("checking that the request is authenticated")
-
-		authorizationHeader := r.Header.Get("authorization")
-		if authorizationHeader == "" {
-			return nil, he.Unauthenticated.New("no authorization header")
+		if subject := clientCertSubject(r.TLS); subject != "" {
+			logging.Entry(ctx).Debugf("authenticated by the client "+
+				"certificate of %q", subject)
+			return h(withPrincipal(ctx, subject), w, r)
 		}
 
-		parts := strings.Fields(authorizationHeader)
-		if len(parts) != 2 {
-			return nil, he.Unauthenticated.New("bad authorization header")
+		if s.settings().InsecureRequestsMode {
+			return h(ctx, w, r)
 		}
 
-		err := validateToken(parts[1]) // part[0] should be "Bearer"
+		logging.Entry(ctx).Debugf("checking that the request is authenticated")
+
+		err := authenticate(r.Header.Get("authorization"))
 		if err != nil {
-			return nil, he.Unauthenticated.New("invalid token: %s", err)
+			return nil, err
 		}
 
 		return h(ctx, w, r)
 	})
 }
+
+// authenticate validates the bearer token in an authorization header. it's
+// shared by the http middleware and the grpc interceptors
+func authenticate(authorizationHeader string) error {
+	if authorizationHeader == "" {
+		return he.Unauthenticated.New("no authorization header")
+	}
+
+	parts := strings.Fields(authorizationHeader)
+	if len(parts) != 2 {
+		return he.Unauthenticated.New("bad authorization header")
+	}
+
+	err := validateToken(parts[1]) // part[0] should be "Bearer"
+	if err != nil {
+		return he.Unauthenticated.New("invalid token: %s", err)
+	}
+
+	return nil
+}
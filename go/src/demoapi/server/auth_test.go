@@ -1,12 +1,19 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	h "demoapi/handler"
+	he "demoapi/httperror"
 )
 
 func TestAuth(baseTest *testing.T) {
@@ -15,11 +22,12 @@ func TestAuth(baseTest *testing.T) {
 
 	t.newUser(ctx, "user1")
 
-	t.server.Config.InsecureRequestsMode = true
-	t.server.router = router(t.server) // remount router with config change
+	insecure := *t.server.Config
+	insecure.InsecureRequestsMode = true
+	t.server.Reload(&insecure)
 
 	w := httptest.NewRecorder()
-	r := httptest.NewRequest(http.MethodGet, "/users", nil)
+	r := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
 	t.server.ServeHTTP(w, r)
 
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -29,16 +37,61 @@ func TestAuth(baseTest *testing.T) {
 	assert.Equal(t, len(resp.Users), 1)
 	assert.Equal(t, resp.Users[0].ID, "user1")
 
-	t.server.Config.InsecureRequestsMode = false
-	t.server.router = router(t.server) // remount router with config change
+	// auth settings are reloaded without remounting the router
+	secure := *t.server.Config
+	secure.InsecureRequestsMode = false
+	t.server.Reload(&secure)
 
 	w = httptest.NewRecorder()
-	r = httptest.NewRequest(http.MethodGet, "/users", nil)
+	r = httptest.NewRequest(http.MethodGet, "/v1/users", nil)
 	t.server.ServeHTTP(w, r)
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 	resp = testResponse{}
 	err = json.NewDecoder(w.Body).Decode(&resp)
 	assert.NoError(t, err)
 	assert.Equal(t, len(resp.Users), 0)
-	assert.Equal(t, resp.Error, "unauthenticated: no authorization header")
+	assert.Equal(t, resp.Detail, "unauthenticated: no authorization header")
+	assert.Equal(t, resp.Code, he.CodeUnauthenticated.Code)
+	assert.Equal(t, he.ProblemContentType, w.Header().Get("Content-Type"))
+}
+
+func TestAuthClientCertificate(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	secure := *t.server.Config
+	secure.InsecureRequestsMode = false
+	t.server.Reload(&secure)
+
+	// a client certificate verified by the tls listener authenticates the
+	// request without a bearer token
+	clientCert := &x509.Certificate{Subject: pkix.Name{CommonName: "client",
+		Organization: []string{"demoapi"}}}
+	r := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+	r.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{clientCert}},
+	}
+
+	var got string
+	authenticated := t.server.Authenticated(func(ctx context.Context,
+		w http.ResponseWriter, r *http.Request) (*h.Response, error) {
+		got = principal(ctx)
+		return nil, nil
+	})
+	_, err := authenticated(ctx, httptest.NewRecorder(), r)
+	assert.NoError(t, err)
+	assert.Equal(t, "CN=client,O=demoapi", got)
+
+	w := httptest.NewRecorder()
+	t.server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// certificates that weren't verified don't count
+	r = httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+	r.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{clientCert},
+	}
+	w = httptest.NewRecorder()
+	t.server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
@@ -0,0 +1,335 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/zeebo/errs"
+
+	"demoapi/database"
+	h "demoapi/handler"
+	he "demoapi/httperror"
+	"demoapi/logging"
+)
+
+// A batch runs an ordered list of operations in one transaction, so that
+// changes like reorganizations apply all at once or not at all. operations
+// can refer to the records of earlier operations by their id, like "$alice",
+// and a dry run reports what a batch would do and then rolls it back.
+
+const (
+	batchCreateUser    = "create_user"
+	batchUpdateUser    = "update_user"
+	batchDeleteUser    = "delete_user"
+	batchCreateGroup   = "create_group"
+	batchUpdateGroup   = "update_group"
+	batchDeleteGroup   = "delete_group"
+	batchSetMembers    = "set_members"
+	batchAddMembers    = "add_members"
+	batchRemoveMembers = "remove_members"
+
+	// batches are limited so that their transaction stays short
+	batchMaxOperations = 100
+
+	// batchRefPrefix starts a reference to an earlier operation. "$$" is a
+	// literal "$"
+	batchRefPrefix = "$"
+)
+
+// batchOps are the operations that a batch can run, in the order they're
+// documented
+var batchOps = []string{batchCreateUser, batchUpdateUser, batchDeleteUser,
+	batchCreateGroup, batchUpdateGroup, batchDeleteGroup, batchSetMembers,
+	batchAddMembers, batchRemoveMembers}
+
+// errDryRun rolls back the transaction of a dry run
+var errDryRun = errs.New("dry run")
+
+// BatchRequest is the body of `POST /v1/batch`
+type BatchRequest struct {
+	Operations []*BatchOperation `json:"operations"`
+	DryRun     bool              `json:"dry_run,omitempty"`
+}
+
+// BatchOperation is one of batchOps. Target is the userid or group name that
+// it changes, and Members are userids. they, and the groups of a user, can be
+// references to earlier operations.
+type BatchOperation struct {
+	ID      string   `json:"id,omitempty"` // to refer to this operation
+	Op      string   `json:"op"`
+	Target  string   `json:"target,omitempty"`
+	User    *User    `json:"user,omitempty"`
+	Group   *Group   `json:"group,omitempty"`
+	Members []string `json:"members,omitempty"`
+}
+
+// BatchResult is the result of an operation. a failed operation has an Error
+// instead of a record
+type BatchResult struct {
+	ID      string       `json:"id,omitempty"`
+	Op      string       `json:"op"`
+	Status  int          `json:"status"`
+	User    *User        `json:"user,omitempty"`
+	Group   *Group       `json:"group,omitempty"`
+	Members []Membership `json:"members,omitempty"`
+	Error   *he.Problem  `json:"error,omitempty"`
+}
+
+// BatchResponse has a result for every operation that ran. the operations
+// after a failed one don't run.
+type BatchResponse struct {
+	Committed bool           `json:"committed"`
+	DryRun    bool           `json:"dry_run,omitempty"`
+	Results   []*BatchResult `json:"results"`
+}
+
+// Batch runs operations in one transaction. Responds with a 200 and every
+// result if they all succeed, or with the status of the first operation that
+// failed after rolling back the others.
+// `POST /v1/batch`
+func (s *Server) Batch(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	req := &BatchRequest{}
+	err := h.Decode(r, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Operations) == 0 {
+		return nil, he.BadRequest.New("no operations in request")
+	}
+	if len(req.Operations) > batchMaxOperations {
+		return nil, he.BadRequest.New("a batch can have at most %d "+
+			"operations", batchMaxOperations)
+	}
+
+	resp, err := s.runBatch(ctx, req)
+	if err == nil {
+		return h.OK(resp), nil
+	}
+
+	// unexpected errors aren't described to clients, so only their problem
+	// is returned
+	failed := resp.Results[len(resp.Results)-1]
+	if failed.Error == nil || failed.Status >= http.StatusInternalServerError {
+		return nil, err
+	}
+	he.Record(ctx, err)
+	response := h.OK(resp)
+	response.Status = failed.Status
+	return response, nil
+}
+
+// runBatch runs the operations of req in a transaction and returns their
+// results with the error of the operation that failed, if any
+func (s *Server) runBatch(ctx context.Context,
+	req *BatchRequest) (*BatchResponse, error) {
+
+	resp := &BatchResponse{DryRun: req.DryRun}
+	refs := map[string]string{}
+	err := s.DB.WithTx(ctx, func(ctx context.Context, tx *database.Tx) error {
+		for i, op := range req.Operations {
+			result, err := s.batchOperation(ctx, i, op, refs)
+			resp.Results = append(resp.Results, result)
+			if err != nil {
+				problem := he.ProblemByError(err, logging.RequestID(ctx),
+					h.DeveloperMode())
+				result.Status, result.Error = problem.Status, problem
+				return err
+			}
+		}
+		if req.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		return resp, nil
+	}
+	resp.Committed = err == nil
+	return resp, err
+}
+
+// batchOperation runs op, the i-th operation of a batch. refs has the userid
+// or group name of every earlier operation with an id. op's is added once it
+// succeeds.
+func (s *Server) batchOperation(ctx context.Context, i int,
+	op *BatchOperation, refs map[string]string) (*BatchResult, error) {
+
+	result := &BatchResult{ID: op.ID, Op: op.Op, Status: http.StatusOK}
+	if op.ID != "" {
+		if _, ok := refs[op.ID]; ok {
+			return result, batchInvalid(i, "id", "%q is already the id of "+
+				"an earlier operation", op.ID)
+		}
+	}
+
+	// references are resolved before the operation runs
+	resolve := func(name, value string) (string, error) {
+		if !strings.HasPrefix(value, batchRefPrefix) {
+			return value, nil
+		}
+		ref := strings.TrimPrefix(value, batchRefPrefix)
+		if strings.HasPrefix(ref, batchRefPrefix) {
+			return ref, nil // escaped
+		}
+		resolved, ok := refs[ref]
+		if !ok {
+			return "", batchInvalid(i, name, "refers to %q, which isn't the "+
+				"id of an earlier operation", value)
+		}
+		return resolved, nil
+	}
+
+	target, err := resolve("target", op.Target)
+	if err != nil {
+		return result, err
+	}
+	members := make([]string, 0, len(op.Members))
+	for j, member := range op.Members {
+		resolved, err := resolve(fmt.Sprintf("members[%d]", j), member)
+		if err != nil {
+			return result, err
+		}
+		members = append(members, resolved)
+	}
+	user := op.User
+	if user != nil {
+		resolved := *user
+		resolved.Groups = make([]Membership, 0, len(user.Groups))
+		for j, group := range user.Groups {
+			name, err := resolve(fmt.Sprintf("user.groups[%d]", j),
+				string(group))
+			if err != nil {
+				return result, err
+			}
+			resolved.Groups = append(resolved.Groups, Membership(name))
+		}
+		user = &resolved
+	}
+
+	needs := func(name string, missing bool) error {
+		if missing {
+			return batchInvalid(i, name, "is required by %s", op.Op)
+		}
+		return nil
+	}
+
+	// ref is what later operations refer to this one by
+	var ref string
+	switch op.Op {
+	case batchCreateUser, batchUpdateUser:
+		if err := needs("user", user == nil); err != nil {
+			return result, err
+		}
+		if op.Op == batchCreateUser {
+			result.Status = http.StatusCreated
+			result.User, err = s.createUser(ctx, user)
+		} else {
+			if err := needs("target", target == ""); err != nil {
+				return result, err
+			}
+			result.User, err = s.updateUser(ctx, target, user)
+		}
+		if err != nil {
+			return result, err
+		}
+		ref = result.User.ID
+
+	case batchCreateGroup, batchUpdateGroup:
+		if err := needs("group", op.Group == nil); err != nil {
+			return result, err
+		}
+		if op.Op == batchCreateGroup {
+			result.Status = http.StatusCreated
+			result.Group, err = s.createGroup(ctx, op.Group)
+		} else {
+			if err := needs("target", target == ""); err != nil {
+				return result, err
+			}
+			result.Group, err = s.updateGroup(ctx, target, op.Group)
+		}
+		if err != nil {
+			return result, err
+		}
+		ref = result.Group.Name
+
+	case batchDeleteUser, batchDeleteGroup:
+		if err := needs("target", target == ""); err != nil {
+			return result, err
+		}
+		result.Status = http.StatusNoContent
+		if op.Op == batchDeleteUser {
+			err = s.deleteUser(ctx, target)
+		} else {
+			err = s.deleteGroup(ctx, target)
+		}
+		if err != nil {
+			return result, err
+		}
+		ref = target
+
+	case batchSetMembers, batchAddMembers, batchRemoveMembers:
+		if err := needs("target", target == ""); err != nil {
+			return result, err
+		}
+		// set_members with no members empties the group, but the others
+		// would do nothing
+		if err := needs("members", op.Op != batchSetMembers &&
+			len(members) == 0); err != nil {
+			return result, err
+		}
+		result.Members, err = s.changeMembers(ctx, op.Op, target, members)
+		if err != nil {
+			return result, err
+		}
+		ref = target
+
+	default:
+		return result, batchInvalid(i, "op", "must be one of %s",
+			strings.Join(batchOps, ", "))
+	}
+
+	if op.ID != "" {
+		refs[op.ID] = ref
+	}
+	return result, nil
+}
+
+// changeMembers sets, adds or removes members of an existing group and
+// returns its members
+func (s *Server) changeMembers(ctx context.Context, op, groupName string,
+	userIDs []string) ([]Membership, error) {
+
+	// the group is checked first, since the membership operations ignore
+	// groups that don't exist
+	if _, err := s.getMemberships(ctx, groupName); err != nil {
+		return nil, err
+	}
+
+	var err error
+	switch op {
+	case batchSetMembers:
+		err = s.updateMembership(ctx, groupName, userIDs)
+	case batchAddMembers:
+		err = s.addMembers(ctx, groupName, userIDs)
+	case batchRemoveMembers:
+		err = s.removeMembers(ctx, groupName, userIDs)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.getMemberships(ctx, groupName)
+}
+
+// batchInvalid is a 400 for a field of the i-th operation of a batch
+func batchInvalid(i int, field, format string, args ...interface{}) error {
+	return he.BadRequest.Wrap(he.InvalidFields{{
+		Field:   fmt.Sprintf("operations[%d].%s", i, field),
+		Message: fmt.Sprintf(format, args...),
+	}})
+}
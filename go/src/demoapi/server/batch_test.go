@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	he "demoapi/httperror"
+	monitor "demoapi/prometheus"
+)
+
+func TestBatch(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newUser(ctx, "user2")
+	t.newGroup(ctx, "old")
+	t.newMembership(ctx, "user1", "old")
+
+	post := func(req BatchRequest) (int, BatchResponse) {
+		w := httptest.NewRecorder()
+		t.server.ServeHTTP(w, jsonRequest(t, http.MethodPost, "/v1/batch",
+			nil, req))
+		var resp BatchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp),
+			w.Body.String())
+		return w.Code, resp
+	}
+	members := func(group string) []Membership {
+		m, err := t.server.getMemberships(ctx, group)
+		require.NoError(t, err)
+		return m
+	}
+
+	// a reorganization
+	reorg := []*BatchOperation{
+		{ID: "new", Op: batchCreateGroup, Group: &Group{Name: "new"}},
+		{ID: "alice", Op: batchCreateUser, User: &User{ID: "alice",
+			FirstName: "a", LastName: "b", Groups: []Membership{"$new"}}},
+		{ID: "renamed", Op: batchUpdateGroup, Target: "old",
+			Group: &Group{Name: "renamed"}},
+		{Op: batchAddMembers, Target: "$renamed",
+			Members: []string{"$alice", "user2"}},
+		{Op: batchRemoveMembers, Target: "$renamed",
+			Members: []string{"user1"}},
+		{Op: batchDeleteUser, Target: "user1"},
+	}
+
+	// a dry run reports the results but changes nothing
+	users := testutil.ToFloat64(monitor.UserGauge)
+	status, resp := post(BatchRequest{Operations: reorg, DryRun: true})
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, resp.DryRun)
+	assert.False(t, resp.Committed)
+	require.Len(t, resp.Results, len(reorg))
+	assert.Equal(t, []Membership{"user2", "alice"}, resp.Results[4].Members)
+	_, err := t.server.getUser(ctx, "alice")
+	assert.Equal(t, he.CodeUserNotFound, he.CodeByError(err))
+	assert.Equal(t, []Membership{"user1"}, members("old"))
+	assert.Equal(t, users, testutil.ToFloat64(monitor.UserGauge))
+
+	status, resp = post(BatchRequest{Operations: reorg})
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, resp.Committed)
+	assert.Equal(t, http.StatusCreated, resp.Results[0].Status)
+	assert.Equal(t, "renamed", resp.Results[2].Group.Name)
+	assert.Equal(t, http.StatusNoContent, resp.Results[5].Status)
+	assert.Equal(t, []Membership{"alice"}, members("new"))
+	assert.Equal(t, []Membership{"user2", "alice"}, members("renamed"))
+	_, err = t.server.getUser(ctx, "user1")
+	assert.Equal(t, he.CodeUserNotFound, he.CodeByError(err))
+	assert.Equal(t, users, testutil.ToFloat64(monitor.UserGauge))
+
+	// a failure rolls back the operations before it
+	status, resp = post(BatchRequest{Operations: []*BatchOperation{
+		{Op: batchDeleteGroup, Target: "new"},
+		{Op: batchDeleteUser, Target: "missing"},
+		{Op: batchDeleteUser, Target: "user2"},
+	}})
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.False(t, resp.Committed)
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, http.StatusNoContent, resp.Results[0].Status)
+	require.NotNil(t, resp.Results[1].Error)
+	assert.Equal(t, he.CodeUserNotFound.Code, resp.Results[1].Error.Code)
+	assert.Equal(t, []Membership{"alice"}, members("new"))
+
+	// references have to be to earlier operations
+	status, resp = post(BatchRequest{Operations: []*BatchOperation{
+		{Op: batchAddMembers, Target: "new", Members: []string{"$later"}},
+		{ID: "later", Op: batchCreateUser, User: &User{ID: "later",
+			FirstName: "a", LastName: "b"}},
+	}})
+	assert.Equal(t, http.StatusBadRequest, status)
+	require.Len(t, resp.Results, 1)
+	assert.Equal(t, "operations[0].members[0]",
+		resp.Results[0].Error.Fields[0].Field)
+
+	for _, req := range []BatchRequest{
+		{},
+		{Operations: []*BatchOperation{{Op: "rename_everything"}}},
+		{Operations: []*BatchOperation{{Op: batchDeleteUser}}},
+	} {
+		status, _ := post(req)
+		assert.Equal(t, http.StatusBadRequest, status)
+	}
+}
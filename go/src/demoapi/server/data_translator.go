@@ -1,7 +1,8 @@
 package server
 
 import (
-	"net/url"
+	"encoding/json"
+	"time"
 
 	"demoapi/database"
 )
@@ -12,6 +13,7 @@ func apiUser(m *database.User, groups []*database.Group) *User {
 		FirstName: m.FirstName,
 		LastName:  m.LastName,
 		Created:   UnixTS(m.Created),
+		Status:    m.Status,
 	}
 
 	if groups != nil && len(groups) > 0 {
@@ -26,8 +28,12 @@ func apiUser(m *database.User, groups []*database.Group) *User {
 
 func apiGroup(m *database.Group, users []*database.User) *Group {
 	d := &Group{
-		Name:    m.Name,
-		Created: UnixTS(m.Created),
+		Name:        m.Name,
+		Created:     UnixTS(m.Created),
+		DisplayName: m.DisplayName,
+		Description: m.Description,
+		Owner:       m.Owner,
+		Labels:      parseLabels(m.Labels),
 	}
 
 	if users != nil && len(users) > 0 {
@@ -40,18 +46,113 @@ func apiGroup(m *database.Group, users []*database.User) *Group {
 	return d
 }
 
-func apiMembership(m string) Membership {
-	return Membership(m)
+func apiAttribute(m *database.Attribute) *Attribute {
+	d := &Attribute{
+		Name:     m.Name,
+		Type:     m.Type,
+		Required: m.IsRequired,
+		Unique:   m.IsUnique,
+		Created:  UnixTS(m.Created),
+	}
+
+	if m.EnumValues != "" {
+		// the column is only written by createAttribute, so it's always valid
+		_ = json.Unmarshal([]byte(m.EnumValues), &d.Values)
+	}
+
+	return d
 }
 
-func apiNextPage(requestedURL *url.URL, token string) *Page {
-	if token == "" {
+func apiAttributes(ms []*database.Attribute) []*Attribute {
+	s := make([]*Attribute, 0, len(ms))
+	for _, m := range ms {
+		s = append(s, apiAttribute(m))
+	}
+	return s
+}
+
+// parseLabels decodes the labels column of a group, which is empty if it has
+// no labels
+func parseLabels(m string) map[string]string {
+	if m == "" {
 		return nil
 	}
+	var labels map[string]string
+	// the column is only written by dbLabels, so it's always valid
+	_ = json.Unmarshal([]byte(m), &labels)
+	if len(labels) == 0 {
+		return nil
+	}
+	return labels
+}
+
+// dbLabels encodes labels for the labels column of a group
+func dbLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	m, _ := json.Marshal(labels) // a map of strings always encodes
+	return string(m)
+}
+
+func apiTransitions(ms []*database.UserTransition) []*Transition {
+	s := make([]*Transition, 0, len(ms))
+	for _, m := range ms {
+		s = append(s, &Transition{
+			From:      m.FromStatus,
+			To:        m.ToStatus,
+			Reason:    m.Reason,
+			Principal: m.Principal,
+			Created:   UnixTS(m.Created),
+		})
+	}
+	return s
+}
+
+// apiUserVersion is apiUser for a version of a user that was created at
+// created
+func apiUserVersion(m *database.UserVersion, created time.Time) *User {
+	return &User{
+		ID:        m.UserId,
+		FirstName: m.FirstName,
+		LastName:  m.LastName,
+		Created:   UnixTS(created),
+		Status:    m.Status,
+	}
+}
 
-	requestedURL.RawQuery = "token=" + token
-	link := requestedURL.String()
-	return &Page{Link: link, Token: token}
+// apiGroupVersion is apiGroup for a version of a group that was created at
+// created
+func apiGroupVersion(m *database.GroupVersion, created time.Time) *Group {
+	return &Group{
+		Name:        m.Name,
+		Created:     UnixTS(created),
+		DisplayName: m.DisplayName,
+		Description: m.Description,
+		Owner:       m.Owner,
+		Labels:      parseLabels(m.Labels),
+	}
+}
+
+func apiMembershipChanges(ms []*database.MembershipVersion) []*Change {
+	s := make([]*Change, 0, len(ms))
+	for _, m := range ms {
+		s = append(s, &Change{
+			Version:   m.Version,
+			Action:    m.Action,
+			Principal: m.Principal,
+			Created:   UnixTS(m.Created),
+			Membership: &MembershipChange{
+				UserID: m.UserId,
+				Group:  m.GroupName,
+			},
+		})
+	}
+	return s
+}
+
+func apiMembership(m string) Membership {
+	return Membership(m)
 }
 
 func apiUsers(ms []*database.User) []*User {
@@ -1,34 +1,210 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type RootJSON struct {
-	User     *User        `json:"user,omitempty"`
-	Group    *Group       `json:"group,omitempty"`
-	Users    []*User      `json:"users,omitempty"`
-	Groups   []*Group     `json:"groups,omitempty"`
-	Members  []Membership `json:"members,omitempty"`
-	NextPage *Page        `json:"next_page,omitempty"`
+	User        *User         `json:"user,omitempty"`
+	Group       *Group        `json:"group,omitempty"`
+	Attribute   *Attribute    `json:"attribute,omitempty"`
+	Users       []*User       `json:"users,omitempty"`
+	Groups      []*Group      `json:"groups,omitempty"`
+	Attributes  []*Attribute  `json:"attributes,omitempty"`
+	Transitions []*Transition `json:"transitions,omitempty"`
+	History     []*Change     `json:"history,omitempty"`
+	Members     []Membership  `json:"members,omitempty"`
+	NextPage    *Page         `json:"next_page,omitempty"`
+	PrevPage    *Page         `json:"prev_page,omitempty"`
+	Total       *int64        `json:"total,omitempty"` // with include_total
+
+	membersOf string // the group of Members, for csv
+}
+
+// MarshalCSV flattens the users, groups, attributes, transitions, history or
+// members of the response into csv rows, with a row per membership. the
+// pages, the total and the attributes of users aren't included, since they
+// don't fit in a row.
+func (j *RootJSON) MarshalCSV() ([][]string, error) {
+	switch {
+	case j.Attribute != nil || j.Attributes != nil:
+		rows := [][]string{{"name", "type", "required", "unique", "values",
+			"created"}}
+		attributes := j.Attributes
+		if j.Attribute != nil {
+			attributes = []*Attribute{j.Attribute}
+		}
+		for _, a := range attributes {
+			rows = append(rows, []string{a.Name, a.Type,
+				strconv.FormatBool(a.Required), strconv.FormatBool(a.Unique),
+				strings.Join(a.Values, "|"), a.Created.csv()})
+		}
+		return rows, nil
+
+	case j.Transitions != nil:
+		rows := [][]string{{"from", "to", "reason", "principal", "created"}}
+		for _, t := range j.Transitions {
+			rows = append(rows, []string{t.From, t.To, t.Reason, t.Principal,
+				t.Created.csv()})
+		}
+		return rows, nil
+
+	case j.History != nil:
+		rows := [][]string{{"version", "action", "principal", "created",
+			"userid", "group"}}
+		for _, c := range j.History {
+			row := []string{strconv.FormatInt(c.Version, 10), c.Action,
+				c.Principal, c.Created.csv(), "", ""}
+			switch {
+			case c.User != nil:
+				row[4] = c.User.ID
+			case c.Group != nil:
+				row[5] = c.Group.Name
+			case c.Membership != nil:
+				row[4], row[5] = c.Membership.UserID, c.Membership.Group
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+
+	case j.User != nil || j.Users != nil:
+		rows := [][]string{{"userid", "uuid", "first_name", "last_name",
+			"created", "status", "group"}}
+		users := j.Users
+		if j.User != nil {
+			users = []*User{j.User}
+		}
+		for _, u := range users {
+			row := []string{u.ID, u.UUID, u.FirstName, u.LastName,
+				u.Created.csv(), u.Status}
+			rows = append(rows, membershipRows(row, u.Groups)...)
+		}
+		return rows, nil
+
+	case j.membersOf != "":
+		rows := [][]string{{"group", "userid"}}
+		for _, m := range j.Members {
+			rows = append(rows, []string{j.membersOf, string(m)})
+		}
+		return rows, nil
+	}
+
+	// labels don't fit in a column, so they're left out
+	rows := [][]string{{"name", "uuid", "created", "display_name",
+		"description", "owner", "userid"}}
+	groups := j.Groups
+	if j.Group != nil {
+		groups = []*Group{j.Group}
+	}
+	for _, g := range groups {
+		row := []string{g.Name, g.UUID, g.Created.csv(), g.DisplayName,
+			g.Description, g.Owner}
+		rows = append(rows, membershipRows(row, g.Users)...)
+	}
+	return rows, nil
+}
+
+// membershipRows repeats row for every membership, or once with an empty
+// membership if there aren't any
+func membershipRows(row []string, memberships []Membership) [][]string {
+	if len(memberships) == 0 {
+		return [][]string{append(row, "")}
+	}
+	rows := make([][]string, 0, len(memberships))
+	for _, m := range memberships {
+		rows = append(rows, append(row[:len(row):len(row)], string(m)))
+	}
+	return rows
 }
 
 type User struct {
-	ID        string       `json:"userid"`
-	UUID      string       `json:"uuid,omitempty"`
-	FirstName string       `json:"first_name"`
-	LastName  string       `json:"last_name"`
-	Created   UnixTime     `json:"created"`
-	Groups    []Membership `json:"groups,omitempty"`
+	ID         string                 `json:"userid"`
+	UUID       string                 `json:"uuid,omitempty"`
+	FirstName  string                 `json:"first_name"`
+	LastName   string                 `json:"last_name"`
+	Created    UnixTime               `json:"created"`
+	Status     string                 `json:"status,omitempty"` // read only
+	Groups     []Membership           `json:"groups,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
 
 type Group struct {
-	Name    string       `json:"name"`
-	UUID    string       `json:"uuid,omitempty"`
-	Created UnixTime     `json:"created"`
-	Users   []Membership `json:"users,omitempty"`
+	Name        string            `json:"name"`
+	UUID        string            `json:"uuid,omitempty"`
+	Created     UnixTime          `json:"created"`
+	DisplayName string            `json:"display_name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Owner       string            `json:"owner,omitempty"` // a userid
+	Labels      map[string]string `json:"labels,omitempty"`
+	Users       []Membership      `json:"users,omitempty"`
+
+	// set are the json names of the fields that were in a decoded group, so
+	// that an update can tell a cleared field from one that was left out
+	set map[string]bool
+}
+
+// UnmarshalJSON decodes a group and remembers which of its fields were set
+func (g *Group) UnmarshalJSON(b []byte) error {
+	type group Group // without this method
+	err := json.Unmarshal(b, (*group)(g))
+	if err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(b, &fields)
+	if err != nil {
+		return err
+	}
+	g.set = make(map[string]bool, len(fields))
+	for name := range fields {
+		g.set[name] = true
+	}
+	return nil
+}
+
+// Attribute defines an attribute that users can have a value of. Values are
+// only the values of an enum.
+type Attribute struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required,omitempty"`
+	Unique   bool     `json:"unique,omitempty"`
+	Values   []string `json:"values,omitempty"`
+	Created  UnixTime `json:"created"`
+}
+
+// Transition is a change to the status of a user. Requests only have the
+// reason.
+type Transition struct {
+	From      string   `json:"from,omitempty"`
+	To        string   `json:"to,omitempty"`
+	Reason    string   `json:"reason"`
+	Principal string   `json:"principal,omitempty"`
+	Created   UnixTime `json:"created"`
+}
+
+// Change is a version of a user or a group, with the user or group as it was
+// after the change, or a change to one of their memberships
+type Change struct {
+	Version    int64             `json:"version"`
+	Action     string            `json:"action"`
+	Principal  string            `json:"principal,omitempty"`
+	Created    UnixTime          `json:"created"`
+	User       *User             `json:"user,omitempty"`
+	Group      *Group            `json:"group,omitempty"`
+	Membership *MembershipChange `json:"membership,omitempty"`
+}
+
+// MembershipChange is the membership that a Change added or removed, by the
+// names its user and group had then
+type MembershipChange struct {
+	UserID string `json:"userid"`
+	Group  string `json:"group"`
 }
 
 // TODO(sam): this could contain additional information, like join time
@@ -53,6 +229,14 @@ func (t UnixTime) MarshalJSON() ([]byte, error) {
 	return []byte(fmt.Sprint(unixTime)), nil
 }
 
+// csv formats t like MarshalJSON, with an empty field instead of null
+func (t UnixTime) csv() string {
+	if t.Time.IsZero() || t.Time.Unix() == 0 {
+		return ""
+	}
+	return strconv.FormatInt(t.Time.Unix(), 10)
+}
+
 // UnmarshalJSON expects time to be int64 unix time stamps in seconds
 func (t *UnixTime) UnmarshalJSON(ts []byte) (err error) {
 	// ignore null, like the main json package
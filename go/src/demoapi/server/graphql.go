@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	h "demoapi/handler"
+	he "demoapi/httperror"
+)
+
+// This file contains the `POST /graphql` endpoint. The schema exposes the same
+// users, groups and memberships as the rest api so that clients can fetch a
+// user and all of its groups (and their members) in a single request. The
+// resolvers live in graphql_resolver.go.
+
+const graphqlSchema = `
+schema {
+	query: Query
+	mutation: Mutation
+}
+
+scalar Time
+
+type Query {
+	user(userid: String!): User
+	users(first: Int, after: String): UserConnection!
+	group(name: String!): Group
+	groups(first: Int, after: String): GroupConnection!
+}
+
+type Mutation {
+	createUser(input: CreateUserInput!): User!
+	# groups replaces all of the user's groups, like PUT /users/{userid}
+	updateUser(userid: String!, input: UpdateUserInput!): User!
+	deleteUser(userid: String!): Boolean!
+	createGroup(name: String!): Group!
+	updateMembership(name: String!, userids: [String!]!): Group!
+	deleteGroup(name: String!): Boolean!
+}
+
+type User {
+	userid: String!
+	uuid: String!
+	firstName: String!
+	lastName: String!
+	created: Time
+	status: String!
+	groups: [Group!]!
+	memberships: [Membership!]!
+}
+
+type Group {
+	name: String!
+	uuid: String!
+	created: Time
+	displayName: String!
+	description: String!
+	owner: String!
+	labels: [Label!]!
+	members: [User!]!
+	memberships: [Membership!]!
+}
+
+type Label {
+	key: String!
+	value: String!
+}
+
+type Membership {
+	user: User!
+	group: Group!
+	created: Time
+}
+
+type UserConnection {
+	edges: [UserEdge!]!
+	pageInfo: PageInfo!
+}
+
+type UserEdge {
+	node: User!
+	cursor: String!
+}
+
+type GroupConnection {
+	edges: [GroupEdge!]!
+	pageInfo: PageInfo!
+}
+
+type GroupEdge {
+	node: Group!
+	cursor: String!
+}
+
+type PageInfo {
+	hasNextPage: Boolean!
+	endCursor: String
+}
+
+input CreateUserInput {
+	userid: String!
+	firstName: String!
+	lastName: String!
+	groups: [String!]
+}
+
+input UpdateUserInput {
+	userid: String
+	firstName: String
+	lastName: String
+	groups: [String!]
+}
+`
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func newGraphQLSchema(s *Server) *graphql.Schema {
+	return graphql.MustParseSchema(graphqlSchema, &gqlResolver{s: s},
+		graphql.UseStringDescriptions())
+}
+
+// GraphQL executes a query or mutation against the graphql schema above.
+// Errors are returned in the graphql response with the http status code and
+// error catalog code of the error as extensions
+// `POST /v1/graphql`
+func (s *Server) GraphQL(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	req := graphqlRequest{}
+	err := h.Decode(r, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Query == "" {
+		return nil, he.BadRequest.New("required fields missing")
+	}
+
+	resp := s.graphql.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	for _, qe := range resp.Errors {
+		if qe.ResolverError == nil {
+			continue
+		}
+		if qe.Extensions == nil {
+			qe.Extensions = map[string]interface{}{}
+		}
+		qe.Message = he.Detail(qe.ResolverError, h.DeveloperMode())
+		qe.Extensions["status"] = he.StatusCodeByError(qe.ResolverError)
+		qe.Extensions["code"] = he.CodeByError(qe.ResolverError).Code
+	}
+
+	return h.OK(resp), nil
+}
@@ -0,0 +1,567 @@
+package server
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"demoapi/database"
+	he "demoapi/httperror"
+)
+
+// This file contains the resolvers for the graphql schema in graphql.go.
+//
+// To avoid N+1 queries, every user or group that is resolved belongs to a
+// batch with its siblings (eg. all of the users on a page). The first time the
+// groups or members of any of them are needed, the memberships of the whole
+// batch are loaded with a single query. The groups or users reached through
+// those memberships become the next batch, so each level of a nested query
+// costs one query no matter how many records it has.
+
+type gqlResolver struct {
+	s *Server
+}
+
+// User resolves `user(userid)`. null is returned if the user doesn't exist
+func (r *gqlResolver) User(ctx context.Context,
+	args struct{ Userid string }) (*gqlUser, error) {
+
+	user, err := r.s.DB.Find_User_By_Id(ctx, database.User_Id(args.Userid))
+	if err != nil || user == nil {
+		return nil, err
+	}
+	return newGQLUserBatch(r.s, []*database.User{user})[0], nil
+}
+
+// Users resolves `users(first, after)` using the same pagination tokens as
+// `GET /users`
+func (r *gqlResolver) Users(ctx context.Context, args struct {
+	First *int32
+	After *string
+}) (*gqlUserConnection, error) {
+
+	cur, err := r.s.gqlPage(args.First, args.After, "users")
+	if err != nil {
+		return nil, err
+	}
+
+	users, next, _, err := r.s.userPage(ctx, cur)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gqlUserConnection{
+		users: newGQLUserBatch(r.s, users),
+		page:  gqlConnectionPage{s: r.s, cur: cur, hasNext: next != nil},
+	}, nil
+}
+
+// Group resolves `group(name)`. null is returned if the group doesn't exist
+func (r *gqlResolver) Group(ctx context.Context,
+	args struct{ Name string }) (*gqlGroup, error) {
+
+	group, err := r.s.DB.Find_Group_By_Name(ctx, database.Group_Name(args.Name))
+	if err != nil || group == nil {
+		return nil, err
+	}
+	return newGQLGroupBatch(r.s, []*database.Group{group})[0], nil
+}
+
+// Groups resolves `groups(first, after)` using the same pagination tokens as
+// `GET /groups`
+func (r *gqlResolver) Groups(ctx context.Context, args struct {
+	First *int32
+	After *string
+}) (*gqlGroupConnection, error) {
+
+	cur, err := r.s.gqlPage(args.First, args.After, "groups")
+	if err != nil {
+		return nil, err
+	}
+
+	groups, next, _, err := r.s.groupPage(ctx, cur)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gqlGroupConnection{
+		groups: newGQLGroupBatch(r.s, groups),
+		page:   gqlConnectionPage{s: r.s, cur: cur, hasNext: next != nil},
+	}, nil
+}
+
+// gqlPage mirrors pageCursor for the connection arguments. after is a
+// cursor of an edge, or a page token of `GET /users` or `GET /groups`
+func (s *Server) gqlPage(first *int32, after *string,
+	list string) (*cursor, error) {
+
+	query := url.Values{}
+	if after != nil {
+		query.Set(tokenParam, *after)
+	}
+	if first != nil {
+		if err := s.checkPageLimit("first", int(*first)); err != nil {
+			return nil, err
+		}
+		query.Set(limitParam, strconv.Itoa(int(*first)))
+	}
+	return s.pageCursor(query, list, nil)
+}
+
+type gqlCreateUserInput struct {
+	Userid    string
+	FirstName string
+	LastName  string
+	Groups    *[]string
+}
+
+func (in *gqlCreateUserInput) user() *User {
+	return &User{
+		ID:        in.Userid,
+		FirstName: in.FirstName,
+		LastName:  in.LastName,
+		Groups:    gqlMemberships(in.Groups),
+	}
+}
+
+type gqlUpdateUserInput struct {
+	Userid    *string
+	FirstName *string
+	LastName  *string
+	Groups    *[]string
+}
+
+func (in *gqlUpdateUserInput) user() *User {
+	d := &User{Groups: gqlMemberships(in.Groups)}
+	if in.Userid != nil {
+		d.ID = *in.Userid
+	}
+	if in.FirstName != nil {
+		d.FirstName = *in.FirstName
+	}
+	if in.LastName != nil {
+		d.LastName = *in.LastName
+	}
+	return d
+}
+
+func gqlMemberships(names *[]string) []Membership {
+	if names == nil {
+		return nil
+	}
+	ms := make([]Membership, 0, len(*names))
+	for _, name := range *names {
+		ms = append(ms, apiMembership(name))
+	}
+	return ms
+}
+
+// CreateUser resolves the `createUser` mutation like `POST /users`
+func (r *gqlResolver) CreateUser(ctx context.Context,
+	args struct{ Input gqlCreateUserInput }) (*gqlUser, error) {
+
+	user, err := r.s.createUser(ctx, args.Input.user())
+	if err != nil {
+		return nil, err
+	}
+	return r.mustUser(ctx, user.ID)
+}
+
+// UpdateUser resolves the `updateUser` mutation like `PUT /users/<userID>`
+func (r *gqlResolver) UpdateUser(ctx context.Context, args struct {
+	Userid string
+	Input  gqlUpdateUserInput
+}) (*gqlUser, error) {
+
+	user, err := r.s.updateUser(ctx, args.Userid, args.Input.user())
+	if err != nil {
+		return nil, err
+	}
+	return r.mustUser(ctx, user.ID)
+}
+
+// DeleteUser resolves the `deleteUser` mutation like `DELETE /users/<userID>`
+func (r *gqlResolver) DeleteUser(ctx context.Context,
+	args struct{ Userid string }) (bool, error) {
+
+	err := r.s.deleteUser(ctx, args.Userid)
+	return err == nil, err
+}
+
+// CreateGroup resolves the `createGroup` mutation like `POST /groups`
+func (r *gqlResolver) CreateGroup(ctx context.Context,
+	args struct{ Name string }) (*gqlGroup, error) {
+
+	group, err := r.s.createGroup(ctx, &Group{Name: args.Name})
+	if err != nil {
+		return nil, err
+	}
+	return r.mustGroup(ctx, group.Name)
+}
+
+// UpdateMembership resolves the `updateMembership` mutation like
+// `PUT /groups/<groupName>`
+func (r *gqlResolver) UpdateMembership(ctx context.Context, args struct {
+	Name    string
+	Userids []string
+}) (*gqlGroup, error) {
+
+	// make sure the group exists before touching any memberships
+	group, err := r.mustGroup(ctx, args.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.s.updateMembership(ctx, args.Name, args.Userids)
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// DeleteGroup resolves the `deleteGroup` mutation like
+// `DELETE /groups/<groupName>`
+func (r *gqlResolver) DeleteGroup(ctx context.Context,
+	args struct{ Name string }) (bool, error) {
+
+	err := r.s.deleteGroup(ctx, args.Name)
+	return err == nil, err
+}
+
+func (r *gqlResolver) mustUser(ctx context.Context, userID string) (*gqlUser,
+	error) {
+
+	user, err := r.User(ctx, struct{ Userid string }{userID})
+	if err == nil && user == nil {
+		err = he.CodeUserNotFound.New("userID %q doesn't exist", userID)
+	}
+	return user, err
+}
+
+func (r *gqlResolver) mustGroup(ctx context.Context, groupName string) (
+	*gqlGroup, error) {
+
+	group, err := r.Group(ctx, struct{ Name string }{groupName})
+	if err == nil && group == nil {
+		err = he.CodeGroupNotFound.New(
+			"groupName %q doesn't exist", groupName)
+	}
+	return group, err
+}
+
+func gqlTime(t time.Time) *graphql.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &graphql.Time{Time: t}
+}
+
+type gqlPageInfo struct {
+	hasNextPage bool
+	endCursor   *string
+}
+
+func (p *gqlPageInfo) HasNextPage() bool  { return p.hasNextPage }
+func (p *gqlPageInfo) EndCursor() *string { return p.endCursor }
+
+// gqlConnectionPage is the page of a connection. the cursor of a record is
+// the page token that continues after it, like the tokens of `GET /users`
+type gqlConnectionPage struct {
+	s       *Server
+	cur     *cursor
+	hasNext bool
+}
+
+func (p gqlConnectionPage) cursor(pk int64) string {
+	return p.s.encodeCursor(p.cur.at(pk, 0))
+}
+
+func (p gqlConnectionPage) info(pks []int64) *gqlPageInfo {
+	info := &gqlPageInfo{hasNextPage: p.hasNext}
+	if len(pks) > 0 {
+		end := p.cursor(pks[len(pks)-1])
+		info.endCursor = &end
+	}
+	return info
+}
+
+type gqlUserConnection struct {
+	users []*gqlUser
+	page  gqlConnectionPage
+}
+
+func (c *gqlUserConnection) Edges() []*gqlUserEdge {
+	edges := make([]*gqlUserEdge, 0, len(c.users))
+	for _, u := range c.users {
+		edges = append(edges, &gqlUserEdge{node: u,
+			cursor: c.page.cursor(u.m.Pk)})
+	}
+	return edges
+}
+
+func (c *gqlUserConnection) PageInfo() *gqlPageInfo {
+	pks := make([]int64, 0, len(c.users))
+	for _, u := range c.users {
+		pks = append(pks, u.m.Pk)
+	}
+	return c.page.info(pks)
+}
+
+type gqlUserEdge struct {
+	node   *gqlUser
+	cursor string
+}
+
+func (e *gqlUserEdge) Node() *gqlUser { return e.node }
+func (e *gqlUserEdge) Cursor() string { return e.cursor }
+
+type gqlGroupConnection struct {
+	groups []*gqlGroup
+	page   gqlConnectionPage
+}
+
+func (c *gqlGroupConnection) Edges() []*gqlGroupEdge {
+	edges := make([]*gqlGroupEdge, 0, len(c.groups))
+	for _, g := range c.groups {
+		edges = append(edges, &gqlGroupEdge{node: g,
+			cursor: c.page.cursor(g.m.Pk)})
+	}
+	return edges
+}
+
+func (c *gqlGroupConnection) PageInfo() *gqlPageInfo {
+	pks := make([]int64, 0, len(c.groups))
+	for _, g := range c.groups {
+		pks = append(pks, g.m.Pk)
+	}
+	return c.page.info(pks)
+}
+
+type gqlGroupEdge struct {
+	node   *gqlGroup
+	cursor string
+}
+
+func (e *gqlGroupEdge) Node() *gqlGroup { return e.node }
+func (e *gqlGroupEdge) Cursor() string  { return e.cursor }
+
+type gqlUser struct {
+	m     *database.User
+	batch *gqlUserBatch
+}
+
+func (u *gqlUser) Userid() string         { return u.m.Id }
+func (u *gqlUser) UUID() string           { return u.m.Uuid }
+func (u *gqlUser) FirstName() string      { return u.m.FirstName }
+func (u *gqlUser) LastName() string       { return u.m.LastName }
+func (u *gqlUser) Status() string         { return u.m.Status }
+func (u *gqlUser) Created() *graphql.Time { return gqlTime(u.m.Created) }
+
+func (u *gqlUser) Memberships(ctx context.Context) ([]*gqlMembership, error) {
+	err := u.batch.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return u.batch.memberships[u.m.Pk], nil
+}
+
+func (u *gqlUser) Groups(ctx context.Context) ([]*gqlGroup, error) {
+	memberships, err := u.Memberships(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]*gqlGroup, 0, len(memberships))
+	for _, m := range memberships {
+		groups = append(groups, m.group)
+	}
+	return groups, nil
+}
+
+type gqlGroup struct {
+	m     *database.Group
+	batch *gqlGroupBatch
+}
+
+func (g *gqlGroup) Name() string           { return g.m.Name }
+func (g *gqlGroup) UUID() string           { return g.m.Uuid }
+func (g *gqlGroup) Created() *graphql.Time { return gqlTime(g.m.Created) }
+func (g *gqlGroup) DisplayName() string    { return g.m.DisplayName }
+func (g *gqlGroup) Description() string    { return g.m.Description }
+func (g *gqlGroup) Owner() string          { return g.m.Owner }
+
+// Labels are sorted by their keys, since graphql has no maps
+func (g *gqlGroup) Labels() []*gqlLabel {
+	labels := parseLabels(g.m.Labels)
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	d := make([]*gqlLabel, 0, len(keys))
+	for _, key := range keys {
+		d = append(d, &gqlLabel{key: key, value: labels[key]})
+	}
+	return d
+}
+
+func (g *gqlGroup) Memberships(ctx context.Context) ([]*gqlMembership, error) {
+	err := g.batch.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return g.batch.memberships[g.m.Pk], nil
+}
+
+func (g *gqlGroup) Members(ctx context.Context) ([]*gqlUser, error) {
+	memberships, err := g.Memberships(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]*gqlUser, 0, len(memberships))
+	for _, m := range memberships {
+		users = append(users, m.user)
+	}
+	return users, nil
+}
+
+type gqlLabel struct {
+	key, value string
+}
+
+func (l *gqlLabel) Key() string   { return l.key }
+func (l *gqlLabel) Value() string { return l.value }
+
+type gqlMembership struct {
+	user    *gqlUser
+	group   *gqlGroup
+	created time.Time
+}
+
+func (m *gqlMembership) User() *gqlUser         { return m.user }
+func (m *gqlMembership) Group() *gqlGroup       { return m.group }
+func (m *gqlMembership) Created() *graphql.Time { return gqlTime(m.created) }
+
+// gqlUserBatch loads the memberships of all of its users at once
+type gqlUserBatch struct {
+	s     *Server
+	users []*gqlUser
+
+	once        sync.Once
+	err         error
+	memberships map[int64][]*gqlMembership // by user pk
+}
+
+func newGQLUserBatch(s *Server, users []*database.User) []*gqlUser {
+	b := &gqlUserBatch{s: s}
+	for _, m := range users {
+		b.users = append(b.users, &gqlUser{m: m, batch: b})
+	}
+	return b.users
+}
+
+func (b *gqlUserBatch) load(ctx context.Context) error {
+	b.once.Do(func() {
+		pks := make([]int64, 0, len(b.users))
+		users := make(map[int64]*gqlUser, len(b.users))
+		for _, u := range b.users {
+			pks = append(pks, u.m.Pk)
+			users[u.m.Pk] = u
+		}
+
+		records, err := b.s.DB.AllMembershipsByUserPks(ctx, pks)
+		if err != nil {
+			b.err = err
+			return
+		}
+
+		// the groups of every user in this batch are the next batch
+		var groupModels []*database.Group
+		seen := map[int64]bool{}
+		for _, rec := range records {
+			if !seen[rec.Group.Pk] {
+				seen[rec.Group.Pk] = true
+				groupModels = append(groupModels, rec.Group)
+			}
+		}
+		groups := map[int64]*gqlGroup{}
+		for _, g := range newGQLGroupBatch(b.s, groupModels) {
+			groups[g.m.Pk] = g
+		}
+
+		b.memberships = map[int64][]*gqlMembership{}
+		for _, rec := range records {
+			b.memberships[rec.User.Pk] = append(b.memberships[rec.User.Pk],
+				&gqlMembership{
+					user:    users[rec.User.Pk],
+					group:   groups[rec.Group.Pk],
+					created: rec.Created,
+				})
+		}
+	})
+	return b.err
+}
+
+// gqlGroupBatch loads the memberships of all of its groups at once
+type gqlGroupBatch struct {
+	s      *Server
+	groups []*gqlGroup
+
+	once        sync.Once
+	err         error
+	memberships map[int64][]*gqlMembership // by group pk
+}
+
+func newGQLGroupBatch(s *Server, groups []*database.Group) []*gqlGroup {
+	b := &gqlGroupBatch{s: s}
+	for _, m := range groups {
+		b.groups = append(b.groups, &gqlGroup{m: m, batch: b})
+	}
+	return b.groups
+}
+
+func (b *gqlGroupBatch) load(ctx context.Context) error {
+	b.once.Do(func() {
+		pks := make([]int64, 0, len(b.groups))
+		groups := make(map[int64]*gqlGroup, len(b.groups))
+		for _, g := range b.groups {
+			pks = append(pks, g.m.Pk)
+			groups[g.m.Pk] = g
+		}
+
+		records, err := b.s.DB.AllMembershipsByGroupPks(ctx, pks)
+		if err != nil {
+			b.err = err
+			return
+		}
+
+		// the members of every group in this batch are the next batch
+		var userModels []*database.User
+		seen := map[int64]bool{}
+		for _, rec := range records {
+			if !seen[rec.User.Pk] {
+				seen[rec.User.Pk] = true
+				userModels = append(userModels, rec.User)
+			}
+		}
+		users := map[int64]*gqlUser{}
+		for _, u := range newGQLUserBatch(b.s, userModels) {
+			users[u.m.Pk] = u
+		}
+
+		b.memberships = map[int64][]*gqlMembership{}
+		for _, rec := range records {
+			b.memberships[rec.Group.Pk] = append(b.memberships[rec.Group.Pk],
+				&gqlMembership{
+					user:    users[rec.User.Pk],
+					group:   groups[rec.Group.Pk],
+					created: rec.Created,
+				})
+		}
+	})
+	return b.err
+}
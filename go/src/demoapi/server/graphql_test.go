@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"demoapi/database"
+)
+
+func TestGraphQLQuery(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newUser(ctx, "user2")
+	t.newUser(ctx, "user3")
+	t.newGroup(ctx, "group1")
+	t.newGroup(ctx, "group2")
+	t.newMembership(ctx, "user1", "group1")
+	t.newMembership(ctx, "user1", "group2")
+	t.newMembership(ctx, "user2", "group1")
+
+	// count the membership queries to make sure they are batched
+	queries := 0
+	logger := database.Logger
+	database.Logger = func(format string, args ...interface{}) {
+		if strings.Contains(fmt.Sprintf(format, args...), "FROM memberships") {
+			queries++
+		}
+	}
+	defer func() { database.Logger = logger }()
+
+	var data struct {
+		Users struct {
+			Edges []struct {
+				Node struct {
+					Userid string
+					Groups []struct {
+						Name    string
+						Members []struct{ Userid string }
+					}
+				}
+				Cursor string
+			}
+			PageInfo struct {
+				HasNextPage bool
+				EndCursor   *string
+			}
+		}
+	}
+	resp := t.graphqlRequest(`{
+		users(first: 2) {
+			edges {
+				node { userid groups { name members { userid } } }
+				cursor
+			}
+			pageInfo { hasNextPage endCursor }
+		}
+	}`, nil, &data)
+	assert.Empty(t, resp.Errors)
+
+	edges := data.Users.Edges
+	assert.Equal(t, 2, len(edges))
+	assert.Equal(t, "user1", edges[0].Node.Userid)
+	assert.Equal(t, 2, len(edges[0].Node.Groups))
+	assert.Equal(t, "group1", edges[0].Node.Groups[0].Name)
+	assert.Equal(t, 2, len(edges[0].Node.Groups[0].Members))
+	assert.Equal(t, "user2", edges[0].Node.Groups[0].Members[1].Userid)
+	assert.Equal(t, 1, len(edges[1].Node.Groups))
+	assert.True(t, data.Users.PageInfo.HasNextPage)
+	assert.Equal(t, edges[1].Cursor, *data.Users.PageInfo.EndCursor)
+
+	// one query for the groups of the users and one for their members
+	assert.Equal(t, 2, queries)
+
+	resp = t.graphqlRequest(`query($after: String) {
+		users(after: $after) {
+			edges { node { userid } }
+			pageInfo { hasNextPage }
+		}
+	}`, map[string]interface{}{"after": edges[1].Cursor}, &data)
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, 1, len(data.Users.Edges))
+	assert.Equal(t, "user3", data.Users.Edges[0].Node.Userid)
+	assert.False(t, data.Users.PageInfo.HasNextPage)
+
+	var group struct {
+		Group *struct {
+			Memberships []struct {
+				User struct{ Userid string }
+			}
+		}
+		Missing *struct{ Name string }
+	}
+	resp = t.graphqlRequest(`{
+		group(name: "group1") { memberships { user { userid } } }
+		missing: group(name: "nope") { name }
+	}`, nil, &group)
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, 2, len(group.Group.Memberships))
+	assert.Nil(t, group.Missing)
+}
+
+func TestGraphQLMutation(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newGroup(ctx, "group1")
+
+	var data struct {
+		CreateUser struct {
+			Userid string
+			Groups []struct{ Name string }
+		}
+	}
+	resp := t.graphqlRequest(`mutation {
+		createUser(input: {userid: "user2", firstName: "f", lastName: "l",
+			groups: ["group1"]}) { userid groups { name } }
+	}`, nil, &data)
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, "user2", data.CreateUser.Userid)
+	assert.Equal(t, 1, len(data.CreateUser.Groups))
+
+	var membership struct {
+		UpdateMembership struct {
+			Members []struct{ Userid string }
+		}
+	}
+	resp = t.graphqlRequest(`mutation {
+		updateMembership(name: "group1", userids: ["user1"]) {
+			members { userid }
+		}
+	}`, nil, &membership)
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, 1, len(membership.UpdateMembership.Members))
+	assert.Equal(t, "user1", membership.UpdateMembership.Members[0].Userid)
+
+	resp = t.graphqlRequest(`mutation { deleteGroup(name: "nope") }`, nil,
+		nil)
+	assert.Equal(t, 1, len(resp.Errors))
+	assert.Equal(t, float64(http.StatusNotFound),
+		resp.Errors[0].Extensions["status"])
+	assert.Equal(t, "group_not_found", resp.Errors[0].Extensions["code"])
+}
+
+type graphqlTestResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func (st *serverTest) graphqlRequest(query string,
+	variables map[string]interface{}, data interface{}) *graphqlTestResponse {
+
+	body := map[string]interface{}{"query": query, "variables": variables}
+	r := jsonRequest(st, http.MethodPost, "/v1/graphql", nil, body)
+	w := httptest.NewRecorder()
+	st.server.ServeHTTP(w, r)
+	assert.Equal(st, http.StatusOK, w.Code)
+
+	resp := &graphqlTestResponse{}
+	assert.NoError(st, json.NewDecoder(w.Body).Decode(resp))
+	if data != nil && resp.Data != nil {
+		assert.NoError(st, json.Unmarshal(resp.Data, data))
+	}
+	return resp
+}
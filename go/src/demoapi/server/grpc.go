@@ -0,0 +1,528 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	h "demoapi/handler"
+	he "demoapi/httperror"
+	"demoapi/logging"
+	"demoapi/rpc"
+	"demoapi/util"
+)
+
+// This file contains the grpc UserService and GroupService defined in
+// rpc/demoapi.proto. Every rpc mirrors one of the http routes in new.go and
+// uses the same operations, database, auth and metrics.
+
+// GRPCServer adapts a grpc.Server to the ListenAndServe/Shutdown methods of an
+// http.Server so that both can be started and stopped the same way
+type GRPCServer struct {
+	*grpc.Server
+	Addr string
+}
+
+func (g *GRPCServer) ListenAndServe() error {
+	lis, err := net.Listen("tcp", g.Addr)
+	if err != nil {
+		return err
+	}
+	return g.Serve(lis)
+}
+
+// Shutdown stops accepting new rpcs and waits for the in flight ones to finish
+// until ctx is done, at which point they are forcefully closed
+func (g *GRPCServer) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		g.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		g.Stop()
+		return ctx.Err()
+	}
+}
+
+// NewGRPCServer constructs a new GRPCServer that serves the api with the
+// Server's database and configs. opts are applied first, so any interceptors
+// they contain wrap every rpc, including the ones rejected by auth or rate
+// limits. tls is set with the grpc.Creds option.
+func NewGRPCServer(s *Server, opts ...grpc.ServerOption) *GRPCServer {
+	unary := []grpc.UnaryServerInterceptor{grpcUnaryRequestID, grpcUnaryErrors,
+		s.grpcUnaryAuthenticated, s.grpcUnaryRateLimited}
+	stream := []grpc.StreamServerInterceptor{grpcStreamRequestID,
+		grpcStreamErrors, s.grpcStreamAuthenticated, s.grpcStreamRateLimited}
+
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...))
+
+	gs := grpc.NewServer(opts...)
+	rpc.RegisterUserServiceServer(gs, &userService{s: s})
+	rpc.RegisterGroupServiceServer(gs, &groupService{s: s})
+
+	return &GRPCServer{Server: gs, Addr: s.Config.GRPCAddress}
+}
+
+// grpcError converts the httperror classes into grpc status errors. like the
+// http api, the text of unexpected errors is hidden outside of developer mode
+func grpcError(ctx context.Context, method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	logging.Entry(ctx).Debugf("%s: %s", method, err)
+	return status.Error(he.GRPCCodeByError(err),
+		he.Detail(err, h.DeveloperMode()))
+}
+
+func grpcUnaryErrors(ctx context.Context, req interface{},
+	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{},
+	error) {
+
+	resp, err := handler(ctx, req)
+	return resp, grpcError(ctx, info.FullMethod, err)
+}
+
+func grpcStreamErrors(srv interface{}, ss grpc.ServerStream,
+	info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+	return grpcError(ss.Context(), info.FullMethod, handler(srv, ss))
+}
+
+// grpcRequestID is the grpc equivalent of logging.RequestIDMiddleware. the id
+// is read from the "x-request-id" metadata and sent back as a header
+func grpcRequestID(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	provided := ""
+	if values := md.Get(logging.RequestIDHeader); len(values) > 0 {
+		provided = values[0]
+	}
+
+	requestID := logging.RequestIDOrNew(provided)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(logging.RequestIDHeader, requestID))
+	return logging.WithRequestID(ctx, requestID)
+}
+
+func grpcUnaryRequestID(ctx context.Context, req interface{},
+	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{},
+	error) {
+
+	return handler(grpcRequestID(ctx), req)
+}
+
+func grpcStreamRequestID(srv interface{}, ss grpc.ServerStream,
+	info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+	return handler(srv, &grpcContextStream{ServerStream: ss,
+		ctx: grpcRequestID(ss.Context())})
+}
+
+// grpcContextStream overrides the context of a grpc.ServerStream
+type grpcContextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *grpcContextStream) Context() context.Context { return s.ctx }
+
+// grpcAuthenticate is the grpc equivalent of the Authenticated middleware. the
+// bearer token is read from the "authorization" metadata, and the subject of
+// a verified client certificate becomes the principal of the rpc
+func (s *Server) grpcAuthenticate(ctx context.Context) (context.Context,
+	error) {
+
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			if subject := clientCertSubject(&info.State); subject != "" {
+				logging.Entry(ctx).Debugf("authenticated by the client "+
+					"certificate of %q", subject)
+				return withPrincipal(ctx, subject), nil
+			}
+		}
+	}
+
+	if s.settings().InsecureRequestsMode {
+		return ctx, nil
+	}
+
+	logging.Entry(ctx).Debugf("checking that the rpc is authenticated")
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	authorization := ""
+	if values := md.Get("authorization"); len(values) > 0 {
+		authorization = values[0]
+	}
+	return ctx, authenticate(authorization)
+}
+
+func (s *Server) grpcUnaryAuthenticated(ctx context.Context, req interface{},
+	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{},
+	error) {
+
+	ctx, err := s.grpcAuthenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) grpcStreamAuthenticated(srv interface{}, ss grpc.ServerStream,
+	info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+	ctx, err := s.grpcAuthenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &grpcContextStream{ServerStream: ss, ctx: ctx})
+}
+
+// grpcRateLimitClasses are the rate limit classes of the rpcs that aren't
+// writes, which match the classes of their http routes
+var grpcRateLimitClasses = map[string]string{
+	rpc.UserService_ListUsers_FullMethodName:         rateLimitReads,
+	rpc.UserService_GetUser_FullMethodName:           rateLimitReads,
+	rpc.UserService_UpdateUser_FullMethodName:        rateLimitBulk,
+	rpc.GroupService_ListGroups_FullMethodName:       rateLimitReads,
+	rpc.GroupService_GetMemberships_FullMethodName:   rateLimitReads,
+	rpc.GroupService_UpdateMembership_FullMethodName: rateLimitBulk,
+}
+
+// grpcRateLimit is the grpc equivalent of the RateLimit middlewares. the
+// buckets are shared with the http api, and the RateLimit headers are sent
+// as metadata
+func (s *Server) grpcRateLimit(ctx context.Context, method string) error {
+	class, ok := grpcRateLimitClasses[method]
+	if !ok {
+		class = rateLimitWrites
+	}
+
+	ip := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = hostIP(p.Addr.String())
+	}
+
+	result, err := s.allow(ctx, class, ip)
+	if result != nil {
+		md := metadata.Pairs(
+			"ratelimit-limit", strconv.Itoa(result.Limit),
+			"ratelimit-remaining", strconv.Itoa(result.Remaining),
+			"ratelimit-reset", ceilSeconds(result.Reset))
+		if !result.Allowed {
+			md.Set("retry-after", ceilSeconds(result.RetryAfter))
+		}
+		_ = grpc.SetHeader(ctx, md)
+	}
+	return err
+}
+
+func (s *Server) grpcUnaryRateLimited(ctx context.Context, req interface{},
+	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{},
+	error) {
+
+	if err := s.grpcRateLimit(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) grpcStreamRateLimited(srv interface{}, ss grpc.ServerStream,
+	info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+	if err := s.grpcRateLimit(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// grpcCursor mirrors pageCursor for the list rpcs. the page size only sets
+// how many records are read at a time, so it's capped instead of rejected
+func (s *Server) grpcCursor(token string, pageSize int32,
+	list string) (*cursor, error) {
+
+	query := url.Values{}
+	if token != "" {
+		query.Set(tokenParam, token)
+	}
+	if pageSize > 0 {
+		limit := util.Min(int(pageSize), s.settings().PaginationMaxLimit)
+		query.Set(limitParam, strconv.Itoa(limit))
+	}
+	return s.pageCursor(query, list, nil)
+}
+
+type userService struct {
+	rpc.UnimplementedUserServiceServer
+	s *Server
+}
+
+// ListUsers mirrors `GET /users` but streams every page
+func (us *userService) ListUsers(req *rpc.ListUsersRequest,
+	stream rpc.UserService_ListUsersServer) error {
+
+	cur, err := us.s.grpcCursor(req.PageToken, req.PageSize, "users")
+	if err != nil {
+		return err
+	}
+	for cur != nil {
+		users, next, _, err := us.s.pagedUsers(stream.Context(), cur)
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			m, err := rpcUser(user)
+			if err != nil {
+				return err
+			}
+			if err := stream.Send(m); err != nil {
+				return err
+			}
+		}
+
+		cur = next
+	}
+	return nil
+}
+
+// GetUser mirrors `GET /users/<userID>`
+func (us *userService) GetUser(ctx context.Context,
+	req *rpc.GetUserRequest) (*rpc.User, error) {
+
+	if req.Userid == "" {
+		return nil, he.BadRequest.New("missing userid")
+	}
+
+	user, err := us.s.getUser(ctx, req.Userid)
+	if err != nil {
+		return nil, err
+	}
+	return rpcUser(user)
+}
+
+// CreateUser mirrors `POST /users`
+func (us *userService) CreateUser(ctx context.Context,
+	req *rpc.CreateUserRequest) (*rpc.User, error) {
+
+	user, err := us.s.createUser(ctx, userFromRPC(req.User))
+	if err != nil {
+		return nil, err
+	}
+	return rpcUser(user)
+}
+
+// UpdateUser mirrors `PUT /users/<userID>`. the groups of the user are only
+// replaced if it has any, since an empty list can't be told from one that was
+// left out.
+func (us *userService) UpdateUser(ctx context.Context,
+	req *rpc.UpdateUserRequest) (*rpc.User, error) {
+
+	if req.Userid == "" {
+		return nil, he.BadRequest.New("missing userid")
+	}
+
+	user, err := us.s.updateUser(ctx, req.Userid, userFromRPC(req.User))
+	if err != nil {
+		return nil, err
+	}
+	return rpcUser(user)
+}
+
+// DeleteUser mirrors `DELETE /users/<userID>`
+func (us *userService) DeleteUser(ctx context.Context,
+	req *rpc.DeleteUserRequest) (*emptypb.Empty, error) {
+
+	if req.Userid == "" {
+		return nil, he.BadRequest.New("missing userid")
+	}
+
+	err := us.s.deleteUser(ctx, req.Userid)
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+type groupService struct {
+	rpc.UnimplementedGroupServiceServer
+	s *Server
+}
+
+// ListGroups mirrors `GET /groups` but streams every page
+func (gs *groupService) ListGroups(req *rpc.ListGroupsRequest,
+	stream rpc.GroupService_ListGroupsServer) error {
+
+	cur, err := gs.s.grpcCursor(req.PageToken, req.PageSize, "groups")
+	if err != nil {
+		return err
+	}
+	for cur != nil {
+		groups, next, _, err := gs.s.pagedGroups(stream.Context(), cur)
+		if err != nil {
+			return err
+		}
+
+		for _, group := range groups {
+			if err := stream.Send(rpcGroup(group)); err != nil {
+				return err
+			}
+		}
+
+		cur = next
+	}
+	return nil
+}
+
+// GetMemberships mirrors `GET /groups/<groupName>`
+func (gs *groupService) GetMemberships(req *rpc.GetMembershipsRequest,
+	stream rpc.GroupService_GetMembershipsServer) error {
+
+	if req.Name == "" {
+		return he.BadRequest.New("missing name")
+	}
+
+	members, err := gs.s.getMemberships(stream.Context(), req.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		err := stream.Send(&rpc.Membership{Userid: string(member)})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateGroup mirrors `POST /groups`
+func (gs *groupService) CreateGroup(ctx context.Context,
+	req *rpc.CreateGroupRequest) (*rpc.Group, error) {
+
+	group, err := gs.s.createGroup(ctx, groupFromRPC(req.Group))
+	if err != nil {
+		return nil, err
+	}
+	return rpcGroup(group), nil
+}
+
+// UpdateMembership mirrors `PUT /groups/<groupName>`
+func (gs *groupService) UpdateMembership(ctx context.Context,
+	req *rpc.UpdateMembershipRequest) (*emptypb.Empty, error) {
+
+	if req.Name == "" {
+		return nil, he.BadRequest.New("missing name")
+	}
+
+	err := gs.s.updateMembership(ctx, req.Name, req.Userids)
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// DeleteGroup mirrors `DELETE /groups/<groupName>`
+func (gs *groupService) DeleteGroup(ctx context.Context,
+	req *rpc.DeleteGroupRequest) (*emptypb.Empty, error) {
+
+	if req.Name == "" {
+		return nil, he.BadRequest.New("missing name")
+	}
+
+	err := gs.s.deleteGroup(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func rpcTimestamp(t UnixTime) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t.Time)
+}
+
+func rpcUser(d *User) (*rpc.User, error) {
+	m := &rpc.User{
+		Userid:    d.ID,
+		Uuid:      d.UUID,
+		FirstName: d.FirstName,
+		LastName:  d.LastName,
+		Created:   rpcTimestamp(d.Created),
+		Groups:    parseMembership(d.Groups),
+		Status:    d.Status,
+	}
+	for name, value := range d.Attributes {
+		v, err := structpb.NewValue(value)
+		if err != nil {
+			return nil, he.Unexpected.New("attribute %q: %s", name, err)
+		}
+		if m.Attributes == nil {
+			m.Attributes = map[string]*structpb.Value{}
+		}
+		m.Attributes[name] = v
+	}
+	return m, nil
+}
+
+func rpcGroup(d *Group) *rpc.Group {
+	return &rpc.Group{
+		Name:        d.Name,
+		Uuid:        d.UUID,
+		Created:     rpcTimestamp(d.Created),
+		DisplayName: d.DisplayName,
+		Description: d.Description,
+		Owner:       d.Owner,
+		Labels:      d.Labels,
+	}
+}
+
+// userFromRPC converts m to a user. its attributes are converted to the
+// values they'd have in json, where null removes one in an update.
+func userFromRPC(m *rpc.User) *User {
+	d := &User{
+		ID:        m.GetUserid(),
+		FirstName: m.GetFirstName(),
+		LastName:  m.GetLastName(),
+	}
+	for _, g := range m.GetGroups() {
+		d.Groups = append(d.Groups, apiMembership(g))
+	}
+	for name, value := range m.GetAttributes() {
+		if d.Attributes == nil {
+			d.Attributes = map[string]interface{}{}
+		}
+		d.Attributes[name] = value.AsInterface()
+	}
+	return d
+}
+
+func groupFromRPC(m *rpc.Group) *Group {
+	return &Group{
+		Name:        m.GetName(),
+		DisplayName: m.GetDisplayName(),
+		Description: m.GetDescription(),
+		Owner:       m.GetOwner(),
+		Labels:      m.GetLabels(),
+	}
+}
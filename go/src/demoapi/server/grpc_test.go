@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"demoapi/rpc"
+)
+
+func TestGRPCUsers(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newGroup(ctx, "group1")
+	t.newUser(ctx, "user1")
+	t.newUser(ctx, "user2")
+	_, err := t.server.createAttribute(ctx, &Attribute{Name: "level",
+		Type: "int"})
+	require.NoError(t, err)
+
+	conn := t.grpcConn()
+	defer conn.Close()
+	users := rpc.NewUserServiceClient(conn)
+
+	created, err := users.CreateUser(ctx, &rpc.CreateUserRequest{
+		User: &rpc.User{Userid: "user3", FirstName: "first", LastName: "last",
+			Groups: []string{"group1"},
+			Attributes: map[string]*structpb.Value{
+				"level": structpb.NewNumberValue(3)}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "user3", created.Userid)
+	assert.Equal(t, []string{"group1"}, created.Groups)
+	assert.NotNil(t, created.Created)
+	assert.Equal(t, userActive, created.Status)
+	assert.Equal(t, 3.0, created.Attributes["level"].GetNumberValue())
+
+	_, err = users.CreateUser(ctx, &rpc.CreateUserRequest{
+		User: &rpc.User{Userid: "user4"},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	user, err := users.UpdateUser(ctx, &rpc.UpdateUserRequest{
+		Userid: "user3", User: &rpc.User{FirstName: "changed"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "changed", user.FirstName)
+	assert.Equal(t, "last", user.LastName)
+	assert.Equal(t, 3.0, user.Attributes["level"].GetNumberValue())
+
+	// a null value removes an attribute
+	user, err = users.UpdateUser(ctx, &rpc.UpdateUserRequest{
+		Userid: "user3", User: &rpc.User{Attributes: map[string]*structpb.Value{
+			"level": structpb.NewNullValue()}},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, user.Attributes)
+
+	// every page is streamed
+	stream, err := users.ListUsers(ctx, &rpc.ListUsersRequest{PageSize: 1})
+	assert.NoError(t, err)
+	var userIDs []string
+	for {
+		user, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		userIDs = append(userIDs, user.Userid)
+	}
+	assert.Equal(t, []string{"user1", "user2", "user3"}, userIDs)
+
+	_, err = users.DeleteUser(ctx, &rpc.DeleteUserRequest{Userid: "user3"})
+	assert.NoError(t, err)
+
+	_, err = users.GetUser(ctx, &rpc.GetUserRequest{Userid: "user3"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPCGroups(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newUser(ctx, "user2")
+
+	conn := t.grpcConn()
+	defer conn.Close()
+	groups := rpc.NewGroupServiceClient(conn)
+
+	group, err := groups.CreateGroup(ctx, &rpc.CreateGroupRequest{
+		Group: &rpc.Group{Name: "group1", DisplayName: "Group 1",
+			Description: "the first", Owner: "user1",
+			Labels: map[string]string{"team": "infra"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "group1", group.Name)
+	assert.Equal(t, "Group 1", group.DisplayName)
+	assert.Equal(t, "the first", group.Description)
+	assert.Equal(t, "user1", group.Owner)
+	assert.Equal(t, map[string]string{"team": "infra"}, group.Labels)
+
+	_, err = groups.UpdateMembership(ctx, &rpc.UpdateMembershipRequest{
+		Name: "group1", Userids: []string{"user1", "user2"},
+	})
+	assert.NoError(t, err)
+
+	stream, err := groups.GetMemberships(ctx,
+		&rpc.GetMembershipsRequest{Name: "group1"})
+	assert.NoError(t, err)
+	var userIDs []string
+	for {
+		member, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		userIDs = append(userIDs, member.Userid)
+	}
+	assert.Equal(t, []string{"user1", "user2"}, userIDs)
+
+	_, err = groups.DeleteGroup(ctx, &rpc.DeleteGroupRequest{Name: "group1"})
+	assert.NoError(t, err)
+
+	_, err = groups.DeleteGroup(ctx, &rpc.DeleteGroupRequest{Name: "group1"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	stream, err = groups.GetMemberships(ctx,
+		&rpc.GetMembershipsRequest{Name: "group1"})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPCAuth(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.server.Config.InsecureRequestsMode = false
+	t.newUser(ctx, "user1")
+
+	conn := t.grpcConn()
+	defer conn.Close()
+	users := rpc.NewUserServiceClient(conn)
+
+	_, err := users.GetUser(ctx, &rpc.GetUserRequest{Userid: "user1"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	stream, err := users.ListUsers(ctx, &rpc.ListUsersRequest{})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	authCtx := metadata.AppendToOutgoingContext(ctx,
+		"authorization", "Bearer dummy_token")
+	user, err := users.GetUser(authCtx, &rpc.GetUserRequest{Userid: "user1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", user.Userid)
+}
+
+// grpcConn serves the test server over an in memory listener and returns a
+// client connection to it
+func (st *serverTest) grpcConn() *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	gs := NewGRPCServer(st.server)
+	go func() { _ = gs.Serve(lis) }()
+	st.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context,
+			_ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(st, err)
+	return conn
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	h "demoapi/handler"
+	"demoapi/health"
+)
+
+// newHealthChecker registers the dependencies that the api needs to be ready
+func newHealthChecker(s *Server) *health.Checker {
+	checker := health.NewChecker(s.Config.ReadinessTimeout)
+	checker.Register("database", health.CheckFunc(s.DB.Ping))
+	checker.Register("migrations", health.CheckFunc(s.DB.CheckSchema))
+	return checker
+}
+
+// RegisterCheck adds a dependency that has to be working for the api to be
+// ready
+func (s *Server) RegisterCheck(name string, check health.Check) {
+	s.health.Register(name, check)
+}
+
+// ShutDown makes the api report that it isn't ready, so that load balancers
+// stop sending it requests while it drains
+func (s *Server) ShutDown() {
+	s.health.ShutDown()
+}
+
+// Liveness reports that the api is running. It doesn't check any of the api's
+// dependencies, since restarting the api won't fix them.
+// `GET /healthz`
+func (s *Server) Liveness(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+	return h.OK(&health.Report{Status: health.StatusOK,
+		Checks: []*health.Result{}}), nil
+}
+
+// Readiness runs every registered check and responds with 503 if any of them
+// failed, or if the api is shutting down.
+// `GET /readyz`
+func (s *Server) Readiness(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	report := s.health.Run(ctx)
+	resp := h.OK(report)
+	if !report.OK() {
+		resp.Status = http.StatusServiceUnavailable
+	}
+	return resp, nil
+}
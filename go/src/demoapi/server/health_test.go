@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"demoapi/health"
+)
+
+func TestReadiness(baseTest *testing.T) {
+	_, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	probe := func(target string) (int, *health.Report) {
+		w := httptest.NewRecorder()
+		t.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		report := &health.Report{}
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(report))
+		return w.Code, report
+	}
+
+	status, report := probe("/readyz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, health.StatusOK, report.Status)
+	if assert.Len(t, report.Checks, 2) {
+		assert.Equal(t, "database", report.Checks[0].Name)
+		assert.Equal(t, "migrations", report.Checks[1].Name)
+		assert.Equal(t, health.StatusOK, report.Checks[0].Status)
+	}
+
+	t.server.RegisterCheck("broken", health.CheckFunc(
+		func(ctx context.Context) error { return errors.New("broken") }))
+	status, report = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, health.StatusFailed, report.Status)
+	if assert.Len(t, report.Checks, 3) {
+		assert.Equal(t, health.StatusOK, report.Checks[1].Status)
+		assert.Equal(t, health.StatusFailed, report.Checks[2].Status)
+		assert.Equal(t, "broken", report.Checks[2].Error)
+	}
+
+	// the api is still alive while it drains, but it isn't ready
+	t.server.ShutDown()
+	status, report = probe("/healthz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, health.StatusOK, report.Status)
+	status, report = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "shutting down", report.Error)
+}
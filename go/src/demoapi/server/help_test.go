@@ -14,6 +14,7 @@ import (
 
 	"demoapi/config"
 	"demoapi/database"
+	he "demoapi/httperror"
 	"demoapi/util"
 )
 
@@ -21,7 +22,7 @@ import (
 
 type testResponse struct {
 	RootJSON
-	Error    string `json:"error"`
+	he.Problem
 	Health   string `json:"health"`
 	Response string `json:"response"`
 }
@@ -38,7 +39,10 @@ func newServerTest(t *testing.T) (context.Context, *serverTest) {
 	testDB, err := database.Connect(testDBURL, nil)
 	assert.NoError(t, err)
 	c := &config.Configs{
-		InsecureRequestsMode: true,
+		InsecureRequestsMode:   true,
+		PaginationMaxLimit:     PaginationLimit,
+		PaginationCursorSecret: "test",
+		MaxRequestBodyBytes:    1 << 20,
 	}
 	return context.Background(), &serverTest{
 		T:      t,
@@ -55,7 +59,8 @@ func (st *serverTest) newUser(ctx context.Context, id string) string {
 		database.User_Uuid(util.MustUUID4()),
 		database.User_Id(id),
 		database.User_FirstName(id+"first_name"),
-		database.User_LastName(id+"last_name"))
+		database.User_LastName(id+"last_name"),
+		database.User_Status(userActive))
 	assert.NoError(st, err)
 	return user.Id
 }
@@ -63,7 +68,9 @@ func (st *serverTest) newUser(ctx context.Context, id string) string {
 func (st *serverTest) newGroup(ctx context.Context, name string) string {
 	group, err := st.server.DB.Create_Group(ctx,
 		database.Group_Uuid(util.MustUUID4()),
-		database.Group_Name(name))
+		database.Group_Name(name), database.Group_Description(""),
+		database.Group_DisplayName(""), database.Group_Owner(""),
+		database.Group_Labels(""))
 	assert.NoError(st, err)
 	return group.Name
 }
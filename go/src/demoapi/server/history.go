@@ -0,0 +1,265 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+
+	"demoapi/database"
+	h "demoapi/handler"
+	he "demoapi/httperror"
+)
+
+// Every change to a user, a group or a membership is stored as a version in
+// the history tables, along with who made it. versions refer to their records
+// by uuid, so the history of a deleted record is kept. records that existed
+// before history was recorded only have history from their next change on.
+const (
+	// asOfParam reads users and groups as they were at a time, in unix
+	// seconds or RFC 3339
+	asOfParam = "as_of"
+)
+
+// GetUserHistory returns every version of a user and every change to their
+// memberships, oldest first. The history of a deleted user is returned too.
+// `GET /v1/users/<userID>/history`
+func (s *Server) GetUserHistory(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		return nil, he.BadRequest.New("incomplete path. missing userID")
+	}
+
+	versions, err := s.DB.UserVersions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(versions) == 0 {
+		return nil, he.CodeUserNotFound.New(
+			"userID %q has no history", userID)
+	}
+
+	memberships, err := s.DB.MembershipVersionsByUserUuid(ctx,
+		versions[0].UserUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]*Change, 0, len(versions)+len(memberships))
+	for _, v := range versions {
+		history = append(history, &Change{
+			Version:   v.Version,
+			Action:    v.Action,
+			Principal: v.Principal,
+			Created:   UnixTS(v.Created),
+			User:      apiUserVersion(v, versions[0].Created),
+		})
+	}
+
+	resp := &RootJSON{
+		History: sortedHistory(append(history,
+			apiMembershipChanges(memberships)...)),
+	}
+
+	return h.OK(resp), nil
+}
+
+// GetGroupHistory returns every version of a group and every change to its
+// members, oldest first. The history of a deleted group is returned too.
+// `GET /v1/groups/<groupName>/history`
+func (s *Server) GetGroupHistory(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	groupName := chi.URLParam(r, "groupName")
+	if groupName == "" {
+		return nil, he.BadRequest.New("incomplete path. missing groupName")
+	}
+
+	versions, err := s.DB.GroupVersions(ctx, groupName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(versions) == 0 {
+		return nil, he.CodeGroupNotFound.New(
+			"groupName %q has no history", groupName)
+	}
+
+	memberships, err := s.DB.MembershipVersionsByGroupUuid(ctx,
+		versions[0].GroupUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]*Change, 0, len(versions)+len(memberships))
+	for _, v := range versions {
+		history = append(history, &Change{
+			Version:   v.Version,
+			Action:    v.Action,
+			Principal: v.Principal,
+			Created:   UnixTS(v.Created),
+			Group:     apiGroupVersion(v, versions[0].Created),
+		})
+	}
+
+	resp := &RootJSON{
+		History: sortedHistory(append(history,
+			apiMembershipChanges(memberships)...)),
+	}
+
+	return h.OK(resp), nil
+}
+
+// sortedHistory orders the changes to a record and to its memberships by
+// when they were made, keeping the order of changes made at the same time
+func sortedHistory(history []*Change) []*Change {
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Created.Before(history[j].Created.Time)
+	})
+	return history
+}
+
+// asOf returns the time of the as_of param, or nil if there isn't one. unix
+// seconds include the whole second, since that's the precision of the
+// timestamps of the api.
+func asOf(query url.Values) (*time.Time, error) {
+	raw := query.Get(asOfParam)
+	if raw == "" {
+		return nil, nil
+	}
+
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		t := time.Unix(seconds, 0).Add(time.Second - time.Nanosecond)
+		return &t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return nil, he.BadRequest.Wrap(he.InvalidFields{{Field: asOfParam,
+			Message: "must be unix seconds or an RFC 3339 timestamp"}})
+	}
+	return &t, nil
+}
+
+// getUserAsOf is getUser as of a time. the attributes of the user aren't
+// versioned, so they're left out.
+func (s *Server) getUserAsOf(ctx context.Context, userID string,
+	asOf time.Time) (*User, error) {
+
+	version, created, err := s.DB.UserAsOf(ctx, userID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == nil {
+		return nil, he.CodeUserNotFound.New(
+			"userID %q didn't exist at %s", userID, asOf.Format(time.RFC3339))
+	}
+
+	groups, err := s.DB.GroupsAsOf(ctx, version.UserUuid, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	user := apiUserVersion(version, created)
+	for _, g := range groups {
+		user.Groups = append(user.Groups, apiMembership(g.Name))
+	}
+	return user, nil
+}
+
+// getGroupAsOf is getGroup as of a time. with activeOnly, the members are the
+// users that were active then.
+func (s *Server) getGroupAsOf(ctx context.Context, groupName string,
+	asOf time.Time, activeOnly bool) (*Group, error) {
+
+	version, created, err := s.DB.GroupAsOf(ctx, groupName, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == nil {
+		return nil, he.CodeGroupNotFound.New(
+			"groupName %q didn't exist at %s", groupName,
+			asOf.Format(time.RFC3339))
+	}
+
+	users, err := s.DB.MembersAsOf(ctx, version.GroupUuid, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	group := apiGroupVersion(version, created)
+	for _, u := range users {
+		if !activeOnly || u.Status == userActive {
+			group.Users = append(group.Users, apiMembership(u.UserId))
+		}
+	}
+	return group, nil
+}
+
+// recordUser stores the version of user after a change by the principal of
+// ctx
+func (s *Server) recordUser(ctx context.Context, user *database.User,
+	action string) error {
+	return s.DB.RecordUserVersion(ctx, user, action, principal(ctx))
+}
+
+// recordGroup is recordUser for groups
+func (s *Server) recordGroup(ctx context.Context, group *database.Group,
+	action string) error {
+	return s.DB.RecordGroupVersion(ctx, group, action, principal(ctx))
+}
+
+// recordUserMemberships calls fn in a transaction, and records the changes it
+// made to the memberships of the user with userID
+func (s *Server) recordUserMemberships(ctx context.Context, userID string,
+	fn func(ctx context.Context) error) error {
+
+	return s.recordMemberships(ctx, func(ctx context.Context) (
+		[]*database.MembershipRef, error) {
+		return s.DB.MembershipRefsByUserId(ctx, userID)
+	}, fn)
+}
+
+// recordGroupMemberships is recordUserMemberships for the members of a group
+func (s *Server) recordGroupMemberships(ctx context.Context,
+	groupName string, fn func(ctx context.Context) error) error {
+
+	return s.recordMemberships(ctx, func(ctx context.Context) (
+		[]*database.MembershipRef, error) {
+		return s.DB.MembershipRefsByGroupName(ctx, groupName)
+	}, fn)
+}
+
+func (s *Server) recordMemberships(ctx context.Context,
+	list func(ctx context.Context) ([]*database.MembershipRef, error),
+	fn func(ctx context.Context) error) error {
+
+	return s.DB.WithTx(ctx, func(ctx context.Context, tx *database.Tx) error {
+		before, err := list(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = fn(ctx)
+		if err != nil {
+			return err
+		}
+
+		after, err := list(ctx)
+		if err != nil {
+			return err
+		}
+
+		return s.DB.RecordMembershipChanges(ctx, before, after,
+			principal(ctx))
+	})
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(baseTest *testing.T) {
+	_, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	// every change is made a second after the previous one
+	now := time.Unix(1700000000, 0)
+	t.server.DB.Hooks.Now = func() time.Time { return now }
+	tick := func() int64 {
+		now = now.Add(time.Second)
+		return now.Unix()
+	}
+
+	do := func(method, target string, body interface{}) (int, *RootJSON) {
+		w := httptest.NewRecorder()
+		t.server.ServeHTTP(w, jsonRequest(t, method, target, nil, body))
+		resp := &RootJSON{}
+		if w.Code != http.StatusNoContent {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp),
+				w.Body.String())
+		}
+		return w.Code, resp
+	}
+	asOf := func(target string, at int64) string {
+		return fmt.Sprintf("%s?as_of=%d", target, at)
+	}
+
+	before := now.Unix()
+	created := tick()
+	status, _ := do(http.MethodPost, "/v1/users",
+		User{ID: "user1", FirstName: "first", LastName: "last"})
+	require.Equal(t, http.StatusCreated, status)
+	status, _ = do(http.MethodPost, "/v1/groups", Group{Name: "group1"})
+	require.Equal(t, http.StatusCreated, status)
+
+	added := tick()
+	status, _ = do(http.MethodPut, "/v1/groups/group1",
+		map[string][]string{"userids": {"user1"}})
+	require.Equal(t, http.StatusOK, status)
+
+	renamed := tick()
+	status, _ = do(http.MethodPut, "/v1/users/user1",
+		User{FirstName: "renamed", Groups: []Membership{"group1"}})
+	require.Equal(t, http.StatusOK, status)
+
+	suspended := tick()
+	status, _ = do(http.MethodPost, "/v1/users/user1:suspend",
+		&Transition{Reason: "on leave"})
+	require.Equal(t, http.StatusOK, status)
+
+	groupRenamed := tick()
+	status, _ = do(http.MethodPatch, "/v1/groups/group1",
+		Group{Name: "group2"})
+	require.Equal(t, http.StatusOK, status)
+
+	deleted := tick()
+	status, _ = do(http.MethodDelete, "/v1/users/user1", nil)
+	require.Equal(t, http.StatusNoContent, status)
+
+	// users as they were
+	status, _ = do(http.MethodGet, asOf("/v1/users/user1", before), nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	status, resp := do(http.MethodGet, asOf("/v1/users/user1", created), nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "first", resp.User.FirstName)
+	assert.Equal(t, userActive, resp.User.Status)
+	assert.Empty(t, resp.User.Groups)
+	assert.Equal(t, created, resp.User.Created.Unix())
+	status, resp = do(http.MethodGet, asOf("/v1/users/user1", added), nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []Membership{"group1"}, resp.User.Groups)
+	status, resp = do(http.MethodGet, asOf("/v1/users/user1", renamed), nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "renamed", resp.User.FirstName)
+	status, resp = do(http.MethodGet, asOf("/v1/users/user1", groupRenamed),
+		nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, userSuspended, resp.User.Status)
+	assert.Equal(t, []Membership{"group2"}, resp.User.Groups)
+	status, _ = do(http.MethodGet, asOf("/v1/users/user1", deleted), nil)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// RFC 3339 timestamps are exact
+	status, resp = do(http.MethodGet, "/v1/users/user1?as_of="+
+		time.Unix(renamed, 0).UTC().Format(time.RFC3339), nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "renamed", resp.User.FirstName)
+	status, _ = do(http.MethodGet, "/v1/users/user1?as_of=yesterday", nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// groups as they were
+	status, resp = do(http.MethodGet, asOf("/v1/groups/group1", created), nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Empty(t, resp.Members)
+	status, resp = do(http.MethodGet, asOf("/v1/groups/group1", added), nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []Membership{"user1"}, resp.Members)
+	status, resp = do(http.MethodGet, asOf("/v1/groups/group1", suspended)+
+		"&active_only=true", nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Empty(t, resp.Members)
+	status, _ = do(http.MethodGet, asOf("/v1/groups/group1", groupRenamed),
+		nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	status, resp = do(http.MethodGet, asOf("/v1/groups/group2", groupRenamed),
+		nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []Membership{"user1"}, resp.Members)
+	status, resp = do(http.MethodGet, asOf("/v1/groups/group2", deleted), nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Empty(t, resp.Members)
+
+	// the history of a deleted user is kept
+	status, resp = do(http.MethodGet, "/v1/users/user1/history", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, resp.History, 6)
+	for i, want := range []struct {
+		version int64
+		action  string
+		at      int64
+	}{
+		{1, "created", created},
+		{1, "added", added},
+		{2, "updated", renamed},
+		{3, "updated", suspended},
+		{4, "deleted", deleted},
+		{2, "removed", deleted},
+	} {
+		got := resp.History[i]
+		assert.Equal(t, want.version, got.Version, i)
+		assert.Equal(t, want.action, got.Action, i)
+		assert.Equal(t, want.at, got.Created.Unix(), i)
+	}
+	assert.Equal(t, "first", resp.History[0].User.FirstName)
+	assert.Equal(t, &MembershipChange{UserID: "user1", Group: "group1"},
+		resp.History[1].Membership)
+	assert.Equal(t, userSuspended, resp.History[3].User.Status)
+	assert.Equal(t, &MembershipChange{UserID: "user1", Group: "group2"},
+		resp.History[5].Membership)
+
+	status, resp = do(http.MethodGet, "/v1/groups/group2/history", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, resp.History, 4)
+	assert.Equal(t, "group1", resp.History[0].Group.Name)
+	assert.Equal(t, "added", resp.History[1].Action)
+	assert.Equal(t, "updated", resp.History[2].Action)
+	assert.Equal(t, "group2", resp.History[2].Group.Name)
+	assert.Equal(t, "removed", resp.History[3].Action)
+
+	// so is the removal of the members of a deleted group
+	status, _ = do(http.MethodPost, "/v1/users",
+		User{ID: "user2", FirstName: "first", LastName: "last"})
+	require.Equal(t, http.StatusCreated, status)
+	status, _ = do(http.MethodPut, "/v1/groups/group2",
+		map[string][]string{"userids": {"user2"}})
+	require.Equal(t, http.StatusOK, status)
+	tick()
+	status, _ = do(http.MethodDelete, "/v1/groups/group2", nil)
+	require.Equal(t, http.StatusNoContent, status)
+	status, resp = do(http.MethodGet, "/v1/users/user2/history", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, resp.History, 3)
+	assert.Equal(t, "removed", resp.History[2].Action)
+	assert.Equal(t, &MembershipChange{UserID: "user2", Group: "group2"},
+		resp.History[2].Membership)
+
+	status, _ = do(http.MethodGet, "/v1/users/missing/history", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = do(http.MethodGet, "/v1/groups/missing/history", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+}
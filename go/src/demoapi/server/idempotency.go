@@ -0,0 +1,225 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"demoapi/database"
+	"demoapi/handler"
+	he "demoapi/httperror"
+	"demoapi/logging"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	idempotentReplayHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength = 255
+
+	// how often expired responses are deleted
+	idempotencySweepInterval = time.Minute
+)
+
+// Idempotent makes writes with an Idempotency-Key header safe to retry. the
+// first response to a key is stored for idempotency_key_ttl_sec and replayed
+// to retries of the same request. reusing a key for a different request is
+// a 422, and retrying while the first request is still running is a 409.
+// responses to unexpected errors aren't stored, so that they can be retried.
+func (s *Server) Idempotent(h handler.Handler) handler.Handler {
+	return handler.Handler(func(ctx context.Context, w http.ResponseWriter,
+		r *http.Request) (*handler.Response, error) {
+
+		key := r.Header.Get(idempotencyKeyHeader)
+		ttl := s.settings().IdempotencyKeyTTL
+		if key == "" || ttl <= 0 || r.Method == http.MethodGet ||
+			r.Method == http.MethodHead {
+			return h(ctx, w, r)
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return nil, he.BadRequest.New("%s is longer than %d characters",
+				idempotencyKeyHeader, maxIdempotencyKeyLength)
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, he.BadRequest.Wrap(err)
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body)) // for the handler
+
+		// keys are only unique per client
+		if p := principal(ctx); p != "" {
+			key = p + " " + key
+		}
+		hash := requestHash(r, body)
+
+		stored, err := s.reserveIdempotencyKey(ctx, key, hash, ttl)
+		if err != nil {
+			return nil, err
+		}
+		if stored != nil {
+			logging.Entry(ctx).Debugf("replaying the response to %s %q",
+				idempotencyKeyHeader, key)
+			return handler.Written, replay(w, stored)
+		}
+
+		// a panic doesn't store a response, so the key is released for the
+		// retries instead of being in progress until it expires
+		defer func() {
+			if p := recover(); p != nil {
+				s.releaseIdempotencyKey(ctx, key)
+				panic(p)
+			}
+		}()
+
+		resp := handler.Capture(ctx, w, r, h)
+		s.storeIdempotentResponse(ctx, key, w.Header(), resp)
+		return handler.Written, resp.Send(w)
+	})
+}
+
+// reserveIdempotencyKey claims key for a request with hash. it returns the
+// stored response if the request already has one.
+func (s *Server) reserveIdempotencyKey(ctx context.Context, key, hash string,
+	ttl time.Duration) (*database.IdempotentRequest, error) {
+
+	dbKey := database.IdempotentRequest_IdempotencyKey(key)
+	stored, err := s.DB.Find_IdempotentRequest_By_IdempotencyKey(ctx, dbKey)
+	if err != nil {
+		return nil, he.Unexpected.Wrap(err)
+	}
+	if stored != nil && !stored.Expires.After(time.Now()) {
+		// the sweeper hasn't deleted it yet
+		_, err = s.DB.Delete_IdempotentRequest_By_IdempotencyKey(ctx, dbKey)
+		if err != nil {
+			return nil, he.Unexpected.Wrap(err)
+		}
+		stored = nil
+	}
+
+	if stored == nil {
+		_, err = s.DB.Create_IdempotentRequest(ctx, dbKey,
+			database.IdempotentRequest_RequestHash(hash),
+			database.IdempotentRequest_Status(0),
+			database.IdempotentRequest_Headers(""),
+			database.IdempotentRequest_Body([]byte{}),
+			database.IdempotentRequest_Expires(time.Now().Add(ttl)))
+		if err == nil {
+			return nil, nil
+		}
+
+		// another request with the key won the race
+		stored, _ = s.DB.Find_IdempotentRequest_By_IdempotencyKey(ctx, dbKey)
+		if stored == nil {
+			return nil, he.Unexpected.Wrap(err)
+		}
+	}
+
+	if stored.RequestHash != hash {
+		return nil, he.CodeIdempotencyKeyReused.New("%s was already used "+
+			"for a different request", idempotencyKeyHeader)
+	}
+	if stored.Status == 0 {
+		return nil, he.CodeIdempotencyKeyInProgress.New("a request with the "+
+			"same %s is still in progress", idempotencyKeyHeader)
+	}
+	return stored, nil
+}
+
+// storeIdempotentResponse saves the response to the request that reserved
+// key. the key is released instead if the request failed unexpectedly.
+func (s *Server) storeIdempotentResponse(ctx context.Context, key string,
+	header http.Header, resp *handler.Captured) {
+
+	if resp.Status >= http.StatusInternalServerError {
+		s.releaseIdempotencyKey(ctx, key)
+		return
+	}
+
+	dbKey := database.IdempotentRequest_IdempotencyKey(key)
+
+	headers, err := json.Marshal(header)
+	if err == nil {
+		_, err = s.DB.Update_IdempotentRequest_By_IdempotencyKey(ctx, dbKey,
+			database.IdempotentRequest_Update_Fields{
+				Status:  database.IdempotentRequest_Status(resp.Status),
+				Headers: database.IdempotentRequest_Headers(string(headers)),
+				Body:    database.IdempotentRequest_Body(resp.Body),
+			})
+	}
+	if err != nil {
+		// retries will get a 409 until the key expires
+		logging.Entry(ctx).Warningf("failed to store the response to %s %q: "+
+			"%s", idempotencyKeyHeader, key, err)
+	}
+}
+
+// releaseIdempotencyKey deletes the reservation of key, so that the request
+// can be retried
+func (s *Server) releaseIdempotencyKey(ctx context.Context, key string) {
+	_, err := s.DB.Delete_IdempotentRequest_By_IdempotencyKey(ctx,
+		database.IdempotentRequest_IdempotencyKey(key))
+	if err != nil {
+		logging.Entry(ctx).Warningf("failed to release %s %q: %s",
+			idempotencyKeyHeader, key, err)
+	}
+}
+
+// replay writes a stored response. headers that are already set for this
+// request, like the rate limits, are kept.
+func replay(w http.ResponseWriter, stored *database.IdempotentRequest) error {
+	var header http.Header
+	if err := json.Unmarshal([]byte(stored.Headers), &header); err != nil {
+		return he.Unexpected.Wrap(err)
+	}
+	for name, values := range header {
+		if _, ok := w.Header()[name]; !ok {
+			w.Header()[name] = values
+		}
+	}
+	w.Header().Set(idempotentReplayHeader, "true")
+
+	w.WriteHeader(stored.Status)
+	_, err := w.Write(stored.Body)
+	return err
+}
+
+// requestHash identifies a request by its method, uri and body
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// SweepIdempotentRequests deletes the expired responses of Idempotent every
+// minute, until ctx is canceled
+func (s *Server) SweepIdempotentRequests(ctx context.Context) error {
+	ticker := time.NewTicker(idempotencySweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		count, err := s.DB.Delete_IdempotentRequest_By_Expires_Less(ctx,
+			database.IdempotentRequest_Expires(time.Now()))
+		if err != nil {
+			logrus.Warningf("failed to delete expired idempotency keys: %s",
+				err)
+			continue
+		}
+		if count > 0 {
+			logrus.Debugf("deleted %d expired idempotency keys", count)
+		}
+	}
+}
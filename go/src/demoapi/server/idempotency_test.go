@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"demoapi/handler"
+	he "demoapi/httperror"
+)
+
+func TestIdempotent(baseTest *testing.T) {
+	_, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	configs := *t.server.Config
+	configs.IdempotencyKeyTTL = time.Hour
+	t.server.Reload(&configs)
+
+	post := func(key string, user User) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := jsonRequest(t, http.MethodPost, "/v1/users", nil, user)
+		r.Header.Set(idempotencyKeyHeader, key)
+		t.server.ServeHTTP(w, r)
+		return w
+	}
+
+	// a retry gets the first response instead of a conflict
+	user := User{FirstName: "fn", LastName: "ln", ID: "user1"}
+	first := post("key1", user)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	retry := post("key1", user)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "/v1/users/user1", retry.Header().Get("Location"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8",
+		retry.Header().Get("Content-Type"))
+	assert.Equal(t, "true", retry.Header().Get(idempotentReplayHeader))
+	assert.Empty(t, first.Header().Get(idempotentReplayHeader))
+
+	// without a key, the request is made again and fails
+	w := post("", user)
+	assert.NotEqual(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(idempotentReplayHeader))
+
+	// a key can't be reused for a different request
+	user.ID = "user2"
+	w = post("key1", user)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), he.CodeIdempotencyKeyReused.Code)
+	assert.Equal(t, http.StatusCreated, post("key2", user).Code)
+
+	// unexpected errors aren't stored, so the request can be retried
+	calls := 0
+	h := t.server.Idempotent(func(context.Context, http.ResponseWriter,
+		*http.Request) (*handler.Response, error) {
+		calls++
+		if calls == 1 {
+			return nil, he.Unexpected.New("try again")
+		}
+		return nil, nil
+	})
+	assert.Equal(t, http.StatusInternalServerError, send(h, "key3").Code)
+	assert.Equal(t, http.StatusOK, send(h, "key3").Code)
+	assert.Equal(t, http.StatusOK, send(h, "key3").Code)
+	assert.Equal(t, 2, calls)
+
+	// a retry while the first request is running is a conflict
+	var retried *httptest.ResponseRecorder
+	h = t.server.Idempotent(func(context.Context, http.ResponseWriter,
+		*http.Request) (*handler.Response, error) {
+		retried = send(h, "key4")
+		return nil, nil
+	})
+	assert.Equal(t, http.StatusOK, send(h, "key4").Code)
+	assert.Equal(t, http.StatusConflict, retried.Code)
+	assert.Contains(t, retried.Body.String(),
+		he.CodeIdempotencyKeyInProgress.Code)
+
+	// a panic releases the key too
+	calls = 0
+	h = t.server.Idempotent(func(context.Context, http.ResponseWriter,
+		*http.Request) (*handler.Response, error) {
+		calls++
+		if calls == 1 {
+			panic("try again")
+		}
+		return nil, nil
+	})
+	assert.PanicsWithValue(t, "try again", func() { send(h, "key5") })
+	assert.Equal(t, http.StatusOK, send(h, "key5").Code)
+	assert.Equal(t, 2, calls)
+}
+
+// send makes a POST with an Idempotency-Key to h
+func send(h handler.Handler, key string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(idempotencyKeyHeader, key)
+	h.ServeHTTP(w, r)
+	return w
+}
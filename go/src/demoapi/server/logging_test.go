@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"demoapi/logging"
+)
+
+func TestRequestID(baseTest *testing.T) {
+	_, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	level := logrus.GetLevel()
+	logrus.SetLevel(logrus.DebugLevel)
+	defer logrus.SetLevel(level)
+	hook := logtest.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(logrus.LevelHooks{})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/users/missing", nil)
+	r.Header.Set(logging.RequestIDHeader, "request-1")
+	t.server.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "request-1", w.Header().Get(logging.RequestIDHeader))
+	resp := testResponse{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "request-1", resp.Instance)
+
+	// the access log and the sql debug logs are tied to the request
+	messages := map[string]bool{}
+	for _, entry := range hook.AllEntries() {
+		assert.Equal(t, "request-1", entry.Data[logging.RequestIDField],
+			entry.Message)
+		messages[entry.Message] = true
+	}
+	assert.True(t, messages["request"])
+	assert.True(t, len(messages) > 1)
+
+	// ids that can't be safely logged are replaced
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(logging.RequestIDHeader, "bad\nid")
+	t.server.ServeHTTP(w, r)
+	requestID := w.Header().Get(logging.RequestIDHeader)
+	assert.NotEmpty(t, requestID)
+	assert.NotEqual(t, "bad\nid", requestID)
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestMetrics(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+	t.newUser(ctx, "user1")
+
+	routeLabels := map[string]string{"method": "GET",
+		"route": "/v1/users/{userID}"}
+	found := map[string]string{"code": "200", "error": ""}
+	missing := map[string]string{"code": "404", "error": "user_not_found"}
+	foundCount := t.metric("http_requests_total", routeLabels, found)
+	missingCount := t.metric("http_requests_total", routeLabels, missing)
+
+	for _, target := range []string{"/v1/users/user1", "/v1/users/missing"} {
+		w := httptest.NewRecorder()
+		t.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	// requests are labeled with their route, rather than their path
+	assert.Equal(t, foundCount+1,
+		t.metric("http_requests_total", routeLabels, found))
+	assert.Equal(t, missingCount+1,
+		t.metric("http_requests_total", routeLabels, missing))
+
+	// request sizes are observed for every route
+	createLabels := map[string]string{"method": "POST", "route": "/v1/users"}
+	created := t.metric("http_request_size_bytes", createLabels)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v1/users",
+		strings.NewReader(`{"userid": "user2", "first_name": "a", `+
+			`"last_name": "b", "groups": []}`))
+	t.server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, created+1, t.metric("http_request_size_bytes",
+		createLabels))
+}
+
+// metric returns the value of the counter, or the sample count of the
+// histogram, with all of the given labels
+func (st *serverTest) metric(name string,
+	labels ...map[string]string) float64 {
+
+	families, err := prom.DefaultGatherer.Gather()
+	require.NoError(st, err)
+
+	total := 0.0
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range family.GetMetric() {
+			values := map[string]string{}
+			for _, pair := range m.GetLabel() {
+				values[pair.GetName()] = pair.GetValue()
+			}
+			for _, l := range labels {
+				for k, v := range l {
+					if values[k] != v {
+						continue metrics
+					}
+				}
+			}
+			if m.GetHistogram() != nil {
+				total += float64(m.GetHistogram().GetSampleCount())
+			} else {
+				total += m.GetCounter().GetValue()
+			}
+		}
+	}
+	return total
+}
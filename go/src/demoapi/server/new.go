@@ -2,19 +2,39 @@ package server
 
 import (
 	"net/http"
+	"sync/atomic"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
+	graphql "github.com/graph-gophers/graphql-go"
 
 	"demoapi/config"
 	"demoapi/database"
 	h "demoapi/handler"
+	"demoapi/health"
+	"demoapi/logging"
+	"demoapi/prometheus"
+	"demoapi/ratelimit"
+	"demoapi/tracing"
 )
 
 type Server struct {
-	DB     *database.Database
-	router http.Handler
+	DB      *database.Database
+	router  http.Handler
+	v1      chi.Router // also serves the unversioned paths
+	graphql *graphql.Schema
+	openapi *OpenAPI
+	health  *health.Checker
+
+	// a token bucket limiter for every route class
+	rateLimiters map[string]*ratelimit.Limiter
+
+	cursorKey []byte // signs page tokens
+
+	// Config holds the settings that the server was started with. Settings
+	// that can be reloaded are read with settings instead.
 	Config *config.Configs
+	live   atomic.Pointer[config.Configs]
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -23,63 +43,118 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 func New(db *database.Database, configs *config.Configs) *Server {
 	s := &Server{DB: db, Config: configs}
+	s.live.Store(configs)
+	s.graphql = newGraphQLSchema(s)
+	s.openapi = newOpenAPI()
+	s.health = newHealthChecker(s)
+	s.rateLimiters = newRateLimiters()
+	s.cursorKey = newCursorKey(configs.PaginationCursorSecret)
 	s.router = router(s)
 	return s
 }
 
+// Reload swaps in the reloadable settings of configs, like
+// InsecureRequestsMode, while requests are being served
+func (s *Server) Reload(configs *config.Configs) {
+	s.live.Store(configs)
+}
+
+// settings returns the current configs
+func (s *Server) settings() *config.Configs {
+	return s.live.Load()
+}
+
 func router(s *Server) http.Handler {
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	r.Use(logging.RequestIDMiddleware)
+	r.Use(tracing.Middleware)
+	r.Use(prometheus.BasicMetricsMiddleware(s.Config.APISlug))
+	r.Use(logging.AccessLog)
 	r.Use(middleware.Recoverer)
+	r.Use(s.UnversionedPaths)
 
 	mw := h.MiddlewareChain()
 	r.Method("GET", "/", mw.JSON(s.Health))
+	r.Method("GET", "/healthz", mw.JSON(s.Liveness))
+	r.Method("GET", "/readyz", mw.JSON(s.Readiness))
+	r.Method("GET", "/openapi.json", mw.JSON(s.OpenAPI))
+
+	// auth is skipped while insecure_requests_mode is set
+	mw = mw.Append(s.Authenticated)
+
+	// once the client is known, its requests are limited by route class.
+	// then they are checked against the OpenAPI document before they reach
+	// the handlers. routes that aren't documented, like scim, are passed
+	// through. writes can be retried safely with an Idempotency-Key
+	reads := mw.Append(s.RateLimitReads, s.ValidateRequest)
+	writes := mw.Append(s.RateLimitWrites, s.ValidateRequest, s.Idempotent)
+	bulk := mw.Append(s.RateLimitBulk, s.ValidateRequest, s.Idempotent)
+
+	// the rest api is versioned. a new version, like {prefix: "/v2"}, only
+	// registers the routes that changed, and an old one can be deprecated
+	// with a date it's sunset on
+	s.v1 = mountVersions(r, []apiVersion{{
+		prefix: apiV1,
+		routes: func(r chi.Router) {
+			r.Method("GET", "/users", reads.JSON(s.PagedUsers))
+			r.Method("GET", "/users/{userID}", reads.JSON(s.GetUser))
+			r.Method("POST", "/users", writes.JSON(s.CreateUser))
+			r.Method("DELETE", "/users/{userID}", writes.JSON(s.DeleteUser))
+			r.Method("PUT", "/users/{userID}", bulk.JSON(s.UpdateUser))
+			r.Method("POST", "/users/{userID}:suspend",
+				writes.JSON(s.SuspendUser))
+			r.Method("POST", "/users/{userID}:activate",
+				writes.JSON(s.ActivateUser))
+			r.Method("POST", "/users/{userID}:deprovision",
+				writes.JSON(s.DeprovisionUser))
+			r.Method("GET", "/users/{userID}/transitions",
+				reads.JSON(s.GetUserTransitions))
+			r.Method("GET", "/users/{userID}/history",
+				reads.JSON(s.GetUserHistory))
+
+			r.Method("GET", "/groups", reads.JSON(s.PagedGroups))
+			r.Method("GET", "/groups/{groupName}",
+				reads.JSON(s.GetMemberships))
+			r.Method("POST", "/groups", writes.JSON(s.CreateGroup))
+			r.Method("PUT", "/groups/{groupName}",
+				bulk.JSON(s.UpdateMembership))
+			r.Method("PATCH", "/groups/{groupName}",
+				writes.JSON(s.UpdateGroup))
+			r.Method("DELETE", "/groups/{groupName}",
+				writes.JSON(s.DeleteGroup))
+			r.Method("GET", "/groups/{groupName}/history",
+				reads.JSON(s.GetGroupHistory))
+
+			r.Method("GET", "/attributes", reads.JSON(s.GetAttributes))
+			r.Method("GET", "/attributes/{attributeName}",
+				reads.JSON(s.GetAttribute))
+			r.Method("POST", "/attributes", writes.JSON(s.CreateAttribute))
+			r.Method("DELETE", "/attributes/{attributeName}",
+				writes.JSON(s.DeleteAttribute))
+
+			// a single graphql request or batch can read or write any
+			// number of records
+			r.Method("POST", "/graphql", bulk.JSON(s.GraphQL))
+			r.Method("POST", "/batch", bulk.JSON(s.Batch))
+		},
+	}})
+
+	// SCIM 2.0 provisioning shares auth with the rest of the api. its paths
+	// are versioned by the scim spec
+	r.Mount(scimPrefix, scimRouter(s,
+		mw.Append(s.RateLimitByMethod, s.ValidateRequest)...))
 
-	if !s.Config.InsecureRequestsMode {
-		mw = mw.Append(s.Authenticated) // add auth middleware
-	}
-
-	apiRoutes := chi.NewRouter()
-	apiRoutes.Method("GET", "/users", mw.JSON(s.PagedUsers))
-	apiRoutes.Method("GET", "/users/{userID}", mw.JSON(s.GetUser))
-	apiRoutes.Method("POST", "/users", mw.JSON(s.CreateUser))
-	apiRoutes.Method("DELETE", "/users/{userID}", mw.JSON(s.DeleteUser))
-	apiRoutes.Method("PUT", "/users/{userID}", mw.JSON(s.UpdateUser))
-
-	apiRoutes.Method("GET", "/groups", mw.JSON(s.PagedGroups))
-	apiRoutes.Method("GET", "/groups/{groupName}", mw.JSON(s.GetMemberships))
-	apiRoutes.Method("POST", "/groups", mw.JSON(s.CreateGroup))
-	apiRoutes.Method("PUT", "/groups/{groupName}", mw.JSON(s.UpdateMembership))
-	apiRoutes.Method("DELETE", "/groups/{groupName}", mw.JSON(s.DeleteGroup))
-
-	// TODO(sam): it might be better if this lived under an "api" route
-	r.Mount("/", apiRoutes)
 	return r
 }
 
 // NewHTTPServer constructs a new http.Server to listen for connections and
 // serve responses as defined by the Server's ServeHTTP defined above.
-func NewHTTPServer(configs *config.Configs,
-	metricMiddleware h.MiddlewareWrapper) (*http.Server, error) {
-
-	// TODO(sam): pass through database configs
-	db, err := database.Connect(configs.DBURL, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var apiHandler http.Handler
-	apiHandler = New(db, configs)
-
-	if metricMiddleware != nil {
-		apiHandler = metricMiddleware(apiHandler)
-	}
-
+func NewHTTPServer(s *Server) *http.Server {
 	return &http.Server{
-		Addr:         configs.APIAddress,
-		WriteTimeout: configs.WriteTimeout,
-		ReadTimeout:  configs.ReadTimeout,
-		IdleTimeout:  configs.IdleTimeout,
-		Handler:      apiHandler,
-	}, nil
+		Addr:         s.Config.APIAddress,
+		WriteTimeout: s.Config.WriteTimeout,
+		ReadTimeout:  s.Config.ReadTimeout,
+		IdleTimeout:  s.Config.IdleTimeout,
+		Handler:      s,
+	}
 }
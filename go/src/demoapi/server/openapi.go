@@ -0,0 +1,473 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+
+	h "demoapi/handler"
+	"demoapi/health"
+	he "demoapi/httperror"
+)
+
+// This file contains the OpenAPI 3 description of the rest api that is served
+// at `GET /openapi.json`. The component schemas are generated from the json
+// tags of the api types in data_type.go so they can't drift from what the
+// handlers return, and TestOpenAPIRoutes checks that every route registered in
+// router has an operation here. The same document is used by ValidateRequest
+// to reject bad requests before they reach a handler.
+
+const openAPIVersion = "3.0.3"
+
+type OpenAPI struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       OpenAPIInfo                `json:"info"`
+	Paths      map[string]OpenAPIPathItem `json:"paths"`
+	Components OpenAPIComponents          `json:"components"`
+	Security   []map[string][]string      `json:"security,omitempty"`
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPIPathItem maps lowercased http methods to their operations
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+	Security    *[]map[string][]string     `json:"security,omitempty"`
+}
+
+type OpenAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPIComponents struct {
+	Schemas         map[string]*OpenAPISchema        `json:"schemas"`
+	SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type OpenAPISecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+// OpenAPI returns the OpenAPI 3 description of this api. the bearer token
+// is left out while insecure_requests_mode is set.
+// `GET /openapi.json`
+func (s *Server) OpenAPI(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	if !s.settings().InsecureRequestsMode {
+		return h.OK(s.openapi), nil
+	}
+	spec := *s.openapi
+	spec.Security = nil
+	spec.Components.SecuritySchemes = nil
+	return h.OK(&spec), nil
+}
+
+// operation returns the documented operation for a chi route pattern
+func (o *OpenAPI) operation(method, pattern string) *OpenAPIOperation {
+	return o.Paths[pattern][strings.ToLower(method)]
+}
+
+// newOpenAPI describes the routes in router. every route but the health
+// checks and this document require a bearer token, unless
+// insecure_requests_mode is set (see Server.OpenAPI).
+func newOpenAPI() *OpenAPI {
+	schemas := openAPISchemas{}
+	rootRef := schemas.of(reflect.TypeOf(RootJSON{}))
+	errorRef := schemas.of(reflect.TypeOf(he.Problem{}))
+	problemCode := schemas["Problem"].Properties["code"]
+	for _, code := range he.Catalog() {
+		problemCode.Enum = append(problemCode.Enum, code.Code)
+	}
+	userSchema := schemas.component(reflect.TypeOf(User{}))
+	groupSchema := schemas.component(reflect.TypeOf(Group{}))
+	attributeSchema := schemas.component(reflect.TypeOf(Attribute{}))
+	attributeSchema.Properties["type"].Enum = attributeTypes
+
+	minimumLimit := float64(1)
+	pageParams := []OpenAPIParameter{
+		{Name: tokenParam, In: "query", Schema: &OpenAPISchema{
+			Type: "string"}},
+		{Name: limitParam, In: "query", Schema: &OpenAPISchema{
+			Type: "integer", Minimum: &minimumLimit}},
+		{Name: totalParam, In: "query", Schema: &OpenAPISchema{
+			Type: "boolean"}},
+	}
+	userIDParam := []OpenAPIParameter{{Name: "userID", In: "path",
+		Required: true, Schema: &OpenAPISchema{Type: "string"}}}
+	groupNameParam := []OpenAPIParameter{{Name: "groupName", In: "path",
+		Required: true, Schema: &OpenAPISchema{Type: "string"}}}
+	asOfQuery := OpenAPIParameter{Name: asOfParam, In: "query",
+		Schema: &OpenAPISchema{Type: "string"}}
+	userAsOfParams := append(userIDParam, asOfQuery)
+	activeOnlyParams := append(groupNameParam, OpenAPIParameter{
+		Name: activeOnlyParam, In: "query",
+		Schema: &OpenAPISchema{Type: "boolean"}}, asOfQuery)
+	transitionBody := schemas.component(reflect.TypeOf(Transition{})).
+		requiring("reason")
+	attributeNameParam := []OpenAPIParameter{{Name: "attributeName",
+		In: "path", Required: true, Schema: &OpenAPISchema{Type: "string"}}}
+
+	membersBody := &OpenAPISchema{
+		Type: "object",
+		Properties: map[string]*OpenAPISchema{
+			"userids": {Type: "array", Items: &OpenAPISchema{Type: "string"}},
+		},
+	}
+	graphqlBody := schemas.of(reflect.TypeOf(graphqlRequest{}))
+	graphqlBody.Required = []string{"query"}
+
+	// the user and group of an operation can be partial, like for an update
+	batchBody := schemas.of(reflect.TypeOf(BatchRequest{}))
+	batchOperation := schemas.component(reflect.TypeOf(BatchOperation{}))
+	batchOperation.Properties["op"].Enum = batchOps
+	batchOperation.Properties["user"] = userSchema.requiring()
+	batchOperation.Properties["group"] = groupSchema.requiring()
+	batchRef := schemas.of(reflect.TypeOf(BatchResponse{}))
+
+	op := func(id, summary string, params []OpenAPIParameter,
+		body *OpenAPISchema) *OpenAPIOperation {
+		o := &OpenAPIOperation{
+			OperationID: id,
+			Summary:     summary,
+			Parameters:  params,
+			Responses: map[string]OpenAPIResponse{
+				"200": openAPIResponse("success", rootRef, &RootJSON{}),
+				"default": {
+					Description: "an RFC 7807 problem, with any invalid fields",
+					Content: map[string]OpenAPIMediaType{
+						he.ProblemContentType: {Schema: errorRef},
+					},
+				},
+			},
+		}
+		if body != nil {
+			o.RequestBody = &OpenAPIRequestBody{
+				Required: true,
+				Content:  openAPIRequestContent(body),
+			}
+		}
+		return o
+	}
+
+	// creates respond with a 201 and deletes with an empty 204
+	created := func(o *OpenAPIOperation) *OpenAPIOperation {
+		resp := o.Responses["200"]
+		resp.Description = "created. the Location header links to it"
+		delete(o.Responses, "200")
+		o.Responses["201"] = resp
+		return o
+	}
+	deleted := func(o *OpenAPIOperation) *OpenAPIOperation {
+		delete(o.Responses, "200")
+		o.Responses["204"] = OpenAPIResponse{Description: "deleted"}
+		return o
+	}
+
+	public := &[]map[string][]string{}
+	healthCheck := op("health", "health check", nil, nil)
+	healthCheck.Security = public
+	healthCheck.Responses["200"] = openAPIResponse("success",
+		&OpenAPISchema{
+			Type: "object",
+			Properties: map[string]*OpenAPISchema{
+				"health": {Type: "string"},
+			},
+		}, nil)
+	reportRef := schemas.of(reflect.TypeOf(health.Report{}))
+	liveness := op("liveness", "liveness probe", nil, nil)
+	liveness.Security = public
+	liveness.Responses["200"] = openAPIResponse("the api is running",
+		reportRef, nil)
+	readiness := op("readiness", "readiness probe", nil, nil)
+	readiness.Security = public
+	readiness.Responses["200"] = openAPIResponse("the api is ready",
+		reportRef, nil)
+	readiness.Responses["503"] = openAPIResponse(
+		"a check failed or the api is shutting down", reportRef, nil)
+	openapi := op("openapi", "this document", nil, nil)
+	openapi.Security = public
+	openapi.Responses["200"] = openAPIResponse("success",
+		&OpenAPISchema{Type: "object"}, nil)
+	graphql := op("graphql", "execute a graphql query or mutation", nil,
+		graphqlBody)
+	graphql.Responses["200"] = openAPIResponse("the graphql response",
+		&OpenAPISchema{Type: "object"}, nil)
+	batch := op("batch", "run operations in one transaction", nil,
+		batchBody)
+	batch.Responses["200"] = openAPIResponse("the result of every operation",
+		batchRef, &BatchResponse{})
+
+	spec := &OpenAPI{
+		OpenAPI: openAPIVersion,
+		Info: OpenAPIInfo{
+			Title:       "demoapi",
+			Description: "Manages users, groups and their memberships",
+			Version:     "1",
+		},
+		Paths: map[string]OpenAPIPathItem{
+			"/":             {"get": healthCheck},
+			"/healthz":      {"get": liveness},
+			"/readyz":       {"get": readiness},
+			"/openapi.json": {"get": openapi},
+			apiV1 + "/users": {
+				"get": op("pagedUsers", "list users", pageParams, nil),
+				"post": created(op("createUser", "create a user", nil,
+					userSchema.requiring("userid", "first_name",
+						"last_name"))),
+			},
+			apiV1 + "/users/{userID}": {
+				"get": op("getUser", "get a user", userAsOfParams, nil),
+				"put": op("updateUser", "update a user", userIDParam,
+					userSchema.requiring()),
+				"delete": deleted(op("deleteUser", "delete a user",
+					userIDParam, nil)),
+			},
+			apiV1 + "/users/{userID}:suspend": {
+				"post": op("suspendUser", "suspend a user", userIDParam,
+					transitionBody),
+			},
+			apiV1 + "/users/{userID}:activate": {
+				"post": op("activateUser", "activate a suspended user",
+					userIDParam, transitionBody),
+			},
+			apiV1 + "/users/{userID}:deprovision": {
+				"post": op("deprovisionUser", "deprovision a user for good",
+					userIDParam, transitionBody),
+			},
+			apiV1 + "/users/{userID}/transitions": {
+				"get": op("getUserTransitions",
+					"list the changes to the status of a user", userIDParam,
+					nil),
+			},
+			apiV1 + "/users/{userID}/history": {
+				"get": op("getUserHistory",
+					"list the versions of a user and its memberships",
+					userIDParam, nil),
+			},
+			apiV1 + "/groups": {
+				"get": op("pagedGroups", "list groups", pageParams, nil),
+				"post": created(op("createGroup", "create a group", nil,
+					groupSchema.requiring("name"))),
+			},
+			apiV1 + "/groups/{groupName}": {
+				"get": op("getMemberships", "list the members of a group",
+					activeOnlyParams, nil),
+				"put": op("updateMembership", "replace the members of a group",
+					groupNameParam, membersBody),
+				"patch": op("updateGroup", "rename a group or update its "+
+					"metadata", groupNameParam, groupSchema.requiring()),
+				"delete": deleted(op("deleteGroup", "delete a group",
+					groupNameParam, nil)),
+			},
+			apiV1 + "/groups/{groupName}/history": {
+				"get": op("getGroupHistory",
+					"list the versions of a group and its memberships",
+					groupNameParam, nil),
+			},
+			apiV1 + "/attributes": {
+				"get": op("getAttributes", "list attribute definitions",
+					nil, nil),
+				"post": created(op("createAttribute",
+					"define a user attribute", nil,
+					attributeSchema.requiring("name", "type"))),
+			},
+			apiV1 + "/attributes/{attributeName}": {
+				"get": op("getAttribute", "get an attribute definition",
+					attributeNameParam, nil),
+				"delete": deleted(op("deleteAttribute",
+					"delete an attribute and its values",
+					attributeNameParam, nil)),
+			},
+			apiV1 + "/graphql": {"post": graphql},
+			apiV1 + "/batch":   {"post": batch},
+		},
+		Components: OpenAPIComponents{Schemas: schemas},
+	}
+
+	// any write can be retried safely with an Idempotency-Key
+	idempotencyKey := OpenAPIParameter{Name: idempotencyKeyHeader,
+		In: "header", Schema: &OpenAPISchema{Type: "string"}}
+	for _, item := range spec.Paths {
+		for method, o := range item {
+			if method != "get" {
+				o.Parameters = append(append([]OpenAPIParameter(nil),
+					o.Parameters...), idempotencyKey)
+			}
+		}
+	}
+
+	spec.Components.SecuritySchemes = map[string]OpenAPISecurityScheme{
+		"bearer": {Type: "http", Scheme: "bearer"},
+	}
+	spec.Security = []map[string][]string{{"bearer": {}}}
+
+	return spec
+}
+
+// openAPIResponse describes a response in every format that value, a zero
+// value of its body, can be negotiated as
+func openAPIResponse(description string, schema *OpenAPISchema,
+	value interface{}) OpenAPIResponse {
+
+	content := map[string]OpenAPIMediaType{}
+	for _, c := range h.Codecs() {
+		if c.CanEncode == nil || c.CanEncode(value) {
+			content[c.MediaType] = OpenAPIMediaType{Schema: schema}
+		}
+	}
+	return OpenAPIResponse{Description: description, Content: content}
+}
+
+// openAPIRequestContent describes a request body in every format that can be
+// decoded
+func openAPIRequestContent(schema *OpenAPISchema) map[string]OpenAPIMediaType {
+	content := map[string]OpenAPIMediaType{}
+	for _, c := range h.Codecs() {
+		if c.Decode != nil {
+			content[c.MediaType] = OpenAPIMediaType{Schema: schema}
+		}
+	}
+	return content
+}
+
+// openAPISchemas are the component schemas, by name
+type openAPISchemas map[string]*OpenAPISchema
+
+// of returns the schema for a go type based on its json encoding. named
+// structs become components and are referenced by $ref
+func (c openAPISchemas) of(t reflect.Type) *OpenAPISchema {
+	if t == reflect.TypeOf(UnixTime{}) {
+		return &OpenAPISchema{Type: "integer", Format: "int64", Nullable: true,
+			Description: "unix timestamp in seconds"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return c.of(t.Elem())
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64:
+		return &OpenAPISchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &OpenAPISchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &OpenAPISchema{Type: "array", Items: c.of(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: c.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" || !isExported(t.Name()) {
+			return c.object(t)
+		}
+		c.component(t)
+		return &OpenAPISchema{Ref: "#/components/schemas/" + t.Name()}
+	}
+
+	// interface{} and anything else could be any json value
+	return &OpenAPISchema{}
+}
+
+// component registers the schema for the named struct t and returns it
+func (c openAPISchemas) component(t reflect.Type) *OpenAPISchema {
+	if schema, ok := c[t.Name()]; ok {
+		return schema
+	}
+	schema := &OpenAPISchema{Type: "object"}
+	c[t.Name()] = schema // registered first in case t refers to itself
+	*schema = *c.object(t)
+	return schema
+}
+
+// object returns the schema for a struct. fields without omitempty are always
+// in the json, so they are required
+func (c openAPISchemas) object(t reflect.Type) *OpenAPISchema {
+	schema := &OpenAPISchema{
+		Type:       "object",
+		Properties: map[string]*OpenAPISchema{},
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+
+		name, opts := field.Name, ""
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			parts := strings.SplitN(tag, ",", 2)
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			if len(parts) > 1 {
+				opts = parts[1]
+			}
+		}
+
+		schema.Properties[name] = c.of(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// requiring returns a copy of an object schema that requires exactly the
+// fields provided. it's used to derive request bodies from response types.
+func (s *OpenAPISchema) requiring(fields ...string) *OpenAPISchema {
+	cp := *s
+	cp.Required = fields
+	return &cp
+}
+
+func isExported(name string) bool {
+	return name != "" && strings.ToUpper(name[:1]) == name[:1]
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+
+	he "demoapi/httperror"
+)
+
+func TestOpenAPIRoutes(baseTest *testing.T) {
+	_, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	routes := map[string]bool{}
+	err := chi.Walk(router(t.server).(chi.Routes), func(method, route string,
+		handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+
+		route = strings.Replace(route, "/*/", "/", -1)
+		if strings.HasPrefix(route, scimPrefix) {
+			return nil // scim is described by its own schemas endpoints
+		}
+		routes[strings.ToLower(method)+" "+route] = true
+		assert.NotNil(t, t.server.openapi.operation(method, route),
+			"%s %s is not in the OpenAPI document", method, route)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	for path, item := range t.server.openapi.Paths {
+		for method := range item {
+			assert.True(t, routes[method+" "+path],
+				"%s %s is in the OpenAPI document but isn't routed", method, path)
+		}
+	}
+}
+
+func TestOpenAPIDocument(baseTest *testing.T) {
+	_, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	w := httptest.NewRecorder()
+	t.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json",
+		nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var doc struct {
+		OpenAPI    string
+		Paths      map[string]map[string]interface{}
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{}
+				Required   []string
+			}
+		}
+	}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&doc))
+	assert.Equal(t, openAPIVersion, doc.OpenAPI)
+	assert.Contains(t, doc.Paths["/v1/users/{userID}"], "put")
+	assert.Contains(t, doc.Components.Schemas["User"].Properties, "first_name")
+	assert.Contains(t, doc.Components.Schemas["RootJSON"].Properties,
+		"next_page")
+}
+
+func TestValidateRequest(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+
+	fields := func(r *http.Request) (int, he.InvalidFields) {
+		w := httptest.NewRecorder()
+		t.server.ServeHTTP(w, r)
+		resp := struct {
+			Fields he.InvalidFields
+		}{}
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return w.Code, resp.Fields
+	}
+
+	code, invalid := fields(jsonRequest(t, http.MethodPost, "/v1/users", nil,
+		map[string]interface{}{"userid": "user2", "first_name": 1,
+			"groups": []interface{}{"group1", true}}))
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.ElementsMatch(t, he.InvalidFields{
+		{Field: "first_name", Message: "must be a string"},
+		{Field: "last_name", Message: "is required"},
+		{Field: "groups[1]", Message: "must be a string"},
+	}, invalid)
+
+	code, invalid = fields(httptest.NewRequest(http.MethodGet,
+		"/v1/users?limit=abc", nil))
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, he.InvalidFields{
+		{Field: "limit", Message: "must be a number"}}, invalid)
+
+	code, invalid = fields(httptest.NewRequest(http.MethodGet,
+		"/v1/users?limit=0", nil))
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, he.InvalidFields{
+		{Field: "limit", Message: "must be at least 1"}}, invalid)
+
+	code, invalid = fields(httptest.NewRequest(http.MethodPut,
+		"/v1/groups/group1", strings.NewReader("{")))
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "body", invalid[0].Field)
+
+	// the body is still readable by the handler after it's validated
+	code, invalid = fields(jsonRequest(t, http.MethodPut, "/v1/users/user1",
+		nil, map[string]interface{}{"first_name": "new"}))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, invalid)
+	user, err := t.server.getUser(ctx, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", user.FirstName)
+
+	code, invalid = fields(jsonRequest(t, http.MethodPost, "/v1/attributes",
+		nil, map[string]interface{}{"name": "color", "type": "colour"}))
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "type", invalid[0].Field)
+	assert.Contains(t, invalid[0].Message, "must be one of")
+
+	t.server.settings().MaxRequestBodyBytes = 16
+	w := httptest.NewRecorder()
+	t.server.ServeHTTP(w, jsonRequest(t, http.MethodPut, "/v1/users/user1",
+		nil, map[string]interface{}{"first_name": "much too long"}))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
@@ -0,0 +1,247 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/zeebo/errs"
+
+	"demoapi/handler"
+	he "demoapi/httperror"
+)
+
+// ValidateRequest checks the query parameters and body of a request
+// against its operation in the OpenAPI document. Every invalid field is listed
+// in a single 400 and the handler isn't called. Bodies longer than
+// max_request_body_bytes are a 413, documented or not. Routes that aren't
+// documented are otherwise passed through untouched.
+func (s *Server) ValidateRequest(h handler.Handler) handler.Handler {
+	return handler.Handler(func(ctx context.Context, w http.ResponseWriter,
+		r *http.Request) (*handler.Response, error) {
+
+		r.Body = http.MaxBytesReader(w, r.Body,
+			int64(s.settings().MaxRequestBodyBytes))
+
+		op := s.openapi.operation(r.Method, chi.RouteContext(ctx).RoutePattern())
+		if op == nil {
+			return h(ctx, w, r)
+		}
+
+		v := &openAPIValidator{spec: s.openapi}
+		query := r.URL.Query()
+		for _, param := range op.Parameters {
+			if param.In == "query" {
+				v.param(param, query)
+			}
+		}
+
+		if op.RequestBody != nil {
+			body, err := ioutil.ReadAll(r.Body)
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return nil, he.CodeRequestTooLarge.New(
+					"request body is longer than %d bytes", tooLarge.Limit)
+			}
+			if err != nil {
+				return nil, he.BadRequest.Wrap(err)
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body)) // for the handler
+
+			err = v.body(op.RequestBody, r.Header.Get("Content-Type"), body)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if len(v.fields) > 0 {
+			return nil, he.BadRequest.Wrap(v.fields)
+		}
+
+		return h(ctx, w, r)
+	})
+}
+
+type openAPIValidator struct {
+	spec   *OpenAPI
+	fields he.InvalidFields
+}
+
+func (v *openAPIValidator) invalid(field, format string, args ...interface{}) {
+	v.fields = append(v.fields, he.FieldError{
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// param validates a query parameter. query parameters are always strings, so
+// they're converted to the type of their schema before they're checked
+func (v *openAPIValidator) param(param OpenAPIParameter, query url.Values) {
+	raw, ok := query[param.Name]
+	if !ok || len(raw) == 0 {
+		if param.Required {
+			v.invalid(param.Name, "is required")
+		}
+		return
+	}
+
+	var value interface{} = raw[0]
+	switch param.Schema.Type {
+	case "integer", "number":
+		n, err := strconv.ParseFloat(raw[0], 64)
+		if err != nil {
+			v.invalid(param.Name, "must be a number")
+			return
+		}
+		value = n
+	case "boolean":
+		b, err := strconv.ParseBool(raw[0])
+		if err != nil {
+			v.invalid(param.Name, "must be a boolean")
+			return
+		}
+		value = b
+	}
+	v.value(param.Name, param.Schema, value)
+}
+
+// body validates a request body in the format of its Content-Type. bodies
+// in a format that can't be decoded are a 415, instead of an invalid field.
+func (v *openAPIValidator) body(body *OpenAPIRequestBody, contentType string,
+	raw []byte) error {
+
+	if len(bytes.TrimSpace(raw)) == 0 {
+		if body.Required {
+			v.invalid("body", "is required")
+		}
+		return nil
+	}
+
+	var value interface{}
+	err := handler.DecodeBody(contentType, bytes.NewReader(raw), &value)
+	if he.UnsupportedMediaType.Has(err) {
+		return err
+	}
+	if err != nil {
+		v.invalid("body", "must be valid %s: %s", contentTypeName(contentType),
+			errs.Unwrap(err))
+		return nil
+	}
+
+	// every format has the same schema
+	v.value("", body.Content["application/json"].Schema, value)
+	return nil
+}
+
+// contentTypeName names the format of a body for error messages
+func contentTypeName(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "json" // the default
+	}
+	return mediaType
+}
+
+// value validates a decoded json value against schema. path is the location
+// of the value in the request, like "groups[1]"
+func (v *openAPIValidator) value(path string, schema *OpenAPISchema,
+	value interface{}) {
+
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		schema = v.spec.Components.Schemas[name]
+	}
+
+	// null is treated like a missing value. required fields are checked by
+	// the parent object
+	if value == nil {
+		return
+	}
+
+	field := path
+	if field == "" {
+		field = "body"
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			v.invalid(field, "must be an object")
+			return
+		}
+		for _, name := range schema.Required {
+			if obj[name] == nil {
+				v.invalid(openAPIJoin(path, name), "is required")
+			}
+		}
+		for name, prop := range obj {
+			propSchema := schema.Properties[name]
+			if propSchema == nil {
+				propSchema = schema.AdditionalProperties
+			}
+			if propSchema != nil {
+				v.value(openAPIJoin(path, name), propSchema, prop)
+			}
+		}
+
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			v.invalid(field, "must be an array")
+			return
+		}
+		if schema.Items != nil {
+			for i, item := range arr {
+				v.value(fmt.Sprintf("%s[%d]", path, i), schema.Items, item)
+			}
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			v.invalid(field, "must be a string")
+			return
+		}
+		if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, str) {
+			v.invalid(field, "must be one of %s",
+				strings.Join(schema.Enum, ", "))
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.invalid(field, "must be a boolean")
+		}
+
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			v.invalid(field, "must be a number")
+			return
+		}
+		if schema.Type == "integer" && n != math.Trunc(n) {
+			v.invalid(field, "must be an integer")
+			return
+		}
+		if schema.Minimum != nil && n < *schema.Minimum {
+			v.invalid(field, "must be at least %v", *schema.Minimum)
+		}
+	}
+}
+
+func openAPIJoin(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,696 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"demoapi/database"
+	he "demoapi/httperror"
+	"demoapi/logging"
+	monitor "demoapi/prometheus"
+	"demoapi/util"
+)
+
+// This file contains the operations behind each of the api routes. They are
+// shared by the http handlers in api.go, the grpc services in grpc.go and the
+// batches in batch.go so that every api behaves the same way. Operations query
+// s.DB.In(ctx), so that they join the transaction of a batch, and only update
+// metrics once their changes are committed.
+
+// getUser returns the matching user with its groups or NotFound
+func (s *Server) getUser(ctx context.Context, userID string) (*User, error) {
+	db := s.DB.In(ctx)
+
+	user, err := db.Find_User_By_Id(ctx, database.User_Id(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, he.CodeUserNotFound.New(
+			"userID %q doesn't exist", userID)
+	}
+
+	groups, err := db.All_Group_By_User_Id(ctx, database.User_Id(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.apiUserWithAttributes(ctx, user, groups)
+}
+
+// apiUserWithAttributes is apiUser with the attribute values of the user
+func (s *Server) apiUserWithAttributes(ctx context.Context,
+	user *database.User, groups []*database.Group) (*User, error) {
+
+	d := apiUser(user, groups)
+	err := s.addAttributes(ctx, []*database.User{user}, []*User{d})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// createUser creates a new user, its attributes and its memberships in one
+// transaction, so that nothing is left behind if any of them fail
+func (s *Server) createUser(ctx context.Context, userJSON *User) (
+	created *User, err error) {
+
+	if userJSON.FirstName == "" || userJSON.LastName == "" || userJSON.ID == "" {
+		return nil, he.BadRequest.New("required fields missing")
+	}
+
+	err = s.DB.WithTx(ctx, func(ctx context.Context, tx *database.Tx) error {
+		db := s.DB.In(ctx)
+		attributes, err := s.checkAttributes(ctx, 0, userJSON)
+		if err != nil {
+			return err
+		}
+
+		user, err := db.Create_User(ctx,
+			database.User_Uuid(util.MustUUID4()),
+			database.User_Id(userJSON.ID),
+			database.User_FirstName(userJSON.FirstName),
+			database.User_LastName(userJSON.LastName),
+			database.User_Status(userActive))
+		if database.IsUniqueViolation(err) {
+			return he.CodeUserExists.New(
+				"userID %q already exists", userJSON.ID)
+		}
+		if err != nil {
+			return err
+		}
+
+		database.AfterCommit(ctx, monitor.UserGauge.Inc)
+
+		err = s.recordUser(ctx, user, database.VersionCreated)
+		if err != nil {
+			return err
+		}
+
+		err = s.DB.SetUserAttributes(ctx, user.Pk, attributes.set, nil)
+		if err != nil {
+			return err
+		}
+
+		if len(userJSON.Groups) > 0 {
+			err = s.setUserMembership(ctx, user.Id, userJSON.Groups)
+			if err != nil {
+				return err
+			}
+		}
+
+		groups, err := db.All_Group_By_User_Id(ctx,
+			database.User_Id(user.Id))
+		if err != nil {
+			return err
+		}
+
+		created, err = s.apiUserWithAttributes(ctx, user, groups)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// updateUser updates the non-empty fields and the attributes of userJSON on an
+// existing user and replaces its memberships if userJSON has groups, even an
+// empty list, all in one transaction. nil groups keep the memberships.
+func (s *Server) updateUser(ctx context.Context, userID string,
+	userJSON *User) (updated *User, err error) {
+
+	updateUserData, updateMemberships := false, userJSON.Groups != nil
+	userUpdates := database.User_Update_Fields{}
+
+	if userJSON.ID != "" {
+		userUpdates.Id = database.User_Id(userJSON.ID)
+		updateUserData = true
+	}
+
+	if userJSON.FirstName != "" {
+		userUpdates.FirstName = database.User_FirstName(userJSON.FirstName)
+		updateUserData = true
+	}
+
+	if userJSON.LastName != "" {
+		userUpdates.LastName = database.User_LastName(userJSON.LastName)
+		updateUserData = true
+	}
+
+	if !updateUserData && !updateMemberships &&
+		len(userJSON.Attributes) == 0 {
+		return nil, he.BadRequest.New("no updates in request")
+	}
+
+	err = s.DB.WithTx(ctx, func(ctx context.Context, tx *database.Tx) error {
+		db := s.DB.In(ctx)
+		user, err := db.Find_User_By_Id(ctx, database.User_Id(userID))
+		if err != nil {
+			return err
+		}
+
+		if user == nil {
+			return he.CodeUserNotFound.New(
+				"userID %q doesn't exist", userID)
+		}
+
+		if user.Status == userDeprovisioned {
+			return he.CodeInvalidTransition.New(
+				"userID %q is deprovisioned", userID)
+		}
+
+		attributes, err := s.checkAttributes(ctx, user.Pk, userJSON)
+		if err != nil {
+			return err
+		}
+		err = s.DB.SetUserAttributes(ctx, user.Pk, attributes.set,
+			attributes.remove)
+		if err != nil {
+			return err
+		}
+
+		// TODO(sam): figure out how to extract "emptyUpdateError" from orm so
+		// that a separate DB call isn't necessary. don't use dbx?
+		if updateUserData {
+			user, err = db.Update_User_By_Id(ctx, database.User_Id(userID),
+				userUpdates)
+			if database.IsUniqueViolation(err) {
+				return he.CodeUserExists.New(
+					"userID %q already exists", userJSON.ID)
+			}
+			if err != nil {
+				return err
+			}
+
+			err = s.recordUser(ctx, user, database.VersionUpdated)
+			if err != nil {
+				return err
+			}
+		}
+
+		if updateMemberships {
+			err = s.setUserMembership(ctx, user.Id, userJSON.Groups)
+			if err != nil {
+				return err
+			}
+		}
+
+		groups, err := db.All_Group_By_User_Id(ctx,
+			database.User_Id(user.Id))
+		if err != nil {
+			return err
+		}
+
+		updated, err = s.apiUserWithAttributes(ctx, user, groups)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// setUserMembership replaces the groups of a user. a group that doesn't exist
+// is a 400, rather than being skipped, and a user that isn't active can't join
+// new groups.
+func (s *Server) setUserMembership(ctx context.Context, userID string,
+	groups []Membership) error {
+
+	db := s.DB.In(ctx)
+	for _, name := range parseMembership(groups) {
+		group, err := db.Find_Group_By_Name(ctx, database.Group_Name(name))
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			return he.BadRequest.Wrap(he.InvalidFields{{
+				Field:   "groups",
+				Message: fmt.Sprintf("group %q doesn't exist", name),
+			}})
+		}
+	}
+
+	var added, removed, unchanged int
+	err := s.recordUserMemberships(ctx, userID,
+		func(ctx context.Context) (err error) {
+			err = s.checkNewGroups(ctx, userID, parseMembership(groups))
+			if err != nil {
+				return err
+			}
+			added, removed, unchanged, err = s.DB.SetUserMembership(ctx,
+				userID, parseMembership(groups))
+			return err
+		})
+	if err != nil {
+		return err
+	}
+
+	logging.Entry(ctx).Debugf("memberships - added: %d, removed: %d, "+
+		"unchanged: %d", added, removed, unchanged)
+
+	countMemberships(ctx, added-removed)
+	return nil
+}
+
+// countMemberships adds the number of memberships that were added, or removed
+// if it's negative, to their gauge once they're committed
+func countMemberships(ctx context.Context, delta int) {
+	database.AfterCommit(ctx, func() {
+		monitor.MembershipGauge.Add(float64(delta))
+	})
+}
+
+// deleteUser deletes a user and its memberships
+func (s *Server) deleteUser(ctx context.Context, userID string) error {
+	return s.DB.WithTx(ctx, func(ctx context.Context, tx *database.Tx) error {
+		db := s.DB.In(ctx)
+
+		// the user is read first so that its last version can be recorded
+		user, err := db.Find_User_By_Id(ctx, database.User_Id(userID))
+		if err != nil {
+			return err
+		}
+
+		if user == nil {
+			return he.CodeUserNotFound.New(
+				"userID %q doesn't exist", userID)
+		}
+
+		// the memberships go with the user, and are recorded as removed
+		err = s.recordUserMemberships(ctx, userID,
+			func(ctx context.Context) error {
+				_, err := db.Delete_User_By_Id(ctx, database.User_Id(userID))
+				return err
+			})
+		if err != nil {
+			return err
+		}
+
+		database.AfterCommit(ctx, monitor.UserGauge.Dec)
+		return s.recordUser(ctx, user, database.VersionDeleted)
+	})
+}
+
+// pagedUsers returns the page of users at cur and the cursors of the pages
+// around it, which are nil at the ends of the list
+func (s *Server) pagedUsers(ctx context.Context, cur *cursor) ([]*User,
+	*cursor, *cursor, error) {
+
+	users, next, prev, err := s.userPage(ctx, cur)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	d := apiUsers(users)
+	err = s.addAttributes(ctx, users, d)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return d, next, prev, nil
+}
+
+// userPage is pagedUsers for the database records. the users are filtered by
+// the attribute filters of cur.
+func (s *Server) userPage(ctx context.Context, cur *cursor) (
+	[]*database.User, *cursor, *cursor, error) {
+
+	db := s.DB.In(ctx)
+	if len(cur.Filters) > 0 {
+		filters, err := s.databaseFilters(ctx, cur.Filters)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return keysetPage(cur, func(u *database.User) int64 { return u.Pk },
+			func(pk int64, n int) ([]*database.User, error) {
+				return s.DB.FilteredUsers(ctx, filters, pk, false, n)
+			},
+			func(pk int64, n int) ([]*database.User, error) {
+				return s.DB.FilteredUsers(ctx, filters, pk, true, n)
+			})
+	}
+
+	return keysetPage(cur, func(u *database.User) int64 { return u.Pk },
+		func(pk int64, n int) ([]*database.User, error) {
+			return db.Limited_User_By_Pk_Greater_OrderBy_Asc_Pk(ctx,
+				database.User_Pk(pk), n, 0)
+		},
+		func(pk int64, n int) ([]*database.User, error) {
+			return db.Limited_User_By_Pk_Less_OrderBy_Desc_Pk(ctx,
+				database.User_Pk(pk), n, 0)
+		})
+}
+
+// countUsers counts the users that match the attribute filters of cur
+func (s *Server) countUsers(ctx context.Context, cur *cursor) (int64,
+	error) {
+
+	if len(cur.Filters) == 0 {
+		return s.DB.In(ctx).Count_User(ctx)
+	}
+	filters, err := s.databaseFilters(ctx, cur.Filters)
+	if err != nil {
+		return 0, err
+	}
+	return s.DB.CountFilteredUsers(ctx, filters)
+}
+
+// getGroup returns the matching group with its members or NotFound. with
+// activeOnly, suspended and deprovisioned users aren't members.
+func (s *Server) getGroup(ctx context.Context, groupName string,
+	activeOnly bool) (*Group, error) {
+
+	db := s.DB.In(ctx)
+	group, err := db.Find_Group_By_Name(ctx, database.Group_Name(groupName))
+	if err != nil {
+		return nil, err
+	}
+
+	if group == nil {
+		return nil, he.CodeGroupNotFound.New(
+			"groupName %q doesn't exist", groupName)
+	}
+
+	// this could return an empty set
+	users, err := db.All_User_By_Group_Name(ctx, database.Group_Name(groupName))
+	if err != nil {
+		return nil, err
+	}
+	if activeOnly {
+		users = activeUsers(users)
+	}
+
+	return apiGroup(group, users), nil
+}
+
+// getMemberships returns the members of an existing group
+func (s *Server) getMemberships(ctx context.Context,
+	groupName string) ([]Membership, error) {
+
+	group, err := s.getGroup(ctx, groupName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return group.Users, nil
+}
+
+// createGroup creates an empty group, and its first version in the same
+// transaction
+func (s *Server) createGroup(ctx context.Context, groupJSON *Group) (
+	created *Group, err error) {
+
+	if groupJSON.Name == "" {
+		return nil, he.BadRequest.New("required fields missing")
+	}
+
+	err = checkLabels(groupJSON.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.DB.WithTx(ctx, func(ctx context.Context, tx *database.Tx) error {
+		// the database enforces the uniqueness of group names
+		group, err := s.DB.In(ctx).Create_Group(ctx,
+			database.Group_Uuid(util.MustUUID4()),
+			database.Group_Name(groupJSON.Name),
+			database.Group_Description(groupJSON.Description),
+			database.Group_DisplayName(groupJSON.DisplayName),
+			database.Group_Owner(groupJSON.Owner),
+			database.Group_Labels(dbLabels(groupJSON.Labels)))
+		if database.IsUniqueViolation(err) {
+			return he.CodeGroupExists.New(
+				"groupName %q already exists", groupJSON.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+		database.AfterCommit(ctx, monitor.GroupGauge.Inc)
+
+		err = s.recordGroup(ctx, group, database.VersionCreated)
+		if err != nil {
+			return err
+		}
+
+		created = apiGroup(group, nil)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// updateMembership replaces the members of a group. users that aren't active
+// can't be added.
+func (s *Server) updateMembership(ctx context.Context, groupName string,
+	userIDs []string) error {
+
+	var added, removed, unchanged int
+	err := s.recordGroupMemberships(ctx, groupName,
+		func(ctx context.Context) (err error) {
+			err = s.checkNewMembers(ctx, groupName, userIDs)
+			if err != nil {
+				return err
+			}
+			added, removed, unchanged, err = s.DB.SetGroupMembership(ctx,
+				groupName, userIDs)
+			return err
+		})
+	if err != nil {
+		return err
+	}
+
+	logging.Entry(ctx).Debugf("memberships - added: %d, removed: %d, "+
+		"unchanged: %d", added, removed, unchanged)
+
+	countMemberships(ctx, added-removed)
+	return nil
+}
+
+// updateGroup renames an existing group and updates the fields of groupJSON
+// on it. a field that was decoded from json is updated even if it's empty, so
+// that "" clears it; otherwise only the non-empty fields are. its labels are
+// replaced if groupJSON has any, so that an empty object removes them. its
+// members are kept. the update and its version are stored in one transaction.
+func (s *Server) updateGroup(ctx context.Context, groupName string,
+	groupJSON *Group) (updated *Group, err error) {
+
+	updateGroupData, newName := false, groupName
+	groupUpdates := database.Group_Update_Fields{}
+	update := func(name, value string) bool {
+		return value != "" || groupJSON.set[name]
+	}
+
+	if groupJSON.Name != "" && groupJSON.Name != groupName {
+		groupUpdates.Name = database.Group_Name(groupJSON.Name)
+		updateGroupData, newName = true, groupJSON.Name
+	}
+
+	if update("display_name", groupJSON.DisplayName) {
+		groupUpdates.DisplayName = database.Group_DisplayName(
+			groupJSON.DisplayName)
+		updateGroupData = true
+	}
+
+	if update("description", groupJSON.Description) {
+		groupUpdates.Description = database.Group_Description(
+			groupJSON.Description)
+		updateGroupData = true
+	}
+
+	if update("owner", groupJSON.Owner) {
+		groupUpdates.Owner = database.Group_Owner(groupJSON.Owner)
+		updateGroupData = true
+	}
+
+	if groupJSON.Labels != nil {
+		err = checkLabels(groupJSON.Labels)
+		if err != nil {
+			return nil, err
+		}
+		groupUpdates.Labels = database.Group_Labels(dbLabels(groupJSON.Labels))
+		updateGroupData = true
+	}
+
+	if !updateGroupData && groupJSON.Name == "" {
+		return nil, he.BadRequest.New("no updates in request")
+	}
+
+	err = s.DB.WithTx(ctx, func(ctx context.Context, tx *database.Tx) error {
+		db := s.DB.In(ctx)
+		group, err := db.Find_Group_By_Name(ctx,
+			database.Group_Name(groupName))
+		if err != nil {
+			return err
+		}
+
+		if group == nil {
+			return he.CodeGroupNotFound.New(
+				"groupName %q doesn't exist", groupName)
+		}
+
+		if updateGroupData {
+			_, err = db.Update_Group_By_Name(ctx,
+				database.Group_Name(groupName), groupUpdates)
+			if database.IsUniqueViolation(err) {
+				return he.CodeGroupExists.New(
+					"groupName %q already exists", newName)
+			}
+			if err != nil {
+				return err
+			}
+
+			// sqlite reads the updated group back by its old name, so it's
+			// read again by its new one
+			group, err = db.Get_Group_By_Name(ctx,
+				database.Group_Name(newName))
+			if err != nil {
+				return err
+			}
+
+			err = s.recordGroup(ctx, group, database.VersionUpdated)
+			if err != nil {
+				return err
+			}
+		}
+
+		users, err := db.All_User_By_Group_Name(ctx,
+			database.Group_Name(group.Name))
+		if err != nil {
+			return err
+		}
+
+		updated = apiGroup(group, users)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// checkLabels returns a 400 if labels can't be stored on a group
+func checkLabels(labels map[string]string) error {
+	for key := range labels {
+		if key == "" {
+			return he.BadRequest.Wrap(he.InvalidFields{{
+				Field:   "labels",
+				Message: "label keys can't be empty",
+			}})
+		}
+	}
+	return nil
+}
+
+// addMembers adds users to a group, keeping its other members. users that
+// aren't active can't be added.
+func (s *Server) addMembers(ctx context.Context, groupName string,
+	userIDs []string) error {
+
+	var added int
+	err := s.recordGroupMemberships(ctx, groupName,
+		func(ctx context.Context) (err error) {
+			err = s.checkNewMembers(ctx, groupName, userIDs)
+			if err != nil {
+				return err
+			}
+			added, err = s.DB.AddGroupMembership(ctx, groupName, userIDs)
+			return err
+		})
+	if err != nil {
+		return err
+	}
+
+	logging.Entry(ctx).Debugf("memberships - added: %d", added)
+
+	countMemberships(ctx, added)
+	return nil
+}
+
+// removeMembers removes users from a group, keeping its other members
+func (s *Server) removeMembers(ctx context.Context, groupName string,
+	userIDs []string) error {
+
+	var removed int
+	err := s.recordGroupMemberships(ctx, groupName,
+		func(ctx context.Context) (err error) {
+			removed, err = s.DB.RemoveGroupMembership(ctx, groupName,
+				userIDs)
+			return err
+		})
+	if err != nil {
+		return err
+	}
+
+	logging.Entry(ctx).Debugf("memberships - removed: %d", removed)
+
+	countMemberships(ctx, -removed)
+	return nil
+}
+
+// deleteGroup deletes a group and its memberships
+func (s *Server) deleteGroup(ctx context.Context, groupName string) error {
+	return s.DB.WithTx(ctx, func(ctx context.Context, tx *database.Tx) error {
+		db := s.DB.In(ctx)
+
+		// the group is read first so that its last version can be recorded
+		group, err := db.Find_Group_By_Name(ctx,
+			database.Group_Name(groupName))
+		if err != nil {
+			return err
+		}
+
+		if group == nil {
+			return he.CodeGroupNotFound.New(
+				"groupName %q doesn't exist", groupName)
+		}
+
+		err = s.recordGroupMemberships(ctx, groupName,
+			func(ctx context.Context) error {
+				_, err := db.Delete_Group_By_Name(ctx,
+					database.Group_Name(groupName))
+				return err
+			})
+		if err != nil {
+			return err
+		}
+
+		database.AfterCommit(ctx, monitor.GroupGauge.Dec)
+		return s.recordGroup(ctx, group, database.VersionDeleted)
+	})
+}
+
+// pagedGroups returns the page of groups at cur and the cursors of the pages
+// around it, which are nil at the ends of the list
+func (s *Server) pagedGroups(ctx context.Context, cur *cursor) ([]*Group,
+	*cursor, *cursor, error) {
+
+	groups, next, prev, err := s.groupPage(ctx, cur)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return apiGroups(groups), next, prev, nil
+}
+
+// groupPage is pagedGroups for the database records
+func (s *Server) groupPage(ctx context.Context, cur *cursor) (
+	[]*database.Group, *cursor, *cursor, error) {
+
+	db := s.DB.In(ctx)
+	return keysetPage(cur, func(g *database.Group) int64 { return g.Pk },
+		func(pk int64, n int) ([]*database.Group, error) {
+			return db.Limited_Group_By_Pk_Greater_OrderBy_Asc_Pk(ctx,
+				database.Group_Pk(pk), n, 0)
+		},
+		func(pk int64, n int) ([]*database.Group, error) {
+			return db.Limited_Group_By_Pk_Less_OrderBy_Desc_Pk(ctx,
+				database.Group_Pk(pk), n, 0)
+		})
+}
@@ -0,0 +1,267 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zeebo/errs"
+
+	h "demoapi/handler"
+	he "demoapi/httperror"
+	"demoapi/util"
+)
+
+// Lists are paged with cursors. a cursor is an opaque token that points
+// between two records of a list and remembers its sort key, filters and page
+// size. cursors are signed, so clients can't forge them or use them on
+// another list. pages are read with keyset queries, so they don't shift when
+// records are created or deleted while a client pages through a list.
+
+const (
+	tokenParam = "token"
+	limitParam = "limit"
+	totalParam = "include_total"
+
+	// sortByPK orders records by when they were created. it's the only sort
+	// key so far
+	sortByPK = "pk"
+)
+
+// cursor is the position of a page in a list. the page is the records after
+// After, or the records before Before if it's set, in sort order
+type cursor struct {
+	List    string            `json:"r"` // like "users"
+	Sort    string            `json:"s"`
+	After   int64             `json:"a,omitempty"`
+	Before  int64             `json:"b,omitempty"`
+	Limit   int               `json:"l"`
+	Filters map[string]string `json:"f,omitempty"`
+}
+
+// at returns a copy of c that points after or before a record
+func (c *cursor) at(after, before int64) *cursor {
+	moved := *c
+	moved.After, moved.Before = after, before
+	return &moved
+}
+
+// newCursorKey returns the key that cursors are signed with. without a
+// secret, cursors only work on this instance until it restarts
+func newCursorKey(secret string) []byte {
+	if secret != "" {
+		key := sha256.Sum256([]byte(secret))
+		return key[:]
+	}
+
+	logrus.Warn("pagination_cursor_secret isn't set. page tokens are signed " +
+		"with a random key and won't work across restarts or replicas")
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		panic(errs.Wrap(err))
+	}
+	return key
+}
+
+// encodeCursor returns the token of c, which is its json and signature
+func (s *Server) encodeCursor(c *cursor) string {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		// a cursor is only strings and numbers
+		panic(errs.Wrap(err))
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(s.signCursor(payload))
+}
+
+func (s *Server) signCursor(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.cursorKey)
+	_, _ = mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// decodeCursor verifies a token from encodeCursor and that it's a cursor of
+// list
+func (s *Server) decodeCursor(token, list string) (*cursor, error) {
+	invalid := he.CodeInvalidCursor.New("invalid page token %q. use the "+
+		"next_page or prev_page of a response", token)
+
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, invalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, invalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.signCursor(payload)) {
+		return nil, invalid
+	}
+
+	var c cursor
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, invalid
+	}
+	if c.List != list || c.Sort != sortByPK || c.Limit < 1 {
+		return nil, he.CodeInvalidCursor.New("page token %q isn't for %s",
+			token, list)
+	}
+	return &c, nil
+}
+
+// checkPageLimit returns a 400 if a page can't have limit records. param is
+// the name of the limit for the error
+func (s *Server) checkPageLimit(param string, limit int) error {
+	max := s.settings().PaginationMaxLimit
+	if limit < 1 || limit > max {
+		return he.BadRequest.Wrap(he.InvalidFields{{Field: param,
+			Message: fmt.Sprintf("must be between 1 and %d", max)}})
+	}
+	return nil
+}
+
+// pageCursor returns the cursor of the page of list that query asks for. a
+// list starts at its first page without a token, and the limit parameter
+// overrides the page size of a token
+func (s *Server) pageCursor(query url.Values, list string,
+	filters map[string]string) (*cursor, error) {
+
+	c := &cursor{
+		List:    list,
+		Sort:    sortByPK,
+		Limit:   util.Min(PaginationLimit, s.settings().PaginationMaxLimit),
+		Filters: filters,
+	}
+	if token := query.Get(tokenParam); token != "" {
+		var err error
+		c, err = s.decodeCursor(token, list)
+		if err != nil {
+			return nil, err
+		}
+		if !maps.Equal(c.Filters, filters) {
+			return nil, he.CodeInvalidCursor.New("page token is for other " +
+				"filters. leave out the token to start over")
+		}
+	}
+
+	if raw := query.Get(limitParam); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, he.BadRequest.Wrap(he.InvalidFields{{
+				Field: limitParam, Message: "must be a number"}})
+		}
+		c.Limit = limit
+	}
+	return c, s.checkPageLimit(limitParam, c.Limit)
+}
+
+// keysetPage reads the page of records at c. after reads up to n records
+// after a key in sort order, and before reads them before a key in reverse
+// order. the records are returned in sort order with the cursors of the pages
+// around them, which are nil at the ends of the list.
+func keysetPage[T any](c *cursor, key func(T) int64,
+	after, before func(key int64, n int) ([]T, error)) (
+	records []T, next, prev *cursor, err error) {
+
+	// an extra record tells whether there's another page
+	if c.Before == 0 {
+		records, err = after(c.After, c.Limit+1)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if len(records) > c.Limit {
+			records = records[:c.Limit]
+			next = c.at(key(records[len(records)-1]), 0)
+		}
+		if c.After > 0 {
+			prev = c.at(0, c.After+1)
+		}
+		return records, next, prev, nil
+	}
+
+	records, err = before(c.Before, c.Limit+1)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	more := len(records) > c.Limit
+	if more {
+		records = records[:c.Limit]
+	}
+	slices.Reverse(records)
+	if more {
+		prev = c.at(0, key(records[0]))
+	}
+	if len(records) > 0 {
+		next = c.at(key(records[len(records)-1]), 0)
+	} else {
+		next = c.at(c.Before-1, 0)
+	}
+	return records, next, prev, nil
+}
+
+// page links to the page of c, keeping the rest of the query of u
+func (s *Server) page(u *url.URL, c *cursor) *Page {
+	if c == nil {
+		return nil
+	}
+	token := s.encodeCursor(c)
+	query := u.Query()
+	query.Set(tokenParam, token)
+	link := *u
+	link.RawQuery = query.Encode()
+	return &Page{Link: link.String(), Token: token}
+}
+
+// includeTotal returns whether query asks for the total count of a list
+func includeTotal(query url.Values) (bool, error) {
+	return boolParam(query, totalParam)
+}
+
+// boolParam returns the value of a boolean query parameter, which is false
+// when it's missing
+func boolParam(query url.Values, param string) (bool, error) {
+	raw := query.Get(param)
+	if raw == "" {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, he.BadRequest.Wrap(he.InvalidFields{{
+			Field: param, Message: "must be true or false"}})
+	}
+	return value, nil
+}
+
+// pageResponse returns a page of a list with RFC 8288 links to the first,
+// next and previous pages
+func pageResponse(u *url.URL, resp *RootJSON) *h.Response {
+	first := *u
+	query := u.Query()
+	query.Del(tokenParam)
+	first.RawQuery = query.Encode()
+
+	response := h.OK(resp)
+	response.Header = http.Header{}
+	link := func(target, rel string) {
+		response.Header.Add("Link", fmt.Sprintf("<%s>; rel=%q", target, rel))
+	}
+	link(first.String(), "first")
+	if resp.NextPage != nil {
+		link(resp.NextPage.Link, "next")
+	}
+	if resp.PrevPage != nil {
+		link(resp.PrevPage.Link, "prev")
+	}
+	return response
+}
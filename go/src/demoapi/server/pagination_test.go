@@ -0,0 +1,130 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	he "demoapi/httperror"
+)
+
+func TestPagedUsers(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	for i := 0; i < 5; i++ {
+		t.newUser(ctx, fmt.Sprintf("user%d", i))
+	}
+	get := func(target string) (*httptest.ResponseRecorder, testResponse) {
+		w := httptest.NewRecorder()
+		t.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		var resp testResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w, resp
+	}
+	ids := func(resp testResponse) []string {
+		var ids []string
+		for _, u := range resp.Users {
+			ids = append(ids, u.ID)
+		}
+		return ids
+	}
+
+	w, first := get("/v1/users?limit=2&extra=kept&include_total=true")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"user0", "user1"}, ids(first))
+	assert.Nil(t, first.PrevPage)
+	require.NotNil(t, first.Total)
+	assert.EqualValues(t, 5, *first.Total)
+	require.NotNil(t, first.NextPage)
+
+	// next_page keeps the query and its token keeps the page size
+	next, err := url.Parse(first.NextPage.Link)
+	require.NoError(t, err)
+	assert.Equal(t, "kept", next.Query().Get("extra"))
+	assert.Equal(t, first.NextPage.Token, next.Query().Get(tokenParam))
+	assert.Equal(t, []string{
+		`</v1/users?extra=kept&include_total=true&limit=2>; rel="first"`,
+		fmt.Sprintf("<%s>; rel=\"next\"", first.NextPage.Link),
+	}, w.Header().Values("Link"))
+
+	_, second := get("/v1/users?token=" + first.NextPage.Token)
+	assert.Equal(t, []string{"user2", "user3"}, ids(second))
+	assert.Nil(t, second.Total)
+	require.NotNil(t, second.PrevPage)
+	_, last := get("/v1/users?token=" + second.NextPage.Token)
+	assert.Equal(t, []string{"user4"}, ids(last))
+	assert.Nil(t, last.NextPage)
+
+	// prev_page walks back to the first page
+	_, back := get("/v1/users?token=" + last.PrevPage.Token)
+	assert.Equal(t, []string{"user2", "user3"}, ids(back))
+	_, back = get("/v1/users?token=" + back.PrevPage.Token)
+	assert.Equal(t, []string{"user0", "user1"}, ids(back))
+	assert.Nil(t, back.PrevPage)
+	require.NotNil(t, back.NextPage)
+
+	// the limit overrides the page size of a token
+	_, rest := get("/v1/users?limit=5&token=" + first.NextPage.Token)
+	assert.Equal(t, []string{"user2", "user3", "user4"}, ids(rest))
+
+	for _, target := range []string{
+		"/v1/users?limit=0",
+		"/v1/users?limit=-1",
+		"/v1/users?limit=51",
+		"/v1/users?limit=many",
+		"/v1/users?include_total=maybe",
+	} {
+		w, _ := get(target)
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+	}
+}
+
+func TestPageCursor(baseTest *testing.T) {
+	_, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	cur, err := t.server.pageCursor(url.Values{}, "users", nil)
+	require.NoError(t, err)
+	assert.Equal(t, &cursor{List: "users", Sort: sortByPK,
+		Limit: PaginationLimit}, cur)
+
+	token := t.server.encodeCursor(cur.at(3, 0))
+	decoded, err := t.server.pageCursor(url.Values{tokenParam: {token}},
+		"users", nil)
+	require.NoError(t, err)
+	assert.Equal(t, cur.at(3, 0), decoded)
+
+	// tokens of another list, with other filters or that were changed are
+	// rejected
+	_, err = t.server.pageCursor(url.Values{tokenParam: {token}}, "groups",
+		nil)
+	assert.Equal(t, he.CodeInvalidCursor, he.CodeByError(err))
+	_, err = t.server.pageCursor(url.Values{tokenParam: {token}}, "users",
+		map[string]string{"group": "admins"})
+	assert.Equal(t, he.CodeInvalidCursor, he.CodeByError(err))
+
+	payload, signature, _ := strings.Cut(token, ".")
+	forged := t.server.encodeCursor(cur.at(4, 0))
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+	for _, tampered := range []string{"3", payload,
+		forgedPayload + "." + signature, token + "x"} {
+		_, err = t.server.pageCursor(url.Values{tokenParam: {tampered}},
+			"users", nil)
+		assert.Equal(t, he.CodeInvalidCursor, he.CodeByError(err), tampered)
+		assert.Equal(t, http.StatusBadRequest, he.StatusCodeByError(err))
+	}
+
+	// another key doesn't accept the token
+	other := New(t.server.DB, t.server.Config)
+	other.cursorKey = newCursorKey("other")
+	_, err = other.pageCursor(url.Values{tokenParam: {token}}, "users", nil)
+	assert.Equal(t, he.CodeInvalidCursor, he.CodeByError(err))
+}
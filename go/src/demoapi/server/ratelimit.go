@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"demoapi/handler"
+	he "demoapi/httperror"
+	"demoapi/logging"
+	monitor "demoapi/prometheus"
+	"demoapi/ratelimit"
+)
+
+// Routes are rate limited by class. every client has a token bucket for each
+// class, sized by the rate_limit_* settings.
+const (
+	rateLimitReads  = "reads"
+	rateLimitWrites = "writes"
+	rateLimitBulk   = "bulk"
+)
+
+func newRateLimiters() map[string]*ratelimit.Limiter {
+	return map[string]*ratelimit.Limiter{
+		rateLimitReads:  ratelimit.NewLimiter(),
+		rateLimitWrites: ratelimit.NewLimiter(),
+		rateLimitBulk:   ratelimit.NewLimiter(),
+	}
+}
+
+// RateLimitReads limits the requests of each client to the read routes
+func (s *Server) RateLimitReads(h handler.Handler) handler.Handler {
+	return s.rateLimited(rateLimitReads, h)
+}
+
+// RateLimitWrites limits the requests of each client to the write routes
+func (s *Server) RateLimitWrites(h handler.Handler) handler.Handler {
+	return s.rateLimited(rateLimitWrites, h)
+}
+
+// RateLimitBulk limits the requests of each client to the routes that take
+// whole lists of members, which are the most expensive to serve
+func (s *Server) RateLimitBulk(h handler.Handler) handler.Handler {
+	return s.rateLimited(rateLimitBulk, h)
+}
+
+// RateLimitByMethod limits GET requests as reads and any others as writes,
+// for routers that mix both, like scim
+func (s *Server) RateLimitByMethod(h handler.Handler) handler.Handler {
+	reads := s.rateLimited(rateLimitReads, h)
+	writes := s.rateLimited(rateLimitWrites, h)
+	return handler.Handler(func(ctx context.Context, w http.ResponseWriter,
+		r *http.Request) (*handler.Response, error) {
+
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			return reads(ctx, w, r)
+		}
+		return writes(ctx, w, r)
+	})
+}
+
+// rateLimited rejects requests with a 429 once the client has used up its
+// bucket for class, and tells every client how much of it is left
+func (s *Server) rateLimited(class string, h handler.Handler) handler.Handler {
+	return handler.Handler(func(ctx context.Context, w http.ResponseWriter,
+		r *http.Request) (*handler.Response, error) {
+
+		result, err := s.allow(ctx, class, clientIP(r))
+		if result != nil {
+			header := w.Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("RateLimit-Reset", ceilSeconds(result.Reset))
+			if !result.Allowed {
+				header.Set("Retry-After", ceilSeconds(result.RetryAfter))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return h(ctx, w, r)
+	})
+}
+
+// allow takes a request of class from the bucket of its client, which is the
+// principal of ctx, if it has one, and otherwise the ip address. the result
+// is nil if class is unlimited, and err is a 429 once the bucket is empty.
+// the limits are read on every request, so they can be reloaded. it's shared
+// by the http and grpc apis.
+func (s *Server) allow(ctx context.Context, class, ip string) (
+	*ratelimit.Result, error) {
+
+	limit := classLimit(s.settings().RateLimits, class)
+	if limit.Unlimited() {
+		return nil, nil
+	}
+
+	key, keyType := principal(ctx), "principal"
+	if key == "" {
+		key, keyType = ip, "ip"
+	}
+
+	result := s.rateLimiters[class].Allow(key, limit)
+	if !result.Allowed {
+		logging.Entry(ctx).Debugf("rate limited %s %q", keyType, key)
+		monitor.RateLimitRejectionCounter.WithLabelValues(
+			s.Config.APISlug, class, keyType).Inc()
+		return &result, he.CodeRateLimited.New("too many %s requests, "+
+			"retry in %s seconds", class, ceilSeconds(result.RetryAfter))
+	}
+	return &result, nil
+}
+
+func classLimit(limits ratelimit.Limits, class string) ratelimit.Limit {
+	switch class {
+	case rateLimitReads:
+		return limits.Reads
+	case rateLimitWrites:
+		return limits.Writes
+	}
+	return limits.Bulk
+}
+
+// clientIP returns the ip address that the request came from
+func clientIP(r *http.Request) string {
+	return hostIP(r.RemoteAddr)
+}
+
+// hostIP returns the ip address of a host:port address
+func hostIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// ceilSeconds formats d as whole seconds, rounded up so that clients don't
+// retry too early
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
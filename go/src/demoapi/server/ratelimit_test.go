@@ -0,0 +1,102 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	he "demoapi/httperror"
+	"demoapi/ratelimit"
+	"demoapi/rpc"
+)
+
+func TestRateLimit(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+	t.newUser(ctx, "user1")
+
+	limited := *t.server.Config
+	limited.RateLimits = ratelimit.Limits{
+		Reads: ratelimit.Limit{PerMinute: 1, Burst: 2},
+	}
+	t.server.Reload(&limited)
+
+	get := func(remoteAddr string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/v1/users/user1", nil)
+		r.RemoteAddr = remoteAddr
+		t.server.ServeHTTP(w, r)
+		return w
+	}
+
+	labels := map[string]string{"class": "reads", "key": "ip"}
+	rejected := t.metric("rate_limit_rejections_total", labels)
+
+	w := get("192.0.2.1:1234")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+
+	// clients are told when to retry once their bucket is empty. the port
+	// doesn't matter
+	assert.Equal(t, http.StatusOK, get("192.0.2.1:1234").Code)
+	w = get("192.0.2.1:5678")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, he.ProblemContentType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), he.CodeRateLimited.Code)
+	assert.Equal(t, rejected+1, t.metric("rate_limit_rejections_total",
+		labels))
+
+	// other clients and route classes have their own buckets
+	assert.Equal(t, http.StatusOK, get("192.0.2.2:1234").Code)
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/v1/users/user1", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	t.server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
+
+func TestGRPCRateLimit(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+	t.newUser(ctx, "user1")
+
+	limited := *t.server.Config
+	limited.RateLimits = ratelimit.Limits{
+		Reads: ratelimit.Limit{PerMinute: 1, Burst: 1},
+	}
+	t.server.Reload(&limited)
+
+	conn := t.grpcConn()
+	defer conn.Close()
+	users := rpc.NewUserServiceClient(conn)
+
+	var header metadata.MD
+	_, err := users.GetUser(ctx, &rpc.GetUserRequest{Userid: "user1"},
+		grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0"}, header.Get("ratelimit-remaining"))
+
+	_, err = users.GetUser(ctx, &rpc.GetUserRequest{Userid: "user1"},
+		grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"60"}, header.Get("retry-after"))
+
+	// streamed reads share the bucket, and writes have their own
+	stream, err := users.ListUsers(ctx, &rpc.ListUsersRequest{})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	_, err = users.DeleteUser(ctx, &rpc.DeleteUserRequest{Userid: "user1"})
+	assert.NoError(t, err)
+}
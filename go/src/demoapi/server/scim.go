@@ -0,0 +1,1182 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/zeebo/errs"
+
+	"demoapi/database"
+	h "demoapi/handler"
+	he "demoapi/httperror"
+	"demoapi/logging"
+	monitor "demoapi/prometheus"
+	"demoapi/util"
+)
+
+// This file contains the SCIM 2.0 (RFC 7643 and RFC 7644) provisioning
+// endpoints. They are a translation layer over the same user, group and
+// membership records as the rest of the api. SCIM resources are identified by
+// their uuid, while the userid and group name are exposed as the `userName`
+// and `displayName` attributes.
+
+const (
+	scimPrefix = "/scim/v2"
+
+	// scimMaxScan is the most users or groups that a filter is checked
+	// against in a request. filters that the database can't narrow down that
+	// far are rejected with tooMany instead of loading every record.
+	scimMaxScan = 10000
+)
+
+var (
+	scimValueErr      = errs.Class("invalid value")
+	scimPathErr       = errs.Class("invalid path")
+	scimMutabilityErr = errs.Class("mutability")
+	scimTooManyErr    = errs.Class("too many")
+)
+
+func scimRouter(s *Server, middlewares ...h.HandlerFunc) http.Handler {
+	// scimResponse is first in the chain so that errors returned by any of the
+	// shared middleware, like failed auth, are also formatted as SCIM errors
+	mw := h.MiddlewareChain(scimResponse).Append(middlewares...)
+
+	r := chi.NewRouter()
+	r.Method("GET", "/ServiceProviderConfig",
+		mw.JSON(s.SCIMServiceProviderConfig))
+	r.Method("GET", "/Schemas", mw.JSON(s.SCIMSchemas))
+	r.Method("GET", "/Schemas/{schemaID}", mw.JSON(s.SCIMSchema))
+	r.Method("GET", "/ResourceTypes", mw.JSON(s.SCIMResourceTypes))
+	r.Method("GET", "/ResourceTypes/{resourceType}",
+		mw.JSON(s.SCIMResourceType))
+
+	r.Method("GET", "/Users", mw.JSON(s.SCIMListUsers))
+	r.Method("GET", "/Users/{scimID}", mw.JSON(s.SCIMGetUser))
+	r.Method("POST", "/Users", mw.JSON(s.SCIMCreateUser))
+	r.Method("PUT", "/Users/{scimID}", mw.JSON(s.SCIMReplaceUser))
+	r.Method("PATCH", "/Users/{scimID}", mw.JSON(s.SCIMPatchUser))
+	r.Method("DELETE", "/Users/{scimID}", mw.JSON(s.SCIMDeleteUser))
+
+	r.Method("GET", "/Groups", mw.JSON(s.SCIMListGroups))
+	r.Method("GET", "/Groups/{scimID}", mw.JSON(s.SCIMGetGroup))
+	r.Method("POST", "/Groups", mw.JSON(s.SCIMCreateGroup))
+	r.Method("PUT", "/Groups/{scimID}", mw.JSON(s.SCIMReplaceGroup))
+	r.Method("PATCH", "/Groups/{scimID}", mw.JSON(s.SCIMPatchGroup))
+	r.Method("DELETE", "/Groups/{scimID}", mw.JSON(s.SCIMDeleteGroup))
+
+	return r
+}
+
+// scimResponse adapts handler results to the SCIM wire format: the
+// application/scim+json content type and errors in the SCIM error schema.
+func scimResponse(next h.Handler) h.Handler {
+	return h.Handler(func(ctx context.Context, w http.ResponseWriter,
+		r *http.Request) (*h.Response, error) {
+
+		resp, err := next(ctx, w, r)
+		if err != nil {
+			he.Record(ctx, err)
+			status := he.StatusCodeByError(err)
+			resp = &h.Response{Status: status,
+				Body: h.JSONBody{Value: scimError(status, err)}}
+		}
+		if resp == nil || resp == h.Written || resp.Body == nil {
+			return resp, nil
+		}
+
+		// scim is always json
+		if b, ok := resp.Body.(h.ValueBody); ok {
+			resp.Body = h.JSONBody{Value: b.Value}
+		}
+		if resp.Header == nil {
+			resp.Header = http.Header{}
+		}
+		resp.Header.Set("Content-Type", scimContentType)
+		return resp, nil
+	})
+}
+
+func scimError(status int, err error) *SCIMError {
+	e := &SCIMError{
+		Schemas: []string{scimErrorSchema},
+		Status:  strconv.Itoa(status),
+		Detail:  he.Detail(err, h.DeveloperMode()),
+	}
+
+	switch {
+	case scimFilterErr.Has(err):
+		e.SCIMType = "invalidFilter"
+	case scimPathErr.Has(err):
+		e.SCIMType = "invalidPath"
+	case scimValueErr.Has(err):
+		e.SCIMType = "invalidValue"
+	case scimMutabilityErr.Has(err):
+		e.SCIMType = "mutability"
+	case scimTooManyErr.Has(err):
+		e.SCIMType = "tooMany"
+	case he.Conflict.Has(err):
+		e.SCIMType = "uniqueness"
+	}
+
+	return e
+}
+
+// scimPagination reads the `startIndex` and `count` query parameters. SCIM
+// says out of range values should be clamped rather than rejected.
+func scimPagination(queryParams url.Values) (int, int, error) {
+	startIndex, count := 1, PaginationLimit
+
+	if v := queryParams.Get("startIndex"); v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, he.BadRequest.Wrap(scimValueErr.Wrap(err))
+		}
+		if i > 1 {
+			startIndex = i
+		}
+	}
+
+	if v := queryParams.Get("count"); v != "" {
+		c, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, he.BadRequest.Wrap(scimValueErr.Wrap(err))
+		}
+		count = util.Min(util.Max(c, 0), PaginationLimit)
+	}
+
+	return startIndex, count, nil
+}
+
+// scimQueryFilter parses the `filter` query parameter, if one was provided
+func scimQueryFilter(queryParams url.Values) (scimFilter, error) {
+	filter := queryParams.Get("filter")
+	if filter == "" {
+		return nil, nil
+	}
+
+	f, err := parseSCIMFilter(filter)
+	if err != nil {
+		return nil, he.BadRequest.Wrap(err)
+	}
+	return f, nil
+}
+
+// scimPage builds a list response out of every resource that matched a
+// filter, respecting the requested window
+func scimPage(startIndex, count int, matched []interface{}) *SCIMListResponse {
+	start := util.Min(startIndex-1, len(matched))
+	end := util.Min(start+count, len(matched))
+	return scimList(startIndex, len(matched), matched[start:end])
+}
+
+// SCIMListUsers returns users in a SCIM list response
+// `GET /scim/v2/Users?filter=userName eq "x"&startIndex=1&count=10`
+func (s *Server) SCIMListUsers(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	queryParams := r.URL.Query()
+	startIndex, count, err := scimPagination(queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := scimQueryFilter(queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter == nil {
+		total, err := s.DB.Count_User(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		resources := []interface{}{}
+		if count > 0 {
+			users, err := s.DB.Limited_User_OrderBy_Asc_Pk(ctx, count,
+				int64(startIndex-1))
+			if err != nil {
+				return nil, err
+			}
+
+			for _, user := range users {
+				resources = append(resources, scimUser(r, user, nil))
+			}
+		}
+
+		return h.OK(scimList(startIndex, int(total), resources)), nil
+	}
+
+	matched, err := scimScan(filter, scimMaxScan,
+		scimColumnFilter(filter, scimUserColumns),
+		func(column *database.ColumnFilter, pk int64, limit int) (
+			[]*database.User, error) {
+			return s.DB.ScanUsers(ctx, column, pk, limit)
+		},
+		func(user *database.User) (int64, scimAttributes, interface{}) {
+			scimUserJSON := scimUser(r, user, nil)
+			return user.Pk, scimUserJSON.scimAttr, scimUserJSON
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return h.OK(scimPage(startIndex, count, matched)), nil
+}
+
+// SCIMGetUser returns a single SCIM user by its id
+// `GET /scim/v2/Users/<uuid>`
+func (s *Server) SCIMGetUser(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	user, err := s.scimFindUser(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.DB.All_Group_By_User_Id(ctx, database.User_Id(user.Id))
+	if err != nil {
+		return nil, err
+	}
+
+	return h.OK(scimUser(r, user, groups)), nil
+}
+
+// SCIMCreateUser provisions a new user. `userName`, `name.givenName` and
+// `name.familyName` are required. A user with `"active": false` is created
+// suspended. The read-only `groups` attribute is ignored.
+// `POST /scim/v2/Users`
+func (s *Server) SCIMCreateUser(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	userJSON := SCIMUser{}
+	err := h.Decode(r, &userJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateSCIMUser(&userJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *database.User
+	err = s.DB.WithTx(ctx, func(ctx context.Context, tx *database.Tx) error {
+		db := s.DB.In(ctx)
+		existing, err := db.Find_User_By_Id(ctx,
+			database.User_Id(userJSON.UserName))
+		if err != nil {
+			return err
+		}
+
+		if existing != nil {
+			return he.CodeUserExists.New("userName %q already exists",
+				userJSON.UserName)
+		}
+
+		user, err = db.Create_User(ctx,
+			database.User_Uuid(util.MustUUID4()),
+			database.User_Id(userJSON.UserName),
+			database.User_FirstName(userJSON.Name.GivenName),
+			database.User_LastName(userJSON.Name.FamilyName),
+			database.User_Status(userActive))
+		if err != nil {
+			return err
+		}
+
+		database.AfterCommit(ctx, monitor.UserGauge.Inc)
+
+		err = s.recordUser(ctx, user, database.VersionCreated)
+		if err != nil {
+			return err
+		}
+
+		user, err = s.scimSetActive(ctx, user, userJSON.Active)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return h.Created(scimLocation(r, "Users", user.Uuid),
+		scimUser(r, user, nil)), nil
+}
+
+// SCIMReplaceUser replaces the writable attributes of a user. `active`
+// suspends or activates it.
+// `PUT /scim/v2/Users/<uuid>`
+func (s *Server) SCIMReplaceUser(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	user, err := s.scimFindUser(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	userJSON := SCIMUser{}
+	err = h.Decode(r, &userJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.scimReplaceUser(ctx, r, user, &userJSON)
+}
+
+// SCIMPatchUser applies a list of add and replace operations to a user.
+// Supported paths are `active`, `userName`, `name.givenName`,
+// `name.familyName` and no path at all, with the value being a partial user.
+// `PATCH /scim/v2/Users/<uuid>`
+func (s *Server) SCIMPatchUser(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	user, err := s.scimFindUser(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	patchJSON := SCIMPatchRequest{}
+	err = h.Decode(r, &patchJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	if !scimHasSchema(patchJSON.Schemas, scimPatchSchema) {
+		return nil, he.BadRequest.Wrap(scimValueErr.New("expected schema %q",
+			scimPatchSchema))
+	}
+
+	if len(patchJSON.Operations) == 0 {
+		return nil, he.BadRequest.Wrap(scimValueErr.New("no operations"))
+	}
+
+	// the operations are applied to the current user, which then replaces it
+	userJSON := scimUser(r, user, nil)
+	for _, op := range patchJSON.Operations {
+		err = scimApplyUserPatch(userJSON, op)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.scimReplaceUser(ctx, r, user, userJSON)
+}
+
+// scimReplaceUser replaces the writable attributes of user with the ones of
+// userJSON in one transaction
+func (s *Server) scimReplaceUser(ctx context.Context, r *http.Request,
+	user *database.User, userJSON *SCIMUser) (*h.Response, error) {
+
+	err := validateSCIMUser(userJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []*database.Group
+	err = s.DB.WithTx(ctx, func(ctx context.Context, tx *database.Tx) error {
+		db := s.DB.In(ctx)
+		if userJSON.UserName != user.Id {
+			existing, err := db.Find_User_By_Id(ctx,
+				database.User_Id(userJSON.UserName))
+			if err != nil {
+				return err
+			}
+
+			if existing != nil {
+				return he.CodeUserExists.New("userName %q already exists",
+					userJSON.UserName)
+			}
+		}
+
+		updated, err := db.Update_User_By_Id(ctx, database.User_Id(user.Id),
+			database.User_Update_Fields{
+				Id: database.User_Id(userJSON.UserName),
+				FirstName: database.User_FirstName(
+					userJSON.Name.GivenName),
+				LastName: database.User_LastName(
+					userJSON.Name.FamilyName),
+			})
+		if err != nil {
+			return err
+		}
+
+		err = s.recordUser(ctx, updated, database.VersionUpdated)
+		if err != nil {
+			return err
+		}
+
+		user, err = s.scimSetActive(ctx, updated, userJSON.Active)
+		if err != nil {
+			return err
+		}
+
+		groups, err = db.All_Group_By_User_Id(ctx, database.User_Id(user.Id))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return h.OK(scimUser(r, user, groups)), nil
+}
+
+// scimSetActive suspends a user that a client deactivated, or activates one
+// that it reactivated, and returns the user as it is then. deprovisioned
+// users can't be activated.
+func (s *Server) scimSetActive(ctx context.Context, user *database.User,
+	active *bool) (*database.User, error) {
+
+	if active == nil || *active == (user.Status == userActive) {
+		return user, nil
+	}
+
+	status, reason := userSuspended, "deactivated with scim"
+	if *active {
+		status, reason = userActive, "activated with scim"
+	} else if user.Status == userDeprovisioned {
+		return user, nil
+	}
+
+	_, err := s.setUserStatus(ctx, user.Id, status, reason)
+	if err != nil {
+		return nil, err
+	}
+	return s.DB.In(ctx).Get_User_By_Id(ctx, database.User_Id(user.Id))
+}
+
+// SCIMDeleteUser deletes a user and their memberships
+// `DELETE /scim/v2/Users/<uuid>`
+func (s *Server) SCIMDeleteUser(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	user, err := s.scimFindUser(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.deleteUser(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.NoContent(), nil
+}
+
+// SCIMListGroups returns groups in a SCIM list response. Members are only
+// included when fetching a single group.
+// `GET /scim/v2/Groups?filter=displayName eq "x"&startIndex=1&count=10`
+func (s *Server) SCIMListGroups(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	queryParams := r.URL.Query()
+	startIndex, count, err := scimPagination(queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := scimQueryFilter(queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter == nil {
+		total, err := s.DB.Count_Group(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		resources := []interface{}{}
+		if count > 0 {
+			groups, err := s.DB.Limited_Group_OrderBy_Asc_Pk(ctx, count,
+				int64(startIndex-1))
+			if err != nil {
+				return nil, err
+			}
+
+			for _, group := range groups {
+				resources = append(resources, scimGroup(r, group, nil))
+			}
+		}
+
+		return h.OK(scimList(startIndex, int(total), resources)), nil
+	}
+
+	matched, err := scimScan(filter, scimMaxScan,
+		scimColumnFilter(filter, scimGroupColumns),
+		func(column *database.ColumnFilter, pk int64, limit int) (
+			[]*database.Group, error) {
+			return s.DB.ScanGroups(ctx, column, pk, limit)
+		},
+		func(group *database.Group) (int64, scimAttributes, interface{}) {
+			scimGroupJSON := scimGroup(r, group, nil)
+			return group.Pk, scimGroupJSON.scimAttr, scimGroupJSON
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return h.OK(scimPage(startIndex, count, matched)), nil
+}
+
+// SCIMGetGroup returns a single SCIM group, with members, by its id
+// `GET /scim/v2/Groups/<uuid>`
+func (s *Server) SCIMGetGroup(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	group, err := s.scimFindGroup(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.DB.All_User_By_Group_Name(ctx,
+		database.Group_Name(group.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	return h.OK(scimGroup(r, group, users)), nil
+}
+
+// SCIMCreateGroup creates a group, optionally with an initial set of members
+// `POST /scim/v2/Groups`
+func (s *Server) SCIMCreateGroup(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	groupJSON := SCIMGroup{}
+	err := h.Decode(r, &groupJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	if groupJSON.DisplayName == "" {
+		return nil, he.BadRequest.Wrap(
+			scimValueErr.New("displayName is required"))
+	}
+
+	var group *database.Group
+	var users []*database.User
+	err = s.DB.WithTx(ctx, func(ctx context.Context, tx *database.Tx) error {
+		members := newSCIMMembers()
+		err := s.scimResolveMembers(ctx, members, groupJSON.Members)
+		if err != nil {
+			return err
+		}
+
+		group, err = s.DB.In(ctx).Create_Group(ctx,
+			database.Group_Uuid(util.MustUUID4()),
+			database.Group_Name(groupJSON.DisplayName),
+			database.Group_Description(""), database.Group_DisplayName(""),
+			database.Group_Owner(""), database.Group_Labels(""))
+		if database.IsUniqueViolation(err) {
+			return he.CodeGroupExists.New("displayName %q already exists",
+				groupJSON.DisplayName)
+		}
+		if err != nil {
+			return err
+		}
+
+		database.AfterCommit(ctx, monitor.GroupGauge.Inc)
+
+		err = s.recordGroup(ctx, group, database.VersionCreated)
+		if err != nil {
+			return err
+		}
+
+		users, err = s.scimSetMembers(ctx, group, members)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return h.Created(scimLocation(r, "Groups", group.Uuid),
+		scimGroup(r, group, users)), nil
+}
+
+// SCIMReplaceGroup replaces the member list of a group, and renames it if
+// `displayName` changed
+// `PUT /scim/v2/Groups/<uuid>`
+func (s *Server) SCIMReplaceGroup(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	group, err := s.scimFindGroup(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	groupJSON := SCIMGroup{}
+	err = h.Decode(r, &groupJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []*database.User
+	err = s.DB.WithTx(ctx, func(ctx context.Context, tx *database.Tx) error {
+		err := s.scimRename(ctx, group, groupJSON.DisplayName)
+		if err != nil {
+			return err
+		}
+
+		members := newSCIMMembers()
+		err = s.scimResolveMembers(ctx, members, groupJSON.Members)
+		if err != nil {
+			return err
+		}
+
+		users, err = s.scimSetMembers(ctx, group, members)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return h.OK(scimGroup(r, group, users)), nil
+}
+
+// SCIMPatchGroup applies a list of add, remove and replace operations to the
+// members of a group. Supported paths are `members`,
+// `members[value eq "<uuid>"]` and no path at all, with the value being a
+// partial group.
+// `PATCH /scim/v2/Groups/<uuid>`
+func (s *Server) SCIMPatchGroup(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	group, err := s.scimFindGroup(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	patchJSON := SCIMPatchRequest{}
+	err = h.Decode(r, &patchJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	if !scimHasSchema(patchJSON.Schemas, scimPatchSchema) {
+		return nil, he.BadRequest.Wrap(scimValueErr.New("expected schema %q",
+			scimPatchSchema))
+	}
+
+	if len(patchJSON.Operations) == 0 {
+		return nil, he.BadRequest.Wrap(scimValueErr.New("no operations"))
+	}
+
+	// the operations are applied together, or not at all
+	var users []*database.User
+	err = s.DB.WithTx(ctx, func(ctx context.Context, tx *database.Tx) error {
+		current, err := s.DB.In(ctx).All_User_By_Group_Name(ctx,
+			database.Group_Name(group.Name))
+		if err != nil {
+			return err
+		}
+
+		members := newSCIMMembers()
+		for _, user := range current {
+			members.add(user.Uuid, user.Id)
+		}
+
+		for _, op := range patchJSON.Operations {
+			err = s.scimApplyPatch(ctx, group, members, op)
+			if err != nil {
+				return err
+			}
+		}
+
+		users, err = s.scimSetMembers(ctx, group, members)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return h.OK(scimGroup(r, group, users)), nil
+}
+
+// SCIMDeleteGroup deletes a group and its memberships
+// `DELETE /scim/v2/Groups/<uuid>`
+func (s *Server) SCIMDeleteGroup(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	group, err := s.scimFindGroup(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.deleteGroup(ctx, group.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.NoContent(), nil
+}
+
+// scimHasSchema checks a request's `schemas` attribute. requests that leave it
+// out are let through since plenty of clients never send it.
+func scimHasSchema(schemas []string, schema string) bool {
+	if len(schemas) == 0 {
+		return true
+	}
+	for _, s := range schemas {
+		if s == schema {
+			return true
+		}
+	}
+	return false
+}
+
+func validateSCIMUser(userJSON *SCIMUser) error {
+	if userJSON.UserName == "" {
+		return he.BadRequest.Wrap(scimValueErr.New("userName is required"))
+	}
+
+	if userJSON.Name == nil || userJSON.Name.GivenName == "" ||
+		userJSON.Name.FamilyName == "" {
+		return he.BadRequest.Wrap(scimValueErr.New(
+			"name.givenName and name.familyName are required"))
+	}
+
+	return nil
+}
+
+// scimRename renames group to displayName with updateGroup, unless it already
+// has that name, and updates group to match
+func (s *Server) scimRename(ctx context.Context, group *database.Group,
+	displayName string) error {
+
+	if displayName == "" || displayName == group.Name {
+		return nil
+	}
+
+	db := s.DB.In(ctx)
+	exists, err := db.Has_Group_By_Name(ctx, database.Group_Name(displayName))
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return he.CodeGroupExists.New("displayName %q already exists",
+			displayName)
+	}
+
+	_, err = s.updateGroup(ctx, group.Name, &Group{Name: displayName})
+	if err != nil {
+		return err
+	}
+
+	renamed, err := db.Get_Group_By_Name(ctx, database.Group_Name(displayName))
+	if err != nil {
+		return err
+	}
+
+	*group = *renamed
+	return nil
+}
+
+func (s *Server) scimFindUser(ctx context.Context,
+	r *http.Request) (*database.User, error) {
+
+	scimID := chi.URLParam(r, "scimID")
+	if scimID == "" {
+		return nil, he.BadRequest.New("incomplete path. missing id")
+	}
+
+	user, err := s.DB.Find_User_By_Uuid(ctx, database.User_Uuid(scimID))
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, he.NotFound.New("user %q doesn't exist", scimID)
+	}
+
+	return user, nil
+}
+
+func (s *Server) scimFindGroup(ctx context.Context,
+	r *http.Request) (*database.Group, error) {
+
+	scimID := chi.URLParam(r, "scimID")
+	if scimID == "" {
+		return nil, he.BadRequest.New("incomplete path. missing id")
+	}
+
+	group, err := s.DB.Find_Group_By_Uuid(ctx, database.Group_Uuid(scimID))
+	if err != nil {
+		return nil, err
+	}
+
+	if group == nil {
+		return nil, he.NotFound.New("group %q doesn't exist", scimID)
+	}
+
+	return group, nil
+}
+
+// scimScan checks filter against the records that the database returns for
+// column, a page at a time, and returns the resources of the ones that match.
+// scan reads the records after a pk, and resource returns the pk, attributes
+// and resource of a record. it fails with tooMany rather than check more than
+// maxScan records.
+func scimScan[T any](filter scimFilter, maxScan int,
+	column *database.ColumnFilter,
+	scan func(column *database.ColumnFilter, pk int64, limit int) ([]T, error),
+	resource func(T) (int64, scimAttributes, interface{})) (
+	[]interface{}, error) {
+
+	matched := []interface{}{}
+	var pk int64
+	for scanned := 0; ; {
+		records, err := scan(column, pk, PaginationLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		scanned += len(records)
+		if scanned > maxScan {
+			return nil, he.BadRequest.Wrap(scimTooManyErr.New(
+				"the filter matches more than %d resources. filter on id, "+
+					"userName or displayName with eq or sw to narrow it down",
+				maxScan))
+		}
+
+		for _, record := range records {
+			var attrs scimAttributes
+			var res interface{}
+			pk, attrs, res = resource(record)
+			if filter.matches(attrs) {
+				matched = append(matched, res)
+			}
+		}
+
+		if len(records) < PaginationLimit {
+			return matched, nil
+		}
+	}
+}
+
+// scimMembers is the member list of a group, keyed by user uuid, while PATCH
+// operations are being applied to it
+type scimMembers struct {
+	uuids   []string
+	userIDs map[string]string // uuid -> userid
+}
+
+func newSCIMMembers() *scimMembers {
+	return &scimMembers{userIDs: map[string]string{}}
+}
+
+func (m *scimMembers) add(uuid, userID string) {
+	if _, ok := m.userIDs[uuid]; !ok {
+		m.uuids = append(m.uuids, uuid)
+	}
+	m.userIDs[uuid] = userID
+}
+
+// removeWhere removes every member that matches the provided filter. members
+// expose the `value` (uuid) and `display` (userid) attributes.
+func (m *scimMembers) removeWhere(filter scimFilter) {
+	kept := m.uuids[:0]
+	for _, uuid := range m.uuids {
+		userID := m.userIDs[uuid]
+		attrs := func(path string) []string {
+			switch path {
+			case "value":
+				return []string{uuid}
+			case "display":
+				return []string{userID}
+			}
+			return nil
+		}
+
+		if filter.matches(attrs) {
+			delete(m.userIDs, uuid)
+			continue
+		}
+		kept = append(kept, uuid)
+	}
+	m.uuids = kept
+}
+
+func (m *scimMembers) clear() {
+	m.uuids = nil
+	m.userIDs = map[string]string{}
+}
+
+func (m *scimMembers) list() []string {
+	s := make([]string, 0, len(m.uuids))
+	for _, uuid := range m.uuids {
+		s = append(s, m.userIDs[uuid])
+	}
+	return s
+}
+
+// scimResolveMembers looks up the users referenced by SCIM member values and
+// adds them to the member list. unknown users are rejected.
+func (s *Server) scimResolveMembers(ctx context.Context, members *scimMembers,
+	values []SCIMMember) error {
+
+	for _, value := range values {
+		if value.Value == "" {
+			return he.BadRequest.Wrap(scimValueErr.New("member value missing"))
+		}
+
+		user, err := s.DB.In(ctx).Find_User_By_Uuid(ctx,
+			database.User_Uuid(value.Value))
+		if err != nil {
+			return err
+		}
+
+		if user == nil {
+			return he.BadRequest.Wrap(scimValueErr.New("user %q doesn't exist",
+				value.Value))
+		}
+
+		members.add(user.Uuid, user.Id)
+	}
+
+	return nil
+}
+
+// scimSetMembers replaces the group membership with the provided list and
+// returns the resulting members
+func (s *Server) scimSetMembers(ctx context.Context, group *database.Group,
+	members *scimMembers) ([]*database.User, error) {
+
+	var added, removed, unchanged int
+	err := s.recordGroupMemberships(ctx, group.Name,
+		func(ctx context.Context) (err error) {
+			err = s.checkNewMembers(ctx, group.Name, members.list())
+			if err != nil {
+				return err
+			}
+			added, removed, unchanged, err = s.DB.SetGroupMembership(ctx,
+				group.Name, members.list())
+			return err
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	logging.Entry(ctx).Debugf("memberships - added: %d, removed: %d, "+
+		"unchanged: %d", added, removed, unchanged)
+
+	countMemberships(ctx, added-removed)
+
+	return s.DB.In(ctx).All_User_By_Group_Name(ctx,
+		database.Group_Name(group.Name))
+}
+
+// scimApplyPatch applies a single PATCH operation to the member list
+func (s *Server) scimApplyPatch(ctx context.Context, group *database.Group,
+	members *scimMembers, op SCIMPatchOp) error {
+
+	opName := strings.ToLower(op.Op)
+	switch opName {
+	case "add", "remove", "replace":
+	default:
+		return he.BadRequest.Wrap(scimValueErr.New("unknown op %q", op.Op))
+	}
+
+	// the filter of a path is parsed from raw, since its values keep their
+	// case
+	raw := trimSCIMSchema(strings.TrimSpace(op.Path))
+	path := strings.ToLower(raw)
+
+	// a value filter, like `members[value eq "<uuid>"]`, selects the members
+	// an operation applies to
+	if i := strings.Index(raw, "["); i != -1 {
+		if strings.ToLower(raw[:i]) != "members" ||
+			!strings.HasSuffix(raw, "]") {
+			return he.BadRequest.Wrap(scimPathErr.New("unsupported path %q",
+				op.Path))
+		}
+		if opName != "remove" {
+			return he.BadRequest.Wrap(scimPathErr.New(
+				"value filters are only supported for remove"))
+		}
+
+		filter, err := parseSCIMFilter(raw[i+1 : len(raw)-1])
+		if err != nil {
+			return he.BadRequest.Wrap(err)
+		}
+
+		members.removeWhere(filter)
+		return nil
+	}
+
+	switch path {
+	case "":
+		// without a path, the value is a partial group to merge in
+		if opName == "remove" {
+			return he.BadRequest.Wrap(scimPathErr.New("remove requires a path"))
+		}
+
+		partial := SCIMGroup{}
+		err := json.Unmarshal(op.Value, &partial)
+		if err != nil {
+			return he.BadRequest.Wrap(scimValueErr.Wrap(err))
+		}
+
+		err = s.scimRename(ctx, group, partial.DisplayName)
+		if err != nil {
+			return err
+		}
+
+		if partial.Members == nil {
+			return nil
+		}
+		if opName == "replace" {
+			members.clear()
+		}
+		return s.scimResolveMembers(ctx, members, partial.Members)
+
+	case "displayname":
+		if opName == "remove" {
+			return he.BadRequest.Wrap(
+				scimMutabilityErr.New("displayName is required"))
+		}
+
+		var displayName string
+		err := json.Unmarshal(op.Value, &displayName)
+		if err != nil {
+			return he.BadRequest.Wrap(scimValueErr.Wrap(err))
+		}
+		return s.scimRename(ctx, group, displayName)
+
+	case "members":
+		values, err := parseSCIMMemberValues(op.Value)
+		if err != nil {
+			return err
+		}
+
+		switch opName {
+		case "add":
+			return s.scimResolveMembers(ctx, members, values)
+		case "replace":
+			members.clear()
+			return s.scimResolveMembers(ctx, members, values)
+		}
+
+		// remove without a value removes every member
+		if len(values) == 0 {
+			members.clear()
+			return nil
+		}
+
+		for _, value := range values {
+			members.removeWhere(&scimCompare{path: "value", op: "eq",
+				value: value.Value})
+		}
+		return nil
+	}
+
+	return he.BadRequest.Wrap(scimPathErr.New("unsupported path %q", op.Path))
+}
+
+// scimApplyUserPatch applies a single PATCH operation to userJSON
+func scimApplyUserPatch(userJSON *SCIMUser, op SCIMPatchOp) error {
+	switch strings.ToLower(op.Op) {
+	case "add", "replace":
+	case "remove":
+		return he.BadRequest.Wrap(scimMutabilityErr.New(
+			"%s can't be removed", op.Path))
+	default:
+		return he.BadRequest.Wrap(scimValueErr.New("unknown op %q", op.Op))
+	}
+
+	path := strings.ToLower(trimSCIMSchema(strings.TrimSpace(op.Path)))
+	switch path {
+	case "":
+		// without a path, the value is a partial user to merge in
+		partial := SCIMUser{}
+		err := json.Unmarshal(op.Value, &partial)
+		if err != nil {
+			return he.BadRequest.Wrap(scimValueErr.Wrap(err))
+		}
+
+		if partial.UserName != "" {
+			userJSON.UserName = partial.UserName
+		}
+		if partial.Name != nil && partial.Name.GivenName != "" {
+			userJSON.Name.GivenName = partial.Name.GivenName
+		}
+		if partial.Name != nil && partial.Name.FamilyName != "" {
+			userJSON.Name.FamilyName = partial.Name.FamilyName
+		}
+		if partial.Active != nil {
+			userJSON.Active = partial.Active
+		}
+		return nil
+
+	case "active":
+		active, err := parseSCIMBool(op.Value)
+		if err != nil {
+			return err
+		}
+		userJSON.Active = &active
+		return nil
+
+	case "username":
+		return setSCIMString(&userJSON.UserName, op.Value)
+	case "name.givenname":
+		return setSCIMString(&userJSON.Name.GivenName, op.Value)
+	case "name.familyname":
+		return setSCIMString(&userJSON.Name.FamilyName, op.Value)
+	}
+
+	return he.BadRequest.Wrap(scimPathErr.New("unsupported path %q", op.Path))
+}
+
+// setSCIMString sets dst to the string in raw
+func setSCIMString(dst *string, raw json.RawMessage) error {
+	err := json.Unmarshal(raw, dst)
+	if err != nil {
+		return he.BadRequest.Wrap(scimValueErr.Wrap(err))
+	}
+	return nil
+}
+
+// parseSCIMBool accepts a boolean or a string of one, since some identity
+// providers send "False"
+func parseSCIMBool(raw json.RawMessage) (bool, error) {
+	var b bool
+	err := json.Unmarshal(raw, &b)
+	if err == nil {
+		return b, nil
+	}
+
+	var str string
+	if json.Unmarshal(raw, &str) == nil {
+		b, strErr := strconv.ParseBool(str)
+		if strErr == nil {
+			return b, nil
+		}
+	}
+
+	return false, he.BadRequest.Wrap(scimValueErr.Wrap(err))
+}
+
+// parseSCIMMemberValues accepts either a list of members or a single member,
+// since identity providers disagree on which one to send
+func parseSCIMMemberValues(raw json.RawMessage) ([]SCIMMember, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var values []SCIMMember
+	err := json.Unmarshal(raw, &values)
+	if err == nil {
+		return values, nil
+	}
+
+	var value SCIMMember
+	if json.Unmarshal(raw, &value) == nil {
+		return []SCIMMember{value}, nil
+	}
+
+	return nil, he.BadRequest.Wrap(scimValueErr.Wrap(err))
+}
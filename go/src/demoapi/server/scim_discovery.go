@@ -0,0 +1,272 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
+
+	h "demoapi/handler"
+	he "demoapi/httperror"
+)
+
+// This file contains the static SCIM discovery endpoints that describe which
+// parts of the spec this service provider supports.
+
+type SCIMServiceProviderConfig struct {
+	Schemas               []string                   `json:"schemas"`
+	DocumentationURI      string                     `json:"documentationUri,omitempty"`
+	Patch                 SCIMSupported              `json:"patch"`
+	Bulk                  SCIMBulk                   `json:"bulk"`
+	Filter                SCIMFilterSupport          `json:"filter"`
+	ChangePassword        SCIMSupported              `json:"changePassword"`
+	Sort                  SCIMSupported              `json:"sort"`
+	ETag                  SCIMSupported              `json:"etag"`
+	AuthenticationSchemes []SCIMAuthenticationScheme `json:"authenticationSchemes"`
+	Meta                  *SCIMMeta                  `json:"meta,omitempty"`
+}
+
+type SCIMSupported struct {
+	Supported bool `json:"supported"`
+}
+
+type SCIMBulk struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+type SCIMFilterSupport struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type SCIMAuthenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Primary     bool   `json:"primary,omitempty"`
+}
+
+type SCIMSchemaDefinition struct {
+	Schemas     []string        `json:"schemas"`
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Attributes  []SCIMAttribute `json:"attributes"`
+	Meta        *SCIMMeta       `json:"meta,omitempty"`
+}
+
+type SCIMAttribute struct {
+	Name          string          `json:"name"`
+	Type          string          `json:"type"`
+	MultiValued   bool            `json:"multiValued"`
+	Required      bool            `json:"required"`
+	CaseExact     bool            `json:"caseExact"`
+	Mutability    string          `json:"mutability"`
+	Returned      string          `json:"returned"`
+	Uniqueness    string          `json:"uniqueness"`
+	SubAttributes []SCIMAttribute `json:"subAttributes,omitempty"`
+}
+
+type SCIMResourceTypeDefinition struct {
+	Schemas     []string  `json:"schemas"`
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Endpoint    string    `json:"endpoint"`
+	Description string    `json:"description"`
+	Schema      string    `json:"schema"`
+	Meta        *SCIMMeta `json:"meta,omitempty"`
+}
+
+// scimStringAttr is a helper for the common single valued string attribute
+func scimStringAttr(name, mutability, uniqueness string,
+	required bool) SCIMAttribute {
+	return SCIMAttribute{
+		Name:       name,
+		Type:       "string",
+		Required:   required,
+		CaseExact:  scimCaseExact[strings.ToLower(name)],
+		Mutability: mutability,
+		Returned:   "default",
+		Uniqueness: uniqueness,
+	}
+}
+
+// scimMemberAttr describes the complex `members` and `groups` attributes
+func scimMemberAttr(name, mutability string) SCIMAttribute {
+	return SCIMAttribute{
+		Name:        name,
+		Type:        "complex",
+		MultiValued: true,
+		Mutability:  mutability,
+		Returned:    "default",
+		Uniqueness:  "none",
+		SubAttributes: []SCIMAttribute{
+			scimStringAttr("value", mutability, "none", false),
+			scimStringAttr("display", "readOnly", "none", false),
+			scimStringAttr("$ref", mutability, "none", false),
+		},
+	}
+}
+
+var (
+	scimUserSchemaDefinition = SCIMSchemaDefinition{
+		Schemas:     []string{scimSchemaSchema},
+		ID:          scimUserSchema,
+		Name:        "User",
+		Description: "User Account",
+		Attributes: []SCIMAttribute{
+			scimStringAttr("userName", "readWrite", "server", true),
+			{
+				Name:       "name",
+				Type:       "complex",
+				Required:   true,
+				Mutability: "readWrite",
+				Returned:   "default",
+				Uniqueness: "none",
+				SubAttributes: []SCIMAttribute{
+					scimStringAttr("formatted", "readOnly", "none", false),
+					scimStringAttr("givenName", "readWrite", "none", true),
+					scimStringAttr("familyName", "readWrite", "none", true),
+				},
+			},
+			{
+				Name:       "active",
+				Type:       "boolean",
+				Mutability: "readWrite",
+				Returned:   "default",
+				Uniqueness: "none",
+			},
+			scimMemberAttr("groups", "readOnly"),
+		},
+	}
+
+	scimGroupSchemaDefinition = SCIMSchemaDefinition{
+		Schemas:     []string{scimSchemaSchema},
+		ID:          scimGroupSchema,
+		Name:        "Group",
+		Description: "Group",
+		Attributes: []SCIMAttribute{
+			scimStringAttr("displayName", "readWrite", "server", true),
+			scimMemberAttr("members", "readWrite"),
+		},
+	}
+
+	scimUserResourceType = SCIMResourceTypeDefinition{
+		Schemas:     []string{scimResourceTypeSchema},
+		ID:          "User",
+		Name:        "User",
+		Endpoint:    "/Users",
+		Description: "User Account",
+		Schema:      scimUserSchema,
+	}
+
+	scimGroupResourceType = SCIMResourceTypeDefinition{
+		Schemas:     []string{scimResourceTypeSchema},
+		ID:          "Group",
+		Name:        "Group",
+		Endpoint:    "/Groups",
+		Description: "Group",
+		Schema:      scimGroupSchema,
+	}
+)
+
+// SCIMServiceProviderConfig describes the SCIM features that are supported
+// `GET /scim/v2/ServiceProviderConfig`
+func (s *Server) SCIMServiceProviderConfig(ctx context.Context,
+	w http.ResponseWriter, r *http.Request) (*h.Response, error) {
+
+	return h.OK(&SCIMServiceProviderConfig{
+		Schemas: []string{scimSPConfigSchema},
+		Patch:   SCIMSupported{Supported: true},
+		Bulk:    SCIMBulk{Supported: false},
+		Filter:  SCIMFilterSupport{Supported: true, MaxResults: PaginationLimit},
+		Sort:    SCIMSupported{Supported: false},
+		ETag:    SCIMSupported{Supported: false},
+		AuthenticationSchemes: []SCIMAuthenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "OAuth Bearer Token",
+			Description: "Authentication with a bearer token",
+			Primary:     true,
+		}},
+		Meta: &SCIMMeta{
+			ResourceType: "ServiceProviderConfig",
+			Location:     scimLocation(r, "ServiceProviderConfig", ""),
+		},
+	}), nil
+}
+
+// SCIMSchemas lists the User and Group schemas
+// `GET /scim/v2/Schemas`
+func (s *Server) SCIMSchemas(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	resources := []interface{}{
+		scimSchemaWithMeta(r, scimUserSchemaDefinition),
+		scimSchemaWithMeta(r, scimGroupSchemaDefinition),
+	}
+	return h.OK(scimList(1, len(resources), resources)), nil
+}
+
+// SCIMSchema returns a single schema by its urn
+// `GET /scim/v2/Schemas/<urn>`
+func (s *Server) SCIMSchema(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	switch schemaID := chi.URLParam(r, "schemaID"); schemaID {
+	case scimUserSchema:
+		return h.OK(scimSchemaWithMeta(r, scimUserSchemaDefinition)), nil
+	case scimGroupSchema:
+		return h.OK(scimSchemaWithMeta(r, scimGroupSchemaDefinition)), nil
+	default:
+		return nil, he.NotFound.New("schema %q doesn't exist", schemaID)
+	}
+}
+
+// SCIMResourceTypes lists the User and Group resource types
+// `GET /scim/v2/ResourceTypes`
+func (s *Server) SCIMResourceTypes(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	resources := []interface{}{
+		scimResourceTypeWithMeta(r, scimUserResourceType),
+		scimResourceTypeWithMeta(r, scimGroupResourceType),
+	}
+	return h.OK(scimList(1, len(resources), resources)), nil
+}
+
+// SCIMResourceType returns a single resource type by name
+// `GET /scim/v2/ResourceTypes/<name>`
+func (s *Server) SCIMResourceType(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+
+	switch resourceType := chi.URLParam(r, "resourceType"); resourceType {
+	case scimUserResourceType.ID:
+		return h.OK(scimResourceTypeWithMeta(r, scimUserResourceType)), nil
+	case scimGroupResourceType.ID:
+		return h.OK(scimResourceTypeWithMeta(r, scimGroupResourceType)), nil
+	default:
+		return nil, he.NotFound.New("resource type %q doesn't exist",
+			resourceType)
+	}
+}
+
+func scimSchemaWithMeta(r *http.Request,
+	d SCIMSchemaDefinition) *SCIMSchemaDefinition {
+	d.Meta = &SCIMMeta{
+		ResourceType: "Schema",
+		Location:     scimLocation(r, "Schemas", d.ID),
+	}
+	return &d
+}
+
+func scimResourceTypeWithMeta(r *http.Request,
+	d SCIMResourceTypeDefinition) *SCIMResourceTypeDefinition {
+	d.Meta = &SCIMMeta{
+		ResourceType: "ResourceType",
+		Location:     scimLocation(r, "ResourceTypes", d.ID),
+	}
+	return &d
+}
@@ -0,0 +1,392 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/zeebo/errs"
+
+	"demoapi/database"
+)
+
+// This file contains a small parser and evaluator for SCIM filter expressions
+// as described in RFC 7644 section 3.4.2.2. It supports the attribute
+// operators (eq, ne, co, sw, ew, gt, ge, lt, le, pr), the logical operators
+// (and, or, not) and grouping with parentheses. Value path filters such as
+// `emails[type eq "work"]` are only understood by the PATCH path parser.
+
+var (
+	scimFilterErr = errs.Class("invalid filter")
+
+	// scimCaseExact are the attribute paths that are compared case sensitively.
+	// group names are unique in the database as they were typed, so their SCIM
+	// attributes are case exact too. userName isn't, as RFC 7643 section 4.1.1
+	// requires, so that identity providers can look users up in any case.
+	scimCaseExact = map[string]bool{
+		"id":              true,
+		"displayname":     true,
+		"value":           true,
+		"display":         true,
+		"members":         true,
+		"members.value":   true,
+		"members.display": true,
+		"groups":          true,
+		"groups.value":    true,
+		"groups.display":  true,
+	}
+)
+
+// scimAttributes resolves a lowercased SCIM attribute path (eg "username" or
+// "name.givenname") to all of its values on a resource
+type scimAttributes func(path string) []string
+
+type scimFilter interface {
+	matches(attrs scimAttributes) bool
+}
+
+type scimLogical struct {
+	op          string // "and" or "or"
+	left, right scimFilter
+}
+
+func (f *scimLogical) matches(attrs scimAttributes) bool {
+	if f.op == "and" {
+		return f.left.matches(attrs) && f.right.matches(attrs)
+	}
+	return f.left.matches(attrs) || f.right.matches(attrs)
+}
+
+type scimNot struct {
+	filter scimFilter
+}
+
+func (f *scimNot) matches(attrs scimAttributes) bool {
+	return !f.filter.matches(attrs)
+}
+
+type scimCompare struct {
+	path  string // lowercased attribute path
+	op    string // lowercased comparison operator
+	value string
+}
+
+func (f *scimCompare) matches(attrs scimAttributes) bool {
+	values := attrs(f.path)
+	if f.op == "pr" {
+		for _, v := range values {
+			if v != "" {
+				return true
+			}
+		}
+		return false
+	}
+
+	caseExact := scimCaseExact[f.path]
+	if f.op == "ne" {
+		for _, v := range values {
+			if compareSCIMValue("eq", v, f.value, caseExact) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, v := range values {
+		if compareSCIMValue(f.op, v, f.value, caseExact) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareSCIMValue(op, actual, expected string, caseExact bool) bool {
+	a, e := actual, expected
+	if !caseExact {
+		a, e = strings.ToLower(actual), strings.ToLower(expected)
+	}
+
+	switch op {
+	case "eq":
+		return a == e
+	case "co":
+		return strings.Contains(a, e)
+	case "sw":
+		return strings.HasPrefix(a, e)
+	case "ew":
+		return strings.HasSuffix(a, e)
+	case "gt":
+		return a > e
+	case "ge":
+		return a >= e
+	case "lt":
+		return a < e
+	case "le":
+		return a <= e
+	}
+	return false
+}
+
+// scimUserColumns and scimGroupColumns are the columns of the attribute
+// paths that the database can filter on
+var (
+	scimUserColumns = map[string]string{"id": "uuid", "username": "id",
+		"name.givenname": "first_name", "name.familyname": "last_name"}
+	scimGroupColumns = map[string]string{"id": "uuid", "displayname": "name"}
+)
+
+// scimColumnFilter returns a condition on one of columns that every resource
+// that matches f meets, or nil if there isn't one. it lets the database
+// narrow down the resources that f is checked against, like for the common
+// `userName eq "x"` filters. only eq and sw are pushed down, and only with
+// ascii values if they aren't case exact, since sqlite only lowercases ascii.
+func scimColumnFilter(f scimFilter,
+	columns map[string]string) *database.ColumnFilter {
+
+	switch f := f.(type) {
+	case *scimCompare:
+		column, ok := columns[f.path]
+		if !ok || (f.op != "eq" && f.op != "sw") {
+			return nil
+		}
+		caseExact := scimCaseExact[f.path]
+		if !caseExact && !isASCII(f.value) {
+			return nil
+		}
+		return &database.ColumnFilter{Column: column, Value: f.value,
+			Prefix: f.op == "sw", CaseExact: caseExact}
+	case *scimLogical:
+		if f.op != "and" {
+			return nil
+		}
+		if c := scimColumnFilter(f.left, columns); c != nil {
+			return c
+		}
+		return scimColumnFilter(f.right, columns)
+	}
+	return nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// parseSCIMFilter parses a SCIM filter expression
+func parseSCIMFilter(filter string) (scimFilter, error) {
+	tokens, err := tokenizeSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &scimFilterParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.done() {
+		return nil, scimFilterErr.New("unexpected %q", p.peek().text)
+	}
+	return f, nil
+}
+
+type scimToken struct {
+	text   string
+	quoted bool
+}
+
+func tokenizeSCIMFilter(filter string) ([]scimToken, error) {
+	var tokens []scimToken
+	runes := []rune(filter)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == '[' || r == ']':
+			tokens = append(tokens, scimToken{text: string(r)})
+			i++
+		case r == '"':
+			var b strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				b.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, scimFilterErr.New("unterminated string")
+			}
+			tokens = append(tokens, scimToken{text: b.String(), quoted: true})
+			i++
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) &&
+				!strings.ContainsRune("()[]\"", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, scimToken{text: string(runes[start:i])})
+		}
+	}
+
+	if len(tokens) == 0 {
+		return nil, scimFilterErr.New("empty filter")
+	}
+	return tokens, nil
+}
+
+type scimFilterParser struct {
+	tokens []scimToken
+	pos    int
+}
+
+func (p *scimFilterParser) done() bool { return p.pos >= len(p.tokens) }
+
+func (p *scimFilterParser) peek() scimToken {
+	if p.done() {
+		return scimToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *scimFilterParser) next() scimToken {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// keyword reports whether the next token is the unquoted keyword provided
+func (p *scimFilterParser) keyword(kw string) bool {
+	t := p.peek()
+	return !t.quoted && strings.EqualFold(t.text, kw)
+}
+
+func (p *scimFilterParser) parseOr() (scimFilter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &scimLogical{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *scimFilterParser) parseAnd() (scimFilter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &scimLogical{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *scimFilterParser) parseUnary() (scimFilter, error) {
+	if p.keyword("not") {
+		p.next()
+		f, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+		return &scimNot{filter: f}, nil
+	}
+
+	if p.peek().text == "(" && !p.peek().quoted {
+		return p.parseGroup()
+	}
+
+	return p.parseCompare()
+}
+
+func (p *scimFilterParser) parseGroup() (scimFilter, error) {
+	if t := p.next(); t.text != "(" || t.quoted {
+		return nil, scimFilterErr.New("expected \"(\"")
+	}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.next(); t.text != ")" || t.quoted {
+		return nil, scimFilterErr.New("expected \")\"")
+	}
+	return f, nil
+}
+
+func (p *scimFilterParser) parseCompare() (scimFilter, error) {
+	path := p.next()
+	if path.quoted || path.text == "" {
+		return nil, scimFilterErr.New("expected attribute path")
+	}
+
+	op := p.next()
+	opText := strings.ToLower(op.text)
+	switch opText {
+	case "pr":
+		return &scimCompare{path: normalizeSCIMPath(path.text), op: opText}, nil
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return nil, scimFilterErr.New("unknown operator %q", op.text)
+	}
+
+	value := p.next()
+	if value.text == "" && !value.quoted {
+		return nil, scimFilterErr.New("missing value for %q", path.text)
+	}
+	if !value.quoted && !validSCIMLiteral(value.text) {
+		return nil, scimFilterErr.New("bad value %q", value.text)
+	}
+
+	return &scimCompare{
+		path:  normalizeSCIMPath(path.text),
+		op:    opText,
+		value: value.text,
+	}, nil
+}
+
+// validSCIMLiteral checks that an unquoted comparison value is one of the
+// literals SCIM allows: true, false, null or a number
+func validSCIMLiteral(v string) bool {
+	switch strings.ToLower(v) {
+	case "true", "false", "null":
+		return true
+	}
+	_, err := strconv.ParseFloat(v, 64)
+	return err == nil
+}
+
+// normalizeSCIMPath lowercases an attribute path and strips the core schema
+// urn prefix if a client sent a fully qualified path
+func normalizeSCIMPath(path string) string {
+	return strings.ToLower(trimSCIMSchema(path))
+}
+
+// trimSCIMSchema strips the core schema from a path, keeping its case
+func trimSCIMSchema(path string) string {
+	for _, schema := range []string{scimUserSchema, scimGroupSchema} {
+		prefix := schema + ":"
+		if len(path) >= len(prefix) &&
+			strings.EqualFold(path[:len(prefix)], prefix) {
+			return path[len(prefix):]
+		}
+	}
+	return path
+}
@@ -0,0 +1,448 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"demoapi/database"
+	he "demoapi/httperror"
+)
+
+func TestSCIMFilter(t *testing.T) {
+	active := true
+	user := &SCIMUser{
+		ID:       "1234",
+		UserName: "jsmith",
+		Name:     &SCIMName{GivenName: "Joe", FamilyName: "Smith"},
+		Active:   &active,
+	}
+
+	matches := map[string]bool{
+		`userName eq "jsmith"`:                              true,
+		`USERNAME eq "jsmith"`:                              true,
+		`userName eq "JSMITH"`:                              true,
+		`name.givenName eq "joe"`:                           true,
+		`userName eq "nope"`:                                false,
+		`userName ne "nope"`:                                true,
+		`name.givenName sw "J" and name.familyName ew "th"`: true,
+		`name.givenName sw "J" and name.familyName ew "x"`:  false,
+		`userName eq "x" or (active eq true)`:               true,
+		`not (userName co "mit")`:                           false,
+		`name.formatted pr`:                                 false,
+		`id pr`:                                             true,
+		scimUserSchema + `:userName eq "jsmith"`:            true,
+	}
+
+	for filter, expected := range matches {
+		f, err := parseSCIMFilter(filter)
+		assert.NoError(t, err, filter)
+		assert.Equal(t, expected, f.matches(user.scimAttr), filter)
+	}
+
+	for _, filter := range []string{``, `userName`, `userName xx "a"`,
+		`userName eq "a`, `(userName eq "a"`, `userName eq a`} {
+		_, err := parseSCIMFilter(filter)
+		assert.Error(t, err, filter)
+		assert.True(t, scimFilterErr.Has(err), filter)
+	}
+}
+
+func TestSCIMColumnFilter(t *testing.T) {
+	columns := map[string]*database.ColumnFilter{
+		`userName eq "JSmith"`: {Column: "id", Value: "JSmith"},
+		`name.familyName sw "Sm"`: {Column: "last_name", Value: "Sm",
+			Prefix: true},
+		`id eq "1234"`: {Column: "uuid", Value: "1234", CaseExact: true},
+		`active eq true and userName sw "j"`: {Column: "id", Value: "j",
+			Prefix: true},
+		`userName co "j"`:                    nil,
+		`userName eq "j" or userName eq "k"`: nil,
+		`not (userName eq "j")`:              nil,
+		`userName eq "jörg"`:                 nil,
+	}
+
+	for filter, expected := range columns {
+		f, err := parseSCIMFilter(filter)
+		assert.NoError(t, err, filter)
+		assert.Equal(t, expected, scimColumnFilter(f, scimUserColumns), filter)
+	}
+}
+
+func TestSCIMUsers(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newUser(ctx, "user2")
+
+	w := scimRequest(t, http.MethodPost, "/Users", SCIMUser{
+		UserName: "jsmith",
+		Name:     &SCIMName{GivenName: "Joe", FamilyName: "Smith"},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, scimContentType, w.Result().Header.Get("Content-Type"))
+
+	created := SCIMUser{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	assert.NotEmpty(t, created.ID)
+	assert.Equal(t, "jsmith", created.UserName)
+	assert.Equal(t, created.Meta.Location, w.Header().Get("Location"))
+
+	// userName is unique
+	w = scimRequest(t, http.MethodPost, "/Users", SCIMUser{
+		UserName: "jsmith",
+		Name:     &SCIMName{GivenName: "Joe", FamilyName: "Smith"},
+	})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	scimErr := SCIMError{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&scimErr))
+	assert.Equal(t, "uniqueness", scimErr.SCIMType)
+	assert.Equal(t, "409", scimErr.Status)
+
+	w = scimRequest(t, http.MethodGet, "/Users?filter="+
+		url.QueryEscape(`userName eq "jsmith"`), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	list := scimTestList{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	assert.Equal(t, 1, list.TotalResults)
+	assert.Equal(t, created.ID, list.Resources[0].ID)
+
+	// userName is matched in any case
+	w = scimRequest(t, http.MethodGet, "/Users?filter="+
+		url.QueryEscape(`userName eq "JSMITH"`), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	list = scimTestList{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	assert.Equal(t, 1, list.TotalResults)
+	assert.Equal(t, created.ID, list.Resources[0].ID)
+
+	w = scimRequest(t, http.MethodGet, "/Users?startIndex=2&count=1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	list = scimTestList{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	assert.Equal(t, 3, list.TotalResults)
+	assert.Equal(t, 2, list.StartIndex)
+	assert.Equal(t, 1, list.ItemsPerPage)
+	assert.Equal(t, "user2", list.Resources[0].UserName)
+
+	w = scimRequest(t, http.MethodGet, "/Users?filter="+
+		url.QueryEscape(`userName sw "user"`)+"&count=1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	list = scimTestList{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	assert.Equal(t, 2, list.TotalResults)
+	assert.Equal(t, 1, len(list.Resources))
+
+	// filters the database can't narrow down are still checked
+	w = scimRequest(t, http.MethodGet, "/Users?filter="+
+		url.QueryEscape(`name.familyName sw "SM" and userName co "mit"`),
+		nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	list = scimTestList{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	assert.Equal(t, 1, list.TotalResults)
+	assert.Equal(t, created.ID, list.Resources[0].ID)
+
+	w = scimRequest(t, http.MethodGet, "/Users?filter="+
+		url.QueryEscape(`userName ew "2" or name.familyName eq "smith"`),
+		nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	list = scimTestList{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	assert.Equal(t, 2, list.TotalResults)
+
+	w = scimRequest(t, http.MethodGet, "/Users?filter=bogus", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	scimErr = SCIMError{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&scimErr))
+	assert.Equal(t, "invalidFilter", scimErr.SCIMType)
+
+	w = scimRequest(t, http.MethodDelete, "/Users/"+created.ID, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = scimRequest(t, http.MethodGet, "/Users/"+created.ID, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSCIMUserActive(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	id := t.scimUUID(ctx, "user1")
+	status := func(userID string) string {
+		user, err := t.server.getUser(ctx, userID)
+		assert.NoError(t, err)
+		return user.Status
+	}
+	patch := func(ops ...SCIMPatchOp) *httptest.ResponseRecorder {
+		return scimRequest(t, http.MethodPatch, "/Users/"+id,
+			SCIMPatchRequest{Schemas: []string{scimPatchSchema},
+				Operations: ops})
+	}
+	inactive, active := false, true
+
+	// deactivating a user suspends it, and activating it activates it again
+	w := patch(SCIMPatchOp{Op: "replace",
+		Value: json.RawMessage(`{"active": false}`)})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	patched := SCIMUser{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&patched))
+	assert.False(t, *patched.Active)
+	assert.Equal(t, userSuspended, status("user1"))
+
+	w = patch(SCIMPatchOp{Op: "replace", Path: "active",
+		Value: json.RawMessage(`"True"`)},
+		SCIMPatchOp{Op: "replace", Path: "name.givenName",
+			Value: json.RawMessage(`"Joe"`)})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, userActive, status("user1"))
+	user, err := t.server.getUser(ctx, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Joe", user.FirstName)
+
+	w = patch(SCIMPatchOp{Op: "remove", Path: "active"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = patch(SCIMPatchOp{Op: "replace", Path: "emails",
+		Value: json.RawMessage(`[]`)})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// a PUT without active keeps the status
+	name := &SCIMName{GivenName: "Joe", FamilyName: "Smith"}
+	w = scimRequest(t, http.MethodPut, "/Users/"+id, SCIMUser{
+		UserName: "user1", Name: name, Active: &inactive})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, userSuspended, status("user1"))
+	w = scimRequest(t, http.MethodPut, "/Users/"+id, SCIMUser{
+		UserName: "user1", Name: name})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, userSuspended, status("user1"))
+
+	// a user can be created inactive
+	w = scimRequest(t, http.MethodPost, "/Users", SCIMUser{
+		UserName: "user2", Name: name, Active: &inactive})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, userSuspended, status("user2"))
+
+	// deprovisioning is final
+	_, err = t.server.setUserStatus(ctx, "user1", userDeprovisioned, "left")
+	assert.NoError(t, err)
+	w = scimRequest(t, http.MethodPut, "/Users/"+id, SCIMUser{
+		UserName: "user1", Name: name, Active: &active})
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	assert.Equal(t, userDeprovisioned, status("user1"))
+}
+
+func TestSCIMGroupPatch(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newUser(ctx, "user2")
+	t.newUser(ctx, "user3")
+	user1 := t.scimUUID(ctx, "user1")
+	user2 := t.scimUUID(ctx, "user2")
+	user3 := t.scimUUID(ctx, "user3")
+
+	w := scimRequest(t, http.MethodPost, "/Groups", SCIMGroup{
+		DisplayName: "group1",
+		Members:     []SCIMMember{{Value: user1}},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	group := SCIMGroup{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&group))
+	assert.Equal(t, 1, len(group.Members))
+
+	w = scimRequest(t, http.MethodPost, "/Groups",
+		SCIMGroup{DisplayName: "group1"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	scimErr := SCIMError{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&scimErr))
+	assert.Equal(t, "uniqueness", scimErr.SCIMType)
+
+	w = scimRequest(t, http.MethodPatch, "/Groups/"+group.ID, SCIMPatchRequest{
+		Schemas: []string{scimPatchSchema},
+		Operations: []SCIMPatchOp{
+			{Op: "add", Path: "members",
+				Value: json.RawMessage(`[{"value":"` + user2 + `"},{"value":"` +
+					user3 + `"}]`)},
+			{Op: "remove", Path: " " + scimGroupSchema +
+				`:members[value eq "` + user1 + `"]`},
+		},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	users, err := t.server.DB.All_User_By_Group_Name(ctx,
+		database.Group_Name("group1"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(users))
+	assert.Equal(t, "user2", users[0].Id)
+	assert.Equal(t, "user3", users[1].Id)
+
+	w = scimRequest(t, http.MethodPatch, "/Groups/"+group.ID, SCIMPatchRequest{
+		Operations: []SCIMPatchOp{
+			{Op: "Replace", Value: json.RawMessage(`{"members":[{"value":"` +
+				user1 + `"}]}`)},
+		},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	group = SCIMGroup{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&group))
+	assert.Equal(t, 1, len(group.Members))
+	assert.Equal(t, user1, group.Members[0].Value)
+	assert.Equal(t, "user1", group.Members[0].Display)
+
+	// unknown members are rejected without changing the group
+	w = scimRequest(t, http.MethodPatch, "/Groups/"+group.ID, SCIMPatchRequest{
+		Operations: []SCIMPatchOp{
+			{Op: "remove", Path: "members"},
+			{Op: "add", Path: "members",
+				Value: json.RawMessage(`{"value":"nobody"}`)},
+		},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	users, err = t.server.DB.All_User_By_Group_Name(ctx,
+		database.Group_Name("group1"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(users))
+
+	w = scimRequest(t, http.MethodGet, "/Groups?filter="+
+		url.QueryEscape(`displayName eq "group1"`), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	list := scimTestList{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	assert.Equal(t, 1, list.TotalResults)
+	assert.Equal(t, group.ID, list.Resources[0].ID)
+
+	// renames go through the same update as the rest api
+	t.newGroup(ctx, "taken")
+	w = scimRequest(t, http.MethodPatch, "/Groups/"+group.ID, SCIMPatchRequest{
+		Operations: []SCIMPatchOp{
+			{Op: "replace", Path: "displayName",
+				Value: json.RawMessage(`"taken"`)},
+		},
+	})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = scimRequest(t, http.MethodPatch, "/Groups/"+group.ID, SCIMPatchRequest{
+		Operations: []SCIMPatchOp{
+			{Op: "replace", Path: "displayName",
+				Value: json.RawMessage(`"group2"`)},
+		},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	group = SCIMGroup{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&group))
+	assert.Equal(t, "group2", group.DisplayName)
+	assert.Equal(t, 1, len(group.Members))
+
+	w = scimRequest(t, http.MethodPut, "/Groups/"+group.ID, SCIMGroup{
+		DisplayName: "group3",
+		Members:     []SCIMMember{{Value: user2}},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	group = SCIMGroup{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&group))
+	assert.Equal(t, "group3", group.DisplayName)
+	assert.Equal(t, 1, len(group.Members))
+	assert.Equal(t, user2, group.Members[0].Value)
+
+	// deletes record the memberships that went with the user or group
+	w = scimRequest(t, http.MethodDelete, "/Users/"+user2, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	versions, err := t.server.DB.MembershipVersionsByGroupUuid(ctx, group.ID)
+	assert.NoError(t, err)
+	last := versions[len(versions)-1]
+	assert.Equal(t, database.MembershipRemoved, last.Action)
+	assert.Equal(t, user2, last.UserUuid)
+
+	w = scimRequest(t, http.MethodPatch, "/Groups/"+group.ID, SCIMPatchRequest{
+		Operations: []SCIMPatchOp{{Op: "add", Path: "members",
+			Value: json.RawMessage(`[{"value":"` + user3 + `"}]`)}},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = scimRequest(t, http.MethodDelete, "/Groups/"+group.ID, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	versions, err = t.server.DB.MembershipVersionsByUserUuid(ctx, user3)
+	assert.NoError(t, err)
+	last = versions[len(versions)-1]
+	assert.Equal(t, database.MembershipRemoved, last.Action)
+	assert.Equal(t, group.ID, last.GroupUuid)
+}
+
+type scimTestList struct {
+	TotalResults int `json:"totalResults"`
+	StartIndex   int `json:"startIndex"`
+	ItemsPerPage int `json:"itemsPerPage"`
+	Resources    []struct {
+		ID          string `json:"id"`
+		UserName    string `json:"userName"`
+		DisplayName string `json:"displayName"`
+	} `json:"Resources"`
+}
+
+func TestSCIMScanTooMany(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newUser(ctx, "user2")
+	t.newUser(ctx, "other")
+
+	filter, err := parseSCIMFilter(`userName co "user"`)
+	assert.NoError(t, err)
+	r := httptest.NewRequest(http.MethodGet, scimPrefix+"/Users", nil)
+	scan := func(column *database.ColumnFilter, pk int64, limit int) (
+		[]*database.User, error) {
+		return t.server.DB.ScanUsers(ctx, column, pk, limit)
+	}
+	resource := func(user *database.User) (
+		int64, scimAttributes, interface{}) {
+		return user.Pk, scimUser(r, user, nil).scimAttr, user.Id
+	}
+
+	matched, err := scimScan(filter, 3, nil, scan, resource)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"user1", "user2"}, matched)
+
+	_, err = scimScan(filter, 2, nil, scan, resource)
+	assert.True(t, he.BadRequest.Has(err))
+	assert.Equal(t, "tooMany",
+		scimError(http.StatusBadRequest, err).SCIMType)
+
+	// the database narrows the scan down below the cap
+	matched, err = scimScan(filter, 2, &database.ColumnFilter{
+		Column: "id", Value: "user", Prefix: true}, scan, resource)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"user1", "user2"}, matched)
+}
+
+func (st *serverTest) scimUUID(ctx context.Context, userID string) string {
+	user, err := st.server.DB.Get_User_By_Id(ctx, database.User_Id(userID))
+	assert.NoError(st, err)
+	return user.Uuid
+}
+
+func scimRequest(st *serverTest, method, target string,
+	body interface{}) *httptest.ResponseRecorder {
+
+	var buf bytes.Buffer
+	if body != nil {
+		assert.NoError(st, json.NewEncoder(&buf).Encode(body))
+	}
+
+	r := httptest.NewRequest(method, scimPrefix+target, &buf)
+	r.Header.Set("Content-Type", scimContentType)
+
+	w := httptest.NewRecorder()
+	st.server.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,261 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"demoapi/database"
+)
+
+const (
+	scimContentType = "application/scim+json"
+
+	scimUserSchema         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimGroupSchema        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimListSchema         = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimPatchSchema        = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	scimErrorSchema        = "urn:ietf:params:scim:api:messages:2.0:Error"
+	scimSPConfigSchema     = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	scimSchemaSchema       = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+	scimResourceTypeSchema = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+)
+
+type SCIMListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+type SCIMMeta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location,omitempty"`
+}
+
+type SCIMUser struct {
+	Schemas  []string     `json:"schemas"`
+	ID       string       `json:"id,omitempty"`
+	UserName string       `json:"userName"`
+	Name     *SCIMName    `json:"name,omitempty"`
+	Active   *bool        `json:"active,omitempty"`
+	Groups   []SCIMMember `json:"groups,omitempty"`
+	Meta     *SCIMMeta    `json:"meta,omitempty"`
+}
+
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName"`
+	FamilyName string `json:"familyName"`
+}
+
+type SCIMGroup struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	DisplayName string       `json:"displayName"`
+	Members     []SCIMMember `json:"members,omitempty"`
+	Meta        *SCIMMeta    `json:"meta,omitempty"`
+}
+
+type SCIMMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type SCIMPatchRequest struct {
+	Schemas    []string      `json:"schemas"`
+	Operations []SCIMPatchOp `json:"Operations"`
+}
+
+type SCIMPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type SCIMError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	SCIMType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// scimAttr resolves the lowercased attribute paths that can be used in a
+// filter on a user
+func (u *SCIMUser) scimAttr(path string) []string {
+	switch path {
+	case "id":
+		return []string{u.ID}
+	case "username":
+		return []string{u.UserName}
+	case "active":
+		if u.Active != nil && *u.Active {
+			return []string{"true"}
+		}
+		return []string{"false"}
+	case "name.givenname":
+		if u.Name != nil {
+			return []string{u.Name.GivenName}
+		}
+	case "name.familyname":
+		if u.Name != nil {
+			return []string{u.Name.FamilyName}
+		}
+	case "name.formatted":
+		if u.Name != nil {
+			return []string{u.Name.Formatted}
+		}
+	case "groups", "groups.value":
+		return scimMemberValues(u.Groups)
+	case "groups.display":
+		return scimMemberDisplays(u.Groups)
+	case "meta.created":
+		if u.Meta != nil {
+			return []string{u.Meta.Created}
+		}
+	}
+	return nil
+}
+
+// scimAttr resolves the lowercased attribute paths that can be used in a
+// filter on a group
+func (g *SCIMGroup) scimAttr(path string) []string {
+	switch path {
+	case "id":
+		return []string{g.ID}
+	case "displayname":
+		return []string{g.DisplayName}
+	case "members", "members.value":
+		return scimMemberValues(g.Members)
+	case "members.display":
+		return scimMemberDisplays(g.Members)
+	case "meta.created":
+		if g.Meta != nil {
+			return []string{g.Meta.Created}
+		}
+	}
+	return nil
+}
+
+func scimMemberValues(ms []SCIMMember) []string {
+	s := make([]string, 0, len(ms))
+	for _, m := range ms {
+		s = append(s, m.Value)
+	}
+	return s
+}
+
+func scimMemberDisplays(ms []SCIMMember) []string {
+	s := make([]string, 0, len(ms))
+	for _, m := range ms {
+		s = append(s, m.Display)
+	}
+	return s
+}
+
+// scimLocation builds the absolute url of a SCIM resource from the incoming
+// request, since SCIM requires meta.location and $ref to be full URIs
+func scimLocation(r *http.Request, resourceType, id string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	u := &url.URL{
+		Scheme: scheme,
+		Host:   r.Host,
+		Path:   scimPrefix + "/" + resourceType,
+	}
+	if id != "" {
+		u.Path += "/" + id
+	}
+	return u.String()
+}
+
+func scimTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func scimUser(r *http.Request, m *database.User,
+	groups []*database.Group) *SCIMUser {
+
+	active := m.Status == userActive
+	d := &SCIMUser{
+		Schemas:  []string{scimUserSchema},
+		ID:       m.Uuid,
+		UserName: m.Id,
+		Name: &SCIMName{
+			Formatted:  strings.TrimSpace(m.FirstName + " " + m.LastName),
+			GivenName:  m.FirstName,
+			FamilyName: m.LastName,
+		},
+		Active: &active,
+		Meta: &SCIMMeta{
+			ResourceType: "User",
+			Created:      scimTime(m.Created),
+			Location:     scimLocation(r, "Users", m.Uuid),
+		},
+	}
+
+	if len(groups) > 0 {
+		d.Groups = make([]SCIMMember, 0, len(groups))
+		for _, g := range groups {
+			d.Groups = append(d.Groups, SCIMMember{
+				Value:   g.Uuid,
+				Display: g.Name,
+				Ref:     scimLocation(r, "Groups", g.Uuid),
+			})
+		}
+	}
+
+	return d
+}
+
+func scimGroup(r *http.Request, m *database.Group,
+	users []*database.User) *SCIMGroup {
+
+	d := &SCIMGroup{
+		Schemas:     []string{scimGroupSchema},
+		ID:          m.Uuid,
+		DisplayName: m.Name,
+		Meta: &SCIMMeta{
+			ResourceType: "Group",
+			Created:      scimTime(m.Created),
+			Location:     scimLocation(r, "Groups", m.Uuid),
+		},
+	}
+
+	if len(users) > 0 {
+		d.Members = make([]SCIMMember, 0, len(users))
+		for _, u := range users {
+			d.Members = append(d.Members, SCIMMember{
+				Value:   u.Uuid,
+				Display: u.Id,
+				Ref:     scimLocation(r, "Users", u.Uuid),
+			})
+		}
+	}
+
+	return d
+}
+
+func scimList(startIndex, total int, resources []interface{}) *SCIMListResponse {
+	if resources == nil {
+		resources = []interface{}{}
+	}
+	return &SCIMListResponse{
+		Schemas:      []string{scimListSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	spanRecorderOnce sync.Once
+	spanRecorder     *tracetest.SpanRecorder
+)
+
+// recordSpans installs a tracer provider that records every span. the
+// tracers are bound to the first provider that's installed, so it's only
+// done once for all of the tests.
+func recordSpans() *tracetest.SpanRecorder {
+	spanRecorderOnce.Do(func() {
+		spanRecorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(
+			sdktrace.WithSpanProcessor(spanRecorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	return spanRecorder
+}
+
+func TestTracing(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	recorder := recordSpans()
+	t.newUser(ctx, "user1")
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/users/user1", nil)
+	r.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	t.server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() == traceID {
+			spans[span.Name()] = span
+		}
+	}
+
+	route := spans["GET /v1/users/{userID}"]
+	handler := spans["server.(*Server).GetUser"]
+	validate := spans["server.(*Server).ValidateRequest"]
+	query := spans["Find_User_By_Id"]
+	if assert.NotNil(t, route) && assert.NotNil(t, handler) &&
+		assert.NotNil(t, validate) && assert.NotNil(t, query) {
+
+		assert.Equal(t, "00f067aa0ba902b7", route.Parent().SpanID().String())
+		assert.Equal(t, validate.SpanContext().SpanID(),
+			handler.Parent().SpanID())
+		assert.Equal(t, handler.SpanContext().SpanID(),
+			query.Parent().SpanID())
+	}
+	assert.Contains(t, spans, "All_Group_By_User_Id")
+}
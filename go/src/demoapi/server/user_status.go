@@ -0,0 +1,261 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi"
+
+	"demoapi/database"
+	h "demoapi/handler"
+	he "demoapi/httperror"
+)
+
+// Users are active until they're suspended or deprovisioned, which keeps the
+// user and their memberships instead of deleting them. suspended users can be
+// activated again, but deprovisioning is final. every change of status is
+// recorded with its reason.
+const (
+	userActive        = "active"
+	userSuspended     = "suspended"
+	userDeprovisioned = "deprovisioned"
+
+	// activeOnlyParam leaves the users that aren't active out of the members
+	// of a group
+	activeOnlyParam = "active_only"
+)
+
+// userTransitions are the statuses a user can change to, by their status
+var userTransitions = map[string][]string{
+	userActive:    {userSuspended, userDeprovisioned},
+	userSuspended: {userActive, userDeprovisioned},
+}
+
+// SuspendUser suspends an active user. The body should contain the `reason`.
+// Responds with the user.
+// `POST /v1/users/<userID>:suspend`
+func (s *Server) SuspendUser(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+	return s.transitionUser(ctx, r, userSuspended)
+}
+
+// ActivateUser activates a suspended user. The body should contain the
+// `reason`. Responds with the user.
+// `POST /v1/users/<userID>:activate`
+func (s *Server) ActivateUser(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+	return s.transitionUser(ctx, r, userActive)
+}
+
+// DeprovisionUser deprovisions an active or suspended user for good. The
+// body should contain the `reason`. Responds with the user.
+// `POST /v1/users/<userID>:deprovision`
+func (s *Server) DeprovisionUser(ctx context.Context, w http.ResponseWriter,
+	r *http.Request) (*h.Response, error) {
+	return s.transitionUser(ctx, r, userDeprovisioned)
+}
+
+// GetUserTransitions returns every change of status of a user, oldest first
+// `GET /v1/users/<userID>/transitions`
+func (s *Server) GetUserTransitions(ctx context.Context,
+	w http.ResponseWriter, r *http.Request) (*h.Response, error) {
+
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		return nil, he.BadRequest.New("incomplete path. missing userID")
+	}
+
+	db := s.DB.In(ctx)
+	user, err := db.Find_User_By_Id(ctx, database.User_Id(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, he.CodeUserNotFound.New(
+			"userID %q doesn't exist", userID)
+	}
+
+	transitions, err := db.All_UserTransition_By_UserPk_OrderBy_Asc_Pk(ctx,
+		database.UserTransition_UserPk(user.Pk))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &RootJSON{
+		Transitions: apiTransitions(transitions),
+	}
+
+	return h.OK(resp), nil
+}
+
+// transitionUser is the handler of the action that changes the status of a
+// user to status
+func (s *Server) transitionUser(ctx context.Context, r *http.Request,
+	status string) (*h.Response, error) {
+
+	userID := chi.URLParam(r, "userID")
+	if userID == "" {
+		return nil, he.BadRequest.New("incomplete path. missing userID")
+	}
+
+	transitionJSON := Transition{}
+	err := h.Decode(r, &transitionJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.setUserStatus(ctx, userID, status, transitionJSON.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &RootJSON{
+		User: user,
+	}
+
+	return h.OK(resp), nil
+}
+
+// setUserStatus changes the status of an existing user and records why
+func (s *Server) setUserStatus(ctx context.Context, userID, status,
+	reason string) (user *User, err error) {
+
+	if reason == "" {
+		return nil, he.BadRequest.Wrap(he.InvalidFields{{Field: "reason",
+			Message: "is required"}})
+	}
+
+	err = s.DB.WithTx(ctx, func(ctx context.Context, tx *database.Tx) error {
+		db := s.DB.In(ctx)
+		u, err := db.Find_User_By_Id(ctx, database.User_Id(userID))
+		if err != nil {
+			return err
+		}
+
+		if u == nil {
+			return he.CodeUserNotFound.New(
+				"userID %q doesn't exist", userID)
+		}
+
+		if !slices.Contains(userTransitions[u.Status], status) {
+			return he.CodeInvalidTransition.New(
+				"user %q is %s and can't be %s", userID, u.Status, status)
+		}
+
+		_, err = db.Create_UserTransition(ctx,
+			database.UserTransition_UserPk(u.Pk),
+			database.UserTransition_FromStatus(u.Status),
+			database.UserTransition_ToStatus(status),
+			database.UserTransition_Reason(reason),
+			database.UserTransition_Principal(principal(ctx)))
+		if err != nil {
+			return err
+		}
+
+		u, err = db.Update_User_By_Id(ctx, database.User_Id(userID),
+			database.User_Update_Fields{Status: database.User_Status(status)})
+		if err != nil {
+			return err
+		}
+
+		err = s.recordUser(ctx, u, database.VersionUpdated)
+		if err != nil {
+			return err
+		}
+
+		groups, err := db.All_Group_By_User_Id(ctx, database.User_Id(userID))
+		if err != nil {
+			return err
+		}
+
+		user, err = s.apiUserWithAttributes(ctx, u, groups)
+		return err
+	})
+	return user, err
+}
+
+// activeUsers returns the users that are active
+func activeUsers(users []*database.User) []*database.User {
+	var active []*database.User
+	for _, u := range users {
+		if u.Status == userActive {
+			active = append(active, u)
+		}
+	}
+	return active
+}
+
+// checkNewMembers returns NotFound if the group doesn't exist, and a conflict
+// if any of userIDs that isn't a member of it yet isn't active. users that
+// aren't active keep the memberships they have, but can't get new ones.
+func (s *Server) checkNewMembers(ctx context.Context, groupName string,
+	userIDs []string) error {
+
+	db := s.DB.In(ctx)
+	exists, err := db.Has_Group_By_Name(ctx, database.Group_Name(groupName))
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return he.CodeGroupNotFound.New(
+			"groupName %q doesn't exist", groupName)
+	}
+
+	members, err := db.All_User_By_Group_Name(ctx,
+		database.Group_Name(groupName))
+	if err != nil {
+		return err
+	}
+
+	isMember := make(map[string]bool, len(members))
+	for _, u := range members {
+		isMember[u.Id] = true
+	}
+	for _, userID := range userIDs {
+		if isMember[userID] {
+			continue
+		}
+		user, err := db.Find_User_By_Id(ctx, database.User_Id(userID))
+		if err != nil {
+			return err
+		}
+		if user != nil && user.Status != userActive {
+			return he.CodeInvalidTransition.New(
+				"userID %q is %s and can't join %q", userID, user.Status,
+				groupName)
+		}
+	}
+	return nil
+}
+
+// checkNewGroups is checkNewMembers for the groups that a user joins
+func (s *Server) checkNewGroups(ctx context.Context, userID string,
+	groupNames []string) error {
+
+	db := s.DB.In(ctx)
+	user, err := db.Find_User_By_Id(ctx, database.User_Id(userID))
+	if err != nil || user == nil || user.Status == userActive {
+		return err
+	}
+
+	groups, err := db.All_Group_By_User_Id(ctx, database.User_Id(userID))
+	if err != nil {
+		return err
+	}
+
+	isMember := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		isMember[g.Name] = true
+	}
+	for _, groupName := range groupNames {
+		if !isMember[groupName] {
+			return he.CodeInvalidTransition.New(
+				"userID %q is %s and can't join %q", userID, user.Status,
+				groupName)
+		}
+	}
+	return nil
+}
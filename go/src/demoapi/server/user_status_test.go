@@ -0,0 +1,173 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	he "demoapi/httperror"
+)
+
+func TestUserStatus(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newUser(ctx, "user2")
+	t.newGroup(ctx, "group1")
+	t.newMembership(ctx, "user1", "group1")
+	t.newMembership(ctx, "user2", "group1")
+
+	do := func(method, target string, body interface{}) (int, *RootJSON) {
+		w := httptest.NewRecorder()
+		t.server.ServeHTTP(w, jsonRequest(t, method, target, nil, body))
+		resp := &RootJSON{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp),
+			w.Body.String())
+		return w.Code, resp
+	}
+	reason := func(reason string) *Transition {
+		return &Transition{Reason: reason}
+	}
+
+	status, resp := do(http.MethodGet, "/v1/users/user1", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, userActive, resp.User.Status)
+
+	status, resp = do(http.MethodPost, "/v1/users/user1:suspend",
+		reason("on leave"))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, userSuspended, resp.User.Status)
+	assert.Equal(t, []Membership{"group1"}, resp.User.Groups)
+
+	// suspended users can still be read, but can be left out of the members
+	status, resp = do(http.MethodGet, "/v1/users/user1", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, userSuspended, resp.User.Status)
+	status, resp = do(http.MethodGet, "/v1/groups/group1", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []Membership{"user1", "user2"}, resp.Members)
+	status, resp = do(http.MethodGet, "/v1/groups/group1?active_only=true",
+		nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []Membership{"user2"}, resp.Members)
+	status, _ = do(http.MethodGet, "/v1/groups/group1?active_only=maybe", nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = do(http.MethodPost, "/v1/users/user1:suspend",
+		reason("again"))
+	assert.Equal(t, http.StatusConflict, status)
+	status, _ = do(http.MethodPost, "/v1/users/user1:activate", reason(""))
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = do(http.MethodPost, "/v1/users/missing:activate",
+		reason("back"))
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, resp = do(http.MethodPost, "/v1/users/user1:activate",
+		reason("back"))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, userActive, resp.User.Status)
+
+	// deprovisioning is final, and keeps the user and its memberships
+	status, resp = do(http.MethodPost, "/v1/users/user1:deprovision",
+		reason("left"))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, userDeprovisioned, resp.User.Status)
+	assert.Equal(t, []Membership{"group1"}, resp.User.Groups)
+	status, _ = do(http.MethodPost, "/v1/users/user1:activate",
+		reason("back"))
+	assert.Equal(t, http.StatusConflict, status)
+	status, _ = do(http.MethodPut, "/v1/users/user1",
+		User{FirstName: "renamed"})
+	assert.Equal(t, http.StatusConflict, status)
+
+	status, resp = do(http.MethodGet, "/v1/users/user1/transitions", nil)
+	assert.Equal(t, http.StatusOK, status)
+	require.Len(t, resp.Transitions, 3)
+	for i, want := range []Transition{
+		{From: userActive, To: userSuspended, Reason: "on leave"},
+		{From: userSuspended, To: userActive, Reason: "back"},
+		{From: userActive, To: userDeprovisioned, Reason: "left"},
+	} {
+		got := resp.Transitions[i]
+		assert.Equal(t, want.From, got.From)
+		assert.Equal(t, want.To, got.To)
+		assert.Equal(t, want.Reason, got.Reason)
+		assert.False(t, got.Created.IsZero())
+	}
+
+	status, _ = do(http.MethodGet, "/v1/users/missing/transitions", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestNewMemberships(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	t.newUser(ctx, "user2")
+	t.newGroup(ctx, "group1")
+	t.newGroup(ctx, "group2")
+	t.newMembership(ctx, "user2", "group1")
+	_, err := t.server.setUserStatus(ctx, "user2", userSuspended, "on leave")
+	require.NoError(t, err)
+
+	do := func(method, target string, body interface{}) (int, *RootJSON) {
+		w := httptest.NewRecorder()
+		t.server.ServeHTTP(w, jsonRequest(t, method, target, nil, body))
+		resp := &RootJSON{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp),
+			w.Body.String())
+		return w.Code, resp
+	}
+	userIDs := func(ids ...string) map[string][]string {
+		return map[string][]string{"userids": ids}
+	}
+
+	// a suspended user can't join a group, and nobody else joins with it
+	status, _ := do(http.MethodPut, "/v1/groups/group2",
+		userIDs("user1", "user2"))
+	assert.Equal(t, http.StatusConflict, status)
+	status, resp := do(http.MethodGet, "/v1/groups/group2", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, resp.Members)
+
+	// the error is returned as it is, after checking that the group exists
+	err = t.server.updateMembership(ctx, "group2", []string{"user2"})
+	assert.Equal(t, he.CodeInvalidTransition, he.CodeByError(err), err)
+	assert.Equal(t, codes.FailedPrecondition, he.GRPCCodeByError(err))
+	assert.False(t, he.Unexpected.Has(err), err)
+	status, _ = do(http.MethodPut, "/v1/groups/missing", userIDs("user2"))
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// and a scim group isn't created with it
+	w := scimRequest(t, http.MethodPost, "/Groups", SCIMGroup{
+		DisplayName: "group3",
+		Members: []SCIMMember{{Value: t.scimUUID(ctx, "user1")},
+			{Value: t.scimUUID(ctx, "user2")}},
+	})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	status, _ = do(http.MethodGet, "/v1/groups/group3", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _ = do(http.MethodPut, "/v1/users/user2", User{FirstName: "fn",
+		LastName: "ln", Groups: []Membership{"group1", "group2"}})
+	assert.Equal(t, http.StatusConflict, status)
+
+	// but it keeps the memberships that it has
+	status, _ = do(http.MethodPut, "/v1/groups/group1",
+		userIDs("user1", "user2"))
+	assert.Equal(t, http.StatusOK, status)
+	status, resp = do(http.MethodGet, "/v1/groups/group1", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []Membership{"user1", "user2"}, resp.Members)
+
+	status, resp = do(http.MethodGet, "/v1/users/user2", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []Membership{"group1"}, resp.User.Groups)
+}
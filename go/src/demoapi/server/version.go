@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+
+	h "demoapi/handler"
+	he "demoapi/httperror"
+)
+
+// apiV1 is the prefix of the first version of the rest api. its routes are
+// also served at their unversioned paths until unversioned_paths_sunset
+const apiV1 = "/v1"
+
+// unversionedPathsDeprecated is when the unversioned paths were deprecated in
+// favor of /v1
+var unversionedPathsDeprecated = time.Date(2026, time.October, 19, 0, 0, 0,
+	0, time.UTC)
+
+// apiVersion is a version of the rest api, served under its prefix side by
+// side with the other versions
+type apiVersion struct {
+	prefix string // like "/v1"
+
+	// routes registers the routes that are new or changed in this version.
+	// every other route is served like in the version before it
+	routes func(r chi.Router)
+
+	// deprecated versions are served with Deprecation and Sunset headers and
+	// a link to the same path in the latest version
+	deprecated time.Time
+	sunset     time.Time // optional
+}
+
+// mountVersions serves every version of the rest api under its prefix and
+// returns the router of the first version. versions are in order, oldest
+// first.
+func mountVersions(root chi.Router, versions []apiVersion) chi.Router {
+	latest := versions[len(versions)-1].prefix
+
+	var first chi.Router
+	for i, v := range versions {
+		r := chi.NewRouter()
+		if !v.deprecated.IsZero() {
+			r.Use(deprecatedVersion(v, latest))
+		}
+		// later registrations of a route replace earlier ones
+		for _, prev := range versions[:i+1] {
+			prev.routes(r)
+		}
+		root.Mount(v.prefix, r)
+		if first == nil {
+			first = r
+		}
+	}
+	return first
+}
+
+// deprecatedVersion adds the deprecation headers of v to its responses
+func deprecatedVersion(v apiVersion, latest string) func(
+	http.Handler) http.Handler {
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			successor := latest + strings.TrimPrefix(r.URL.Path, v.prefix)
+			deprecationHeaders(w.Header(), v.deprecated, v.sunset, successor)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// deprecationHeaders sets the Deprecation (RFC 9745) and Sunset (RFC 8594)
+// headers of a deprecated route and links to the route that replaces it
+func deprecationHeaders(header http.Header, deprecated, sunset time.Time,
+	successor string) {
+
+	header.Set("Deprecation", fmt.Sprintf("@%d", deprecated.Unix()))
+	if !sunset.IsZero() {
+		header.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	}
+	header.Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"",
+		successor))
+}
+
+// UnversionedPaths serves the routes of /v1 at their unversioned paths, like
+// /users, so that clients from before versioning keep working. The responses
+// are deprecated and sunset at unversioned_paths_sunset, after which the
+// unversioned paths are a 410.
+func (s *Server) UnversionedPaths(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.v1.Match(chi.NewRouteContext(), r.Method, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		successor := apiV1 + r.URL.Path
+		sunset := s.settings().UnversionedPathsSunset
+		deprecationHeaders(w.Header(), unversionedPathsDeprecated, sunset,
+			successor)
+
+		if !sunset.IsZero() && !time.Now().Before(sunset) {
+			gone := h.MiddlewareChain().JSON(h.Handler(
+				func(context.Context, http.ResponseWriter,
+					*http.Request) (*h.Response, error) {
+					return nil, he.CodeUnversionedPathRemoved.New("%s was "+
+						"removed on %s. use %s", r.URL.Path,
+						sunset.Format(time.DateOnly), successor)
+				}))
+			gone.ServeHTTP(w, r)
+			return
+		}
+
+		// the router routes the rewritten path, while the access log keeps
+		// the path that was requested
+		versioned := r.Clone(r.Context())
+		versioned.URL.Path = successor
+		if r.URL.RawPath != "" {
+			versioned.URL.RawPath = apiV1 + r.URL.RawPath
+		}
+		next.ServeHTTP(w, versioned)
+	})
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+
+	he "demoapi/httperror"
+)
+
+func TestUnversionedPaths(baseTest *testing.T) {
+	ctx, t := newServerTest(baseTest)
+	defer t.cleanup()
+
+	t.newUser(ctx, "user1")
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		t.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	// versioned paths aren't deprecated
+	w := get("/v1/users/user1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	// unversioned paths are served like /v1, but deprecated
+	unversioned := get("/users/user1")
+	assert.Equal(t, http.StatusOK, unversioned.Code)
+	assert.Equal(t, w.Body.String(), unversioned.Body.String())
+	assert.Equal(t, "@1792368000", unversioned.Header().Get("Deprecation"))
+	assert.Empty(t, unversioned.Header().Get("Sunset"))
+	assert.Equal(t, `</v1/users/user1>; rel="successor-version"`,
+		unversioned.Header().Get("Link"))
+
+	// paths that were never versioned aren't touched
+	w = get("/healthz")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	configs := *t.server.Config
+	configs.UnversionedPathsSunset = time.Now().Add(time.Hour).UTC()
+	t.server.Reload(&configs)
+	w = get("/users/user1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, configs.UnversionedPathsSunset.Format(http.TimeFormat),
+		w.Header().Get("Sunset"))
+
+	// after the sunset, clients are pointed to /v1
+	configs.UnversionedPathsSunset = time.Now().Add(-time.Hour)
+	t.server.Reload(&configs)
+	w = get("/users/user1")
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Contains(t, w.Body.String(), he.CodeUnversionedPathRemoved.Code)
+	assert.Contains(t, w.Header().Get("Link"), "</v1/users/user1>")
+	assert.Equal(t, http.StatusOK, get("/v1/users/user1").Code)
+}
+
+func TestMountVersions(t *testing.T) {
+	text := func(s string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(s))
+		}
+	}
+	sunset := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	r := chi.NewRouter()
+	mountVersions(r, []apiVersion{{
+		prefix: "/v1",
+		routes: func(r chi.Router) {
+			r.Get("/users", text("v1 users"))
+			r.Get("/groups", text("v1 groups"))
+		},
+		deprecated: sunset.AddDate(-1, 0, 0),
+		sunset:     sunset,
+	}, {
+		prefix: "/v2",
+		routes: func(r chi.Router) {
+			r.Get("/users", text("v2 users"))
+		},
+	}})
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	w := get("/v1/users")
+	assert.Equal(t, "v1 users", w.Body.String())
+	assert.Equal(t, "@1861920000", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Tue, 01 Jan 2030 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `</v2/users>; rel="successor-version"`,
+		w.Header().Get("Link"))
+
+	// a version serves the routes it didn't change like the one before it
+	w = get("/v2/users")
+	assert.Equal(t, "v2 users", w.Body.String())
+	assert.Empty(t, w.Header().Get("Deprecation"))
+	assert.Equal(t, "v1 groups", get("/v2/groups").Body.String())
+}
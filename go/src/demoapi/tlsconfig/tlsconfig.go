@@ -0,0 +1,263 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zeebo/errs"
+)
+
+var tlsErr = errs.Class("tls")
+
+// how often the certificate files are checked for changes
+const watchInterval = 2 * time.Second
+
+// Settings are the tls settings of a listener. tls is disabled without a
+// certificate.
+type Settings struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string // optional. client certificates are required if set
+	MinVersion   uint16
+	CipherSuites []uint16 // optional. only applies below tls 1.3
+}
+
+// Enabled returns whether the listener serves tls
+func (s Settings) Enabled() bool {
+	return s.CertFile != ""
+}
+
+// String formats the settings so that reloads can tell when they change
+func (s Settings) String() string {
+	if !s.Enabled() {
+		return "disabled"
+	}
+	var suites []string
+	for _, id := range s.CipherSuites {
+		suites = append(suites, tls.CipherSuiteName(id))
+	}
+	return fmt.Sprintf("cert=%s key=%s client_ca=%s min_version=%s "+
+		"cipher_suites=%s", s.CertFile, s.KeyFile, s.ClientCAFile,
+		tls.VersionName(s.MinVersion), strings.Join(suites, ","))
+}
+
+// ParseVersion parses a minimum tls version like "1.2". it defaults to 1.2
+func ParseVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, tlsErr.New("unsupported version %q. use \"1.2\" or \"1.3\"",
+		version)
+}
+
+// ParseCipherSuites parses a comma separated list of cipher suite names, like
+// "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". only the suites that go considers
+// secure are allowed. an empty list uses go's defaults.
+func ParseCipherSuites(names string) ([]uint16, error) {
+	secure := map[string]uint16{}
+	for _, suite := range tls.CipherSuites() {
+		secure[suite.Name] = suite.ID
+	}
+
+	var ids []uint16
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		id, ok := secure[name]
+		if !ok {
+			return nil, tlsErr.New("unknown or insecure cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Reloader serves the certificate and client CAs of Settings, and loads them
+// again when the files change, so that certificates can be rotated without a
+// restart. connections that are already open keep their certificate.
+type Reloader struct {
+	settings Settings
+	current  atomic.Pointer[tls.Config]
+
+	mu      sync.Mutex // serializes reloads
+	version string
+}
+
+// NewReloader returns a Reloader that has loaded the files of settings
+func NewReloader(settings Settings) (*Reloader, error) {
+	r := &Reloader{settings: settings}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Config returns the tls.Config of an http.Server, or of a grpc.Server with
+// credentials.NewTLS. every handshake uses the latest certificate and client
+// CAs.
+func (r *Reloader) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion: r.settings.MinVersion,
+		// http.Server only serves tls if a certificate is configured
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &r.current.Load().Certificates[0], nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current.Load(), nil
+		},
+	}
+}
+
+// Reload loads the files again. the current certificate is kept if they
+// aren't valid
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the version is kept even if the files aren't valid, so that a bad
+	// rotation is only reported once
+	r.version = r.filesVersion()
+	config, err := r.load()
+	if err != nil {
+		return err
+	}
+	r.current.Store(config)
+	return nil
+}
+
+func (r *Reloader) load() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(r.settings.CertFile, r.settings.KeyFile)
+	if err != nil {
+		return nil, tlsErr.Wrap(err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   r.settings.MinVersion,
+		CipherSuites: r.settings.CipherSuites,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	if r.settings.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(r.settings.ClientCAFile)
+		if err != nil {
+			return nil, tlsErr.Wrap(err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, tlsErr.New("no certificates in %s",
+				r.settings.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// Watch reloads the files whenever they change, until ctx is canceled.
+// Failed reloads are logged and the current certificate is kept.
+func (r *Reloader) Watch(ctx context.Context) error {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		r.mu.Lock()
+		changed := r.filesVersion() != r.version
+		r.mu.Unlock()
+		if !changed {
+			continue
+		}
+
+		if err := r.Reload(); err != nil {
+			logrus.Errorf("tls reload of %s failed: %s", r.settings.CertFile,
+				err)
+			continue
+		}
+		logrus.Infof("tls reloaded %s", r.settings.CertFile)
+	}
+}
+
+// filesVersion identifies the current contents of the files
+func (r *Reloader) filesVersion() string {
+	var version []string
+	for _, path := range []string{r.settings.CertFile, r.settings.KeyFile,
+		r.settings.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			version = append(version, "missing")
+			continue
+		}
+		version = append(version, fmt.Sprintf("%d-%d",
+			info.ModTime().UnixNano(), info.Size()))
+	}
+	return strings.Join(version, ",")
+}
+
+// Server serves an http.Server over tls when its Settings enable it, and
+// over plain http otherwise
+type Server struct {
+	*http.Server
+	reloader *Reloader // nil without tls
+}
+
+// NewServer wraps s with the tls settings. The files are loaded right away,
+// so a missing or invalid certificate fails at startup.
+func NewServer(s *http.Server, settings Settings) (*Server, error) {
+	if !settings.Enabled() {
+		return &Server{Server: s}, nil
+	}
+
+	reloader, err := NewReloader(settings)
+	if err != nil {
+		return nil, err
+	}
+	s.TLSConfig = reloader.Config()
+	return &Server{Server: s, reloader: reloader}, nil
+}
+
+func (s *Server) ListenAndServe() error {
+	if s.reloader == nil {
+		return s.Server.ListenAndServe()
+	}
+
+	lis, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	return s.ServeTLS(lis, "", "") // the certificate is in TLSConfig
+}
+
+// Watch reloads the certificate files when they change, until ctx is
+// canceled. it only waits for ctx without tls.
+func (s *Server) Watch(ctx context.Context) error {
+	if s.reloader == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return s.reloader.Watch(ctx)
+}
@@ -0,0 +1,220 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pair tls.Certificate
+}
+
+// newTestCert creates a certificate for name, signed by parent or self
+// signed if parent is nil
+func newTestCert(t *testing.T, name string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject: pkix.Name{CommonName: name,
+			Organization: []string{"demoapi"}},
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+		DNSNames:  []string{"localhost"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth,
+			x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer,
+		&key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCert{cert: cert, key: key, pair: tls.Certificate{
+		Certificate: [][]byte{der}, PrivateKey: key}}
+}
+
+// write writes the certificate and key as pem files to dir
+func (c *testCert) write(t *testing.T, dir string) (certFile,
+	keyFile string) {
+
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"),
+		filepath.Join(dir, "key.pem")
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(
+		&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(
+		&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", nil)
+	caFile, _ := ca.write(t, t.TempDir())
+	certFile, keyFile := newTestCert(t, "server1", ca).write(t, dir)
+
+	reloader, err := NewReloader(Settings{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+		MinVersion:   tls.VersionTLS12,
+	})
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			subject := r.TLS.VerifiedChains[0][0].Subject
+			_, _ = w.Write([]byte(subject.String()))
+		}))
+	server.TLS = reloader.Config()
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(clientCerts ...tls.Certificate) (string, string, error) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs:      roots,
+				ServerName:   "localhost",
+				Certificates: clientCerts,
+			}}}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return "", "", err
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		return resp.TLS.PeerCertificates[0].Subject.CommonName, string(body),
+			err
+	}
+
+	// the client certificate is verified and its subject is available
+	client := newTestCert(t, "client", ca)
+	serverName, subject, err := get(client.pair)
+	require.NoError(t, err)
+	assert.Equal(t, "server1", serverName)
+	assert.Equal(t, "CN=client,O=demoapi", subject)
+
+	// client certificates are required
+	_, _, err = get()
+	assert.Error(t, err)
+
+	// client certificates from another CA aren't accepted
+	_, _, err = get(newTestCert(t, "stranger", nil).pair)
+	assert.Error(t, err)
+
+	// a rotated certificate is served to new connections
+	newTestCert(t, "server2", ca).write(t, dir)
+	require.NoError(t, reloader.Reload())
+	serverName, _, err = get(client.pair)
+	require.NoError(t, err)
+	assert.Equal(t, "server2", serverName)
+
+	// an invalid certificate is rejected and the current one is kept
+	require.NoError(t, ioutil.WriteFile(certFile, []byte("nope"), 0600))
+	assert.Error(t, reloader.Reload())
+	serverName, _, err = get(client.pair)
+	require.NoError(t, err)
+	assert.Equal(t, "server2", serverName)
+}
+
+func TestReloaderGRPC(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	caFile, _ := ca.write(t, t.TempDir())
+	certFile, keyFile := newTestCert(t, "server", ca).write(t, t.TempDir())
+
+	reloader, err := NewReloader(Settings{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+		MinVersion:   tls.VersionTLS12,
+	})
+	require.NoError(t, err)
+
+	// the config of an http.Server serves grpc too
+	subjects := make(chan string, 1)
+	gs := grpc.NewServer(grpc.Creds(credentials.NewTLS(reloader.Config())),
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{},
+			info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (
+			interface{}, error) {
+			p, _ := peer.FromContext(ctx)
+			state := p.AuthInfo.(credentials.TLSInfo).State
+			subjects <- state.VerifiedChains[0][0].Subject.String()
+			return handler(ctx, req)
+		}))
+	healthpb.RegisterHealthServer(gs, health.NewServer())
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = gs.Serve(lis) }()
+	defer gs.Stop()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := newTestCert(t, "client", ca)
+	conn, err := grpc.NewClient(lis.Addr().String(),
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			RootCAs:      roots,
+			ServerName:   "localhost",
+			Certificates: []tls.Certificate{client.pair},
+		})))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(),
+		&healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "CN=client,O=demoapi", <-subjects)
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	ids, err := ParseCipherSuites("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, " +
+		"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384}, ids)
+
+	ids, err = ParseCipherSuites("")
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	_, err = ParseCipherSuites("TLS_RSA_WITH_RC4_128_SHA")
+	assert.Error(t, err)
+}
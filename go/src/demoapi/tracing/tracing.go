@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/zeebo/errs"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"demoapi/config"
+)
+
+// This package sets up OpenTelemetry tracing. The spans themselves are started
+// where the work happens: Middleware for each http request, the handler
+// package for each middleware and handler, and the database package for each
+// query.
+
+const (
+	ExporterNone   = ""
+	ExporterOTLP   = "otlp"   // grpc to an OpenTelemetry collector
+	ExporterStdout = "stdout" // json spans written to stdout
+	ExporterFile   = "file"   // json spans appended to a file
+)
+
+var tracingErr = errs.Class("tracing")
+
+// Setup installs the global tracer provider and the W3C trace context
+// propagator. Incoming traces are always propagated, but spans are only
+// recorded and exported when an exporter is configured. The returned function
+// flushes any buffered spans and must be called before exiting.
+func Setup(ctx context.Context, conf *config.Configs, version string) (
+	func(context.Context) error, error) {
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, closer, err := newExporter(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(conf.APISlug),
+			semconv.ServiceVersion(version))))
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errs.Combine(err, closer.Close())
+		}
+		return tracingErr.Wrap(err)
+	}, nil
+}
+
+func newExporter(ctx context.Context, conf *config.Configs) (
+	sdktrace.SpanExporter, io.Closer, error) {
+
+	switch conf.TraceExporter {
+	case ExporterNone:
+		return nil, nil, nil
+
+	case ExporterOTLP:
+		exporter, err := otlptracegrpc.New(ctx,
+			otlptracegrpc.WithEndpoint(conf.TraceOTLPEndpoint),
+			otlptracegrpc.WithInsecure())
+		return exporter, nil, tracingErr.Wrap(err)
+
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		return exporter, nil, tracingErr.Wrap(err)
+
+	case ExporterFile:
+		f, err := os.OpenFile(conf.TraceFile,
+			os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, tracingErr.Wrap(err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			_ = f.Close()
+			return nil, nil, tracingErr.Wrap(err)
+		}
+		return exporter, f, nil
+	}
+
+	return nil, nil, tracingErr.New("unknown exporter %q", conf.TraceExporter)
+}
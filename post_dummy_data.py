@@ -8,8 +8,8 @@ import urllib.request
 
 
 API_SERVER = "http://localhost:8080"
-API_USERS  = API_SERVER + "/users"
-API_GROUPS = API_SERVER + "/groups"
+API_USERS  = API_SERVER + "/v1/users"
+API_GROUPS = API_SERVER + "/v1/groups"
 
 # the API server is only expecting a non-empty auth token, if at all.
 # NOTE: even though the Authorization header isn't required if the API server
@@ -27,12 +27,13 @@ def generateID():
 def jsonRequest(url="", method="", headers={}, jsonData={}):
     data = json.dumps(jsonData).encode("utf-8")
     req = urllib.request.Request(url=url, data=data, method=method)
+    req.add_header("Content-Type", "application/json")
     for h in headers:
         req.add_header(h, headers[h])
 
     try:
         resp = urllib.request.urlopen(req)
-        if resp.status != 200:
+        if resp.status not in (200, 201):
             raise Exception("unexpected resp status", url, resp.status,
                     resp.reason)
     except Exception as e: